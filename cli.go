@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	conf "github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/backup"
+	"github.com/muety/wakapi/migrations"
+	"github.com/muety/wakapi/repositories"
+	"github.com/muety/wakapi/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// runCommand executes one of the command line sub-commands (e.g. "wakapi backup") instead of starting the web server and returns the process' exit code
+func runCommand(name string, args []string) int {
+	var err error
+	switch name {
+	case "backup":
+		err = runBackup(args)
+	case "restore":
+		err = runRestore(args)
+	case "dbmigrate":
+		err = runDbMigrate(args)
+	default:
+		err = fmt.Errorf("unknown command '%s', expected one of: backup, restore, dbmigrate", name)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	output := flags.String("output", fmt.Sprintf("wakapi_backup_%s.jsonl.gz", time.Now().UTC().Format("20060102T150405Z")), "file to write the backup to, '-' for stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, closeDb, err := openCommandDb(config.Db.GetDialector(), conf.GetWakapiDBOpts(&config.Db))
+	if err != nil {
+		return err
+	}
+	defer closeDb()
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o640)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	manifest, err := services.NewBackupService(nil, repositories.NewBackupRepository(db)).Create(w)
+	if err != nil {
+		if *output != "-" {
+			os.Remove(*output)
+		}
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	printManifest("wrote backup", manifest)
+	return nil
+}
+
+func runRestore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	input := flags.String("input", "", "backup file to restore, '-' for stdin")
+	force := flags.Bool("force", false, "delete all existing data before restoring")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return fmt.Errorf("missing -input")
+	}
+
+	db, closeDb, err := openCommandDb(config.Db.GetDialector(), conf.GetWakapiDBOpts(&config.Db))
+	if err != nil {
+		return err
+	}
+	defer closeDb()
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	// only create the schema, data migrations would be pointless on an empty database and the backup already contains migrated data anyway
+	migrations.RunSchemaMigrations(db, config)
+
+	manifest, err := services.NewBackupService(nil, repositories.NewBackupRepository(db)).Restore(r, *force)
+	if err != nil {
+		if errors.Is(err, backup.ErrNotEmpty) {
+			return fmt.Errorf("failed to restore backup: %w (use -force to overwrite existing data)", err)
+		}
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	printManifest("restored backup", manifest)
+	return nil
+}
+
+func runDbMigrate(args []string) error {
+	flags := flag.NewFlagSet("dbmigrate", flag.ContinueOnError)
+	sourceType := flags.String("source-type", "sqlite3", "type of the database to migrate from (sqlite3, mysql, postgres)")
+	sourceDsn := flags.String("source-dsn", "wakapi_db.db", "connection string of the database to migrate from (file name for sqlite)")
+	chunkSize := flags.Int("chunk-size", 1000, "number of rows to copy at once")
+	skipVerify := flags.Bool("skip-verify", false, "skip comparing row counts and checksums after copying")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	sourceConfig := conf.NewDbConfig(*sourceType, *sourceDsn)
+	if sourceConfig.GetDialector() == nil {
+		return fmt.Errorf("unsupported source database type '%s'", *sourceType)
+	}
+
+	source, closeSource, err := openCommandDb(sourceConfig.GetDialector(), conf.GetWakapiDBOpts(sourceConfig))
+	if err != nil {
+		return err
+	}
+	defer closeSource()
+
+	target, closeTarget, err := openCommandDb(config.Db.GetDialector(), conf.GetWakapiDBOpts(&config.Db))
+	if err != nil {
+		return err
+	}
+	defer closeTarget()
+
+	// the configured database is the target, so times will be stored in whatever way the target's dialect requires, see models.CustomTime
+	migrations.RunSchemaMigrations(target, config)
+
+	fmt.Fprintf(os.Stderr, "migrating from %s to %s\n", sourceConfig.Dialect, config.Db.Dialect)
+
+	var currentTable string
+	result, err := backup.Migrate(source, target, migrations.Models(), backup.MigrateOptions{
+		ChunkSize:  *chunkSize,
+		SkipVerify: *skipVerify,
+		OnProgress: func(table string, rows int64) {
+			if currentTable != "" && table != currentTable {
+				fmt.Fprintln(os.Stderr)
+			}
+			currentTable = table
+			fmt.Fprintf(os.Stderr, "\r%s: %d rows", table, rows)
+		},
+	})
+	if currentTable != "" {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err != nil {
+		if errors.Is(err, backup.ErrNotEmpty) {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		if errors.Is(err, backup.ErrMismatch) {
+			return fmt.Errorf("failed to migrate database: %w (make sure wakapi is not running while migrating)", err)
+		}
+		return fmt.Errorf("failed to migrate database: %w (run again to resume)", err)
+	}
+
+	if result.Resumed {
+		fmt.Fprintln(os.Stderr, "resumed previous migration")
+	}
+	fmt.Fprintf(os.Stderr, "migrated %d rows in %d tables\n", result.TotalRows(), len(result.Tables))
+	return nil
+}
+
+func openCommandDb(dialector gorm.Dialector, opts *conf.WakapiDBOpts) (*gorm.DB, func(), error) {
+	gormLogger := logger.New(log.New(os.Stderr, "", log.LstdFlags), logger.Config{LogLevel: logger.Silent})
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: gormLogger, TranslateError: true}, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not connect to database: %w", err)
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not connect to database: %w", err)
+	}
+	return db, func() { sqlDb.Close() }, nil
+}
+
+func printManifest(message string, manifest *backup.Manifest) {
+	// stdout might be the backup itself
+	fmt.Fprintf(os.Stderr, "%s (version %s, %s, created %s): %d rows in %d tables\n", message, manifest.AppVersion, manifest.Dialect, manifest.CreatedAt.Format(time.RFC3339), manifest.TotalRows(), len(manifest.Tables))
+}
@@ -50,6 +50,25 @@ For Postgres, the story is even a different one.
 - According to https://www.cybertec-postgresql.com/en/time-zone-management-in-postgresql/, good practice is to always use timestamptz, leaving conversions to the database itself
 */
 
+// DbReplicaResolver is the name of the database resolver that routes queries to the read replica, see repositories.BaseRepository
+const DbReplicaResolver = "replica"
+
+// NewDbConfig creates the configuration for a database other than the one configured for wakapi itself (e.g. the source of a migration), given its type (e.g. postgres) and connection string (file name for sqlite)
+func NewDbConfig(dbType, dsn string) *dbConfig {
+	c := &dbConfig{
+		Type:    dbType,
+		Dialect: resolveDbDialect(dbType),
+		Charset: "utf8mb4",
+		MaxConn: 2,
+	}
+	if c.IsSQLite() {
+		c.Name = dsn
+	} else {
+		c.DSN = dsn
+	}
+	return c
+}
+
 func (c *dbConfig) GetDialector() gorm.Dialector {
 	switch c.Dialect {
 	case SQLDialectMysql:
@@ -68,6 +87,17 @@ func (c *dbConfig) GetDialector() gorm.Dialector {
 	return nil
 }
 
+func (c *dbConfig) HasReplica() bool {
+	return c.ReplicaDSN != ""
+}
+
+// GetReplicaDialector returns the dialector for the read-only replica, which is always of the same type as the primary database
+func (c *dbConfig) GetReplicaDialector() gorm.Dialector {
+	replica := *c
+	replica.DSN = c.ReplicaDSN
+	return replica.GetDialector()
+}
+
 func mysqlConnectionString(config *dbConfig) string {
 	if len(config.DSN) > 0 {
 		return config.DSN
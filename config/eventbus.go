@@ -15,17 +15,22 @@ const (
 	EventUserUpdate              = "user.update"
 	EventUserDelete              = "user.delete"
 	EventHeartbeatCreate         = "heartbeat.create"
+	EventHeartbeatsChanged       = "heartbeat.changed" // only relayed from other instances, summarizes all heartbeats a user created there recently
 	EventProjectLabelCreate      = "project_label.create"
 	EventProjectLabelDelete      = "project_label.delete"
 	EventAliasCreate             = "alias.create"
 	EventAliasDelete             = "alias.delete"
 	EventWakatimeFailure         = "wakatime.failure"
 	EventLanguageMappingsChanged = "language_mappings.changed"
+	EventDaysOffChanged          = "days_off.changed"
+	EventFilterPresetsChanged    = "filter_presets.changed"
 	EventApiKeyCreate            = "api_key.create"
 	EventApiKeyDelete            = "api_key.delete"
 	FieldPayload                 = "payload"
 	FieldUser                    = "user"
 	FieldUserId                  = "user.id"
+	FieldCount                   = "count"
+	FieldRemote                  = "remote"
 )
 
 var eventHub *hub.Hub
@@ -38,6 +43,13 @@ func EventBus() *hub.Hub {
 	return eventHub
 }
 
+// IsRemoteEvent returns whether the given event was published by another wakapi instance and only relayed to this one.
+// Subscribers with side effects other than updating local state (sending mails, writing to the database, ...) should ignore remote events, as the originating instance will already take care of them.
+func IsRemoteEvent(m hub.Message) bool {
+	remote, _ := m.Fields[FieldRemote].(bool)
+	return remote
+}
+
 func SetEventBus(eventBus *hub.Hub) { // only for testing purposes!
 	eventHub = eventBus
 }
@@ -2,11 +2,16 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+
 	"github.com/muety/artifex/v2"
+	"github.com/muety/wakapi/lib/metrics"
 	"github.com/muety/wakapi/utils"
-	"log/slog"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+const jobQueueCapacity = 4096
+
 var jobQueues map[string]*artifex.Dispatcher
 var jobCounts map[string]int
 
@@ -24,10 +29,12 @@ type JobQueueMetrics struct {
 	Queue        string
 	EnqueuedJobs int
 	FinishedJobs int
+	Capacity     int
 }
 
 func init() {
 	jobQueues = make(map[string]*artifex.Dispatcher)
+	metrics.Registry.MustRegister(&jobQueueCollector{})
 }
 
 func StartJobs() {
@@ -45,7 +52,7 @@ func InitQueue(name string, workers int) error {
 		return fmt.Errorf("queue '%s' already existing", name)
 	}
 	slog.Info("creating job queue", "name", name, "workers", workers)
-	jobQueues[name] = artifex.NewDispatcher(workers, 4096)
+	jobQueues[name] = artifex.NewDispatcher(workers, jobQueueCapacity)
 	jobQueues[name].Start()
 	return nil
 }
@@ -68,6 +75,7 @@ func GetQueueMetrics() []*JobQueueMetrics {
 			Queue:        name,
 			EnqueuedJobs: queue.CountEnqueued(),
 			FinishedJobs: queue.CountDispatched(),
+			Capacity:     jobQueueCapacity,
 		})
 	}
 	return metrics
@@ -78,3 +86,38 @@ func CloseQueues() {
 		q.Stop()
 	}
 }
+
+var (
+	descQueueBacklog = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "queue", "jobs_backlog"),
+		"Number of jobs currently waiting in the queue.",
+		[]string{"queue"}, nil,
+	)
+	descQueueCapacity = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "queue", "jobs_capacity"),
+		"Maximum number of jobs the queue can hold.",
+		[]string{"queue"}, nil,
+	)
+	descQueueDispatched = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "queue", "jobs_dispatched_total"),
+		"Total number of jobs dispatched to workers.",
+		[]string{"queue"}, nil,
+	)
+)
+
+// jobQueueCollector reads the dispatchers' current state on every scrape, which is cheap
+type jobQueueCollector struct{}
+
+func (c *jobQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- descQueueBacklog
+	ch <- descQueueCapacity
+	ch <- descQueueDispatched
+}
+
+func (c *jobQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, qm := range GetQueueMetrics() {
+		ch <- prometheus.MustNewConstMetric(descQueueBacklog, prometheus.GaugeValue, float64(qm.EnqueuedJobs), qm.Queue)
+		ch <- prometheus.MustNewConstMetric(descQueueCapacity, prometheus.GaugeValue, float64(qm.Capacity), qm.Queue)
+		ch <- prometheus.MustNewConstMetric(descQueueDispatched, prometheus.CounterValue, float64(qm.FinishedJobs), qm.Queue)
+	}
+}
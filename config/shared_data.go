@@ -9,6 +9,7 @@ type SharedDataKey string
 const (
 	MiddlewareKeyPrincipal   = SharedDataKey("principal")
 	MiddlewareKeyPrincipalId = SharedDataKey("principal_identity")
+	MiddlewareKeySession     = SharedDataKey("session")
 )
 
 type SharedData struct {
@@ -12,4 +12,7 @@ const (
 	SummaryTemplate       = "summary.tpl.html"
 	LeaderboardTemplate   = "leaderboard.tpl.html"
 	ProjectsTemplate      = "projects.tpl.html"
+	ProjectTemplate       = "project.tpl.html"
+	DashboardsTemplate    = "dashboards.tpl.html"
+	DashboardTemplate     = "dashboard.tpl.html"
 )
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InitTracing sets up a global opentelemetry tracer provider, which exports spans via otlp.
+// If no exporter is configured, the default no-op provider stays in place. The returned function flushes pending spans and must be called on shutdown.
+func InitTracing(config *Config) (func(context.Context) error, error) {
+	if config.Tracing.Exporter == TracingExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newTraceExporter(config.Tracing)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.Tracing.ServiceName),
+		semconv.ServiceVersion(config.Version),
+		semconv.ServiceInstanceID(config.InstanceId),
+		semconv.DeploymentEnvironment(config.Env),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(newRouteSampler(config.Tracing))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	slog.Info("enabling opentelemetry tracing", "exporter", config.Tracing.Exporter, "endpoint", config.Tracing.Endpoint, "sample_rate", config.Tracing.SampleRate)
+	return provider.Shutdown, nil
+}
+
+func newTraceExporter(config tracingConfig) (*otlptrace.Exporter, error) {
+	ctx := context.Background()
+
+	switch config.Exporter {
+	case TracingExporterOtlpGrpc:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(config.Headers)}
+		if config.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(config.Endpoint))
+		}
+		if config.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case TracingExporterOtlpHttp:
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(config.Headers)}
+		if config.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(config.Endpoint))
+		}
+		if config.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+
+	return nil, fmt.Errorf("unknown tracing exporter '%s'", config.Exporter)
+}
+
+// routeSampler samples root spans according to their name, analogous to the sentry traces sampler.
+// heartbeat requests are very frequent and usually uninteresting, so they get a separate (lower) sample rate, while static assets and health checks are never traced.
+type routeSampler struct {
+	defaultSampler    sdktrace.Sampler
+	heartbeatsSampler sdktrace.Sampler
+}
+
+func newRouteSampler(config tracingConfig) *routeSampler {
+	return &routeSampler{
+		defaultSampler:    sdktrace.TraceIDRatioBased(config.SampleRate),
+		heartbeatsSampler: sdktrace.TraceIDRatioBased(config.SampleRateHeartbeats),
+	}
+}
+
+func (s *routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, ex := range excludedRoutes {
+		if strings.HasPrefix(p.Name, ex) {
+			return sdktrace.SamplingResult{Decision: sdktrace.Drop, Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState()}
+		}
+	}
+	if heartbeatsRouteRegex.MatchString(p.Name) {
+		return s.heartbeatsSampler.ShouldSample(p)
+	}
+	return s.defaultSampler.ShouldSample(p)
+}
+
+func (s *routeSampler) Description() string {
+	return "RouteSampler"
+}
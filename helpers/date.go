@@ -56,3 +56,17 @@ func FmtWakatimeDuration(d time.Duration) string {
 	m := d / time.Minute
 	return fmt.Sprintf("%d hrs %d mins", h, m)
 }
+
+func FmtTimeAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%d mins ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d hrs ago", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%d days ago", int(d/(24*time.Hour)))
+	}
+}
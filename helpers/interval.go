@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"errors"
+	"math"
 	"time"
 
 	"github.com/muety/wakapi/models"
@@ -106,3 +107,37 @@ func ResolveMaximumRange(days int) (error, *models.IntervalKey) {
 	}
 	return nil, models.IntervalPast12Months
 }
+
+// ResolvePreviousPeriod returns the period to compare the given one against. Calendar-based intervals (e.g. this week) are shifted by their calendar unit,
+// such that, for instance, monday to wednesday of this week are compared to monday to wednesday of last week. All other periods are shifted by their length.
+func ResolvePreviousPeriod(interval *models.IntervalKey, from, to time.Time) (time.Time, time.Time) {
+	var previousFrom, previousTo time.Time
+
+	switch interval {
+	case models.IntervalToday, models.IntervalYesterday:
+		previousFrom, previousTo = from.AddDate(0, 0, -1), to.AddDate(0, 0, -1)
+	case models.IntervalThisWeek, models.IntervalLastWeek:
+		previousFrom, previousTo = from.AddDate(0, 0, -7), to.AddDate(0, 0, -7)
+	case models.IntervalThisMonth, models.IntervalLastMonth:
+		previousFrom, previousTo = from.AddDate(0, -1, 0), to.AddDate(0, -1, 0)
+	case models.IntervalThisYear:
+		previousFrom, previousTo = from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0)
+	default:
+		if isMidnight(from) && isMidnight(to) {
+			// whole days, shift by days to not be affected by dst changes
+			days := int(math.Round(to.Sub(from).Hours() / 24))
+			previousFrom, previousTo = from.AddDate(0, 0, -days), from
+		} else {
+			previousFrom, previousTo = from.Add(-to.Sub(from)), from
+		}
+	}
+
+	if previousTo.After(from) { // e.g. when shifting march 31st by one month
+		previousTo = from
+	}
+	return previousFrom, previousTo
+}
+
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
@@ -26,3 +26,27 @@ func TestResolveMaximumRange_EdgeCases(t *testing.T) {
 	_, maximumInterval := ResolveMaximumRange(-1)
 	assert.Equal(t, models.IntervalAny, maximumInterval)
 }
+
+func TestResolvePreviousPeriod(t *testing.T) {
+	tz, _ := time.LoadLocation("Europe/Berlin")
+	now := time.Date(2024, 3, 31, 15, 30, 0, 0, tz)
+
+	// calendar-based intervals are shifted by their calendar unit
+	from, to := ResolvePreviousPeriod(models.IntervalThisWeek, time.Date(2024, 3, 25, 0, 0, 0, 0, tz), now)
+	assert.Equal(t, time.Date(2024, 3, 18, 0, 0, 0, 0, tz), from)
+	assert.Equal(t, time.Date(2024, 3, 24, 15, 30, 0, 0, tz), to)
+
+	from, to = ResolvePreviousPeriod(models.IntervalThisMonth, time.Date(2024, 3, 1, 0, 0, 0, 0, tz), now)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, tz), from)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, tz), to) // feb 31st doesn't exist, so clamped to beginning of current period
+
+	// whole days are shifted by days, regardless of dst changes
+	from, to = ResolvePreviousPeriod(nil, time.Date(2024, 3, 31, 0, 0, 0, 0, tz), time.Date(2024, 4, 2, 0, 0, 0, 0, tz))
+	assert.Equal(t, time.Date(2024, 3, 29, 0, 0, 0, 0, tz), from)
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, tz), to)
+
+	// all others by their length
+	from, to = ResolvePreviousPeriod(models.IntervalPast7Days, now.AddDate(0, 0, -7), now)
+	assert.Equal(t, now.AddDate(0, 0, -7).Add(-now.Sub(now.AddDate(0, 0, -7))), from)
+	assert.Equal(t, now.AddDate(0, 0, -7), to)
+}
@@ -45,36 +45,56 @@ func ParseSummaryParams(r *http.Request) (*models.SummaryParams, error) {
 	}, nil
 }
 
-func ParseSummaryFilters(r *http.Request) *models.Filters {
-	filters := &models.Filters{}
-	if q := r.URL.Query().Get("project"); q != "" {
-		filters.With(models.SummaryProject, q)
-	}
-	if q := r.URL.Query().Get("language"); q != "" {
-		filters.With(models.SummaryLanguage, q)
-	}
-	if q := r.URL.Query().Get("editor"); q != "" {
-		filters.With(models.SummaryEditor, q)
-	}
-	if q := r.URL.Query().Get("machine"); q != "" {
-		filters.With(models.SummaryMachine, q)
-	}
-	if q := r.URL.Query().Get("operating_system"); q != "" {
-		filters.With(models.SummaryOS, q)
-	}
-	if q := r.URL.Query().Get("label"); q != "" {
-		filters.With(models.SummaryLabel, q)
-	}
-	if q := r.URL.Query().Get("branch"); q != "" {
-		filters.With(models.SummaryBranch, q)
-	}
-	if q := r.URL.Query().Get("entity"); q != "" {
-		filters.With(models.SummaryBranch, q)
+// ParseSummaryComparisonParams returns the params for the period to compare the summary given by params against or nil, if no comparison was requested.
+// The period is either given explicitly by "compare_from" and "compare_to" or by "compare", which is either an interval key or "previous" for the period before.
+func ParseSummaryComparisonParams(r *http.Request, params *models.SummaryParams) (*models.SummaryParams, error) {
+	query := r.URL.Query()
+	user := params.User
+
+	var err error
+	var from, to time.Time
+
+	if compare := query.Get("compare"); compare == "previous" {
+		interval := query.Get("interval")
+		if interval == "" {
+			interval = query.Get("start")
+		}
+		parsedInterval, _ := ParseInterval(interval)
+		from, to = ResolvePreviousPeriod(parsedInterval, params.From, params.To)
+	} else if compare != "" {
+		if err, from, to = ResolveIntervalRawTZ(compare, user.TZ(), user.StartOfWeekDay()); err != nil {
+			return nil, errors.New("invalid 'compare' parameter")
+		}
+	} else if query.Get("compare_from") != "" || query.Get("compare_to") != "" {
+		from, err = ParseDateTimeTZ(query.Get("compare_from"), user.TZ())
+		if err != nil {
+			return nil, errors.New("missing or invalid 'compare_from' parameter")
+		}
+
+		to, err = ParseDateTimeTZ(query.Get("compare_to"), user.TZ())
+		if err != nil {
+			return nil, errors.New("missing or invalid 'compare_to' parameter")
+		}
+	} else {
+		return nil, nil
 	}
-	if q := r.URL.Query().Get("category"); q != "" {
-		filters.With(models.SummaryCategory, q)
+
+	if !from.Before(to) {
+		return nil, errors.New("invalid comparison range")
 	}
-	return filters
+
+	return &models.SummaryParams{
+		From:      from,
+		To:        to,
+		User:      user,
+		Recompute: params.Recompute,
+		Filters:   params.Filters,
+	}, nil
+}
+
+// ParseSummaryFilters parses filters from the request's query, see models.NewFiltersFromQuery
+func ParseSummaryFilters(r *http.Request) *models.Filters {
+	return models.NewFiltersFromQuery(r.URL.Query())
 }
 
 func extractUser(r *http.Request) *models.User {
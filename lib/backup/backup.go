@@ -0,0 +1,459 @@
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// A backup is a gzip-compressed stream of json lines. It starts with a header, followed by one line per table, each of which is followed by one line per row of that table, and ends with a footer that holds the number of rows per table.
+// Rows are (de-)serialized through gorm's schema of the respective model, that is, values are written in their go representation instead of whatever representation a specific database uses (e.g. sqlite stores times as unix milliseconds).
+// This way, a backup can be restored into any of the supported dialects.
+
+const (
+	Format        = "wakapi-backup"
+	FormatVersion = 1
+	batchSize     = 500
+)
+
+var (
+	ErrNotEmpty   = errors.New("target database is not empty")
+	ErrInvalid    = errors.New("not a valid backup")
+	ErrIncomplete = errors.New("backup is incomplete")
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type Header struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	AppVersion string    `json:"app_version"`
+	Dialect    string    `json:"dialect"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Footer struct {
+	Tables map[string]int64 `json:"tables"`
+}
+
+// Manifest describes a backup that was written or restored, including the number of rows per table
+type Manifest struct {
+	Header
+	Tables map[string]int64
+}
+
+func (m *Manifest) TotalRows() (total int64) {
+	for _, n := range m.Tables {
+		total += n
+	}
+	return total
+}
+
+type record struct {
+	Header *Header                    `json:"header,omitempty"`
+	Table  string                     `json:"table,omitempty"`
+	Row    map[string]json.RawMessage `json:"row,omitempty"`
+	Footer *Footer                    `json:"footer,omitempty"`
+}
+
+type table struct {
+	schema *schema.Schema
+	fields []*schema.Field
+	byName map[string]*schema.Field
+}
+
+// Create writes a snapshot of all given entities (models) to w. All tables are read within a single transaction to get a consistent view of the database.
+func Create(db *gorm.DB, entities []interface{}, w io.Writer, appVersion string) (*Manifest, error) {
+	tables, err := parseTables(db, entities)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		Header: Header{
+			Format:     Format,
+			Version:    FormatVersion,
+			AppVersion: appVersion,
+			Dialect:    db.Dialector.Name(),
+			CreatedAt:  time.Now(),
+		},
+		Tables: make(map[string]int64, len(tables)),
+	}
+
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+
+	if err := enc.Encode(record{Header: &manifest.Header}); err != nil {
+		return nil, err
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range tables {
+			n, err := t.dump(tx, enc)
+			if err != nil {
+				return fmt.Errorf("failed to dump table '%s': %w", t.schema.Table, err)
+			}
+			manifest.Tables[t.schema.Table] = n
+		}
+		return nil
+	}, snapshotTxOptions(db)...); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Encode(record{Footer: &Footer{Tables: manifest.Tables}}); err != nil {
+		return nil, err
+	}
+	return manifest, gz.Close()
+}
+
+// Restore reads a backup from r and inserts all of its rows into the database, all within a single transaction.
+// The database schema must already exist (i.e. migrations must have been run before) and all tables must be empty, unless force is set, in which case they are cleared first.
+// Tables or columns contained in the backup, but unknown to the given entities (e.g. because the backup originates from a different version), are skipped.
+func Restore(db *gorm.DB, entities []interface{}, r io.Reader, force bool) (*Manifest, error) {
+	tables, err := parseTables(db, entities)
+	if err != nil {
+		return nil, err
+	}
+
+	tablesByName := make(map[string]*table, len(tables))
+	for _, t := range tables {
+		tablesByName[t.schema.Table] = t
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	defer gz.Close()
+	dec := json.NewDecoder(gz)
+
+	var first record
+	if err := dec.Decode(&first); err != nil || first.Header == nil || first.Header.Format != Format {
+		return nil, ErrInvalid
+	}
+	if first.Header.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported backup format version %d", first.Header.Version)
+	}
+
+	manifest := &Manifest{
+		Header: *first.Header,
+		Tables: make(map[string]int64, len(tables)),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := prepareTarget(tx, tables, force); err != nil {
+			return err
+		}
+
+		var current *table
+		batch := make([]reflect.Value, 0, batchSize)
+
+		flush := func() error {
+			if current == nil || len(batch) == 0 {
+				return nil
+			}
+			if err := current.insert(tx, batch); err != nil {
+				return fmt.Errorf("failed to restore table '%s': %w", current.schema.Table, err)
+			}
+			manifest.Tables[current.schema.Table] += int64(len(batch))
+			batch = batch[:0]
+			return nil
+		}
+
+		for {
+			var rec record
+			if err := dec.Decode(&rec); err != nil {
+				if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+					return ErrIncomplete
+				}
+				return err
+			}
+
+			switch {
+			case rec.Footer != nil:
+				if err := flush(); err != nil {
+					return err
+				}
+				if err := verifyCounts(manifest, rec.Footer, tablesByName); err != nil {
+					return err
+				}
+				// read until the end of the stream for gzip to verify its checksum
+				if _, err := io.Copy(io.Discard, gz); err != nil {
+					return fmt.Errorf("%w: %v", ErrIncomplete, err)
+				}
+				return resetSequences(tx, tables)
+			case rec.Table != "":
+				if err := flush(); err != nil {
+					return err
+				}
+				if current = tablesByName[rec.Table]; current == nil {
+					slog.Warn("skipping unknown table from backup", "table", rec.Table)
+				} else if _, ok := manifest.Tables[rec.Table]; !ok {
+					manifest.Tables[rec.Table] = 0 // list empty tables as well
+				}
+			case rec.Row != nil:
+				if current == nil {
+					continue
+				}
+				item, err := current.decodeRow(rec.Row)
+				if err != nil {
+					return fmt.Errorf("failed to decode row of table '%s': %w", current.schema.Table, err)
+				}
+				if batch = append(batch, item); len(batch) >= batchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func parseTables(db *gorm.DB, entities []interface{}) ([]*table, error) {
+	cache := &sync.Map{}
+	tables := make([]*table, 0, len(entities))
+
+	for _, entity := range entities {
+		s, err := schema.Parse(entity, cache, db.NamingStrategy)
+		if err != nil {
+			return nil, err
+		}
+
+		t := &table{schema: s, byName: make(map[string]*schema.Field)}
+		for _, f := range s.Fields {
+			// skip relations, ignored and read-only fields
+			if f.DBName == "" || !f.Readable || !f.Creatable {
+				continue
+			}
+			t.fields = append(t.fields, f)
+			t.byName[f.DBName] = f
+		}
+		tables = append(tables, t)
+	}
+
+	return tables, nil
+}
+
+func (t *table) dump(tx *gorm.DB, enc *json.Encoder) (int64, error) {
+	if err := enc.Encode(record{Table: t.schema.Table}); err != nil {
+		return 0, err
+	}
+
+	q := tx.Model(t.newItem().Interface())
+	for _, name := range t.schema.PrimaryFieldDBNames {
+		q = q.Order(clause.OrderByColumn{Column: clause.Column{Name: name}})
+	}
+
+	rows, err := q.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	ctx := context.Background()
+
+	var n int64
+	for rows.Next() {
+		item := t.newItem()
+		if err := tx.ScanRows(rows, item.Interface()); err != nil {
+			return n, err
+		}
+
+		row := make(map[string]json.RawMessage, len(t.fields))
+		for _, f := range t.fields {
+			raw, err := encodeValue(f.ReflectValueOf(ctx, item.Elem()))
+			if err != nil {
+				return n, fmt.Errorf("failed to encode column '%s': %w", f.DBName, err)
+			}
+			row[f.DBName] = raw
+		}
+
+		if err := enc.Encode(record{Row: row}); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, rows.Err()
+}
+
+func (t *table) decodeRow(row map[string]json.RawMessage) (reflect.Value, error) {
+	ctx := context.Background()
+	item := t.newItem()
+
+	for name, raw := range row {
+		f, ok := t.byName[name]
+		if !ok {
+			continue
+		}
+		v, err := decodeValue(raw, f.FieldType)
+		if err != nil {
+			return item, fmt.Errorf("failed to decode column '%s': %w", name, err)
+		}
+		f.ReflectValueOf(ctx, item.Elem()).Set(v)
+	}
+
+	return item, nil
+}
+
+func (t *table) insert(tx *gorm.DB, items []reflect.Value) error {
+	ctx := context.Background()
+
+	// rows are inserted as maps, because for structs, gorm would replace zero values (e.g. false) by the respective column's default (e.g. true)
+	rows := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		row := make(map[string]interface{}, len(t.fields))
+		for _, f := range t.fields {
+			value, zero := f.ValueOf(ctx, item.Elem())
+			// columns which default to null (e.g. a user's optional, but unique e-mail address) are stored as null rather than as their zero value
+			if zero && f.HasDefaultValue && strings.EqualFold(f.DefaultValue, "null") {
+				value = nil
+			}
+			row[f.DBName] = value
+		}
+		rows = append(rows, row)
+	}
+
+	return tx.
+		Session(&gorm.Session{SkipHooks: true}).
+		Model(t.newItem().Interface()).
+		Create(&rows).Error
+}
+
+func (t *table) newItem() reflect.Value {
+	return reflect.New(t.schema.ModelType)
+}
+
+func prepareTarget(tx *gorm.DB, tables []*table, force bool) error {
+	// in reverse order, to delete referencing rows first
+	for i := len(tables) - 1; i >= 0; i-- {
+		name := tables[i].schema.Table
+
+		var count int64
+		if err := tx.Table(name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			continue
+		}
+		if !force {
+			return fmt.Errorf("%w (table '%s' contains %d rows)", ErrNotEmpty, name, count)
+		}
+
+		slog.Warn("clearing table before restore", "table", name, "count", count)
+		if err := tx.Exec("DELETE FROM ?", clause.Table{Name: name}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verifyCounts(manifest *Manifest, footer *Footer, tables map[string]*table) error {
+	for name, expected := range footer.Tables {
+		if _, ok := tables[name]; !ok {
+			continue
+		}
+		if actual := manifest.Tables[name]; actual != expected {
+			return fmt.Errorf("%w (expected %d rows for table '%s', got %d)", ErrIncomplete, expected, name, actual)
+		}
+	}
+	return nil
+}
+
+// resetSequences updates postgres' auto-increment sequences after rows have been inserted with explicit ids
+// mysql and sqlite adapt their auto-increment counters automatically
+func resetSequences(tx *gorm.DB, tables []*table) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	for _, t := range tables {
+		f := t.schema.PrioritizedPrimaryField
+		if f == nil || !f.AutoIncrement {
+			continue
+		}
+		if err := tx.Exec(
+			"SELECT setval(pg_get_serial_sequence(?, ?), COALESCE(MAX(?), 0) + 1, false) FROM ?",
+			t.schema.Table, f.DBName, clause.Column{Name: f.DBName}, clause.Table{Name: t.schema.Table},
+		).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func snapshotTxOptions(db *gorm.DB) []*sql.TxOptions {
+	// sqlite transactions are serializable anyway and the driver doesn't support setting an isolation level
+	if strings.HasPrefix(db.Dialector.Name(), "sqlite") {
+		return nil
+	}
+	return []*sql.TxOptions{{Isolation: sql.LevelRepeatableRead, ReadOnly: true}}
+}
+
+// times (including custom types based on time.Time) are always encoded as rfc 3339 strings, regardless of their own json representation
+func encodeValue(v reflect.Value) (json.RawMessage, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return json.RawMessage("null"), nil
+		}
+		v = v.Elem()
+	}
+	if isTimeType(v.Type()) {
+		return json.Marshal(v.Convert(timeType).Interface())
+	}
+	return json.Marshal(v.Interface())
+}
+
+func decodeValue(raw json.RawMessage, typ reflect.Type) (reflect.Value, error) {
+	baseType := typ
+	if typ.Kind() == reflect.Pointer {
+		baseType = typ.Elem()
+	}
+
+	if isTimeType(baseType) {
+		if string(raw) == "null" {
+			return reflect.Zero(typ), nil
+		}
+
+		var t time.Time
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return reflect.Value{}, err
+		}
+
+		v := reflect.ValueOf(t).Convert(baseType)
+		if typ.Kind() == reflect.Pointer {
+			ptr := reflect.New(baseType)
+			ptr.Elem().Set(v)
+			return ptr, nil
+		}
+		return v, nil
+	}
+
+	v := reflect.New(typ)
+	if err := json.Unmarshal(raw, v.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return v.Elem(), nil
+}
+
+func isTimeType(t reflect.Type) bool {
+	return t == timeType || (t.Kind() == reflect.Struct && t.ConvertibleTo(timeType))
+}
@@ -0,0 +1,134 @@
+package backup
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// mimics models.CustomTime, which is stored as unix milliseconds and has an asymmetric json representation
+type backupTestTime time.Time
+
+func (t *backupTestTime) Scan(value interface{}) error {
+	*t = backupTestTime(time.UnixMilli(value.(int64)))
+	return nil
+}
+
+func (t backupTestTime) Value() (driver.Value, error) {
+	return time.Time(t).UnixMilli(), nil
+}
+
+func (t *backupTestTime) MarshalJSON() ([]byte, error) {
+	return []byte("0"), nil
+}
+
+type backupTestUser struct {
+	ID     string  `gorm:"primary_key"`
+	Email  string  `gorm:"uniqueIndex; default:null"`
+	Active bool    `gorm:"default:true"`
+	Parent *string `gorm:"default:null"`
+}
+
+type backupTestEvent struct {
+	ID     uint
+	User   *backupTestUser `gorm:"constraint:OnDelete:CASCADE"`
+	UserID string
+	Time   backupTestTime
+	Data   []byte
+}
+
+func TestBackup_CreateAndRestore(t *testing.T) {
+	source := newBackupTestDb(t)
+	target := newBackupTestDb(t)
+
+	parent := "user1"
+	now := time.Now().Round(time.Millisecond)
+
+	assert.Nil(t, source.Create(&backupTestUser{ID: "user1", Email: "user1@example.org", Active: true}).Error)
+	assert.Nil(t, source.Create(&backupTestUser{ID: "user2", Parent: &parent}).Error)
+	assert.Nil(t, source.Model(&backupTestUser{ID: "user2"}).Update("active", false).Error) // gorm would otherwise insert the default instead of false
+	assert.Nil(t, source.Create(&backupTestUser{ID: "user3", Active: true}).Error)
+	for i := 0; i < batchSize+1; i++ {
+		assert.Nil(t, source.Create(&backupTestEvent{UserID: "user2", Time: backupTestTime(now.Add(time.Duration(i) * time.Second)), Data: []byte{byte(i)}}).Error)
+	}
+
+	var buf bytes.Buffer
+	manifest, err := Create(source, backupTestEntities(), &buf, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "sqlite", manifest.Dialect)
+	assert.Equal(t, int64(3), manifest.Tables["backup_test_users"])
+	assert.Equal(t, int64(batchSize+1), manifest.Tables["backup_test_events"])
+
+	restored, err := Restore(target, backupTestEntities(), bytes.NewReader(buf.Bytes()), false)
+	assert.Nil(t, err)
+	assert.Equal(t, manifest.Tables, restored.Tables)
+	assert.Equal(t, "test", restored.AppVersion)
+
+	var users []*backupTestUser
+	assert.Nil(t, target.Order("id").Find(&users).Error)
+	assert.Len(t, users, 3)
+	assert.Equal(t, "user1@example.org", users[0].Email)
+	assert.False(t, users[1].Active)
+	assert.Equal(t, "user1", *users[1].Parent)
+	assert.Nil(t, users[2].Parent)
+
+	var nullEmails int64
+	assert.Nil(t, target.Model(&backupTestUser{}).Where("email is null").Count(&nullEmails).Error)
+	assert.Equal(t, int64(2), nullEmails)
+
+	var events []*backupTestEvent
+	assert.Nil(t, target.Order("id").Find(&events).Error)
+	assert.Len(t, events, batchSize+1)
+	assert.Equal(t, uint(batchSize+1), events[batchSize].ID)
+	assert.True(t, now.Add(time.Duration(batchSize)*time.Second).Equal(time.Time(events[batchSize].Time)))
+	assert.Equal(t, []byte{byte(batchSize % 256)}, events[batchSize].Data)
+}
+
+func TestBackup_Restore_Fails(t *testing.T) {
+	source := newBackupTestDb(t)
+	assert.Nil(t, source.Create(&backupTestUser{ID: "user1", Active: true}).Error)
+
+	var buf bytes.Buffer
+	_, err := Create(source, backupTestEntities(), &buf, "test")
+	assert.Nil(t, err)
+
+	t.Run("when target is not empty", func(t *testing.T) {
+		_, err := Restore(source, backupTestEntities(), bytes.NewReader(buf.Bytes()), false)
+		assert.ErrorIs(t, err, ErrNotEmpty)
+
+		// unless forced
+		_, err = Restore(source, backupTestEntities(), bytes.NewReader(buf.Bytes()), true)
+		assert.Nil(t, err)
+	})
+
+	t.Run("when backup is truncated", func(t *testing.T) {
+		target := newBackupTestDb(t)
+		_, err := Restore(target, backupTestEntities(), bytes.NewReader(buf.Bytes()[:buf.Len()-10]), false)
+		assert.NotNil(t, err)
+
+		var count int64
+		assert.Nil(t, target.Model(&backupTestUser{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("when input is no backup", func(t *testing.T) {
+		_, err := Restore(newBackupTestDb(t), backupTestEntities(), bytes.NewBufferString("foo"), false)
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+}
+
+func newBackupTestDb(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(backupTestEntities()...))
+	return db
+}
+
+func backupTestEntities() []interface{} {
+	return []interface{}{&backupTestUser{}, &backupTestEvent{}}
+}
@@ -0,0 +1,333 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Migrate copies all rows of the given entities from one database to another, possibly of a different dialect, chunk by chunk.
+// Like backups, rows are transferred in their go representation, so dialect-specific storage formats (e.g. sqlite's integer timestamps) are converted on the fly.
+// Every chunk is committed to the target together with the migration's progress, so an interrupted migration is resumed where it left off when run again.
+// Afterwards, row counts and checksums of every table are compared between source and target.
+// The target's schema must already exist, the source should not be written to while migrating.
+
+const progressTableName = "dbmigrate_progress"
+
+var ErrMismatch = errors.New("source and target differ")
+
+type MigrateOptions struct {
+	ChunkSize  int
+	SkipVerify bool
+	OnProgress func(table string, rows int64) // called after every chunk
+}
+
+// MigrateResult holds the number of rows per table that were copied in total, including previous, interrupted runs
+type MigrateResult struct {
+	Tables  map[string]int64
+	Resumed bool
+}
+
+func (r *MigrateResult) TotalRows() (total int64) {
+	for _, n := range r.Tables {
+		total += n
+	}
+	return total
+}
+
+type migrationProgress struct {
+	Name     string `gorm:"primary_key; type:varchar(255)"`
+	LastKey  string `gorm:"type:text"` // json-encoded primary key of the last copied row
+	Rows     int64
+	Checksum string // uint64 as string, because postgres doesn't support unsigned integers
+	Done     bool
+}
+
+func (migrationProgress) TableName() string {
+	return progressTableName
+}
+
+func (p *migrationProgress) checksum() uint64 {
+	sum, _ := strconv.ParseUint(p.Checksum, 10, 64)
+	return sum
+}
+
+func Migrate(source, target *gorm.DB, entities []interface{}, opts MigrateOptions) (*MigrateResult, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = batchSize
+	}
+
+	tables, err := parseTables(target, entities)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := target.AutoMigrate(&migrationProgress{}); err != nil {
+		return nil, err
+	}
+
+	var existing []*migrationProgress
+	if err := target.Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	progress := make(map[string]*migrationProgress, len(existing))
+	for _, p := range existing {
+		progress[p.Name] = p
+	}
+
+	result := &MigrateResult{Tables: make(map[string]int64, len(tables)), Resumed: len(existing) > 0}
+
+	for _, t := range tables {
+		p, ok := progress[t.schema.Table]
+		if !ok {
+			if p, err = t.initProgress(source, target); err != nil {
+				return nil, err
+			}
+			progress[t.schema.Table] = p
+		}
+
+		if !p.Done {
+			if err := t.copy(source, target, p, opts); err != nil {
+				return nil, fmt.Errorf("failed to migrate table '%s': %w", t.schema.Table, err)
+			}
+		}
+		result.Tables[t.schema.Table] = p.Rows
+	}
+
+	if err := resetSequences(target, tables); err != nil {
+		return nil, err
+	}
+
+	if !opts.SkipVerify {
+		for _, t := range tables {
+			if err := t.verify(source, target, progress[t.schema.Table]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := target.Migrator().DropTable(&migrationProgress{}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (t *table) initProgress(source, target *gorm.DB) (*migrationProgress, error) {
+	p := &migrationProgress{Name: t.schema.Table, Checksum: "0"}
+
+	var count int64
+	if err := target.Table(t.schema.Table).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w (table '%s' contains %d rows)", ErrNotEmpty, t.schema.Table, count)
+	}
+
+	// e.g. when migrating from an older version
+	if !source.Migrator().HasTable(t.schema.Table) {
+		slog.Warn("table does not exist in source database, skipping", "table", t.schema.Table)
+		p.Done = true
+	}
+
+	return p, target.Create(p).Error
+}
+
+func (t *table) copy(source, target *gorm.DB, p *migrationProgress, opts MigrateOptions) error {
+	if p.Rows > 0 {
+		slog.Info("resuming migration of table", "table", t.schema.Table, "rows", p.Rows)
+	}
+
+	checksum := p.checksum()
+
+	for {
+		items, err := t.fetchChunk(source, p.LastKey, opts.ChunkSize)
+		if err != nil {
+			return err
+		}
+
+		if len(items) > 0 {
+			for _, item := range items {
+				checksum += t.hashRow(item)
+			}
+
+			lastKey, err := t.encodeKey(items[len(items)-1])
+			if err != nil {
+				return err
+			}
+
+			p.LastKey = lastKey
+			p.Rows += int64(len(items))
+			p.Checksum = strconv.FormatUint(checksum, 10)
+		}
+		p.Done = len(items) < opts.ChunkSize
+
+		if err := target.Transaction(func(tx *gorm.DB) error {
+			if len(items) > 0 {
+				if err := t.insert(tx, items); err != nil {
+					return err
+				}
+			}
+			return tx.Save(p).Error
+		}); err != nil {
+			return err
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(t.schema.Table, p.Rows)
+		}
+		if p.Done {
+			return nil
+		}
+	}
+}
+
+// fetchChunk reads the next rows following the given primary key (keyset pagination), ordered by the source database's collation
+func (t *table) fetchChunk(source *gorm.DB, lastKey string, limit int) ([]reflect.Value, error) {
+	// all of wakapi's models have a single-column primary key
+	if len(t.schema.PrimaryFields) != 1 {
+		return nil, fmt.Errorf("table '%s' does not have a single-column primary key", t.schema.Table)
+	}
+	column := clause.Column{Name: t.schema.PrimaryFields[0].DBName}
+
+	q := source.
+		Model(t.newItem().Interface()).
+		Order(clause.OrderByColumn{Column: column}).
+		Limit(limit)
+
+	if lastKey != "" {
+		values, err := t.decodeKey(lastKey)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("? > ?", column, values[0])
+	}
+
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]reflect.Value, 0, limit)
+	for rows.Next() {
+		item := t.newItem()
+		if err := source.ScanRows(rows, item.Interface()); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (t *table) verify(source, target *gorm.DB, p *migrationProgress) error {
+	if !source.Migrator().HasTable(t.schema.Table) {
+		return nil
+	}
+
+	var sourceCount, targetCount int64
+	if err := source.Table(t.schema.Table).Count(&sourceCount).Error; err != nil {
+		return err
+	}
+	if err := target.Table(t.schema.Table).Count(&targetCount).Error; err != nil {
+		return err
+	}
+	if sourceCount != targetCount {
+		return fmt.Errorf("%w (table '%s' has %d rows in source, but %d in target)", ErrMismatch, t.schema.Table, sourceCount, targetCount)
+	}
+
+	rows, err := target.Model(t.newItem().Interface()).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var checksum uint64
+	for rows.Next() {
+		item := t.newItem()
+		if err := target.ScanRows(rows, item.Interface()); err != nil {
+			return err
+		}
+		checksum += t.hashRow(item)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if checksum != p.checksum() {
+		return fmt.Errorf("%w (checksums of table '%s' don't match)", ErrMismatch, t.schema.Table)
+	}
+	return nil
+}
+
+// hashRow computes a hash over all of a row's values. Per table, the hashes of all rows are summed up, so the resulting checksum does not depend on the order of rows, which might differ between dialects.
+func (t *table) hashRow(item reflect.Value) uint64 {
+	ctx := context.Background()
+	h := fnv.New64a()
+
+	for _, f := range t.fields {
+		v := f.ReflectValueOf(ctx, item.Elem())
+		if v.Kind() == reflect.Pointer && !v.IsNil() {
+			v = v.Elem()
+		}
+		// time precision and zone depend on the database
+		if v.Kind() != reflect.Pointer && isTimeType(v.Type()) {
+			v = reflect.ValueOf(v.Convert(timeType).Interface().(time.Time).Round(time.Millisecond).UTC())
+		}
+
+		raw, err := encodeValue(v)
+		if err != nil {
+			raw = []byte(fmt.Sprintf("%v", v.Interface()))
+		}
+		h.Write([]byte(f.DBName))
+		h.Write(raw)
+		h.Write([]byte{0})
+	}
+
+	return h.Sum64()
+}
+
+func (t *table) encodeKey(item reflect.Value) (string, error) {
+	ctx := context.Background()
+
+	key := make([]json.RawMessage, 0, len(t.schema.PrimaryFields))
+	for _, f := range t.schema.PrimaryFields {
+		raw, err := encodeValue(f.ReflectValueOf(ctx, item.Elem()))
+		if err != nil {
+			return "", err
+		}
+		key = append(key, raw)
+	}
+
+	data, err := json.Marshal(key)
+	return string(data), err
+}
+
+func (t *table) decodeKey(data string) ([]interface{}, error) {
+	var key []json.RawMessage
+	if err := json.Unmarshal([]byte(data), &key); err != nil {
+		return nil, err
+	}
+	if len(key) != len(t.schema.PrimaryFields) {
+		return nil, fmt.Errorf("invalid key '%s' for table '%s'", data, t.schema.Table)
+	}
+
+	values := make([]interface{}, 0, len(key))
+	for i, f := range t.schema.PrimaryFields {
+		v, err := decodeValue(key[i], f.FieldType)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v.Interface())
+	}
+	return values, nil
+}
@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	source := newBackupTestDb(t)
+	target := newBackupTestDb(t)
+
+	now := time.Now().Round(time.Millisecond)
+
+	assert.Nil(t, source.Create(&backupTestUser{ID: "user1", Email: "user1@example.org", Active: true}).Error)
+	assert.Nil(t, source.Create(&backupTestUser{ID: "user2"}).Error)
+	assert.Nil(t, source.Model(&backupTestUser{ID: "user2"}).Update("active", false).Error)
+	for i := 0; i < 25; i++ {
+		assert.Nil(t, source.Create(&backupTestEvent{UserID: "user1", Time: backupTestTime(now.Add(time.Duration(i) * time.Second)), Data: []byte{byte(i)}}).Error)
+	}
+
+	// interrupt after two chunks of events
+	var chunks int
+	func() {
+		defer func() { assert.NotNil(t, recover()) }()
+		Migrate(source, target, backupTestEntities(), MigrateOptions{
+			ChunkSize: 10,
+			OnProgress: func(table string, rows int64) {
+				if table == "backup_test_events" {
+					if chunks++; chunks == 2 {
+						panic("interrupted")
+					}
+				}
+			},
+		})
+	}()
+
+	var count int64
+	assert.Nil(t, target.Model(&backupTestEvent{}).Count(&count).Error)
+	assert.Equal(t, int64(20), count)
+
+	// resume
+	var resumedRows []int64
+	result, err := Migrate(source, target, backupTestEntities(), MigrateOptions{
+		ChunkSize: 10,
+		OnProgress: func(table string, rows int64) {
+			resumedRows = append(resumedRows, rows)
+		},
+	})
+	assert.Nil(t, err)
+	assert.True(t, result.Resumed)
+	assert.Equal(t, map[string]int64{"backup_test_users": 2, "backup_test_events": 25}, result.Tables)
+	assert.Equal(t, []int64{25}, resumedRows)
+	assert.False(t, target.Migrator().HasTable(progressTableName))
+
+	var users []*backupTestUser
+	assert.Nil(t, target.Order("id").Find(&users).Error)
+	assert.Len(t, users, 2)
+	assert.False(t, users[1].Active)
+
+	var events []*backupTestEvent
+	assert.Nil(t, target.Order("id").Find(&events).Error)
+	assert.Len(t, events, 25)
+	assert.True(t, now.Add(24*time.Second).Equal(time.Time(events[24].Time)))
+}
+
+func TestMigrate_Fails(t *testing.T) {
+	source := newBackupTestDb(t)
+	assert.Nil(t, source.Create(&backupTestUser{ID: "user1", Active: true}).Error)
+
+	t.Run("when target is not empty", func(t *testing.T) {
+		target := newBackupTestDb(t)
+		assert.Nil(t, target.Create(&backupTestUser{ID: "user2", Active: true}).Error)
+
+		_, err := Migrate(source, target, backupTestEntities(), MigrateOptions{})
+		assert.ErrorIs(t, err, ErrNotEmpty)
+	})
+
+	t.Run("when target differs from source", func(t *testing.T) {
+		target := newBackupTestDb(t)
+		_, err := Migrate(source, target, backupTestEntities(), MigrateOptions{
+			OnProgress: func(table string, rows int64) {
+				// simulate a lossy copy
+				target.Model(&backupTestUser{}).Where("id = ?", "user1").Update("active", false)
+			},
+		})
+		assert.ErrorIs(t, err, ErrMismatch)
+	})
+}
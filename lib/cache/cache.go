@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/muety/wakapi/lib/metrics"
+)
+
+const (
+	// NoExpiration is used with Set to indicate that an item should never expire
+	NoExpiration time.Duration = -1
+	// DefaultExpiration is used with Set to indicate that an item should expire after the cache's default expiration time
+	DefaultExpiration time.Duration = 0
+)
+
+// Cache is a key-value store for arbitrary objects, which is safe for concurrent use.
+// Depending on the configured backend, it is either local to the process or shared among multiple wakapi instances.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{}, ttl time.Duration)
+	SetDefault(key string, value interface{})
+	IncrementInt64(key string, n int64) (int64, error)
+	Delete(key string)
+	// DeleteMatching deletes all items whose key contains the given substring
+	DeleteMatching(substr string)
+	// DeleteFunc deletes all items for which the given predicate is true. As predicates can only be evaluated locally, shared caches will drop their remote copies entirely.
+	DeleteFunc(match func(key string, value interface{}) bool)
+	Flush()
+	ItemCount() int
+}
+
+type Backend interface {
+	New(name string, defaultExpiration, cleanupInterval time.Duration) Cache
+}
+
+var backend Backend = &MemoryBackend{}
+
+// SetBackend replaces the backend used for all caches created afterwards, should be called once at startup
+func SetBackend(b Backend) {
+	backend = b
+}
+
+// New creates a new cache using the currently configured backend. The name identifies the cache among wakapi instances and must be unique per service.
+func New(name string, defaultExpiration, cleanupInterval time.Duration) Cache {
+	return &instrumentedCache{
+		Cache: backend.New(name, defaultExpiration, cleanupInterval),
+		hits:  metrics.CacheRequests.WithLabelValues(name, metrics.ResultHit),
+		miss:  metrics.CacheRequests.WithLabelValues(name, metrics.ResultMiss),
+	}
+}
+
+// instrumentedCache counts hits and misses per named cache
+type instrumentedCache struct {
+	Cache
+	hits metrics.Counter
+	miss metrics.Counter
+}
+
+func (c *instrumentedCache) Get(key string) (interface{}, bool) {
+	value, found := c.Cache.Get(key)
+	if found {
+		c.hits.Inc()
+	} else {
+		c.miss.Inc()
+	}
+	return value, found
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"strings"
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+type MemoryBackend struct{}
+
+func (b *MemoryBackend) New(name string, defaultExpiration, cleanupInterval time.Duration) Cache {
+	return NewMemoryCache(defaultExpiration, cleanupInterval)
+}
+
+// MemoryCache is a process-local, in-memory cache backed by go-cache
+type MemoryCache struct {
+	cache *gocache.Cache
+}
+
+func NewMemoryCache(defaultExpiration, cleanupInterval time.Duration) *MemoryCache {
+	return &MemoryCache{cache: gocache.New(defaultExpiration, cleanupInterval)}
+}
+
+func (c *MemoryCache) Get(key string) (interface{}, bool) {
+	return c.cache.Get(key)
+}
+
+func (c *MemoryCache) Set(key string, value interface{}, ttl time.Duration) {
+	c.cache.Set(key, value, ttl)
+}
+
+func (c *MemoryCache) SetDefault(key string, value interface{}) {
+	c.cache.SetDefault(key, value)
+}
+
+func (c *MemoryCache) IncrementInt64(key string, n int64) (int64, error) {
+	return c.cache.IncrementInt64(key, n)
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.cache.Delete(key)
+}
+
+func (c *MemoryCache) DeleteMatching(substr string) {
+	for key := range c.cache.Items() {
+		if strings.Contains(key, substr) {
+			c.cache.Delete(key)
+		}
+	}
+}
+
+func (c *MemoryCache) DeleteFunc(match func(key string, value interface{}) bool) {
+	for key, item := range c.cache.Items() {
+		if match(key, item.Object) {
+			c.cache.Delete(key)
+		}
+	}
+}
+
+func (c *MemoryCache) Flush() {
+	c.cache.Flush()
+}
+
+func (c *MemoryCache) ItemCount() int {
+	return c.cache.ItemCount()
+}
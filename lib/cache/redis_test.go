@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/lib/metrics"
+)
+
+type testObject struct {
+	ID string
+}
+
+func setupRedisBackends(t *testing.T) (*miniredis.Miniredis, *RedisBackend, *RedisBackend) {
+	server := miniredis.RunT(t)
+
+	b1, err := NewRedisBackend("redis://"+server.Addr(), "wakapi_test", "instance1")
+	require.Nil(t, err)
+	b2, err := NewRedisBackend("redis://"+server.Addr(), "wakapi_test", "instance2")
+	require.Nil(t, err)
+
+	t.Cleanup(func() {
+		b1.Close()
+		b2.Close()
+	})
+
+	return server, b1, b2
+}
+
+func TestRedisCache_SharesPrimitiveValues(t *testing.T) {
+	server, b1, b2 := setupRedisBackends(t)
+	c1, c2 := b1.New("test", time.Hour, time.Hour), b2.New("test", time.Hour, time.Hour)
+
+	c1.SetDefault("foo", "bar")
+	c1.Set("count", int64(42), 10*time.Minute)
+	c1.Set("forever", true, NoExpiration)
+
+	result, found := c2.Get("foo")
+	assert.True(t, found)
+	assert.Equal(t, "bar", result)
+
+	result, found = c2.Get("count")
+	assert.True(t, found)
+	assert.Equal(t, int64(42), result)
+
+	assert.True(t, server.Exists("wakapi_test:cache:test:forever"))
+	assert.Equal(t, time.Duration(0), server.TTL("wakapi_test:cache:test:forever"))
+	assert.Equal(t, time.Hour, server.TTL("wakapi_test:cache:test:foo"))
+}
+
+func TestRedisCache_KeepsObjectsLocal(t *testing.T) {
+	server, b1, b2 := setupRedisBackends(t)
+	c1, c2 := b1.New("test", time.Hour, time.Hour), b2.New("test", time.Hour, time.Hour)
+
+	obj := &testObject{ID: "1"}
+	c1.SetDefault("obj", obj)
+
+	result, found := c1.Get("obj")
+	assert.True(t, found)
+	assert.Same(t, obj, result)
+
+	_, found = c2.Get("obj")
+	assert.False(t, found)
+	assert.False(t, server.Exists("wakapi_test:cache:test:obj"))
+}
+
+func TestRedisCache_PropagatesInvalidations(t *testing.T) {
+	_, b1, b2 := setupRedisBackends(t)
+	c1, c2 := b1.New("test", time.Hour, time.Hour), b2.New("test", time.Hour, time.Hour)
+	other := b2.New("other", time.Hour, time.Hour)
+
+	c2.SetDefault("obj", &testObject{ID: "1"})
+	c2.SetDefault("user1_summary", &testObject{ID: "2"})
+	c2.SetDefault("user2_summary", &testObject{ID: "3"})
+	other.SetDefault("obj", &testObject{ID: "4"})
+	assert.Equal(t, 3, c2.ItemCount())
+
+	c1.Delete("obj")
+	assert.Eventually(t, func() bool {
+		_, found := c2.Get("obj")
+		return !found
+	}, time.Second, 10*time.Millisecond)
+
+	c1.DeleteMatching("user1")
+	assert.Eventually(t, func() bool {
+		_, found := c2.Get("user1_summary")
+		return !found
+	}, time.Second, 10*time.Millisecond)
+	_, found := c2.Get("user2_summary")
+	assert.True(t, found)
+
+	c1.DeleteFunc(func(key string, value interface{}) bool { return false })
+	assert.Eventually(t, func() bool {
+		return c2.ItemCount() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// caches of other names are unaffected
+	_, found = other.Get("obj")
+	assert.True(t, found)
+}
+
+func TestRedisCache_SetOverridesStaleValues(t *testing.T) {
+	_, b1, b2 := setupRedisBackends(t)
+	c1, c2 := b1.New("test", time.Hour, time.Hour), b2.New("test", time.Hour, time.Hour)
+
+	c1.SetDefault("foo", "bar")
+	result, _ := c2.Get("foo")
+	assert.Equal(t, "bar", result)
+
+	c1.SetDefault("foo", "baz")
+	assert.Eventually(t, func() bool {
+		result, _ := c2.Get("foo")
+		return result == "baz"
+	}, time.Second, 10*time.Millisecond)
+
+	c1.SetDefault("foo", &testObject{ID: "1"})
+	assert.Eventually(t, func() bool {
+		_, found := c2.Get("foo")
+		return !found
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMemoryCache_DeleteMatching(t *testing.T) {
+	c := NewMemoryCache(NoExpiration, NoExpiration)
+	c.SetDefault("summary_user1_a", 1)
+	c.SetDefault("summary_user1_b", 2)
+	c.SetDefault("summary_user2_a", 3)
+
+	c.DeleteMatching("user1")
+	assert.Equal(t, 1, c.ItemCount())
+
+	c.DeleteFunc(func(key string, value interface{}) bool { return value.(int) == 3 })
+	assert.Equal(t, 0, c.ItemCount())
+}
+
+func TestCache_CountsHitsAndMisses(t *testing.T) {
+	c := New("hit_ratio_test", NoExpiration, NoExpiration)
+	c.SetDefault("foo", 1)
+
+	c.Get("foo")
+	c.Get("foo")
+	c.Get("bar")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("hit_ratio_test", metrics.ResultHit)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("hit_ratio_test", metrics.ResultMiss)))
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type gormTestItem struct {
+	ID   uint
+	Name string
+}
+
+func TestGormPlugin_RecordsQueries(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(NewGormPlugin()))
+	assert.Nil(t, db.AutoMigrate(&gormTestItem{}))
+
+	assert.Nil(t, db.Create(&gormTestItem{Name: "foo"}).Error)
+
+	var items []*gormTestItem
+	assert.Nil(t, db.Find(&items).Error)
+	assert.Len(t, items, 1)
+
+	var missing gormTestItem
+	assert.ErrorIs(t, db.Where("id = ?", -1).First(&missing).Error, gorm.ErrRecordNotFound)
+
+	assert.NotNil(t, db.Exec("delete from nonexisting_table").Error)
+
+	assert.Equal(t, uint64(1), sampleCount(t, "create", "gorm_test_items"))
+	assert.Equal(t, uint64(2), sampleCount(t, "query", "gorm_test_items"))
+	assert.Equal(t, 0.0, testutil.ToFloat64(DbQueryErrors.WithLabelValues("query", "gorm_test_items")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(DbQueryErrors.WithLabelValues("raw", "-")))
+}
+
+func sampleCount(t *testing.T, labels ...string) uint64 {
+	observer, err := DbQueryDuration.GetMetricWithLabelValues(labels...)
+	assert.Nil(t, err)
+
+	var m dto.Metric
+	assert.Nil(t, observer.(prometheus.Metric).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
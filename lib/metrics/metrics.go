@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Instrumentation of wakapi's internals (http, database, caches, job queues, ...) in terms of native prometheus metrics.
+// As opposed to the user-facing "wakatime_*" metrics, which are computed by the metrics api handler, these describe the health of the application itself.
+
+const Namespace = "wakapi"
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	HttpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of http requests by route pattern, method and status code.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"route", "method", "status"})
+
+	DbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of database queries by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"operation", "table"})
+
+	DbQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "Number of failed database queries by operation and table.",
+	}, []string{"operation", "table"})
+
+	HeartbeatsIngested = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "heartbeats",
+		Name:      "ingested_total",
+		Help:      "Number of heartbeats received through the api.",
+	})
+
+	HeartbeatsImported = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "heartbeats",
+		Name:      "imported_total",
+		Help:      "Number of heartbeats imported from external services by importer.",
+	}, []string{"importer"})
+
+	ImportDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "imports",
+		Name:      "duration_seconds",
+		Help:      "Duration of completed import jobs by importer and result.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"importer", "result"})
+
+	RelayRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "relay",
+		Name:      "requests_total",
+		Help:      "Number of heartbeat requests relayed to wakatime (or compatible services) by result.",
+	}, []string{"result"})
+
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Number of cache lookups by cache name and result (hit or miss).",
+	}, []string{"cache", "result"})
+)
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HttpRequestDuration,
+		DbQueryDuration,
+		DbQueryErrors,
+		HeartbeatsIngested,
+		HeartbeatsImported,
+		ImportDuration,
+		RelayRequests,
+		CacheRequests,
+	)
+}
+
+// Counter is re-exported for convenience, so that instrumented packages don't need to depend on the prometheus client directly
+type Counter = prometheus.Counter
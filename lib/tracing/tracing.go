@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Thin helpers around opentelemetry's global tracer provider. As long as no exporter is configured (see config.InitTracing), all spans are no-ops.
+
+const instrumentationName = "github.com/muety/wakapi"
+
+const (
+	AttrUser    = attribute.Key("wakapi.user")
+	AttrFrom    = attribute.Key("wakapi.from")
+	AttrTo      = attribute.Key("wakapi.to")
+	AttrFilters = attribute.Key("wakapi.filters")
+	AttrJob     = attribute.Key("wakapi.job")
+	AttrCount   = attribute.Key("wakapi.count")
+)
+
+func Tracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(instrumentationName)
+}
+
+// Start creates a new span as a child of whichever span is contained in the given context (if any)
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End finishes the given span and marks it as failed, if an error is passed
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// StartJob creates a new root span for a background job, e.g. one that was dispatched to a job queue
+func StartJob(name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(context.Background(), "job "+name, trace.WithNewRoot(), trace.WithAttributes(append(attrs, AttrJob.String(name))...))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"flag"
 	"io/fs"
@@ -25,8 +26,12 @@ import (
 	_ "gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 
 	conf "github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/lib/metrics"
+	"github.com/muety/wakapi/lib/tracing"
 	"github.com/muety/wakapi/middlewares"
 	"github.com/muety/wakapi/migrations"
 	"github.com/muety/wakapi/repositories"
@@ -35,6 +40,7 @@ import (
 	shieldsV1Routes "github.com/muety/wakapi/routes/compat/shields/v1"
 	wtV1Routes "github.com/muety/wakapi/routes/compat/wakatime/v1"
 	"github.com/muety/wakapi/services"
+	"github.com/muety/wakapi/services/events"
 	"github.com/muety/wakapi/services/mail"
 	"github.com/muety/wakapi/static/docs"
 	fsutils "github.com/muety/wakapi/utils/fs"
@@ -56,19 +62,28 @@ var (
 )
 
 var (
-	aliasRepository           repositories.IAliasRepository
-	heartbeatRepository       repositories.IHeartbeatRepository
-	userRepository            repositories.IUserRepository
-	languageMappingRepository repositories.ILanguageMappingRepository
-	projectLabelRepository    repositories.IProjectLabelRepository
-	summaryRepository         repositories.ISummaryRepository
-	leaderboardRepository     *repositories.LeaderboardRepository
-	keyValueRepository        repositories.IKeyValueRepository
-	diagnosticsRepository     repositories.IDiagnosticsRepository
-	metricsRepository         *repositories.MetricsRepository
-	durationRepository        *repositories.DurationRepository
-	apiKeyRepository          repositories.IApiKeyRepository
-	webAuthnRepository        repositories.IWebAuthnRepository
+	aliasRepository            repositories.IAliasRepository
+	heartbeatRepository        repositories.IHeartbeatRepository
+	heartbeatArchiveRepository repositories.IHeartbeatArchiveRepository
+	userRepository             repositories.IUserRepository
+	languageMappingRepository  repositories.ILanguageMappingRepository
+	projectLabelRepository     repositories.IProjectLabelRepository
+	dayOffRepository           repositories.IDayOffRepository
+	dashboardRepository        repositories.IDashboardRepository
+	filterPresetRepository     repositories.IFilterPresetRepository
+	summaryRepository          repositories.ISummaryRepository
+	leaderboardRepository      *repositories.LeaderboardRepository
+	keyValueRepository         repositories.IKeyValueRepository
+	diagnosticsRepository      repositories.IDiagnosticsRepository
+	metricsRepository          *repositories.MetricsRepository
+	durationRepository         *repositories.DurationRepository
+	apiKeyRepository           repositories.IApiKeyRepository
+	webAuthnRepository         repositories.IWebAuthnRepository
+	sessionRepository          repositories.ISessionRepository
+	securityEventRepository    repositories.ISecurityEventRepository
+	leaseRepository            repositories.ILeaseRepository
+	backupRepository           repositories.IBackupRepository
+	eventOutboxRepository      repositories.IEventOutboxRepository
 )
 
 var (
@@ -77,6 +92,9 @@ var (
 	userService            services.IUserService
 	languageMappingService services.ILanguageMappingService
 	projectLabelService    services.IProjectLabelService
+	dayOffService          services.IDayOffService
+	dashboardService       services.IDashboardService
+	filterPresetService    services.IFilterPresetService
 	projectService         services.IProjectService
 	durationService        services.IDurationService
 	summaryService         services.ISummaryService
@@ -86,11 +104,19 @@ var (
 	keyValueService        services.IKeyValueService
 	reportService          services.IReportService
 	activityService        services.IActivityService
+	insightsService        services.IInsightsService
+	focusService           services.IFocusService
+	badgeService           services.IBadgeService
+	readmeCardService      services.IReadmeCardService
 	diagnosticsService     services.IDiagnosticsService
 	housekeepingService    services.IHousekeepingService
 	miscService            services.IMiscService
 	apiKeyService          services.IApiKeyService
 	webAuthnService        services.IWebAuthnService
+	sessionService         services.ISessionService
+	securityEventService   services.ISecurityEventService
+	leaseService           services.ILeaseService
+	backupService          services.IBackupService
 )
 
 // TODO: Refactor entire project to be structured after business domains
@@ -125,11 +151,23 @@ func main() {
 	}
 	config = conf.Load(*configFlag, version)
 
+	// Run sub-command (e.g. backup, restore) instead of the web server
+	if command := flag.Arg(0); command != "" {
+		os.Exit(runCommand(command, flag.Args()[1:]))
+	}
+
 	// Configure Swagger docs
 	docs.SwaggerInfo.BasePath = config.Server.BasePath + "/api"
 
 	slog.Info("Wakapi", "version", config.Version)
 
+	// Set up tracing
+	shutdownTracing, err := conf.InitTracing(config)
+	if err != nil {
+		conf.Log().Fatal("failed to initialize tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Set up GORM
 	gormLogger := logger.New(
 		log.New(os.Stdout, "", log.LstdFlags),
@@ -141,7 +179,6 @@ func main() {
 	)
 
 	// Connect to database
-	var err error
 	slog.Info("starting with database", "dialect", config.Db.Dialect)
 	db, err = gorm.Open(
 		config.Db.GetDialector(),
@@ -152,6 +189,30 @@ func main() {
 		conf.Log().Fatal("could not connect to database", "error", err)
 	}
 
+	if config.Security.ExposeMetrics {
+		if err := db.Use(metrics.NewGormPlugin()); err != nil {
+			conf.Log().Fatal("failed to register database metrics", "error", err)
+		}
+	}
+	if config.Tracing.Exporter != conf.TracingExporterNone {
+		if err := db.Use(tracing.NewGormPlugin()); err != nil {
+			conf.Log().Fatal("failed to register database tracing", "error", err)
+		}
+	}
+
+	if config.Db.HasReplica() {
+		// queries are only routed to the replica explicitly (see repositories.BaseRepository), all others keep going to the primary
+		replicaResolver := dbresolver.Register(dbresolver.Config{
+			Replicas: []gorm.Dialector{config.Db.GetReplicaDialector()},
+		}, conf.DbReplicaResolver).
+			SetMaxIdleConns(int(config.Db.MaxConn)).
+			SetMaxOpenConns(int(config.Db.MaxConn))
+		if err := db.Use(replicaResolver); err != nil {
+			conf.Log().Fatal("could not connect to database replica", "error", err)
+		}
+		slog.Info("using read replica for analytics queries")
+	}
+
 	if config.IsDev() {
 		db = db.Debug()
 	}
@@ -168,12 +229,27 @@ func main() {
 		migrations.Run(db, config)
 	}
 
+	// Set up shared cache
+	if config.Cache.Backend == "redis" {
+		slog.Info("using redis cache backend")
+		cacheBackend, err := cache.NewRedisBackend(config.Cache.RedisUrl, config.Cache.KeyPrefix, config.InstanceId)
+		if err != nil {
+			conf.Log().Fatal("could not connect to redis", "error", err)
+		}
+		defer cacheBackend.Close()
+		cache.SetBackend(cacheBackend)
+	}
+
 	// Repositories
 	aliasRepository = repositories.NewAliasRepository(db)
 	heartbeatRepository = repositories.NewHeartbeatRepository(db)
+	heartbeatArchiveRepository = repositories.NewHeartbeatArchiveRepository(db)
 	userRepository = repositories.NewUserRepository(db)
 	languageMappingRepository = repositories.NewLanguageMappingRepository(db)
 	projectLabelRepository = repositories.NewProjectLabelRepository(db)
+	dayOffRepository = repositories.NewDayOffRepository(db)
+	dashboardRepository = repositories.NewDashboardRepository(db)
+	filterPresetRepository = repositories.NewFilterPresetRepository(db)
 	summaryRepository = repositories.NewSummaryRepository(db)
 	leaderboardRepository = repositories.NewLeaderboardRepository(db)
 	keyValueRepository = repositories.NewKeyValueRepository(db)
@@ -182,37 +258,77 @@ func main() {
 	durationRepository = repositories.NewDurationRepository(db)
 	apiKeyRepository = repositories.NewApiKeyRepository(db)
 	webAuthnRepository = repositories.NewWebAuthnRepository(db)
+	sessionRepository = repositories.NewSessionRepository(db)
+	securityEventRepository = repositories.NewSecurityEventRepository(db)
+	leaseRepository = repositories.NewLeaseRepository(db)
+	eventOutboxRepository = repositories.NewEventOutboxRepository(db)
+	backupRepository = repositories.NewBackupRepository(db)
 
 	// Services
 	mailService = mail.NewMailService()
+	leaseService = services.NewLeaseService(leaseRepository)
 	aliasService = services.NewAliasService(aliasRepository)
 	keyValueService = services.NewKeyValueService(keyValueRepository)
 	apiKeyService = services.NewApiKeyService(apiKeyRepository)
-	userService = services.NewUserService(keyValueService, mailService, apiKeyService, userRepository)
+	sessionService = services.NewSessionService(sessionRepository)
+	securityEventService = services.NewSecurityEventService(mailService, securityEventRepository)
+	userService = services.NewUserService(keyValueService, mailService, apiKeyService, sessionService, userRepository)
 	languageMappingService = services.NewLanguageMappingService(languageMappingRepository)
 	projectLabelService = services.NewProjectLabelService(projectLabelRepository)
-	heartbeatService = services.NewHeartbeatService(heartbeatRepository, languageMappingService)
-	projectService = services.NewProjectService(aliasService, heartbeatRepository, heartbeatService)
+	dayOffService = services.NewDayOffService(dayOffRepository)
+	filterPresetService = services.NewFilterPresetService(filterPresetRepository)
+	heartbeatService = services.NewHeartbeatService(heartbeatRepository, heartbeatArchiveRepository, languageMappingService)
+	projectService = services.NewProjectService(aliasService, heartbeatRepository, heartbeatArchiveRepository, summaryRepository, heartbeatService)
 	durationService = services.NewDurationService(durationRepository, heartbeatService, userService, languageMappingService)
 	summaryService = services.NewSummaryService(summaryRepository, heartbeatService, durationService, aliasService, projectLabelService)
-	aggregationService = services.NewAggregationService(userService, summaryService, heartbeatService, durationService)
-	reportService = services.NewReportService(summaryService, userService, mailService)
-	activityService = services.NewActivityService(summaryService)
+	aggregationService = services.NewAggregationService(userService, summaryService, heartbeatService, durationService, leaseService)
+	reportService = services.NewReportService(summaryService, userService, mailService, leaseService, dayOffService, filterPresetService)
+	activityService = services.NewActivityService(summaryService, durationService)
+	insightsService = services.NewInsightsService(summaryService, durationService, heartbeatService, dayOffService)
+	focusService = services.NewFocusService(durationService)
+	dashboardService = services.NewDashboardService(dashboardRepository, summaryService, activityService)
+	badgeService = services.NewBadgeService(summaryService, heartbeatService)
+	readmeCardService = services.NewReadmeCardService(summaryService)
 	diagnosticsService = services.NewDiagnosticsService(diagnosticsRepository)
-	housekeepingService = services.NewHousekeepingService(userService, heartbeatService, projectService, summaryService, aliasRepository) // can pass any repo here
-	miscService = services.NewMiscService(userService, heartbeatService, summaryService, keyValueService, mailService)
+	housekeepingService = services.NewHousekeepingService(userService, heartbeatService, projectService, summaryService, sessionService, securityEventService, diagnosticsService, leaseService, aliasRepository) // can pass any repo here
+	miscService = services.NewMiscService(userService, heartbeatService, summaryService, keyValueService, mailService, leaseService)
 	webAuthnService = services.NewWebAuthnService(webAuthnRepository)
+	backupService = services.NewBackupService(leaseService, backupRepository)
 
 	if config.App.LeaderboardEnabled {
-		leaderboardService = services.NewLeaderboardService(leaderboardRepository, summaryService, userService)
+		leaderboardService = services.NewLeaderboardService(leaderboardRepository, summaryService, userService, leaseService)
+	}
+
+	// Relay events to other instances
+	if config.Events.Transport != conf.EventTransportLocal {
+		var transport events.Transport
+		switch config.Events.Transport {
+		case conf.EventTransportDatabase:
+			transport = events.NewDatabaseTransport(eventOutboxRepository, config.InstanceId, time.Duration(config.Events.PollIntervalMs)*time.Millisecond)
+		case conf.EventTransportPostgres:
+			transport = events.NewPostgresTransport(sqlDb)
+		case conf.EventTransportNats:
+			if transport, err = events.NewNatsTransport(config.Events.NatsUrl, config.Events.NatsSubject); err != nil {
+				conf.Log().Fatal("could not connect to nats", "error", err)
+			}
+		}
+
+		slog.Info("relaying events to other instances", "transport", config.Events.Transport)
+		eventBridge := events.NewBridge(conf.EventBus(), transport, config.InstanceId)
+		if err := eventBridge.Start(); err != nil {
+			conf.Log().Fatal("failed to start event transport", "error", err)
+		}
+		defer eventBridge.Close()
 	}
 
 	// Schedule background tasks
 	go conf.StartJobs()
+	leaseService.Schedule() // elect leader before scheduling anything else
 	go aggregationService.Schedule()
 	go reportService.Schedule()
 	go housekeepingService.Schedule()
 	go miscService.Schedule()
+	go backupService.Schedule()
 
 	if config.App.LeaderboardEnabled {
 		go leaderboardService.Schedule()
@@ -224,19 +340,22 @@ func main() {
 	rootApiHandler := api.NewApiRootHandler()
 	healthApiHandler := api.NewHealthApiHandler(db)
 	heartbeatApiHandler := api.NewHeartbeatApiHandler(userService, heartbeatService, languageMappingService)
-	summaryApiHandler := api.NewSummaryApiHandler(userService, summaryService)
+	summaryApiHandler := api.NewSummaryApiHandler(userService, summaryService, filterPresetService)
+	focusApiHandler := api.NewFocusApiHandler(userService, focusService)
 	metricsHandler := api.NewMetricsHandler(userService, summaryService, heartbeatService, leaderboardService, keyValueService, metricsRepository)
 	diagnosticsHandler := api.NewDiagnosticsApiHandler(userService, diagnosticsService)
 	avatarHandler := api.NewAvatarHandler()
 	activityHandler := api.NewActivityApiHandler(userService, activityService)
-	badgeHandler := api.NewBadgeHandler(userService, summaryService)
+	readmeCardHandler := api.NewReadmeCardApiHandler(userService, readmeCardService)
+	badgeHandler := api.NewBadgeHandler(userService, summaryService, badgeService, filterPresetService)
 	captchaHandler := api.NewCaptchaHandler()
 
 	// Compat Handlers
 	wakatimeV1StatusBarHandler := wtV1Routes.NewStatusBarHandler(userService, summaryService)
 	wakatimeV1AllHandler := wtV1Routes.NewAllTimeHandler(userService, summaryService)
-	wakatimeV1SummariesHandler := wtV1Routes.NewSummariesHandler(userService, summaryService)
-	wakatimeV1StatsHandler := wtV1Routes.NewStatsHandler(userService, summaryService)
+	wakatimeV1SummariesHandler := wtV1Routes.NewSummariesHandler(userService, summaryService, dayOffService, filterPresetService)
+	wakatimeV1StatsHandler := wtV1Routes.NewStatsHandler(userService, summaryService, insightsService, dayOffService)
+	wakatimeV1InsightsHandler := wtV1Routes.NewInsightsHandler(userService, insightsService)
 	wakatimeV1UsersHandler := wtV1Routes.NewUsersHandler(userService, heartbeatService)
 	wakatimeV1ProjectsHandler := wtV1Routes.NewProjectsHandler(userService, heartbeatService, projectService)
 	wakatimeV1HeartbeatsHandler := wtV1Routes.NewHeartbeatHandler(userService, heartbeatService)
@@ -245,12 +364,13 @@ func main() {
 	shieldV1BadgeHandler := shieldsV1Routes.NewBadgeHandler(summaryService, userService)
 
 	// MVC Handlers
-	summaryHandler := routes.NewSummaryHandler(summaryService, userService, heartbeatService, durationService, aliasService)
-	settingsHandler := routes.NewSettingsHandler(userService, heartbeatService, durationService, summaryService, aliasService, aggregationService, languageMappingService, projectLabelService, keyValueService, mailService, apiKeyService, webAuthnService)
+	summaryHandler := routes.NewSummaryHandler(summaryService, userService, heartbeatService, durationService, aliasService, dayOffService, focusService, filterPresetService)
+	settingsHandler := routes.NewSettingsHandler(userService, heartbeatService, durationService, summaryService, aliasService, aggregationService, languageMappingService, projectLabelService, dayOffService, filterPresetService, keyValueService, mailService, apiKeyService, webAuthnService, sessionService, securityEventService, diagnosticsService)
 	subscriptionHandler := routes.NewSubscriptionHandler(userService, mailService, keyValueService)
-	projectsHandler := routes.NewProjectsHandler(userService, heartbeatService, projectService)
+	projectsHandler := routes.NewProjectsHandler(userService, heartbeatService, projectService, summaryService, activityService)
+	dashboardsHandler := routes.NewDashboardsHandler(userService, dashboardService)
 	homeHandler := routes.NewHomeHandler(userService, keyValueService)
-	loginHandler := routes.NewLoginHandler(userService, mailService, keyValueService, webAuthnService)
+	loginHandler := routes.NewLoginHandler(userService, mailService, keyValueService, webAuthnService, sessionService, securityEventService)
 	imprintHandler := routes.NewImprintHandler(keyValueService)
 	setupHandler := routes.NewSetupHandler(userService)
 	leaderboardHandler := condition.Ternary[bool, routes.Handler](config.App.LeaderboardEnabled, routes.NewLeaderboardHandler(userService, leaderboardService), routes.NewNoopHandler())
@@ -283,6 +403,12 @@ func main() {
 			"/api/avatar",
 		}),
 	)
+	if config.Security.ExposeMetrics {
+		router.Use(middlewares.NewMetricsMiddleware())
+	}
+	if config.Tracing.Exporter != conf.TracingExporterNone {
+		router.Use(middlewares.NewTracingMiddleware())
+	}
 	if config.Sentry.Dsn != "" {
 		router.Use(middlewares.NewSentryMiddleware())
 	}
@@ -305,6 +431,7 @@ func main() {
 	summaryHandler.RegisterRoutes(rootRouter)
 	leaderboardHandler.RegisterRoutes(rootRouter)
 	projectsHandler.RegisterRoutes(rootRouter)
+	dashboardsHandler.RegisterRoutes(rootRouter)
 	settingsHandler.RegisterRoutes(rootRouter)
 	subscriptionHandler.RegisterRoutes(rootRouter)
 	miscHandler.RegisterRoutes(rootRouter)
@@ -312,17 +439,20 @@ func main() {
 	// API route registrations
 	rootApiHandler.RegisterRoutes(apiRouter)
 	summaryApiHandler.RegisterRoutes(apiRouter)
+	focusApiHandler.RegisterRoutes(apiRouter)
 	healthApiHandler.RegisterRoutes(apiRouter)
 	heartbeatApiHandler.RegisterRoutes(apiRouter)
 	metricsHandler.RegisterRoutes(apiRouter)
 	diagnosticsHandler.RegisterRoutes(apiRouter)
 	avatarHandler.RegisterRoutes(apiRouter)
 	activityHandler.RegisterRoutes(apiRouter)
+	readmeCardHandler.RegisterRoutes(apiRouter)
 	badgeHandler.RegisterRoutes(apiRouter)
 	wakatimeV1StatusBarHandler.RegisterRoutes(apiRouter)
 	wakatimeV1AllHandler.RegisterRoutes(apiRouter)
 	wakatimeV1SummariesHandler.RegisterRoutes(apiRouter)
 	wakatimeV1StatsHandler.RegisterRoutes(apiRouter)
+	wakatimeV1InsightsHandler.RegisterRoutes(apiRouter)
 	wakatimeV1UsersHandler.RegisterRoutes(apiRouter)
 	wakatimeV1ProjectsHandler.RegisterRoutes(apiRouter)
 	wakatimeV1HeartbeatsHandler.RegisterRoutes(apiRouter)
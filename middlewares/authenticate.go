@@ -113,6 +113,7 @@ func (m *AuthenticateMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Reques
 			http.SetCookie(w, m.config.GetClearCookie(models.OidcProviderCookieKey))
 			http.SetCookie(w, m.config.GetClearCookie(models.OidcIdTokenCookieKey))
 			http.SetCookie(w, m.config.GetClearCookie(models.OidcRefreshTokenCookieKey))
+			http.SetCookie(w, m.config.GetClearCookie(models.OidcSessionCookieKey))
 			http.Redirect(w, r, m.redirectTarget, http.StatusFound)
 		}
 		return
@@ -204,19 +205,22 @@ func (m *AuthenticateMiddleware) tryGetUserByTrustedHeader(r *http.Request, crea
 }
 
 func (m *AuthenticateMiddleware) tryGetUserByCookie(r *http.Request) (*models.User, error) {
-	username, err := routeutils.ExtractCookieAuth(r)
+	username, sessionId, err := routeutils.ExtractCookieAuth(r)
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := m.userSrvc.GetUserById(*username)
+	// no need to check password here, as securecookie decoding will fail anyway,
+	// if cookie is not properly signed, but the referenced session must still exist (i.e. not have been revoked)
+	user, session, err := m.userSrvc.GetUserBySession(*sessionId)
 	if err != nil {
 		return nil, err
 	}
+	if user.ID != *username {
+		return nil, errors.New("session does not belong to user")
+	}
 
-	// no need to check password here, as securecookie decoding will fail anyway,
-	// if cookie is not properly signed
-
+	routeutils.SetSession(r, session)
 	return user, nil
 }
 
@@ -230,5 +234,19 @@ func (m *AuthenticateMiddleware) tryGetUserByOidc(w http.ResponseWriter, r *http
 		return nil, err
 	}
 
+	// a valid id token alone is not sufficient, the login's session must not have been revoked either
+	username, sessionId, err := routeutils.ExtractOidcSessionCookie(r)
+	if err != nil {
+		return nil, err
+	}
+	sessionUser, session, err := m.userSrvc.GetUserBySession(*sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if sessionUser.ID != user.ID || *username != user.ID {
+		return nil, errors.New("session does not belong to user")
+	}
+
+	routeutils.SetSession(r, session)
 	return user, nil
 }
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -18,6 +19,7 @@ import (
 	"github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/mocks"
 	"github.com/muety/wakapi/models"
+	routeutils "github.com/muety/wakapi/routes/utils"
 	testutils "github.com/muety/wakapi/utils/test"
 )
 
@@ -315,6 +317,7 @@ func TestAuthenticateMiddleware_tryGetUserByOidc_ValidToken(t *testing.T) {
 	config.WithOidcProvider(cfg, testProvider, oidcMock.ClientID, oidcMock.ClientSecret, oidcMock.Addr()+"/oidc", "")
 
 	r := httptest.NewRequest(http.MethodGet, "/summary", nil)
+	r = r.WithContext(context.WithValue(r.Context(), config.KeySharedData, config.NewSharedData()))
 	w := httptest.NewRecorder()
 
 	session, err := oidcMock.SessionStore.NewSession(
@@ -335,15 +338,75 @@ func TestAuthenticateMiddleware_tryGetUserByOidc_ValidToken(t *testing.T) {
 
 	r.AddCookie(cfg.CreateCookie(config.CookieKeyOidcProvider, testProvider))
 	r.AddCookie(cfg.CreateCookie(config.CookieKeyOidcIdToken, idToken))
+	r.AddCookie(mockOidcSessionCookie(t, testUser))
 
 	userServiceMock := new(mocks.UserServiceMock)
 	userServiceMock.On("GetUserByOidc", testProvider, testSub).Return(testUser, nil)
+	userServiceMock.On("GetUserBySession", "session_"+testUser.ID).Return(testUser, &models.Session{ID: "session_" + testUser.ID, UserID: testUser.ID}, nil)
 
 	sut := NewAuthenticateMiddleware(userServiceMock)
 
 	result, err := sut.tryGetUserByOidc(w, r)
 	assert.NoError(t, err)
 	assert.Equal(t, testUser, result)
+	assert.Equal(t, "session_"+testUser.ID, routeutils.GetSession(r).ID)
+}
+
+func TestAuthenticateMiddleware_tryGetUserByOidc_RevokedSession(t *testing.T) {
+	const (
+		testProvider = "mock"
+		testSub      = "testsub"
+		testEmail    = "test@example.com"
+	)
+	var testUser = &models.User{ID: "testuser"}
+
+	oidcMock, _ := mockoidc.Run()
+	defer oidcMock.Shutdown()
+
+	cfg := config.Empty()
+	config.Set(cfg)
+	config.WithOidcProvider(cfg, testProvider, oidcMock.ClientID, oidcMock.ClientSecret, oidcMock.Addr()+"/oidc", "")
+
+	session, err := oidcMock.SessionStore.NewSession(
+		"openid profile email",
+		"",
+		&mockoidc.MockUser{
+			Subject:           testSub,
+			Email:             testEmail,
+			PreferredUsername: testUser.ID,
+		},
+		"code",
+		"method",
+	)
+	assert.NoError(t, err)
+
+	idToken, err := session.IDToken(oidcMock.Config(), oidcMock.Keypair, time.Now())
+	assert.NoError(t, err)
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserByOidc", testProvider, testSub).Return(testUser, nil)
+	userServiceMock.On("GetUserBySession", "session_"+testUser.ID).Return(nil, nil, errors.New("record not found"))
+
+	sut := NewAuthenticateMiddleware(userServiceMock)
+
+	// missing session cookie
+	r := httptest.NewRequest(http.MethodGet, "/summary", nil)
+	r.AddCookie(cfg.CreateCookie(config.CookieKeyOidcProvider, testProvider))
+	r.AddCookie(cfg.CreateCookie(config.CookieKeyOidcIdToken, idToken))
+
+	result, err := sut.tryGetUserByOidc(httptest.NewRecorder(), r)
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	// revoked session
+	r = httptest.NewRequest(http.MethodGet, "/summary", nil)
+	r.AddCookie(cfg.CreateCookie(config.CookieKeyOidcProvider, testProvider))
+	r.AddCookie(cfg.CreateCookie(config.CookieKeyOidcIdToken, idToken))
+	r.AddCookie(mockOidcSessionCookie(t, testUser))
+
+	result, err = sut.tryGetUserByOidc(httptest.NewRecorder(), r)
+	assert.Error(t, err)
+	assert.Nil(t, result)
 }
 
 func TestAuthenticateMiddleware_tryGetUserByOidc_ExpiredTokenNoRefreshToken(t *testing.T) {
@@ -362,6 +425,7 @@ func TestAuthenticateMiddleware_tryGetUserByOidc_ExpiredTokenNoRefreshToken(t *t
 	config.WithOidcProvider(cfg, testProvider, oidcMock.ClientID, oidcMock.ClientSecret, oidcMock.Addr()+"/oidc", "")
 
 	r := httptest.NewRequest(http.MethodGet, "/summary", nil)
+	r = r.WithContext(context.WithValue(r.Context(), config.KeySharedData, config.NewSharedData()))
 	w := httptest.NewRecorder()
 
 	session, err := oidcMock.SessionStore.NewSession(
@@ -412,6 +476,7 @@ func TestAuthenticateMiddleware_tryGetUserByOidc_ExpiredTokenWithRefreshToken(t
 	config.WithOidcProvider(cfg, testProvider, oidcMock.ClientID, oidcMock.ClientSecret, oidcMock.Addr()+"/oidc", "")
 
 	r := httptest.NewRequest(http.MethodGet, "/summary", nil)
+	r = r.WithContext(context.WithValue(r.Context(), config.KeySharedData, config.NewSharedData()))
 	w := httptest.NewRecorder()
 
 	session, err := oidcMock.SessionStore.NewSession(
@@ -439,9 +504,11 @@ func TestAuthenticateMiddleware_tryGetUserByOidc_ExpiredTokenWithRefreshToken(t
 	r.AddCookie(cfg.CreateCookie(config.CookieKeyOidcProvider, testProvider))
 	r.AddCookie(cfg.CreateCookie(config.CookieKeyOidcIdToken, idToken))
 	r.AddCookie(cfg.CreateCookie(config.CookieKeyOidcRefreshToken, refreshToken))
+	r.AddCookie(mockOidcSessionCookie(t, testUser))
 
 	userServiceMock := new(mocks.UserServiceMock)
 	userServiceMock.On("GetUserByOidc", testProvider, testSub).Return(testUser, nil)
+	userServiceMock.On("GetUserBySession", "session_"+testUser.ID).Return(testUser, &models.Session{ID: "session_" + testUser.ID, UserID: testUser.ID}, nil)
 
 	sut := NewAuthenticateMiddleware(userServiceMock)
 
@@ -459,3 +526,13 @@ func TestAuthenticateMiddleware_tryGetUserByOidc_ExpiredTokenWithRefreshToken(t
 }
 
 // TODO: somehow test cookie auth function
+
+func mockOidcSessionCookie(t *testing.T, user *models.User) *http.Cookie {
+	cookie, err := routeutils.CreateOidcSessionCookie(&models.Session{
+		ID:        "session_" + user.ID,
+		UserID:    user.ID,
+		ExpiresAt: models.CustomTime(time.Now().Add(time.Hour)),
+	})
+	assert.NoError(t, err)
+	return cookie
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/leandro-lugaresi/hub"
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/metrics"
 	"github.com/muety/wakapi/middlewares"
 	"github.com/muety/wakapi/models"
 	routeutils "github.com/muety/wakapi/routes/utils"
@@ -117,6 +118,7 @@ func (m *WakatimeRelayMiddleware) send(method, url string, body io.Reader, heade
 	request, err := http.NewRequest(method, url, body)
 	if err != nil {
 		slog.Warn("error constructing relayed request", "error", err)
+		metrics.RelayRequests.WithLabelValues(metrics.ResultFailure).Inc()
 		return
 	}
 
@@ -129,11 +131,14 @@ func (m *WakatimeRelayMiddleware) send(method, url string, body io.Reader, heade
 	response, err := m.httpClient.Do(request)
 	if err != nil {
 		slog.Warn("error executing relayed request", "error", err)
+		metrics.RelayRequests.WithLabelValues(metrics.ResultFailure).Inc()
 		return
 	}
+	defer response.Body.Close()
 
 	if response.StatusCode < 200 || response.StatusCode >= 300 {
 		slog.Warn("failed to relay request for user", "userID", forUser.ID, "statusCode", response.StatusCode)
+		metrics.RelayRequests.WithLabelValues(metrics.ResultFailure).Inc()
 
 		// TODO: use leaky bucket instead of expiring cache?
 		if _, found := m.failureCache.Get(forUser.ID); !found {
@@ -147,7 +152,10 @@ func (m *WakatimeRelayMiddleware) send(method, url string, body io.Reader, heade
 		} else if n%10 == 0 {
 			slog.Warn("failed wakatime heartbeat relaying attempts for user", "failedCount", n, "maxFailures", maxFailuresPerDay, "userID", forUser.ID)
 		}
+		return
 	}
+
+	metrics.RelayRequests.WithLabelValues(metrics.ResultSuccess).Inc()
 }
 
 // filterByCache returns the JSON body for the relay request, minus any heartbeats we've already forwarded.
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/muety/wakapi/lib/metrics"
+)
+
+// MetricsMiddleware records request durations in a histogram, labeled by chi route pattern (not by raw path, to keep cardinality bounded)
+type MetricsMiddleware struct {
+	handler http.Handler
+}
+
+func NewMetricsMiddleware() func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &MetricsMiddleware{handler: h}
+	}
+}
+
+func (m *MetricsMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ww := wrapWriter(w)
+
+	start := time.Now()
+	m.handler.ServeHTTP(ww, r)
+	duration := time.Since(start)
+
+	status := ww.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	route := "unmatched"
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		route = rctx.RoutePattern()
+	}
+
+	metrics.HttpRequestDuration.WithLabelValues(route, r.Method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/muety/wakapi/lib/metrics"
+)
+
+func TestMetricsMiddleware_RecordsRoutePattern(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(NewMetricsMiddleware())
+	router.Get("/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for _, id := range []string{"a", "b", "c"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics-test/"+id, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics-test-unknown", nil))
+
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.HttpRequestDuration))
+	assert.Equal(t, uint64(3), histogramCount(t, "/metrics-test/{id}", http.MethodGet, "418"))
+	assert.Equal(t, uint64(1), histogramCount(t, "unmatched", http.MethodGet, "404"))
+}
+
+func histogramCount(t *testing.T, labels ...string) uint64 {
+	observer, err := metrics.HttpRequestDuration.GetMetricWithLabelValues(labels...)
+	assert.Nil(t, err)
+
+	var m dto.Metric
+	assert.Nil(t, observer.(prometheus.Metric).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/muety/wakapi/lib/tracing"
+)
+
+// TracingMiddleware starts an opentelemetry server span for every request, which is then available from the request context for downstream handlers and services.
+// Spans are started under the raw request path (which the sampler decides upon) and renamed to the chi route pattern once the request was routed.
+type TracingMiddleware struct {
+	handler    http.Handler
+	propagator propagation.TextMapPropagator
+}
+
+func NewTracingMiddleware() func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &TracingMiddleware{
+			handler:    h,
+			propagator: otel.GetTextMapPropagator(),
+		}
+	}
+}
+
+func (m *TracingMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := m.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+r.URL.Path,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLPath(r.URL.Path),
+			semconv.UserAgentOriginal(r.UserAgent()),
+		),
+	)
+	defer span.End()
+
+	ww := wrapWriter(w)
+	m.handler.ServeHTTP(ww, r.WithContext(ctx))
+
+	status := ww.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		span.SetName(r.Method + " " + rctx.RoutePattern())
+		span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+	}
+	if user := GetPrincipal(r); user != nil {
+		span.SetAttributes(tracing.AttrUser.String(user.ID))
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= 500 {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/muety/wakapi/lib/tracing"
+)
+
+func TestTracingMiddleware_CreatesServerSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	defer setTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))()
+
+	var handlerSpan trace.SpanContext
+
+	router := chi.NewRouter()
+	router.Use(NewTracingMiddleware())
+	router.Get("/tracing-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "child")
+		handlerSpan = span.SpanContext()
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tracing-test/a", nil))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "child", child.Name())
+	assert.Equal(t, handlerSpan.SpanID(), child.SpanContext().SpanID())
+	assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+
+	assert.Equal(t, "GET /tracing-test/{id}", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, codes.Error, server.Status().Code)
+	assert.Contains(t, server.Attributes(), semconv.HTTPRoute("/tracing-test/{id}"))
+	assert.Contains(t, server.Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+}
+
+func setTracerProvider(provider trace.TracerProvider) func() {
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	return func() {
+		otel.SetTracerProvider(previous)
+	}
+}
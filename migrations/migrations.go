@@ -27,48 +27,42 @@ var (
 	postMigrations migrationFuncs
 )
 
+// Models returns all entities persisted to the database, in an order that satisfies their foreign key constraints (i.e. referenced tables first)
+func Models() []interface{} {
+	return []interface{}{
+		&models.User{},
+		&models.WebAuthnCredential{},
+		&models.KeyStringValue{},
+		&models.Alias{},
+		&models.Heartbeat{},
+		&models.HeartbeatArchive{},
+		&models.Summary{},
+		&models.SummaryItem{},
+		&models.LanguageMapping{},
+		&models.ProjectLabel{},
+		&models.DayOff{},
+		&models.Dashboard{},
+		&models.DashboardWidget{},
+		&models.FilterPreset{},
+		&models.Diagnostics{},
+		&models.LeaderboardItem{},
+		&models.Duration{},
+		&models.ApiKey{},
+		&models.Session{},
+		&models.SecurityEvent{},
+		&models.Lease{},
+		&models.EventOutboxEntry{},
+	}
+}
+
 func GetMigrationFunc(cfg *config.Config) GormMigrationFunc {
 	switch cfg.Db.Dialect {
 	default:
 		return func(db *gorm.DB) error {
-			if err := db.AutoMigrate(&models.User{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.WebAuthnCredential{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.KeyStringValue{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.Alias{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.Heartbeat{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.Summary{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.SummaryItem{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.LanguageMapping{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.ProjectLabel{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.Diagnostics{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.LeaderboardItem{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.Duration{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
-			}
-			if err := db.AutoMigrate(&models.ApiKey{}); err != nil && !cfg.Db.AutoMigrateFailSilently {
-				return err
+			for _, model := range Models() {
+				if err := db.AutoMigrate(model); err != nil && !cfg.Db.AutoMigrateFailSilently {
+					return err
+				}
 			}
 			return nil
 		}
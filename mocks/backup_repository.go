@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"io"
+
+	"github.com/muety/wakapi/lib/backup"
+	"github.com/stretchr/testify/mock"
+)
+
+type BackupRepositoryMock struct {
+	BaseRepositoryMock
+	mock.Mock
+}
+
+func (m *BackupRepositoryMock) Create(w io.Writer, appVersion string) (*backup.Manifest, error) {
+	args := m.Called(w, appVersion)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*backup.Manifest), args.Error(1)
+}
+
+func (m *BackupRepositoryMock) Restore(r io.Reader, force bool) (*backup.Manifest, error) {
+	args := m.Called(r, force)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*backup.Manifest), args.Error(1)
+}
@@ -0,0 +1,71 @@
+package mocks
+
+import (
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type DashboardRepositoryMock struct {
+	BaseRepositoryMock
+	mock.Mock
+}
+
+func (m *DashboardRepositoryMock) GetById(id uint) (*models.Dashboard, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Dashboard), args.Error(1)
+}
+
+func (m *DashboardRepositoryMock) GetByShareToken(token string) (*models.Dashboard, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Dashboard), args.Error(1)
+}
+
+func (m *DashboardRepositoryMock) GetByUser(userId string) ([]*models.Dashboard, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]*models.Dashboard), args.Error(1)
+}
+
+func (m *DashboardRepositoryMock) Insert(dashboard *models.Dashboard) (*models.Dashboard, error) {
+	args := m.Called(dashboard)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Dashboard), args.Error(1)
+}
+
+func (m *DashboardRepositoryMock) Update(dashboard *models.Dashboard) (*models.Dashboard, error) {
+	args := m.Called(dashboard)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Dashboard), args.Error(1)
+}
+
+func (m *DashboardRepositoryMock) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *DashboardRepositoryMock) InsertWidget(widget *models.DashboardWidget) (*models.DashboardWidget, error) {
+	args := m.Called(widget)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DashboardWidget), args.Error(1)
+}
+
+func (m *DashboardRepositoryMock) UpdateWidgetPositions(widgets []*models.DashboardWidget) error {
+	args := m.Called(widgets)
+	return args.Error(0)
+}
+
+func (m *DashboardRepositoryMock) DeleteWidget(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
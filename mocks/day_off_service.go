@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"io"
+	"time"
+
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type DayOffServiceMock struct {
+	mock.Mock
+}
+
+func (m *DayOffServiceMock) GetById(u uint) (*models.DayOff, error) {
+	args := m.Called(u)
+	return args.Get(0).(*models.DayOff), args.Error(1)
+}
+
+func (m *DayOffServiceMock) GetByUser(s string) ([]*models.DayOff, error) {
+	args := m.Called(s)
+	return args.Get(0).([]*models.DayOff), args.Error(1)
+}
+
+func (m *DayOffServiceMock) GetCalendar(u *models.User) (*models.WorkCalendar, error) {
+	args := m.Called(u)
+	return args.Get(0).(*models.WorkCalendar), args.Error(1)
+}
+
+func (m *DayOffServiceMock) Create(d *models.DayOff) (*models.DayOff, error) {
+	args := m.Called(d)
+	return args.Get(0).(*models.DayOff), args.Error(1)
+}
+
+func (m *DayOffServiceMock) CreateRange(u *models.User, t1, t2 time.Time, s string) (int, error) {
+	args := m.Called(u, t1, t2, s)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *DayOffServiceMock) ImportCalendar(u *models.User, r io.Reader) (int, error) {
+	args := m.Called(u, r)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *DayOffServiceMock) Delete(d *models.DayOff) error {
+	args := m.Called(d)
+	return args.Error(0)
+}
+
+func (m *DayOffServiceMock) DeleteImported(u *models.User) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type DiagnosticsServiceMock struct {
+	mock.Mock
+}
+
+func (m *DiagnosticsServiceMock) Create(diagnostics *models.Diagnostics) (*models.Diagnostics, error) {
+	args := m.Called(diagnostics)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Diagnostics), args.Error(1)
+}
+
+func (m *DiagnosticsServiceMock) GetAll(limit int) ([]*models.Diagnostics, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Diagnostics), args.Error(1)
+}
+
+func (m *DiagnosticsServiceMock) GetByUser(userId string, limit int) ([]*models.Diagnostics, error) {
+	args := m.Called(userId, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Diagnostics), args.Error(1)
+}
+
+func (m *DiagnosticsServiceMock) DeleteByUser(userId string) error {
+	args := m.Called(userId)
+	return args.Error(0)
+}
+
+func (m *DiagnosticsServiceMock) DeleteBefore(t time.Time) (int64, error) {
+	args := m.Called(t)
+	return int64(args.Int(0)), args.Error(1)
+}
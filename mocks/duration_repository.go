@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"time"
 
 	"github.com/muety/wakapi/models"
@@ -27,7 +28,7 @@ func (m *DurationRepositoryMock) GetAllWithin(t time.Time, t2 time.Time, u *mode
 	return args.Get(0).([]*models.Duration), args.Error(1)
 }
 
-func (m *DurationRepositoryMock) GetAllWithinByFilters(t time.Time, t2 time.Time, u *models.User, m2 map[string][]string) ([]*models.Duration, error) {
+func (m *DurationRepositoryMock) GetAllWithinByFilters(ctx context.Context, t time.Time, t2 time.Time, u *models.User, m2 models.ColumnFilters) ([]*models.Duration, error) {
 	args := m.Called(t, t2, u, m2)
 	return args.Get(0).([]*models.Duration), args.Error(1)
 }
@@ -52,7 +53,7 @@ func (m *DurationRepositoryMock) StreamAllWithin(t time.Time, t2 time.Time, u *m
 	return args.Get(0).(chan *models.Duration), args.Error(1)
 }
 
-func (m *DurationRepositoryMock) StreamAllWithinByFilters(t time.Time, t2 time.Time, u *models.User, m2 map[string][]string) (chan *models.Duration, error) {
+func (m *DurationRepositoryMock) StreamAllWithinByFilters(t time.Time, t2 time.Time, u *models.User, m2 models.ColumnFilters) (chan *models.Duration, error) {
 	args := m.Called(t, t2, u, m2)
 	return args.Get(0).(chan *models.Duration), args.Error(1)
 }
@@ -66,3 +67,8 @@ func (m *DurationRepositoryMock) DeleteByUserBefore(u *models.User, t time.Time)
 	args := m.Called(u, t)
 	return args.Error(0)
 }
+
+func (m *DurationRepositoryMock) DeleteByUserAfter(u *models.User, t time.Time) error {
+	args := m.Called(u, t)
+	return args.Error(0)
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"github.com/muety/wakapi/models"
 	"github.com/stretchr/testify/mock"
 	"time"
@@ -10,7 +11,7 @@ type DurationServiceMock struct {
 	mock.Mock
 }
 
-func (m *DurationServiceMock) Get(time time.Time, time2 time.Time, user *models.User, f *models.Filters, d *time.Duration, b bool) (models.Durations, error) {
+func (m *DurationServiceMock) Get(ctx context.Context, time time.Time, time2 time.Time, user *models.User, f *models.Filters, d *time.Duration, b bool) (models.Durations, error) {
 	args := m.Called(time, time2, user, f, d, b)
 	return args.Get(0).(models.Durations), args.Error(1)
 }
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type EventOutboxRepositoryMock struct {
+	BaseRepositoryMock
+	mock.Mock
+}
+
+func (m *EventOutboxRepositoryMock) Insert(entry *models.EventOutboxEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *EventOutboxRepositoryMock) GetAfter(id uint64, limit int) ([]*models.EventOutboxEntry, error) {
+	args := m.Called(id, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.EventOutboxEntry), args.Error(1)
+}
+
+func (m *EventOutboxRepositoryMock) GetLatestId() (uint64, error) {
+	args := m.Called()
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func (m *EventOutboxRepositoryMock) DeleteBefore(t time.Time) (int64, error) {
+	args := m.Called(t)
+	return int64(args.Int(0)), args.Error(1)
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type FilterPresetRepositoryMock struct {
+	BaseRepositoryMock
+	mock.Mock
+}
+
+func (m *FilterPresetRepositoryMock) GetById(id uint) (*models.FilterPreset, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FilterPreset), args.Error(1)
+}
+
+func (m *FilterPresetRepositoryMock) GetByUser(userId string) ([]*models.FilterPreset, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]*models.FilterPreset), args.Error(1)
+}
+
+func (m *FilterPresetRepositoryMock) Insert(preset *models.FilterPreset) (*models.FilterPreset, error) {
+	args := m.Called(preset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FilterPreset), args.Error(1)
+}
+
+func (m *FilterPresetRepositoryMock) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
@@ -0,0 +1,44 @@
+package mocks
+
+import (
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type FilterPresetServiceMock struct {
+	mock.Mock
+}
+
+func (m *FilterPresetServiceMock) GetById(id uint) (*models.FilterPreset, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FilterPreset), args.Error(1)
+}
+
+func (m *FilterPresetServiceMock) GetByUser(userId string) ([]*models.FilterPreset, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]*models.FilterPreset), args.Error(1)
+}
+
+func (m *FilterPresetServiceMock) GetByUserAndSlug(userId, slug string) (*models.FilterPreset, error) {
+	args := m.Called(userId, slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FilterPreset), args.Error(1)
+}
+
+func (m *FilterPresetServiceMock) Create(user *models.User, name, interval string, filters *models.Filters) (*models.FilterPreset, error) {
+	args := m.Called(user, name, interval, filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FilterPreset), args.Error(1)
+}
+
+func (m *FilterPresetServiceMock) Delete(preset *models.FilterPreset) error {
+	args := m.Called(preset)
+	return args.Error(0)
+}
@@ -0,0 +1,102 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type HeartbeatArchiveRepositoryMock struct {
+	BaseRepositoryMock
+	mock.Mock
+}
+
+func (m *HeartbeatArchiveRepositoryMock) Archive(user *models.User, from, to time.Time) (*models.HeartbeatArchive, error) {
+	args := m.Called(user, from, to)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.HeartbeatArchive), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) GetByUserWithin(user *models.User, from, to time.Time) ([]*models.HeartbeatArchive, error) {
+	args := m.Called(user, from, to)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.HeartbeatArchive), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) GetRangeByUser(user *models.User) (*models.RangeByUser, error) {
+	args := m.Called(user)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.RangeByUser), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) GetFirstAll() ([]*models.TimeByUser, error) {
+	args := m.Called()
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.TimeByUser), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) GetArchivedUntil(user *models.User) (time.Time, error) {
+	args := m.Called(user)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) CountByUser(user *models.User) (int64, error) {
+	args := m.Called(user)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) CountByUsers(users []*models.User) ([]*models.CountByUser, error) {
+	args := m.Called(users)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.CountByUser), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) GetEntitySetByUser(entityType uint8, userId string) ([]string, error) {
+	args := m.Called(entityType, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]string), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) GetUserProjectStats(user *models.User, from, to time.Time) ([]*models.ProjectStats, error) {
+	args := m.Called(user, from, to)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.ProjectStats), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) GetUserAgentsByUser(user *models.User) ([]*models.UserAgent, error) {
+	args := m.Called(user)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.UserAgent), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) DeleteBefore(t time.Time) error {
+	args := m.Called(t)
+	return args.Error(0)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) DeleteByUser(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *HeartbeatArchiveRepositoryMock) DeleteByUserBefore(user *models.User, t time.Time) error {
+	args := m.Called(user, t)
+	return args.Error(0)
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"time"
 
 	"github.com/muety/wakapi/models"
@@ -33,7 +34,7 @@ func (m *HeartbeatRepositoryMock) GetWithin(from, to time.Time, user *models.Use
 	return nil, args.Error(1)
 }
 
-func (m *HeartbeatRepositoryMock) GetAllWithinByFilters(from, to time.Time, user *models.User, filters map[string][]string) ([]*models.Heartbeat, error) {
+func (m *HeartbeatRepositoryMock) GetAllWithinByFilters(from, to time.Time, user *models.User, filters models.ColumnFilters) ([]*models.Heartbeat, error) {
 	args := m.Called(from, to, user, filters)
 	if args.Get(0) != nil {
 		return args.Get(0).([]*models.Heartbeat), args.Error(1)
@@ -41,7 +42,7 @@ func (m *HeartbeatRepositoryMock) GetAllWithinByFilters(from, to time.Time, user
 	return nil, args.Error(1)
 }
 
-func (m *HeartbeatRepositoryMock) GetLatestByFilters(user *models.User, filters map[string][]string) (*models.Heartbeat, error) {
+func (m *HeartbeatRepositoryMock) GetLatestByFilters(user *models.User, filters models.ColumnFilters) (*models.Heartbeat, error) {
 	args := m.Called(user, filters)
 	if args.Get(0) != nil {
 		return args.Get(0).(*models.Heartbeat), args.Error(1)
@@ -89,7 +90,7 @@ func (m *HeartbeatRepositoryMock) GetLatestByOriginAndUser(origin string, user *
 	return nil, args.Error(1)
 }
 
-func (m *HeartbeatRepositoryMock) StreamWithin(from, to time.Time, user *models.User) (chan *models.Heartbeat, error) {
+func (m *HeartbeatRepositoryMock) StreamWithin(ctx context.Context, from, to time.Time, user *models.User) (chan *models.Heartbeat, error) {
 	args := m.Called(from, to, user)
 	if args.Get(0) != nil {
 		return args.Get(0).(chan *models.Heartbeat), args.Error(1)
@@ -97,7 +98,7 @@ func (m *HeartbeatRepositoryMock) StreamWithin(from, to time.Time, user *models.
 	return nil, args.Error(1)
 }
 
-func (m *HeartbeatRepositoryMock) StreamWithinByFilters(from, to time.Time, user *models.User, filters map[string][]string) (chan *models.Heartbeat, error) {
+func (m *HeartbeatRepositoryMock) StreamWithinByFilters(from, to time.Time, user *models.User, filters models.ColumnFilters) (chan *models.Heartbeat, error) {
 	args := m.Called(from, to, user, filters)
 	if args.Get(0) != nil {
 		return args.Get(0).(chan *models.Heartbeat), args.Error(1)
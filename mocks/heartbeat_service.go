@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"time"
 
 	"github.com/muety/wakapi/models"
@@ -42,12 +43,12 @@ func (m *HeartbeatServiceMock) GetAllWithin(time time.Time, time2 time.Time, use
 	return args.Get(0).([]*models.Heartbeat), args.Error(1)
 }
 
-func (m *HeartbeatServiceMock) StreamAllWithin(t time.Time, t2 time.Time, u *models.User) (chan *models.Heartbeat, error) {
+func (m *HeartbeatServiceMock) StreamAllWithin(ctx context.Context, t time.Time, t2 time.Time, u *models.User) (chan *models.Heartbeat, error) {
 	args := m.Called(t, t2, u)
 	return args.Get(0).(chan *models.Heartbeat), args.Error(1)
 }
 
-func (m *HeartbeatServiceMock) StreamAllWithinRaw(t time.Time, t2 time.Time, u *models.User) (chan *models.Heartbeat, error) {
+func (m *HeartbeatServiceMock) StreamAllWithinRaw(ctx context.Context, t time.Time, t2 time.Time, u *models.User) (chan *models.Heartbeat, error) {
 	args := m.Called(t, t2, u)
 	return args.Get(0).(chan *models.Heartbeat), args.Error(1)
 }
@@ -131,3 +132,13 @@ func (m *HeartbeatServiceMock) GetUserAgentsByUser(u *models.User) ([]*models.Us
 	args := m.Called(u)
 	return args.Get(0).([]*models.UserAgent), args.Error(0)
 }
+
+func (m *HeartbeatServiceMock) ArchiveByUserBefore(u *models.User, t time.Time) (int, error) {
+	args := m.Called(u, t)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *HeartbeatServiceMock) GetArchivedUntil(u *models.User) (time.Time, error) {
+	args := m.Called(u)
+	return args.Get(0).(time.Time), args.Error(1)
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type LeaseRepositoryMock struct {
+	BaseRepositoryMock
+	mock.Mock
+}
+
+func (m *LeaseRepositoryMock) GetByName(name string) (*models.Lease, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Lease), args.Error(1)
+}
+
+func (m *LeaseRepositoryMock) Acquire(name, owner string, ttl time.Duration) (bool, error) {
+	args := m.Called(name, owner, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *LeaseRepositoryMock) Release(name, owner string) error {
+	args := m.Called(name, owner)
+	return args.Error(0)
+}
+
+func (m *LeaseRepositoryMock) DeleteExpired() (int64, error) {
+	args := m.Called()
+	return int64(args.Int(0)), args.Error(1)
+}
@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type LeaseServiceMock struct {
+	mock.Mock
+}
+
+func (m *LeaseServiceMock) Schedule() {
+	m.Called()
+}
+
+func (m *LeaseServiceMock) Acquire(name string, ttl time.Duration) (bool, error) {
+	args := m.Called(name, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *LeaseServiceMock) Release(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *LeaseServiceMock) DeleteExpired() (int64, error) {
+	args := m.Called()
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *LeaseServiceMock) IsLeader() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *LeaseServiceMock) Exclusive(job string, f func()) func() {
+	m.Called(job, f)
+	return f
+}
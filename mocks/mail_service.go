@@ -35,3 +35,8 @@ func (m *MailServiceMock) SendSubscriptionNotification(user *models.User, hasExp
 	args := m.Called(user, hasExpired)
 	return args.Error(0)
 }
+
+func (m *MailServiceMock) SendSecurityNotification(user *models.User, event *models.SecurityEvent) error {
+	args := m.Called(user, event)
+	return args.Error(0)
+}
@@ -19,3 +19,8 @@ func (m *ProjectServiceMock) GetUserProjectStats(u *models.User, t, t2 time.Time
 	}
 	return nil, args.Error(1)
 }
+
+func (m *ProjectServiceMock) GetProjectContributors(u *models.User, s string) ([]*models.ProjectContributor, error) {
+	args := m.Called(u, s)
+	return args.Get(0).([]*models.ProjectContributor), args.Error(1)
+}
@@ -0,0 +1,44 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type SecurityEventRepositoryMock struct {
+	BaseRepositoryMock
+	mock.Mock
+}
+
+func (m *SecurityEventRepositoryMock) GetByUser(userId string, limit int) ([]*models.SecurityEvent, error) {
+	args := m.Called(userId, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.SecurityEvent), args.Error(1)
+}
+
+func (m *SecurityEventRepositoryMock) CountByUserAndType(userId string, eventType models.SecurityEventType) (int64, error) {
+	args := m.Called(userId, eventType)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *SecurityEventRepositoryMock) ExistsByUserAndTypeAndIp(userId string, eventType models.SecurityEventType, ip string) (bool, error) {
+	args := m.Called(userId, eventType, ip)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *SecurityEventRepositoryMock) Insert(event *models.SecurityEvent) (*models.SecurityEvent, error) {
+	args := m.Called(event)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SecurityEvent), args.Error(1)
+}
+
+func (m *SecurityEventRepositoryMock) DeleteBefore(t time.Time) (int64, error) {
+	args := m.Called(t)
+	return int64(args.Int(0)), args.Error(1)
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type SecurityEventServiceMock struct {
+	mock.Mock
+}
+
+func (m *SecurityEventServiceMock) Log(user *models.User, eventType models.SecurityEventType, ip, userAgent, details string) (*models.SecurityEvent, error) {
+	args := m.Called(user, eventType, ip, userAgent, details)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SecurityEvent), args.Error(1)
+}
+
+func (m *SecurityEventServiceMock) LogLogin(user *models.User, method, ip, userAgent string) (*models.SecurityEvent, error) {
+	args := m.Called(user, method, ip, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SecurityEvent), args.Error(1)
+}
+
+func (m *SecurityEventServiceMock) GetByUser(userId string, limit int) ([]*models.SecurityEvent, error) {
+	args := m.Called(userId, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.SecurityEvent), args.Error(1)
+}
+
+func (m *SecurityEventServiceMock) DeleteBefore(t time.Time) (int64, error) {
+	args := m.Called(t)
+	return int64(args.Int(0)), args.Error(1)
+}
@@ -0,0 +1,60 @@
+package mocks
+
+import (
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionRepositoryMock struct {
+	BaseRepositoryMock
+	mock.Mock
+}
+
+func (m *SessionRepositoryMock) GetById(id string) (*models.Session, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *SessionRepositoryMock) GetByUser(userId string) ([]*models.Session, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+func (m *SessionRepositoryMock) Insert(session *models.Session) (*models.Session, error) {
+	args := m.Called(session)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *SessionRepositoryMock) Touch(session *models.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) DeleteByUser(userId string) error {
+	args := m.Called(userId)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) DeleteByUserExcept(userId, id string) error {
+	args := m.Called(userId, id)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) DeleteExpired() (int64, error) {
+	args := m.Called()
+	return int64(args.Int(0)), args.Error(1)
+}
@@ -0,0 +1,54 @@
+package mocks
+
+import (
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionServiceMock struct {
+	mock.Mock
+}
+
+func (m *SessionServiceMock) Create(user *models.User, ip, userAgent string) (*models.Session, error) {
+	args := m.Called(user, ip, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *SessionServiceMock) GetById(id string) (*models.Session, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *SessionServiceMock) GetByUser(userId string) ([]*models.Session, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+func (m *SessionServiceMock) Touch(session *models.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *SessionServiceMock) Delete(session *models.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *SessionServiceMock) DeleteByUser(userId, exceptId string) error {
+	args := m.Called(userId, exceptId)
+	return args.Error(0)
+}
+
+func (m *SessionServiceMock) DeleteExpired() (int64, error) {
+	args := m.Called()
+	return int64(args.Int(0)), args.Error(1)
+}
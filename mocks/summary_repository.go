@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"time"
 
 	"github.com/muety/wakapi/models"
@@ -27,7 +28,7 @@ func (m *SummaryRepositoryMock) GetAll() ([]*models.Summary, error) {
 	return args.Get(0).([]*models.Summary), args.Error(1)
 }
 
-func (m *SummaryRepositoryMock) GetByUserWithin(u *models.User, t1 time.Time, t2 time.Time) ([]*models.Summary, error) {
+func (m *SummaryRepositoryMock) GetByUserWithin(ctx context.Context, u *models.User, t1 time.Time, t2 time.Time) ([]*models.Summary, error) {
 	args := m.Called(u, t1, t2)
 	return args.Get(0).([]*models.Summary), args.Error(1)
 }
@@ -41,6 +42,11 @@ func (m *SummaryRepositoryMock) GetLastBySingleUser(s string) (time.Time, error)
 	return args.Get(0).(time.Time), args.Error(1)
 }
 
+func (m *SummaryRepositoryMock) GetProjectContributors(s []string, s2 string, i int) ([]*models.ProjectContributor, error) {
+	args := m.Called(s, s2, i)
+	return args.Get(0).([]*models.ProjectContributor), args.Error(1)
+}
+
 func (m *SummaryRepositoryMock) DeleteByUser(s string) error {
 	args := m.Called(s)
 	return args.Error(0)
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/models/types"
 	"github.com/stretchr/testify/mock"
@@ -11,21 +12,37 @@ type SummaryServiceMock struct {
 	mock.Mock
 }
 
-func (m *SummaryServiceMock) Aliased(t time.Time, t2 time.Time, u *models.User, r types.SummaryRetriever, f *models.Filters, d *time.Duration, b bool) (*models.Summary, error) {
+func (m *SummaryServiceMock) Aliased(ctx context.Context, t time.Time, t2 time.Time, u *models.User, r types.SummaryRetriever, f *models.Filters, d *time.Duration, b bool) (*models.Summary, error) {
 	args := m.Called(t, t2, u, r, f, d, b)
 	return args.Get(0).(*models.Summary), args.Error(1)
 }
 
-func (m *SummaryServiceMock) Retrieve(t time.Time, t2 time.Time, u *models.User, f *models.Filters, d *time.Duration) (*models.Summary, error) {
+func (m *SummaryServiceMock) Retrieve(ctx context.Context, t time.Time, t2 time.Time, u *models.User, f *models.Filters, d *time.Duration) (*models.Summary, error) {
 	args := m.Called(t, t2, u, d, f)
 	return args.Get(0).(*models.Summary), args.Error(1)
 }
 
-func (m *SummaryServiceMock) Summarize(t time.Time, t2 time.Time, u *models.User, f *models.Filters, d *time.Duration) (*models.Summary, error) {
+func (m *SummaryServiceMock) Summarize(ctx context.Context, t time.Time, t2 time.Time, u *models.User, f *models.Filters, d *time.Duration) (*models.Summary, error) {
 	args := m.Called(t, t2, u, d, f)
 	return args.Get(0).(*models.Summary), args.Error(1)
 }
 
+func (m *SummaryServiceMock) Daily(ctx context.Context, t time.Time, t2 time.Time, u *models.User, f *models.Filters, d *time.Duration) ([]*models.Summary, error) {
+	args := m.Called(t, t2, u, f, d)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Summary), args.Error(1)
+}
+
+func (m *SummaryServiceMock) WithAiSessions(ctx context.Context, s *models.Summary, u *models.User, f *models.Filters) (*models.Summary, error) {
+	args := m.Called(s, u, f)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Summary), args.Error(1)
+}
+
 func (m *SummaryServiceMock) GetLatestByUser() ([]*models.TimeByUser, error) {
 	args := m.Called()
 	return args.Get(0).([]*models.TimeByUser), args.Error(1)
@@ -145,6 +145,14 @@ func (m *UserServiceMock) GenerateUnsubscribeToken(user *models.User) (*models.U
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *UserServiceMock) GetUserBySession(sessionId string) (*models.User, *models.Session, error) {
+	args := m.Called(sessionId)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.User), args.Get(1).(*models.Session), args.Error(2)
+}
+
 func (m *UserServiceMock) ChangeUserId(user *models.User, s1 string) (*models.User, error) {
 	args := m.Called(user, s1)
 	return args.Get(0).(*models.User), args.Error(1)
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ActivityChartDaily  = "daily"  // github-like contribution timeline, one cell per day
+	ActivityChartHourly = "hourly" // punch card, one cell per hour of day and weekday
+)
+
+const MaxActivityDays = 5 * 366 // longer custom ranges are rejected, longer intervals (e.g. "all time") are cut to the most recent days
+
+type ActivityParams struct {
+	From            time.Time
+	To              time.Time
+	Filters         *Filters
+	Type            string
+	DarkTheme       bool
+	HideAttribution bool
+}
+
+type ActivityData struct {
+	From  time.Time       `json:"from"`
+	To    time.Time       `json:"to"`
+	Type  string          `json:"type"`
+	Days  []*ActivityDay  `json:"days,omitempty"`
+	Hours []*ActivityHour `json:"hours,omitempty"`
+}
+
+type ActivityDay struct {
+	Date  string  `json:"date"`
+	Total float64 `json:"total"` // in seconds
+}
+
+type ActivityHour struct {
+	Weekday int     `json:"weekday"` // 0 is sunday
+	Hour    int     `json:"hour"`
+	Total   float64 `json:"total"` // in seconds
+}
+
+func (p *ActivityParams) HashData() string {
+	filtersHash := "-"
+	if p.Filters != nil {
+		filtersHash = p.Filters.Hash()
+	}
+	// day granularity is sufficient, as charts are cached for several hours anyway
+	return fmt.Sprintf("%s_%s_%s_%s", p.Type, p.From.Format(time.DateOnly), p.To.Format(time.DateOnly), filtersHash)
+}
+
+func (p *ActivityParams) Hash() string {
+	return fmt.Sprintf("%s_%v_%v", p.HashData(), p.DarkTheme, p.HideAttribution)
+}
+
+func (d *ActivityData) MaxTotal() (max float64) {
+	for _, day := range d.Days {
+		if day.Total > max {
+			max = day.Total
+		}
+	}
+	for _, hour := range d.Hours {
+		if hour.Total > max {
+			max = hour.Total
+		}
+	}
+	return max
+}
+
+// Weekly sums up daily totals per calendar week, where each resulting day represents the (possibly partial) week starting at it
+func (d *ActivityData) Weekly(startOfWeek time.Weekday) []*ActivityDay {
+	weeks := make([]*ActivityDay, 0, len(d.Days)/7+1)
+	for _, day := range d.Days {
+		date, err := time.Parse(time.DateOnly, day.Date)
+		if err != nil {
+			continue
+		}
+		if len(weeks) == 0 || date.Weekday() == startOfWeek {
+			weeks = append(weeks, &ActivityDay{Date: day.Date})
+		}
+		weeks[len(weeks)-1].Total += day.Total
+	}
+	return weeks
+}
+
+// BestDay returns the day with the most coding time or nil, if there was no activity at all
+func (d *ActivityData) BestDay() *ActivityDay {
+	var best *ActivityDay
+	for _, day := range d.Days {
+		if day.Total > 0 && (best == nil || day.Total > best.Total) {
+			best = day
+		}
+	}
+	return best
+}
+
+func (d *ActivityData) NumActiveDays() (n int) {
+	for _, day := range d.Days {
+		if day.Total > 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityData_Weekly(t *testing.T) {
+	sut := &ActivityData{Days: []*ActivityDay{
+		{Date: "2024-01-05", Total: 10}, // friday
+		{Date: "2024-01-06", Total: 20},
+		{Date: "2024-01-07", Total: 30}, // sunday
+		{Date: "2024-01-08", Total: 40}, // monday
+		{Date: "2024-01-09", Total: 50},
+		{Date: "2024-01-15", Total: 60}, // monday
+	}}
+
+	weeks := sut.Weekly(time.Monday)
+	assert.Len(t, weeks, 3)
+	assert.Equal(t, "2024-01-05", weeks[0].Date)
+	assert.Equal(t, 60.0, weeks[0].Total)
+	assert.Equal(t, "2024-01-08", weeks[1].Date)
+	assert.Equal(t, 90.0, weeks[1].Total)
+	assert.Equal(t, "2024-01-15", weeks[2].Date)
+	assert.Equal(t, 60.0, weeks[2].Total)
+
+	weeks = sut.Weekly(time.Sunday)
+	assert.Len(t, weeks, 2)
+	assert.Equal(t, 30.0, weeks[0].Total)
+	assert.Equal(t, "2024-01-07", weeks[1].Date)
+	assert.Equal(t, 180.0, weeks[1].Total)
+}
+
+func TestActivityData_BestDay(t *testing.T) {
+	sut := &ActivityData{Days: []*ActivityDay{
+		{Date: "2024-01-05", Total: 0},
+		{Date: "2024-01-06", Total: 20},
+		{Date: "2024-01-07", Total: 0},
+		{Date: "2024-01-08", Total: 40},
+		{Date: "2024-01-09", Total: 40},
+	}}
+
+	assert.Equal(t, "2024-01-08", sut.BestDay().Date)
+	assert.Equal(t, 3, sut.NumActiveDays())
+
+	sut = &ActivityData{Days: []*ActivityDay{{Date: "2024-01-05", Total: 0}}}
+	assert.Nil(t, sut.BestDay())
+	assert.Zero(t, sut.NumActiveDays())
+}
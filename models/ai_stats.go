@@ -0,0 +1,168 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/duke-git/lancet/v2/condition"
+	"github.com/duke-git/lancet/v2/mathutil"
+)
+
+// AICounters holds counts of ai-assisted coding activity as reported by heartbeats, which are summed up when aggregating heartbeats into durations and durations into summary items
+type AICounters struct {
+	AIInputTokens    int `json:"ai_input_tokens,omitempty" gorm:"default:0"`
+	AIOutputTokens   int `json:"ai_output_tokens,omitempty" gorm:"default:0"`
+	AILineChanges    int `json:"ai_line_changes,omitempty" gorm:"default:0"`
+	HumanLineChanges int `json:"human_line_changes,omitempty" gorm:"default:0"`
+}
+
+func NewAICountersFromHeartbeat(h *Heartbeat) AICounters {
+	return AICounters{
+		AIInputTokens:    h.AIInputTokens,
+		AIOutputTokens:   h.AIOutputTokens,
+		AILineChanges:    h.AILineChanges,
+		HumanLineChanges: h.HumanLineChanges,
+	}
+}
+
+func (c *AICounters) Add(other AICounters) {
+	c.AIInputTokens += other.AIInputTokens
+	c.AIOutputTokens += other.AIOutputTokens
+	c.AILineChanges += other.AILineChanges
+	c.HumanLineChanges += other.HumanLineChanges
+}
+
+func (c AICounters) Tokens() int {
+	return c.AIInputTokens + c.AIOutputTokens
+}
+
+func (c AICounters) HasAIActivity() bool {
+	return c.Tokens() > 0 || c.AILineChanges > 0
+}
+
+// AILineChangesRatio returns the share of line changes made by ai, relative to all line changes (0 - 1)
+func (c AICounters) AILineChangesRatio() float64 {
+	total := c.AILineChanges + c.HumanLineChanges
+	return mathutil.RoundToFloat(condition.Ternary(total > 0, float64(c.AILineChanges)/float64(total), 0), 2)
+}
+
+// MaxAiSessionDays is the longest range for which ai sessions are derived from heartbeats, see NewAiSessionItems
+const MaxAiSessionDays = 31
+
+// NewAiSessionItems derives time and counters per ai session from the given heartbeats.
+// Like for durations, the time between two subsequent heartbeats of the same session is counted, unless exceeding the timeout.
+func NewAiSessionItems(heartbeats []*Heartbeat, timeout time.Duration) SummaryItems {
+	sorted := make([]*Heartbeat, len(heartbeats))
+	copy(sorted, heartbeats)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.T().Before(sorted[j].Time.T())
+	})
+
+	items := make(map[string]*SummaryItem)
+	totals := make(map[string]time.Duration)
+	latest := make(map[string]time.Time)
+	for _, h := range sorted {
+		if h.AISession == "" {
+			continue
+		}
+		item, ok := items[h.AISession]
+		if !ok {
+			item = &SummaryItem{Type: SummaryAiSession, Key: h.AISession}
+			items[h.AISession] = item
+		} else if gap := h.Time.T().Sub(latest[h.AISession]); gap <= timeout {
+			totals[h.AISession] += gap
+		}
+		item.AICounters.Add(NewAICountersFromHeartbeat(h))
+		latest[h.AISession] = h.Time.T()
+	}
+
+	result := make(SummaryItems, 0, len(items))
+	for key, item := range items {
+		item.Total = totals[key] / time.Second
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total == result[j].Total {
+			return result[i].Key < result[j].Key
+		}
+		return result[i].Total > result[j].Total
+	})
+	return result
+}
+
+// AIStats summarizes ai-assisted coding activity within a summary, see Summary.AIStats
+type AIStats struct {
+	AICounters
+	LineChangesRatio     float64      `json:"ai_line_changes_ratio"`
+	NumSessions          int          `json:"num_sessions"`
+	SessionsTotalSeconds float64      `json:"sessions_total_seconds"`
+	SessionsAvgSeconds   float64      `json:"sessions_avg_seconds"`
+	Models               SummaryItems `json:"models"`   // time and counters per ai model
+	Projects             SummaryItems `json:"projects"` // counters per project, only projects with ai activity
+	Sessions             SummaryItems `json:"sessions"` // time and counters per ai session
+}
+
+func (s *AIStats) HasData() bool {
+	return s.HasAIActivity() || s.NumSessions > 0 || len(s.Models) > 0
+}
+
+func (s *AIStats) SessionsTotal() time.Duration {
+	return time.Duration(s.SessionsTotalSeconds * float64(time.Second))
+}
+
+func (s *AIStats) SessionsAvg() time.Duration {
+	return time.Duration(s.SessionsAvgSeconds * float64(time.Second))
+}
+
+// HasAIActivity returns whether any of the summary's time was attributed to an ai model or came with ai counters
+func (s *Summary) HasAIActivity() bool {
+	for _, item := range s.AiModels {
+		if item.Key != UnknownSummaryKey || item.HasAIActivity() {
+			return true
+		}
+	}
+	return false
+}
+
+// AIStats derives ai usage analytics from the summary's ai model, ai session and project items
+func (s *Summary) AIStats() *AIStats {
+	stats := &AIStats{
+		Models:   SummaryItems{},
+		Projects: SummaryItems{},
+		Sessions: SummaryItems{},
+	}
+
+	// ai model items cover all of the summary's durations, including those without a model ("unknown"), so they sum up to the summary's totals
+	for _, item := range s.AiModels {
+		stats.AICounters.Add(item.AICounters)
+		if item.Key != UnknownSummaryKey || item.HasAIActivity() {
+			stats.Models = append(stats.Models, item)
+		}
+	}
+
+	var sessionsTotal time.Duration
+	for _, item := range s.AiSessions {
+		if item.Key == UnknownSummaryKey {
+			continue
+		}
+		stats.Sessions = append(stats.Sessions, item)
+		sessionsTotal += item.TotalFixed()
+	}
+	stats.NumSessions = len(stats.Sessions)
+	stats.SessionsTotalSeconds = sessionsTotal.Seconds()
+	if stats.NumSessions > 0 {
+		stats.SessionsAvgSeconds = mathutil.RoundToFloat(stats.SessionsTotalSeconds/float64(stats.NumSessions), 0)
+	}
+
+	for _, item := range s.Projects {
+		if item.HasAIActivity() {
+			stats.Projects = append(stats.Projects, item)
+		}
+	}
+	sort.SliceStable(stats.Projects, func(i, j int) bool {
+		return stats.Projects[i].Tokens() > stats.Projects[j].Tokens()
+	})
+
+	stats.LineChangesRatio = stats.AILineChangesRatio()
+	return stats
+}
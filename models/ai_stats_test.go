@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary_AIStats(t *testing.T) {
+	sut := &Summary{
+		Projects: []*SummaryItem{
+			{Type: SummaryProject, Key: "wakapi", Total: 60, AICounters: AICounters{AIInputTokens: 100, AIOutputTokens: 50, AILineChanges: 30, HumanLineChanges: 10}},
+			{Type: SummaryProject, Key: "anchr", Total: 120, AICounters: AICounters{HumanLineChanges: 20}},
+			{Type: SummaryProject, Key: "mininote", Total: 30, AICounters: AICounters{AIInputTokens: 400, AIOutputTokens: 100}},
+		},
+		AiModels: []*SummaryItem{
+			{Type: SummaryAiModel, Key: "claude-3-5-sonnet", Total: 90, AICounters: AICounters{AIInputTokens: 500, AIOutputTokens: 150, AILineChanges: 30}},
+			{Type: SummaryAiModel, Key: UnknownSummaryKey, Total: 120, AICounters: AICounters{HumanLineChanges: 30}},
+		},
+		AiSessions: []*SummaryItem{
+			{Type: SummaryAiSession, Key: "session1", Total: 60},
+			{Type: SummaryAiSession, Key: "session2", Total: 30},
+			{Type: SummaryAiSession, Key: UnknownSummaryKey, Total: 120},
+		},
+	}
+
+	stats := sut.AIStats()
+
+	assert.True(t, stats.HasData())
+	assert.Equal(t, 500, stats.AIInputTokens)
+	assert.Equal(t, 150, stats.AIOutputTokens)
+	assert.Equal(t, 30, stats.AILineChanges)
+	assert.Equal(t, 30, stats.HumanLineChanges)
+	assert.Equal(t, 0.5, stats.LineChangesRatio)
+
+	assert.Len(t, stats.Models, 1) // unknown model without ai activity is left out
+	assert.Equal(t, "claude-3-5-sonnet", stats.Models[0].Key)
+
+	assert.Len(t, stats.Projects, 2)
+	assert.Equal(t, "mininote", stats.Projects[0].Key)
+	assert.Equal(t, "wakapi", stats.Projects[1].Key)
+
+	assert.Equal(t, 2, stats.NumSessions)
+	assert.Equal(t, float64(90), stats.SessionsTotalSeconds)
+	assert.Equal(t, float64(45), stats.SessionsAvgSeconds)
+}
+
+func TestSummary_AIStats_NoData(t *testing.T) {
+	sut := &Summary{
+		Projects: []*SummaryItem{{Type: SummaryProject, Key: "wakapi", Total: 60}},
+		AiModels: []*SummaryItem{{Type: SummaryAiModel, Key: UnknownSummaryKey, Total: 60}},
+	}
+
+	stats := sut.AIStats()
+
+	assert.False(t, stats.HasData())
+	assert.Zero(t, stats.LineChangesRatio)
+	assert.Empty(t, stats.Models)
+	assert.Empty(t, stats.Sessions)
+}
+
+func TestNewAiSessionItems(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	heartbeats := []*Heartbeat{
+		{AISession: "session2", Time: CustomTime(t0.Add(1 * time.Minute)), AIInputTokens: 10},
+		{AISession: "session1", Time: CustomTime(t0), AIInputTokens: 100},
+		{Time: CustomTime(t0.Add(30 * time.Second))}, // no ai session
+		{AISession: "session1", Time: CustomTime(t0.Add(2 * time.Minute)), AIInputTokens: 50},
+		{AISession: "session2", Time: CustomTime(t0.Add(90 * time.Second))},
+		{AISession: "session1", Time: CustomTime(t0.Add(20 * time.Minute))}, // beyond timeout
+	}
+
+	items := NewAiSessionItems(heartbeats, 10*time.Minute)
+
+	assert.Len(t, items, 2)
+	assert.Equal(t, "session1", items[0].Key)
+	assert.Equal(t, SummaryAiSession, items[0].Type)
+	assert.Equal(t, 2*time.Minute, items[0].TotalFixed())
+	assert.Equal(t, 150, items[0].AIInputTokens)
+	assert.Equal(t, "session2", items[1].Key)
+	assert.Equal(t, 30*time.Second, items[1].TotalFixed())
+}
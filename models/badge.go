@@ -0,0 +1,70 @@
+package models
+
+type BadgeMetric string
+
+const (
+	BadgeMetricTotal         BadgeMetric = "total"
+	BadgeMetricTopLanguage   BadgeMetric = "top_language"
+	BadgeMetricLanguageShare BadgeMetric = "language_share"
+	BadgeMetricStreak        BadgeMetric = "streak"
+	BadgeMetricDailyAverage  BadgeMetric = "daily_average"
+	BadgeMetricLastActive    BadgeMetric = "last_active"
+)
+
+var AllBadgeMetrics = []BadgeMetric{
+	BadgeMetricTotal,
+	BadgeMetricTopLanguage,
+	BadgeMetricLanguageShare,
+	BadgeMetricStreak,
+	BadgeMetricDailyAverage,
+	BadgeMetricLastActive,
+}
+
+// BadgeDefinition describes what to display on a badge and how it should look like
+type BadgeDefinition struct {
+	Metric     BadgeMetric
+	Interval   *KeyedInterval
+	Filters    *Filters
+	Language   string // only relevant for language_share metric
+	MaxDays    int    // upper bound for days to look back for streak metric
+	Label      string
+	Color      string
+	LabelColor string
+	Style      string
+}
+
+func ParseBadgeMetric(s string) (BadgeMetric, bool) {
+	for _, m := range AllBadgeMetrics {
+		if string(m) == s {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// RequiresLanguages returns whether the metric exposes language information, which requires the user to have opted in to share languages
+func (m BadgeMetric) RequiresLanguages() bool {
+	return m == BadgeMetricTopLanguage || m == BadgeMetricLanguageShare
+}
+
+// IsTimeless returns whether the metric is independent of the requested interval
+func (m BadgeMetric) IsTimeless() bool {
+	return m == BadgeMetricStreak || m == BadgeMetricLastActive
+}
+
+func (m BadgeMetric) DefaultLabel() string {
+	switch m {
+	case BadgeMetricTopLanguage:
+		return "top language"
+	case BadgeMetricLanguageShare:
+		return "share"
+	case BadgeMetricStreak:
+		return "streak"
+	case BadgeMetricDailyAverage:
+		return "daily average"
+	case BadgeMetricLastActive:
+		return "last active"
+	default:
+		return "wakapi.dev"
+	}
+}
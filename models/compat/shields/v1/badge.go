@@ -17,6 +17,8 @@ type BadgeData struct {
 	Label         string `json:"label"`
 	Message       string `json:"message"`
 	Color         string `json:"color"`
+	LabelColor    string `json:"labelColor,omitempty"`
+	Style         string `json:"style,omitempty"`
 }
 
 func NewBadgeDataFrom(summary *models.Summary) *BadgeData {
@@ -27,3 +29,21 @@ func NewBadgeDataFrom(summary *models.Summary) *BadgeData {
 		Color:         defaultColor,
 	}
 }
+
+func NewBadgeDataFromDefinition(def *models.BadgeDefinition, message string) *BadgeData {
+	data := &BadgeData{
+		SchemaVersion: 1,
+		Label:         def.Metric.DefaultLabel(),
+		Message:       message,
+		Color:         defaultColor,
+		LabelColor:    def.LabelColor,
+		Style:         def.Style,
+	}
+	if def.Label != "" {
+		data.Label = def.Label
+	}
+	if def.Color != "" {
+		data.Color = def.Color
+	}
+	return data
+}
@@ -0,0 +1,191 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/utils"
+)
+
+// https://wakatime.com/developers#insights
+
+type InsightsViewModel struct {
+	Data *InsightsData `json:"data"`
+}
+
+type InsightsData struct {
+	Username           string                  `json:"username"`
+	UserId             string                  `json:"user_id"`
+	Start              string                  `json:"start"`
+	End                string                  `json:"end"`
+	Timezone           string                  `json:"timezone"`
+	Range              string                  `json:"range"`
+	HumanReadableRange string                  `json:"human_readable_range"`
+	Status             string                  `json:"status"`
+	IsUpToDate         bool                    `json:"is_up_to_date"`
+	Weekdays           []*InsightsWeekdayEntry `json:"weekdays,omitempty"`
+	Days               []*InsightsDayEntry     `json:"days,omitempty"`
+	BestDay            *InsightsDayEntry       `json:"best_day,omitempty"`
+	DailyAverage       *InsightsDailyAverage   `json:"daily_average,omitempty"`
+	Hours              []*InsightsHourEntry    `json:"hours,omitempty"`
+	Projects           []*InsightsEntityEntry  `json:"projects,omitempty"`
+	Languages          []*InsightsEntityEntry  `json:"languages,omitempty"`
+	Editors            []*InsightsEntityEntry  `json:"editors,omitempty"`
+	OperatingSystems   []*InsightsEntityEntry  `json:"operating_systems,omitempty"`
+	Machines           []*InsightsEntityEntry  `json:"machines,omitempty"`
+	Labels             []*InsightsEntityEntry  `json:"labels,omitempty"`
+	Categories         []*InsightsEntityEntry  `json:"categories,omitempty"`
+}
+
+type InsightsDayEntry struct {
+	Date         string  `json:"date"`
+	TotalSeconds float64 `json:"total_seconds"`
+	Text         string  `json:"text"`
+}
+
+type InsightsWeekdayEntry struct {
+	Name                 string  `json:"name"`
+	TotalSeconds         float64 `json:"total_seconds"`
+	AverageSeconds       float64 `json:"average_seconds"`
+	HumanReadableTotal   string  `json:"human_readable_total"`
+	HumanReadableAverage string  `json:"human_readable_average"`
+	NumDays              int     `json:"num_days"`
+}
+
+type InsightsDailyAverage struct {
+	Seconds               float64 `json:"seconds"`
+	Text                  string  `json:"text"`
+	SecondsActiveDays     float64 `json:"seconds_active_days"`
+	TextActiveDays        string  `json:"text_active_days"`
+	DaysIncludingHolidays int     `json:"days_including_holidays"`
+	DaysMinusHolidays     int     `json:"days_minus_holidays"`
+	Holidays              int     `json:"holidays"`
+	PreviousSeconds       float64 `json:"previous_seconds"`
+	PreviousText          string  `json:"previous_text"`
+	ChangePercent         float64 `json:"change_percent"`
+}
+
+type InsightsHourEntry struct {
+	Hour           int     `json:"hour"`
+	TotalSeconds   float64 `json:"total_seconds"`
+	AverageSeconds float64 `json:"average_seconds"`
+}
+
+type InsightsEntityEntry struct {
+	Name         string              `json:"name"`
+	TotalSeconds float64             `json:"total_seconds"`
+	Text         string              `json:"text"`
+	Days         []*InsightsDayEntry `json:"days"`
+}
+
+func NewInsightsFrom(insights *models.Insights, user *models.User) *InsightsViewModel {
+	data := &InsightsData{
+		Username:   user.ID,
+		UserId:     user.ID,
+		Start:      insights.From.Format(time.RFC3339),
+		End:        insights.To.Format(time.RFC3339),
+		Timezone:   utils.ResolveIANAZone(user.TZ()),
+		Status:     "ok",
+		IsUpToDate: true,
+	}
+
+	switch insights.Type {
+	case models.InsightWeekdays:
+		data.Weekdays = make([]*InsightsWeekdayEntry, len(insights.Weekdays))
+		for i, w := range insights.Weekdays {
+			data.Weekdays[i] = &InsightsWeekdayEntry{
+				Name:                 w.Weekday.String(),
+				TotalSeconds:         w.Total,
+				AverageSeconds:       w.Average,
+				HumanReadableTotal:   helpers.FmtWakatimeDuration(fromSeconds(w.Total)),
+				HumanReadableAverage: helpers.FmtWakatimeDuration(fromSeconds(w.Average)),
+				NumDays:              w.NumDays,
+			}
+		}
+	case models.InsightDays:
+		data.Days = newInsightsDays(insights.Days)
+	case models.InsightBestDay:
+		data.BestDay = newInsightsDay(insights.BestDay)
+	case models.InsightDailyAverage:
+		if avg := insights.DailyAverage; avg != nil {
+			data.DailyAverage = &InsightsDailyAverage{
+				Seconds:               avg.Average,
+				Text:                  helpers.FmtWakatimeDuration(fromSeconds(avg.Average)),
+				SecondsActiveDays:     avg.AverageActiveDays,
+				TextActiveDays:        helpers.FmtWakatimeDuration(fromSeconds(avg.AverageActiveDays)),
+				DaysIncludingHolidays: avg.NumDays,
+				DaysMinusHolidays:     avg.NumDays - avg.NumHolidays,
+				Holidays:              avg.NumHolidays,
+				PreviousSeconds:       avg.PreviousAverage,
+				PreviousText:          helpers.FmtWakatimeDuration(fromSeconds(avg.PreviousAverage)),
+				ChangePercent:         avg.ChangePercent,
+			}
+		}
+	case models.InsightHours:
+		data.Hours = make([]*InsightsHourEntry, len(insights.Hours))
+		for i, h := range insights.Hours {
+			data.Hours[i] = &InsightsHourEntry{Hour: h.Hour, TotalSeconds: h.Total, AverageSeconds: h.Average}
+		}
+	case models.InsightProjects:
+		data.Projects = newInsightsEntities(insights)
+	case models.InsightLanguages:
+		data.Languages = newInsightsEntities(insights)
+	case models.InsightEditors:
+		data.Editors = newInsightsEntities(insights)
+	case models.InsightOperatingSystems:
+		data.OperatingSystems = newInsightsEntities(insights)
+	case models.InsightMachines:
+		data.Machines = newInsightsEntities(insights)
+	case models.InsightLabels:
+		data.Labels = newInsightsEntities(insights)
+	case models.InsightCategories:
+		data.Categories = newInsightsEntities(insights)
+	}
+
+	return &InsightsViewModel{Data: data}
+}
+
+func newInsightsDay(day *models.InsightsDay) *InsightsDayEntry {
+	if day == nil {
+		return nil
+	}
+	return &InsightsDayEntry{
+		Date:         day.Date,
+		TotalSeconds: day.Total,
+		Text:         helpers.FmtWakatimeDuration(fromSeconds(day.Total)),
+	}
+}
+
+func newInsightsDays(days []*models.InsightsDay) []*InsightsDayEntry {
+	entries := make([]*InsightsDayEntry, len(days))
+	for i, d := range days {
+		entries[i] = newInsightsDay(d)
+	}
+	return entries
+}
+
+func newInsightsEntities(insights *models.Insights) []*InsightsEntityEntry {
+	entries := make([]*InsightsEntityEntry, len(insights.Entities))
+	for i, e := range insights.Entities {
+		days := make([]*InsightsDayEntry, len(e.Days))
+		for j, total := range e.Days {
+			days[j] = &InsightsDayEntry{
+				Date:         insights.Days[j].Date,
+				TotalSeconds: total,
+				Text:         helpers.FmtWakatimeDuration(fromSeconds(total)),
+			}
+		}
+		entries[i] = &InsightsEntityEntry{
+			Name:         e.Key,
+			TotalSeconds: e.Total,
+			Text:         helpers.FmtWakatimeDuration(fromSeconds(e.Total)),
+			Days:         days,
+		}
+	}
+	return entries
+}
+
+func fromSeconds(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -26,6 +26,8 @@ type StatsData struct {
 	TotalSeconds              float64           `json:"total_seconds"`
 	DailyAverage              float64           `json:"daily_average"`
 	DaysIncludingHolidays     int               `json:"days_including_holidays"`
+	DaysMinusHolidays         int               `json:"days_minus_holidays"`
+	Holidays                  int               `json:"holidays"`
 	Range                     string            `json:"range"`
 	HumanReadableRange        string            `json:"human_readable_range"`
 	HumanReadableTotal        string            `json:"human_readable_total"`
@@ -39,11 +41,16 @@ type StatsData struct {
 	OperatingSystems          []*SummariesEntry `json:"operating_systems"`
 	Branches                  []*SummariesEntry `json:"branches,omitempty"`
 	Categories                []*SummariesEntry `json:"categories"`
+	BestDay                   *InsightsDayEntry `json:"best_day,omitempty"`
+	AI                        *models.AIStats   `json:"ai,omitempty"` // wakapi-specific
+	*SummariesLines
 }
 
-func NewStatsFrom(summary *models.Summary, filters *models.Filters) *StatsViewModel {
+// NewStatsFrom converts a summary to wakatime-compatible stats, where the daily average only considers working days according to the given (optional) calendar
+func NewStatsFrom(summary *models.Summary, filters *models.Filters, calendar *models.WorkCalendar) *StatsViewModel {
 	totalTime := summary.TotalTime()
 	numDays := int(summary.ToTime.T().Sub(summary.FromTime.T()).Hours() / 24)
+	holidays := calendar.CountHolidays(summary.FromTime.T().In(summary.User.TZ()), numDays)
 
 	data := &StatsData{
 		Username:              summary.UserID,
@@ -54,12 +61,15 @@ func NewStatsFrom(summary *models.Summary, filters *models.Filters) *StatsViewMo
 		Timezone:              utils.ResolveIANAZone(summary.User.TZ()),
 		TotalSeconds:          totalTime.Seconds(),
 		DaysIncludingHolidays: numDays,
+		DaysMinusHolidays:     numDays - holidays,
+		Holidays:              holidays,
 		HumanReadableTotal:    helpers.FmtWakatimeDuration(totalTime),
+		SummariesLines:        newLinesFrom(summary.TotalLines()),
 	}
 
-	if numDays > 0 {
-		data.DailyAverage = totalTime.Seconds() / float64(numDays)
-		data.HumanReadableDailyAverage = helpers.FmtWakatimeDuration(totalTime / time.Duration(numDays))
+	if data.DaysMinusHolidays > 0 {
+		data.DailyAverage = totalTime.Seconds() / float64(data.DaysMinusHolidays)
+		data.HumanReadableDailyAverage = helpers.FmtWakatimeDuration(totalTime / time.Duration(data.DaysMinusHolidays))
 	}
 	if math.IsInf(data.DailyAverage, 0) || math.IsNaN(data.DailyAverage) {
 		data.DailyAverage = 0
@@ -114,6 +124,10 @@ func NewStatsFrom(summary *models.Summary, filters *models.Filters) *StatsViewMo
 		data.Branches = nil
 	}
 
+	if aiStats := summary.AIStats(); aiStats.HasData() {
+		data.AI = aiStats
+	}
+
 	return &StatsViewModel{
 		Data: data,
 	}
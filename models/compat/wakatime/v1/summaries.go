@@ -53,6 +53,7 @@ type SummariesData struct {
 	Entities         []*SummariesEntry    `json:"entities"`
 	GrandTotal       *SummariesGrandTotal `json:"grand_total"`
 	Range            *SummariesRange      `json:"range"`
+	AI               *models.AIStats      `json:"ai,omitempty"` // wakapi-specific
 }
 
 type SummariesEntry struct {
@@ -64,6 +65,7 @@ type SummariesEntry struct {
 	Seconds      int     `json:"seconds"`
 	Text         string  `json:"text"`
 	TotalSeconds float64 `json:"total_seconds"`
+	*SummariesLines
 }
 
 type SummariesGrandTotal struct {
@@ -72,6 +74,14 @@ type SummariesGrandTotal struct {
 	Minutes      int     `json:"minutes"`
 	Text         string  `json:"text"`
 	TotalSeconds float64 `json:"total_seconds"`
+	*SummariesLines
+}
+
+// SummariesLines is wakapi-specific and only included if any lines were changed
+type SummariesLines struct {
+	LineAdditions int `json:"line_additions"`
+	LineDeletions int `json:"line_deletions"`
+	NetLines      int `json:"net_lines"`
 }
 
 type SummariesRange struct {
@@ -95,7 +105,8 @@ func (s *SummariesData) MarshalJSON() ([]byte, error) {
 	return json.Marshal((*alias)(s))
 }
 
-func NewSummariesFrom(summaries []*models.Summary) *SummariesViewModel {
+// NewSummariesFrom converts per-day summaries to wakatime-compatible summaries, where the daily average only considers working days according to the given (optional) calendar
+func NewSummariesFrom(summaries []*models.Summary, calendar *models.WorkCalendar) *SummariesViewModel {
 	data := make([]*SummariesData, len(summaries))
 	minDate, maxDate := time.Now().Add(1*time.Second), time.Time{}
 
@@ -120,7 +131,9 @@ func NewSummariesFrom(summaries []*models.Summary) *SummariesViewModel {
 
 	totalHrs, totalMins, totalSecs := totalTime.Hours(), (totalTime - time.Duration(totalTime.Hours())*time.Hour).Minutes(), totalTime.Seconds()
 	totalDays := mathutil.Max[int](len(utils.SplitRangeByDays(minDate, maxDate)), 1)
-	totalTimeAvg, totalTimeKnownAvg := totalTime/time.Duration(totalDays), totalTimeKnown/time.Duration(totalDays)
+	holidays := calendar.CountHolidays(minDate, totalDays)
+	workingDays := mathutil.Max[int](totalDays-holidays, 1)
+	totalTimeAvg, totalTimeKnownAvg := totalTime/time.Duration(workingDays), totalTimeKnown/time.Duration(workingDays)
 	totalSecsAvg, totalSecsKnownAvg := int64(totalTimeAvg.Seconds()), int64(totalTimeKnownAvg.Seconds())
 
 	return &SummariesViewModel{
@@ -135,8 +148,8 @@ func NewSummariesFrom(summaries []*models.Summary) *SummariesViewModel {
 		},
 		DailyAverage: &SummariesDailyAverage{
 			DaysIncludingHolidays:         totalDays,
-			DaysMinusHolidays:             totalDays,
-			Holidays:                      0, // not implemented, because we don't track user location
+			DaysMinusHolidays:             totalDays - holidays,
+			Holidays:                      holidays,
 			Seconds:                       totalSecsKnownAvg,
 			SecondsIncludingOtherLanguage: totalSecsAvg,
 			Text:                          helpers.FmtWakatimeDuration(totalTimeKnownAvg),
@@ -161,11 +174,12 @@ func newDataFrom(s *models.Summary) *SummariesData {
 		Entities:         make([]*SummariesEntry, len(s.Entities)),
 		Categories:       make([]*SummariesEntry, len(s.Categories)),
 		GrandTotal: &SummariesGrandTotal{
-			Digital:      fmt.Sprintf("%d:%d", totalHrs, totalMins),
-			Hours:        totalHrs,
-			Minutes:      totalMins,
-			Text:         helpers.FmtWakatimeDuration(total),
-			TotalSeconds: total.Seconds(),
+			Digital:        fmt.Sprintf("%d:%d", totalHrs, totalMins),
+			Hours:          totalHrs,
+			Minutes:        totalMins,
+			Text:           helpers.FmtWakatimeDuration(total),
+			TotalSeconds:   total.Seconds(),
+			SummariesLines: newLinesFrom(s.TotalLines()),
 		},
 		Range: &SummariesRange{
 			Date:     time.Now().Format(time.RFC3339),
@@ -248,6 +262,9 @@ func newDataFrom(s *models.Summary) *SummariesData {
 	if s.Entities == nil {
 		data.Entities = nil
 	}
+	if aiStats := s.AIStats(); aiStats.HasData() {
+		data.AI = aiStats
+	}
 
 	wg.Wait()
 	return data
@@ -264,13 +281,25 @@ func convertEntry(e *models.SummaryItem, entityTotal time.Duration) *SummariesEn
 	}
 
 	return &SummariesEntry{
-		Digital:      fmt.Sprintf("%d:%d:%d", hrs, mins, secs),
-		Hours:        hrs,
-		Minutes:      mins,
-		Name:         e.Key,
-		Percent:      percentage,
-		Seconds:      secs,
-		Text:         helpers.FmtWakatimeDuration(total),
-		TotalSeconds: total.Seconds(),
+		Digital:        fmt.Sprintf("%d:%d:%d", hrs, mins, secs),
+		Hours:          hrs,
+		Minutes:        mins,
+		Name:           e.Key,
+		Percent:        percentage,
+		Seconds:        secs,
+		Text:           helpers.FmtWakatimeDuration(total),
+		TotalSeconds:   total.Seconds(),
+		SummariesLines: newLinesFrom(e.LineCounters),
+	}
+}
+
+func newLinesFrom(lines models.LineCounters) *SummariesLines {
+	if !lines.HasLineChanges() {
+		return nil
+	}
+	return &SummariesLines{
+		LineAdditions: lines.LineAdditions,
+		LineDeletions: lines.LineDeletions,
+		NetLines:      lines.NetLines(),
 	}
 }
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/duke-git/lancet/v2/slice"
+)
+
+const (
+	WidgetChartBar      = "bar"      // horizontal bars of total time per entity
+	WidgetChartPie      = "pie"      // share of total time per entity
+	WidgetChartTimeline = "timeline" // stacked bars of total time per entity and day
+	WidgetChartHeatmap  = "heatmap"  // github-like contribution timeline, see ActivityChartDaily
+	WidgetChartHourly   = "hourly"   // punch card of coding time per hour of day and weekday, see ActivityChartHourly
+)
+
+const (
+	MaxDashboardsPerUser   = 16
+	MaxWidgetsPerDashboard = 24
+	MaxWidgetItems         = 10  // number of entities shown in bar, pie and timeline charts, all others are summed up
+	MaxWidgetTimelineDays  = 31  // timelines are limited to the most recent days of the widget's interval
+	MaxWidgetActivityDays  = 366 // heatmaps and punch cards are limited to the most recent days of the widget's interval
+	WidgetOtherKey         = "other"
+)
+
+func WidgetChartTypes() []string {
+	return []string{WidgetChartBar, WidgetChartPie, WidgetChartTimeline, WidgetChartHeatmap, WidgetChartHourly}
+}
+
+// Dashboard is a named, user-defined collection of widgets, optionally shared publicly via a secret link
+type Dashboard struct {
+	ID         uint               `json:"id" gorm:"primary_key"`
+	User       *User              `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID     string             `json:"-" gorm:"not null; index:idx_dashboard_user"`
+	Name       string             `json:"name" gorm:"not null; type:varchar(255)"`
+	Shared     bool               `json:"shared" gorm:"default:false"`
+	ShareToken string             `json:"-" gorm:"not null; uniqueIndex:idx_dashboard_share_token; type:varchar(64)"` // random, regenerated whenever sharing is turned on
+	Widgets    []*DashboardWidget `json:"widgets" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CreatedAt  CustomTime         `json:"created_at" gorm:"timeScale:3"` // filled by gorm
+}
+
+type DashboardWidget struct {
+	ID          uint   `json:"id" gorm:"primary_key"`
+	DashboardID uint   `json:"-" gorm:"not null; index:idx_dashboard_widget_dashboard"`
+	Position    int    `json:"position"`
+	Title       string `json:"title" gorm:"type:varchar(255)"`
+	ChartType   string `json:"chart_type" gorm:"not null; type:varchar(16)"`
+	SummaryType uint8  `json:"summary_type"` // only relevant for bar, pie and timeline charts
+	Interval    string `json:"interval" gorm:"not null; type:varchar(32)"`
+	Filters     string `json:"filters" gorm:"type:varchar(1024)"` // url-encoded query parameters, e.g. "project=wakapi&language=Go", see NewFiltersFromQuery
+}
+
+// DashboardWidgetData holds the data to render a widget's chart. Bar and pie charts come with a single series, timelines with one series per entity,
+// activity charts (heatmaps and punch cards) are pre-rendered as svg.
+type DashboardWidgetData struct {
+	WidgetID  uint                     `json:"widget_id"`
+	ChartType string                   `json:"chart_type"`
+	From      time.Time                `json:"from"`
+	To        time.Time                `json:"to"`
+	Labels    []string                 `json:"labels,omitempty"`
+	Series    []*DashboardWidgetSeries `json:"series,omitempty"`
+	Svg       string                   `json:"-"`
+}
+
+type DashboardWidgetSeries struct {
+	Key    string    `json:"key"`
+	Values []float64 `json:"values"` // in seconds
+}
+
+func (d *Dashboard) IsValid() bool {
+	return d.UserID != "" && d.Name != "" && len(d.Name) <= 255 && d.ShareToken != ""
+}
+
+func (d *Dashboard) FindWidget(id uint) *DashboardWidget {
+	for _, w := range d.Widgets {
+		if w.ID == id {
+			return w
+		}
+	}
+	return nil
+}
+
+func (w *DashboardWidget) IsValid() bool {
+	if !slice.Contain(WidgetChartTypes(), w.ChartType) || len(w.Title) > 255 || len(w.Filters) > 1024 {
+		return false
+	}
+	if w.HasSummaryType() && !slice.Contain(SummaryTypes(), w.SummaryType) {
+		return false
+	}
+	if _, err := url.ParseQuery(w.Filters); err != nil {
+		return false
+	}
+	return slice.ContainBy(AllIntervals, func(i *IntervalKey) bool {
+		return i.HasAlias(w.Interval)
+	})
+}
+
+// HasSummaryType returns whether the widget's chart breaks down coding time by entities of its summary type
+func (w *DashboardWidget) HasSummaryType() bool {
+	return w.ChartType == WidgetChartBar || w.ChartType == WidgetChartPie || w.ChartType == WidgetChartTimeline
+}
+
+// IsActivityChart returns whether the widget is rendered server-side as svg
+func (w *DashboardWidget) IsActivityChart() bool {
+	return w.ChartType == WidgetChartHeatmap || w.ChartType == WidgetChartHourly
+}
+
+func (w *DashboardWidget) ParsedFilters() *Filters {
+	query, _ := url.ParseQuery(w.Filters)
+	return NewFiltersFromQuery(query)
+}
+
+func (w *DashboardWidget) IntervalLabel() string {
+	for _, i := range AllIntervals {
+		if i.HasAlias(w.Interval) {
+			return i.GetHumanReadable()
+		}
+	}
+	return w.Interval
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboardWidget_IsValid(t *testing.T) {
+	assert.True(t, (&DashboardWidget{ChartType: WidgetChartPie, SummaryType: SummaryLanguage, Interval: "last_7_days"}).IsValid())
+	assert.True(t, (&DashboardWidget{ChartType: WidgetChartHeatmap, SummaryType: SummaryUnknown, Interval: "year", Filters: "project=wakapi"}).IsValid())
+	assert.False(t, (&DashboardWidget{ChartType: "radar", Interval: "today"}).IsValid())
+	assert.False(t, (&DashboardWidget{ChartType: WidgetChartBar, SummaryType: SummaryUnknown, Interval: "today"}).IsValid())
+	assert.False(t, (&DashboardWidget{ChartType: WidgetChartBar, Interval: "last_3_days"}).IsValid())
+	assert.False(t, (&DashboardWidget{ChartType: WidgetChartBar, Interval: "today", Filters: "project=%zz"}).IsValid())
+}
+
+func TestDashboardWidget_ParsedFilters(t *testing.T) {
+	sut := &DashboardWidget{Filters: "project=wakapi&project=anchr&language=Go"}
+	filters := sut.ParsedFilters()
+	assert.Equal(t, OrFilter{"wakapi", "anchr"}, filters.Project)
+	assert.Equal(t, OrFilter{"Go"}, filters.Language)
+
+	assert.True(t, (&DashboardWidget{}).ParsedFilters().IsEmpty())
+}
+
+func TestDashboard_FindWidget(t *testing.T) {
+	sut := &Dashboard{Widgets: []*DashboardWidget{{ID: 1}, {ID: 2}}}
+	assert.Equal(t, uint(2), sut.FindWidget(2).ID)
+	assert.Nil(t, sut.FindWidget(3))
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	DayOffSourceManual   = "manual"   // marked off by the user
+	DayOffSourceCalendar = "calendar" // imported from an uploaded icalendar file
+)
+
+const MaxDayOffNameLength = 255 // in characters, not bytes
+
+// DayOff is a single non-working day of a user, e.g. a public holiday or a vacation day
+type DayOff struct {
+	ID     uint   `json:"id" gorm:"primary_key"`
+	User   *User  `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID string `json:"-" gorm:"not null; index:idx_day_off_user; uniqueIndex:idx_day_off_composite"`
+	Date   string `json:"date" gorm:"not null; uniqueIndex:idx_day_off_composite; type:varchar(10)"` // in yyyy-mm-dd format
+	Name   string `json:"name" gorm:"type:varchar(255)"`
+	Source string `json:"source" gorm:"type:varchar(16); default:manual"`
+}
+
+func (d *DayOff) IsValid() bool {
+	_, err := time.Parse(time.DateOnly, d.Date)
+	return err == nil && d.UserID != "" && utf8.RuneCountInString(d.Name) <= MaxDayOffNameLength && (d.Source == DayOffSourceManual || d.Source == DayOffSourceCalendar)
+}
+
+// WorkCalendar tells apart working days from weekends, holidays and vacations for a given user.
+// A nil calendar considers every day a working day.
+type WorkCalendar struct {
+	user    *User
+	daysOff map[string]*DayOff
+}
+
+func NewWorkCalendar(user *User, daysOff []*DayOff) *WorkCalendar {
+	calendar := &WorkCalendar{user: user, daysOff: make(map[string]*DayOff, len(daysOff))}
+	for _, d := range daysOff {
+		calendar.daysOff[d.Date] = d
+	}
+	return calendar
+}
+
+// IsWorkingDay returns whether the day which t falls into (in t's time zone) is neither a non-working weekday, nor a day off
+func (c *WorkCalendar) IsWorkingDay(t time.Time) bool {
+	if c == nil {
+		return true
+	}
+	if !c.user.IsWorkingWeekday(t.Weekday()) {
+		return false
+	}
+	_, isDayOff := c.daysOff[t.Format(time.DateOnly)]
+	return !isDayOff
+}
+
+// CountHolidays returns the number of non-working days among the numDays consecutive days starting at from
+func (c *WorkCalendar) CountHolidays(from time.Time, numDays int) int {
+	if c == nil {
+		return 0
+	}
+	var holidays int
+	for i := 0; i < numDays; i++ {
+		if !c.IsWorkingDay(from.AddDate(0, 0, i)) {
+			holidays++
+		}
+	}
+	return holidays
+}
+
+// CountWorkingDays returns the number of working days among the numDays consecutive days starting at from
+func (c *WorkCalendar) CountWorkingDays(from time.Time, numDays int) int {
+	return numDays - c.CountHolidays(from, numDays)
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_WorkingWeekdays(t *testing.T) {
+	sut := &User{}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		assert.True(t, sut.IsWorkingWeekday(d))
+	}
+
+	sut.SetWorkingWeekdays([]time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday})
+	assert.Equal(t, 0b0111110, sut.WorkingWeekdays)
+	assert.False(t, sut.IsWorkingWeekday(time.Sunday))
+	assert.True(t, sut.IsWorkingWeekday(time.Monday))
+	assert.False(t, sut.IsWorkingWeekday(time.Saturday))
+
+	sut.SetWorkingWeekdays([]time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday})
+	assert.Zero(t, sut.WorkingWeekdays)
+}
+
+func TestDayOff_IsValid(t *testing.T) {
+	sut := &DayOff{UserID: "user1", Date: "2024-12-24", Name: strings.Repeat("ä", MaxDayOffNameLength), Source: DayOffSourceCalendar}
+	assert.True(t, sut.IsValid()) // 510 bytes, but 255 characters
+
+	sut.Name += "a"
+	assert.False(t, sut.IsValid())
+
+	sut.Name = "Christmas Eve"
+	sut.Date = "24.12.2024"
+	assert.False(t, sut.IsValid())
+}
+
+func TestWorkCalendar_CountHolidays(t *testing.T) {
+	user := &User{}
+	user.SetWorkingWeekdays([]time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday})
+
+	sut := NewWorkCalendar(user, []*DayOff{
+		{UserID: user.ID, Date: "2023-01-04", Source: DayOffSourceManual},
+		{UserID: user.ID, Date: "2023-01-07", Source: DayOffSourceCalendar}, // saturday anyway
+	})
+
+	from := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC) // monday
+
+	assert.True(t, sut.IsWorkingDay(from))
+	assert.False(t, sut.IsWorkingDay(from.AddDate(0, 0, 2)))
+	assert.False(t, sut.IsWorkingDay(from.AddDate(0, 0, 5)))
+	assert.Equal(t, 3, sut.CountHolidays(from, 7))
+	assert.Equal(t, 4, sut.CountWorkingDays(from, 7))
+	assert.Equal(t, 9, sut.CountWorkingDays(from, 14))
+}
+
+func TestWorkCalendar_Nil(t *testing.T) {
+	var sut *WorkCalendar
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, sut.IsWorkingDay(from))
+	assert.Zero(t, sut.CountHolidays(from, 7))
+	assert.Equal(t, 7, sut.CountWorkingDays(from, 7))
+}
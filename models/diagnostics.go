@@ -1,11 +1,40 @@
 package models
 
+import (
+	"fmt"
+
+	"github.com/muety/wakapi/utils"
+)
+
+// Diagnostics is a crash report (logs and stack trace) sent by wakatime-cli when a plugin fails
 type Diagnostics struct {
-	ID           uint   `gorm:"primary_key"`
-	Platform     string `json:"platform"`
-	Architecture string `json:"architecture"`
-	Plugin       string `json:"plugin"`
-	CliVersion   string `json:"cli_version"`
-	Logs         string `json:"logs" gorm:"type:text"`
-	StackTrace   string `json:"stacktrace" gorm:"type:text"`
+	ID           uint       `gorm:"primary_key"`
+	User         *User      `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID       *string    `json:"-" gorm:"index:idx_diagnostics_user"` // pointer because nullable, diagnostics may also be submitted anonymously
+	UserAgent    string     `json:"-" gorm:"type:varchar(255)"`
+	CreatedAt    CustomTime `json:"-" gorm:"timeScale:3; index:idx_diagnostics_created"`
+	Platform     string     `json:"platform"`
+	Architecture string     `json:"architecture"`
+	Plugin       string     `json:"plugin"`
+	CliVersion   string     `json:"cli_version"`
+	Logs         string     `json:"logs" gorm:"type:text"`
+	StackTrace   string     `json:"stacktrace" gorm:"type:text"`
+}
+
+// Device returns a human-readable description of the editor and operating system the diagnostics were sent from
+func (d *Diagnostics) Device() string {
+	if parsed, err := utils.ParseUserAgent(d.UserAgent); err == nil {
+		return fmt.Sprintf("%s on %s", parsed.Editor, parsed.OS)
+	}
+	if d.Plugin != "" {
+		return fmt.Sprintf("%s on %s", d.Plugin, d.Platform)
+	}
+	return "Unknown device"
+}
+
+func (d *Diagnostics) Owner() string {
+	if d.UserID == nil {
+		return ""
+	}
+	return *d.UserID
 }
@@ -33,7 +33,9 @@ type Duration struct {
 	NumHeartbeats   int           `json:"-" hash:"ignore"`
 	GroupHash       string        `json:"-" hash:"ignore" gorm:"type:varchar(17)"`
 	Timeout         time.Duration `json:"-" gorm:"not null; default:600000000000"` // heartbeat timeout preference, see DefaultHeartbeatsTimeout
-	excludeEntity   bool          `json:"-" hash:"ignore"`
+	AICounters      `hash:"ignore"`
+	LineCounters    `hash:"ignore"`
+	excludeEntity   bool `json:"-" hash:"ignore"`
 }
 
 func (d *Duration) TimeEnd() time.Time {
@@ -50,6 +52,8 @@ func (d *Duration) HashInclude(field string, v interface{}) (bool, error) {
 		field == "GroupHash" ||
 		field == "ID" ||
 		field == "Timeout" ||
+		field == "AICounters" ||
+		field == "LineCounters" ||
 		unicode.IsLower(rune(field[0])) {
 		return false, nil
 	}
@@ -92,6 +96,8 @@ func NewDurationFromHeartbeat(h *Heartbeat) *Duration {
 		Extension:       extension,
 		NumHeartbeats:   1,
 		Timeout:         interval,
+		AICounters:      NewAICountersFromHeartbeat(h),
+		LineCounters:    NewLineCountersFromHeartbeat(h),
 	}
 	return d
 }
@@ -0,0 +1,9 @@
+package models
+
+// EventOutboxEntry is an event published by one wakapi instance, which is picked up by all others polling the outbox table (see services/events)
+type EventOutboxEntry struct {
+	ID        uint64     `gorm:"primary_key"`
+	Instance  string     `gorm:"not null; type:varchar(64)"`
+	Payload   []byte     `gorm:"not null"`
+	CreatedAt CustomTime `gorm:"timeScale:3; index:idx_event_outbox_created"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/duke-git/lancet/v2/slice"
+)
+
+const (
+	MaxFilterPresetsPerUser = 32
+	FilterPresetQueryParam  = "preset" // e.g. "?preset=client-a"
+)
+
+var filterPresetSlugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// FilterPreset is a named combination of filters and, optionally, an interval, that a user can refer to by its slug, e.g. "?preset=client-a"
+type FilterPreset struct {
+	ID       uint   `json:"id" gorm:"primary_key"`
+	User     *User  `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID   string `json:"-" gorm:"not null; index:idx_filter_preset_user; uniqueIndex:idx_filter_preset_composite"`
+	Name     string `json:"name" gorm:"not null; type:varchar(255)"`
+	Slug     string `json:"slug" gorm:"not null; uniqueIndex:idx_filter_preset_composite; type:varchar(64)"`
+	Interval string `json:"interval" gorm:"type:varchar(32)"`  // optional, used unless a request specifies its own interval
+	Filters  string `json:"filters" gorm:"type:varchar(1024)"` // url-encoded query parameters, e.g. "project=wakapi&language=Go", see NewFiltersFromQuery
+}
+
+// NewFilterPresetSlug derives a url-friendly identifier from a preset's name, e.g. "client-a" from "Client A"
+func NewFilterPresetSlug(name string) string {
+	slug := filterPresetSlugInvalidChars.ReplaceAllString(strings.ToLower(name), "-")
+	slug = strings.Trim(slug, "-")
+	if len(slug) > 64 {
+		slug = strings.TrimRight(slug[:64], "-")
+	}
+	return slug
+}
+
+func (p *FilterPreset) IsValid() bool {
+	if p.UserID == "" || p.Name == "" || len(p.Name) > 255 || p.Slug == "" || p.Slug != NewFilterPresetSlug(p.Slug) || len(p.Filters) > 1024 {
+		return false
+	}
+	if _, err := url.ParseQuery(p.Filters); err != nil {
+		return false
+	}
+	return p.Interval == "" || slice.ContainBy(AllIntervals, func(i *IntervalKey) bool {
+		return i.HasAlias(p.Interval)
+	})
+}
+
+func (p *FilterPreset) ParsedFilters() *Filters {
+	query, _ := url.ParseQuery(p.Filters)
+	return NewFiltersFromQuery(query)
+}
+
+func (p *FilterPreset) IntervalLabel() string {
+	for _, i := range AllIntervals {
+		if i.HasAlias(p.Interval) {
+			return i.GetHumanReadable()
+		}
+	}
+	return p.Interval
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFilterPresetSlug(t *testing.T) {
+	assert.Equal(t, "client-a", NewFilterPresetSlug("Client A"))
+	assert.Equal(t, "oss-only", NewFilterPresetSlug("  OSS only!! "))
+	assert.Equal(t, "", NewFilterPresetSlug("🚀"))
+}
+
+func TestFilterPreset_IsValid(t *testing.T) {
+	assert.True(t, (&FilterPreset{UserID: "user1", Name: "Client A", Slug: "client-a", Filters: "project=wakapi"}).IsValid())
+	assert.True(t, (&FilterPreset{UserID: "user1", Name: "OSS only", Slug: "oss-only", Interval: "last_30_days", Filters: "label=oss"}).IsValid())
+	assert.False(t, (&FilterPreset{UserID: "user1", Name: "Client A", Slug: "Client A"}).IsValid())
+	assert.False(t, (&FilterPreset{UserID: "user1", Name: "Client A", Slug: "client-a", Interval: "last_3_days"}).IsValid())
+	assert.False(t, (&FilterPreset{UserID: "user1", Name: "Client A", Slug: "client-a", Filters: "project=%zz"}).IsValid())
+	assert.False(t, (&FilterPreset{Name: "Client A", Slug: "client-a"}).IsValid())
+}
+
+func TestFilterPreset_ParsedFilters(t *testing.T) {
+	sut := &FilterPreset{Filters: "project=wakapi&project=anchr&label=oss"}
+	filters := sut.ParsedFilters()
+	assert.Equal(t, OrFilter{"wakapi", "anchr"}, filters.Project)
+	assert.Equal(t, OrFilter{"oss"}, filters.Label)
+}
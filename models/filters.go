@@ -3,6 +3,8 @@ package models
 import (
 	"fmt"
 	"log/slog"
+	"net/url"
+	"strings"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/gohugoio/hashstructure"
@@ -19,12 +21,44 @@ type Filters struct {
 	Entity                   OrFilter
 	Category                 OrFilter
 	AIModel                  OrFilter
-	SelectFilteredOnly       bool // flag indicating to drop all Entity types from a summary except the single one filtered by
+	Excluded                 map[uint8]OrFilter // values per entity type to exclude, i.e. none of which must be matched
+	SelectFilteredOnly       bool               // flag indicating to drop all Entity types from a summary except the single one filtered by
 	hasResolvedProjectLabels bool
+	hasResolvedExclusions    bool
 	hasResolvedAliases       bool
 	aliasCount               map[uint8]int
 }
 
+// FilterQueryParams maps the names of query parameters, by which summaries can be filtered, to their respective entity types
+var FilterQueryParams = map[string]uint8{
+	"project":          SummaryProject,
+	"language":         SummaryLanguage,
+	"editor":           SummaryEditor,
+	"operating_system": SummaryOS,
+	"machine":          SummaryMachine,
+	"label":            SummaryLabel,
+	"branch":           SummaryBranch,
+	"entity":           SummaryEntity,
+	"category":         SummaryCategory,
+}
+
+// ColumnFilters are filters as applied to database columns, i.e. a row must match any of the included and none of the excluded values of every column
+type ColumnFilters struct {
+	Included map[string][]string
+	Excluded map[string][]string
+}
+
+func NewColumnFilters() ColumnFilters {
+	return ColumnFilters{Included: map[string][]string{}, Excluded: map[string][]string{}}
+}
+
+func (c ColumnFilters) IsEmpty() bool {
+	return len(c.Included) == 0 && len(c.Excluded) == 0
+}
+
+// FilterNegationPrefix marks a filter value as exclusion, e.g. "?project=!wakapi" to match everything except project "wakapi"
+const FilterNegationPrefix = "!"
+
 type OrFilter []string
 
 func (f OrFilter) Exists() bool {
@@ -49,19 +83,65 @@ func NewFiltersWith(entity uint8, key string) *Filters {
 	return NewFilterWithMultiple(entity, []string{key})
 }
 
+// NewFiltersWithQueryValue creates filters for a single value as given in a query, e.g. "wakapi" to include or "!wakapi" to exclude project "wakapi"
+func NewFiltersWithQueryValue(entity uint8, value string) *Filters {
+	filters := &Filters{}
+	return filters.WithQueryValue(entity, value)
+}
+
 func NewFilterWithMultiple(entity uint8, keys []string) *Filters {
 	filters := &Filters{}
 	return filters.WithMultiple(entity, keys)
 }
 
+// NewFiltersFromQuery parses filters from query parameters (see FilterQueryParams), whereas a parameter may be given multiple times to match any of its values
+func NewFiltersFromQuery(query url.Values) *Filters {
+	filters := &Filters{}
+	for param, entity := range FilterQueryParams {
+		for _, v := range query[param] {
+			if v != "" {
+				filters.WithQueryValue(entity, v)
+			}
+		}
+	}
+	return filters
+}
+
 func (f *Filters) With(entity uint8, key string) *Filters {
 	return f.WithMultiple(entity, []string{key})
 }
 
+// WithQueryValue adds a filter value as given in a query parameter, i.e. as an exclusion if prefixed with FilterNegationPrefix (e.g. "!wakapi")
+func (f *Filters) WithQueryValue(entity uint8, value string) *Filters {
+	if key, ok := strings.CutPrefix(value, FilterNegationPrefix); ok {
+		if key == "" {
+			return f
+		}
+		return f.Without(entity, key)
+	}
+	return f.With(entity, value)
+}
+
+func (f *Filters) Without(entity uint8, key string) *Filters {
+	return f.WithoutMultiple(entity, []string{key})
+}
+
+func (f *Filters) WithoutMultiple(entity uint8, keys []string) *Filters {
+	// copy on write, because shallow copies of filters (see ActivityService) would otherwise share the same map
+	excluded := make(map[uint8]OrFilter, len(f.Excluded)+1)
+	for t, e := range f.Excluded {
+		excluded[t] = e
+	}
+	excluded[entity] = append(append(OrFilter{}, excluded[entity]...), keys...)
+	f.Excluded = excluded
+	return f
+}
+
 func (f *Filters) WithSelectFilteredOnly() *Filters {
 	// use with caution: setting this usually only makes sense when interested only in the entity-specific part of a summary
 	// e.g. when only wanting to retrieve the total time coded in a certain language, while disregarding projects, etc.
-	if f.CountDistinctTypes() <= 1 {
+	// not applicable to exclusions, because these can't be applied to a pre-computed summary's items of a different type
+	if f.CountDistinctTypes() <= 1 && !f.HasExclusions() {
 		f.SelectFilteredOnly = true
 	}
 	return f
@@ -127,7 +207,16 @@ func (f *Filters) OneOrEmpty() FilterElement {
 
 func (f *Filters) IsEmpty() bool {
 	nonEmpty, _, _ := f.One()
-	return !nonEmpty
+	return !nonEmpty && !f.HasExclusions()
+}
+
+func (f *Filters) HasExclusions() bool {
+	for _, e := range f.Excluded {
+		if e.Exists() {
+			return true
+		}
+	}
+	return false
 }
 
 func (f *Filters) Count() int {
@@ -152,6 +241,15 @@ func (f *Filters) CountByType(entity uint8) int {
 	return len(*f.ResolveType(entity))
 }
 
+func (f *Filters) CountExcludedByType(entity uint8) int {
+	return len(f.ResolveExcludedType(entity))
+}
+
+// HasType returns whether the filters include or exclude any values of the given entity type
+func (f *Filters) HasType(entity uint8) bool {
+	return f.CountByType(entity) > 0 || f.CountExcludedByType(entity) > 0
+}
+
 func (f *Filters) CountAliasesByType(entity uint8) int {
 	if f.aliasCount == nil {
 		return 0
@@ -196,6 +294,34 @@ func (f *Filters) ResolveType(entityId uint8) *OrFilter {
 	}
 }
 
+func (f *Filters) ResolveExcludedType(entityId uint8) OrFilter {
+	return f.Excluded[entityId]
+}
+
+// QueryValuesByType returns the filter values of the given type as given in a query, i.e. exclusions prefixed with FilterNegationPrefix
+func (f *Filters) QueryValuesByType(entityId uint8) []string {
+	included, excluded := *f.ResolveType(entityId), f.ResolveExcludedType(entityId)
+	values := make([]string, 0, len(included)+len(excluded))
+	values = append(values, included...)
+	for _, v := range excluded {
+		values = append(values, FilterNegationPrefix+v)
+	}
+	return values
+}
+
+// Query serializes the filters to query parameters, such that they can be parsed again using NewFiltersFromQuery
+func (f *Filters) Query() url.Values {
+	query := url.Values{}
+	for param, entity := range FilterQueryParams {
+		for _, v := range f.QueryValuesByType(entity) {
+			if v != "" && v != FilterNegationPrefix {
+				query.Add(param, v)
+			}
+		}
+	}
+	return query
+}
+
 func (f *Filters) Hash() string {
 	hash, err := hashstructure.Hash(f, &hashstructure.HashOptions{Hasher: xxhash.New()})
 	if err != nil {
@@ -211,7 +337,15 @@ func (f *Filters) MatchHeartbeat(h *Heartbeat) bool {
 		(f.Editor == nil || f.Editor.MatchAny(h.Editor)) &&
 		(f.Machine == nil || f.Machine.MatchAny(h.Machine)) &&
 		(f.Category == nil || f.Category.MatchAny(h.Category)) &&
-		(f.AIModel == nil || f.AIModel.MatchAny(h.AIModel))
+		(f.AIModel == nil || f.AIModel.MatchAny(h.AIModel)) &&
+		!f.isExcluded(SummaryProject, h.Project) &&
+		!f.isExcluded(SummaryOS, h.OperatingSystem) &&
+		!f.isExcluded(SummaryLanguage, h.Language) &&
+		!f.isExcluded(SummaryEditor, h.Editor) &&
+		!f.isExcluded(SummaryMachine, h.Machine) &&
+		!f.isExcluded(SummaryBranch, h.Branch) &&
+		!f.isExcluded(SummaryCategory, h.Category) &&
+		!f.isExcluded(SummaryAiModel, h.AIModel)
 }
 
 func (f *Filters) MatchDuration(d *Duration) bool {
@@ -221,7 +355,19 @@ func (f *Filters) MatchDuration(d *Duration) bool {
 		(f.Editor == nil || f.Editor.MatchAny(d.Editor)) &&
 		(f.Machine == nil || f.Machine.MatchAny(d.Machine)) &&
 		(f.Category == nil || f.Category.MatchAny(d.Category)) &&
-		(f.AIModel == nil || f.AIModel.MatchAny(d.AIModel))
+		(f.AIModel == nil || f.AIModel.MatchAny(d.AIModel)) &&
+		!f.isExcluded(SummaryProject, d.Project) &&
+		!f.isExcluded(SummaryOS, d.OperatingSystem) &&
+		!f.isExcluded(SummaryLanguage, d.Language) &&
+		!f.isExcluded(SummaryEditor, d.Editor) &&
+		!f.isExcluded(SummaryMachine, d.Machine) &&
+		!f.isExcluded(SummaryBranch, d.Branch) &&
+		!f.isExcluded(SummaryCategory, d.Category) &&
+		!f.isExcluded(SummaryAiModel, d.AIModel)
+}
+
+func (f *Filters) isExcluded(entity uint8, search string) bool {
+	return f.ResolveExcludedType(entity).MatchAny(search)
 }
 
 // WithAliases adds OR-conditions for every alias of a Filter key as additional Filter keys
@@ -308,6 +454,22 @@ func (f *Filters) WithAliases(resolve AliasReverseResolver) *Filters {
 	}
 	// no aliases for entities / files
 
+	// excluding a key excludes all of its aliases as well
+	if f.Excluded != nil {
+		excluded := make(map[uint8]OrFilter, len(f.Excluded))
+		for t, keys := range f.Excluded {
+			updated := OrFilter(make([]string, 0, len(keys)))
+			for _, e := range keys {
+				updated = append(updated, e)
+				if t != SummaryEntity && t != SummaryLabel {
+					updated = append(updated, resolve(t, e)...)
+				}
+			}
+			excluded[t] = updated
+		}
+		f.Excluded = excluded
+	}
+
 	f.hasResolvedAliases = true
 	return f
 }
@@ -323,6 +485,18 @@ func (f *Filters) WithProjectLabels(resolve ProjectLabelReverseResolver) *Filter
 	return f
 }
 
+// WithExcludedProjectLabels adds every project of an excluded label as excluded project
+func (f *Filters) WithExcludedProjectLabels(resolve ProjectLabelReverseResolver) *Filters {
+	if !f.ResolveExcludedType(SummaryLabel).Exists() || f.hasResolvedExclusions {
+		return f
+	}
+	for _, l := range f.ResolveExcludedType(SummaryLabel) {
+		f.WithoutMultiple(SummaryProject, resolve(l))
+	}
+	f.hasResolvedExclusions = true
+	return f
+}
+
 func (f *Filters) IsProjectDetails() bool {
 	return f != nil && f.Project != nil && f.Project.Exists()
 }
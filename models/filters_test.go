@@ -1,6 +1,7 @@
 package models
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -86,6 +87,7 @@ func TestFiltersTestSuite(t *testing.T) {
 func (suite *FiltersTestSuite) TestFilters_IsEmpty() {
 	assert.False(suite.T(), NewFiltersWith(SummaryProject, "wakapi").IsEmpty())
 	assert.True(suite.T(), (&Filters{}).IsEmpty())
+	assert.False(suite.T(), (&Filters{}).Without(SummaryProject, "wakapi").IsEmpty())
 }
 
 func (suite *FiltersTestSuite) TestFilters_Match() {
@@ -115,6 +117,33 @@ func (suite *FiltersTestSuite) TestFilters_Match() {
 	assert.False(suite.T(), sut5.MatchHeartbeat(heartbeats[1]))
 }
 
+func (suite *FiltersTestSuite) TestFilters_MatchExcluded() {
+	heartbeats := []*Heartbeat{
+		{Project: "wakapi", Language: "Go", Machine: "ci"},
+		{Project: "anchr", Language: "Markdown", Machine: "laptop"},
+		{Project: "", Language: "JSON", Machine: "laptop"},
+	}
+
+	sut1 := NewFiltersWithQueryValue(SummaryProject, "!wakapi")
+	assert.False(suite.T(), sut1.MatchHeartbeat(heartbeats[0]))
+	assert.True(suite.T(), sut1.MatchHeartbeat(heartbeats[1]))
+	assert.True(suite.T(), sut1.MatchHeartbeat(heartbeats[2]))
+
+	sut2 := (&Filters{}).WithoutMultiple(SummaryLanguage, []string{"Markdown", "JSON"})
+	assert.True(suite.T(), sut2.MatchHeartbeat(heartbeats[0]))
+	assert.False(suite.T(), sut2.MatchHeartbeat(heartbeats[1]))
+	assert.False(suite.T(), sut2.MatchHeartbeat(heartbeats[2]))
+
+	sut3 := NewFiltersWith(SummaryMachine, "laptop").Without(SummaryProject, "-")
+	assert.False(suite.T(), sut3.MatchHeartbeat(heartbeats[0]))
+	assert.True(suite.T(), sut3.MatchHeartbeat(heartbeats[1]))
+	assert.False(suite.T(), sut3.MatchHeartbeat(heartbeats[2]))
+
+	sut4 := NewFiltersWithQueryValue(SummaryMachine, "!ci")
+	assert.False(suite.T(), sut4.MatchDuration(&Duration{Project: "wakapi", Machine: "ci"}))
+	assert.True(suite.T(), sut4.MatchDuration(&Duration{Project: "wakapi", Machine: "laptop"}))
+}
+
 func (suite *FiltersTestSuite) TestFilters_One() {
 	sut1 := NewFiltersWith(SummaryLanguage, "Java")
 	ok1, type1, filters1 := sut1.One()
@@ -135,6 +164,26 @@ func (suite *FiltersTestSuite) TestFilters_One() {
 	assert.Equal(suite.T(), "claude-3-5-sonnet", filters3[0])
 }
 
+func (suite *FiltersTestSuite) TestFilters_Query() {
+	sut1 := NewFilterWithMultiple(SummaryProject, []string{"wakapi", "anchr"}).With(SummaryOS, "Linux")
+	assert.Equal(suite.T(), "operating_system=Linux&project=wakapi&project=anchr", sut1.Query().Encode())
+
+	sut2 := NewFiltersFromQuery(sut1.Query())
+	assert.Equal(suite.T(), sut1.Project, sut2.Project)
+	assert.Equal(suite.T(), sut1.OS, sut2.OS)
+	assert.Empty(suite.T(), sut2.Language)
+
+	sut3 := NewFiltersFromQuery(url.Values{"entity": []string{"main.go"}, "foo": []string{"bar"}, "language": []string{""}})
+	assert.Equal(suite.T(), OrFilter{"main.go"}, sut3.Entity)
+	assert.Equal(suite.T(), 1, sut3.Count())
+
+	sut4 := NewFiltersFromQuery(url.Values{"project": []string{"wakapi", "!wakapi-docs"}, "language": []string{"!"}})
+	assert.Equal(suite.T(), OrFilter{"wakapi"}, sut4.Project)
+	assert.Equal(suite.T(), OrFilter{"wakapi-docs"}, sut4.ResolveExcludedType(SummaryProject))
+	assert.False(suite.T(), sut4.HasType(SummaryLanguage))
+	assert.Equal(suite.T(), "project=wakapi&project=%21wakapi-docs", sut4.Query().Encode())
+}
+
 func (suite *FiltersTestSuite) TestFilters_WithAliases() {
 	sut1 := NewFiltersWith(SummaryProject, "wakapi")
 	sut1 = sut1.WithAliases(suite.GetAliasReverseResolver([]int{0, 1, 2}))
@@ -167,6 +216,12 @@ func (suite *FiltersTestSuite) TestFilters_WithAliases() {
 	assert.Contains(suite.T(), sut4.AIModel, "Claude 3.5 Sonnet")
 	assert.Contains(suite.T(), sut4.AIModel, "claude-3-5-sonnet")
 	assert.Equal(suite.T(), 1, sut4.CountAliasesByType(SummaryAiModel))
+
+	sut5 := (&Filters{}).Without(SummaryProject, "wakapi")
+	sut5 = sut5.WithAliases(suite.GetAliasReverseResolver([]int{0, 1, 2}))
+	assert.Len(suite.T(), sut5.Project, 0)
+	assert.ElementsMatch(suite.T(), OrFilter{"wakapi", "wakapi-mobile", "wakapi-desktop"}, sut5.ResolveExcludedType(SummaryProject))
+	assert.Equal(suite.T(), 0, sut5.CountAliasesByType(SummaryProject))
 }
 
 func (suite *FiltersTestSuite) TestFilters_WithProjectLabels() {
@@ -185,3 +240,11 @@ func (suite *FiltersTestSuite) TestFilters_WithProjectLabels() {
 	assert.Contains(suite.T(), sut2.Project, "anchr")
 	assert.Contains(suite.T(), sut2.Label, "oss")
 }
+
+func (suite *FiltersTestSuite) TestFilters_WithExcludedProjectLabels() {
+	sut1 := NewFiltersWith(SummaryProject, "wakapi").Without(SummaryLabel, "work")
+	sut1 = sut1.WithExcludedProjectLabels(suite.GetProjectLabelReverseResolver([]int{0, 1, 2}))
+	assert.Equal(suite.T(), OrFilter{"wakapi"}, sut1.Project)
+	assert.Equal(suite.T(), OrFilter{"business-application"}, sut1.ResolveExcludedType(SummaryProject))
+	assert.Equal(suite.T(), OrFilter{"work"}, sut1.ResolveExcludedType(SummaryLabel))
+}
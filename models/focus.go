@@ -0,0 +1,205 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/duke-git/lancet/v2/mathutil"
+	"github.com/duke-git/lancet/v2/slice"
+)
+
+const (
+	DefaultFocusSessionLength = 25 * time.Minute
+	MinFocusSessionLength     = 5 * time.Minute
+	MaxFocusSessionLength     = 4 * time.Hour
+	// upper bound for the range covered by focus stats, as they're computed from raw durations
+	MaxFocusRangeDays = 92
+)
+
+// penalty on a day's focus score per context switch per hour of coding
+const focusScoreSwitchPenalty = 5
+
+type FocusParams struct {
+	From    time.Time
+	To      time.Time
+	Filters *Filters
+}
+
+func (p *FocusParams) Hash() string {
+	filtersHash := "-"
+	if p.Filters != nil {
+		filtersHash = p.Filters.Hash()
+	}
+	return fmt.Sprintf("%d_%d_%s", p.From.Unix(), p.To.Unix(), filtersHash)
+}
+
+// FocusStats describes how continuously a user worked within a time range, based on their durations
+type FocusStats struct {
+	From             time.Time   `json:"from"`
+	To               time.Time   `json:"to"`
+	MinSessionLength float64     `json:"min_session_length"` // in seconds, minimum length of an uninterrupted block to count as focus session
+	Total            *FocusDay   `json:"total"`              // aggregated over the entire range, date is empty
+	Days             []*FocusDay `json:"days"`
+}
+
+type FocusDay struct {
+	Date            string          `json:"date,omitempty"`
+	Total           float64         `json:"total"`            // in seconds, total coding time
+	FocusTime       float64         `json:"focus_time"`       // in seconds, coding time within focus sessions
+	Score           int             `json:"score"`            // 0 - 100, share of focus time, reduced by context switches
+	LongestSession  float64         `json:"longest_session"`  // in seconds, longest uninterrupted block, regardless of whether it counts as focus session
+	NumSessions     int             `json:"num_sessions"`     // number of focus sessions
+	NumBlocks       int             `json:"num_blocks"`       // number of uninterrupted blocks, including short ones
+	ContextSwitches int             `json:"context_switches"` // number of changes between projects or branches
+	Fragmentation   float64         `json:"fragmentation"`    // uninterrupted blocks per hour of coding, higher means more interruptions
+	Sessions        []*FocusSession `json:"sessions,omitempty"`
+}
+
+type FocusSession struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Duration        float64   `json:"duration"` // in seconds
+	Projects        []string  `json:"projects"`
+	ContextSwitches int       `json:"context_switches"`
+}
+
+type focusBlock struct {
+	start, end      time.Time
+	projects        []string
+	contextSwitches int
+}
+
+// NewFocusDay groups a day's durations into uninterrupted blocks, i.e. such without breaks longer than breakTolerance, and considers all blocks of at least minSessionLength focus sessions
+func NewFocusDay(date string, durations Durations, minSessionLength, breakTolerance time.Duration) *FocusDay {
+	sorted := make(Durations, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Time.T().Before(sorted[j].Time.T())
+	})
+
+	day := &FocusDay{Date: date, Sessions: []*FocusSession{}}
+
+	var blocks []*focusBlock
+	var prev *Duration
+	for _, d := range sorted {
+		if d.Duration <= 0 {
+			continue
+		}
+
+		var current *focusBlock
+		if len(blocks) > 0 {
+			current = blocks[len(blocks)-1]
+		}
+
+		isNewBlock := current == nil || d.Time.T().Sub(current.end) > breakTolerance
+		if isNewBlock {
+			current = &focusBlock{start: d.Time.T(), end: d.TimeEnd()}
+			blocks = append(blocks, current)
+		} else if d.TimeEnd().After(current.end) {
+			current.end = d.TimeEnd()
+		}
+
+		if prev != nil && isContextSwitch(prev, d) {
+			day.ContextSwitches++
+			if !isNewBlock {
+				current.contextSwitches++
+			}
+		}
+		if !slice.Contain(current.projects, d.Project) {
+			current.projects = append(current.projects, d.Project)
+		}
+		prev = d
+	}
+
+	var total, focusTime, longest time.Duration
+	for _, b := range blocks {
+		length := b.end.Sub(b.start)
+		total += length
+		if length > longest {
+			longest = length
+		}
+		if length >= minSessionLength {
+			focusTime += length
+			day.Sessions = append(day.Sessions, &FocusSession{
+				Start:           b.start,
+				End:             b.end,
+				Duration:        length.Seconds(),
+				Projects:        b.projects,
+				ContextSwitches: b.contextSwitches,
+			})
+		}
+	}
+
+	day.Total = total.Seconds()
+	day.FocusTime = focusTime.Seconds()
+	day.LongestSession = longest.Seconds()
+	day.NumSessions = len(day.Sessions)
+	day.NumBlocks = len(blocks)
+	day.computeScores()
+	return day
+}
+
+// NewFocusTotal aggregates multiple days, whereas the score is averaged over days with activity
+func NewFocusTotal(days []*FocusDay) *FocusDay {
+	total := &FocusDay{}
+	var scores, activeDays int
+	for _, day := range days {
+		total.Total += day.Total
+		total.FocusTime += day.FocusTime
+		total.LongestSession = math.Max(total.LongestSession, day.LongestSession)
+		total.NumSessions += day.NumSessions
+		total.NumBlocks += day.NumBlocks
+		total.ContextSwitches += day.ContextSwitches
+		if day.Total > 0 {
+			scores += day.Score
+			activeDays++
+		}
+	}
+	if total.Total > 0 {
+		total.Fragmentation = mathutil.RoundToFloat(float64(total.NumBlocks)/(total.Total/3600), 2)
+	}
+	if activeDays > 0 {
+		total.Score = int(math.Round(float64(scores) / float64(activeDays)))
+	}
+	return total
+}
+
+// computeScores derives the focus score as the share of focus time in percent, minus a penalty for each context switch per hour of coding
+func (d *FocusDay) computeScores() {
+	if d.Total <= 0 {
+		return
+	}
+	hours := d.Total / 3600
+	d.Fragmentation = mathutil.RoundToFloat(float64(d.NumBlocks)/hours, 2)
+	score := d.FocusTime/d.Total*100 - focusScoreSwitchPenalty*float64(d.ContextSwitches)/hours
+	d.Score = int(math.Round(math.Max(0, math.Min(100, score))))
+}
+
+func (d *FocusDay) TotalDuration() time.Duration {
+	return time.Duration(d.Total * float64(time.Second))
+}
+
+func (d *FocusDay) FocusDuration() time.Duration {
+	return time.Duration(d.FocusTime * float64(time.Second))
+}
+
+func (d *FocusDay) LongestSessionDuration() time.Duration {
+	return time.Duration(d.LongestSession * float64(time.Second))
+}
+
+// FocusShare returns the share of coding time spent in focus sessions in percent
+func (d *FocusDay) FocusShare() float64 {
+	if d.Total <= 0 {
+		return 0
+	}
+	return d.FocusTime / d.Total * 100
+}
+
+func isContextSwitch(d1, d2 *Duration) bool {
+	if d1.Project != d2.Project {
+		return true
+	}
+	return d1.Branch != "" && d2.Branch != "" && d1.Branch != d2.Branch
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFocusDay(t *testing.T) {
+	t0 := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+
+	durations := Durations{
+		// first block: 9:00 - 10:00, one switch between branches, one between projects
+		{Time: CustomTime(t0), Duration: 20 * time.Minute, Project: "wakapi", Branch: "master"},
+		{Time: CustomTime(t0.Add(20 * time.Minute)), Duration: 20 * time.Minute, Project: "wakapi", Branch: "feature"},
+		{Time: CustomTime(t0.Add(42 * time.Minute)), Duration: 18 * time.Minute, Project: "anchr"},
+		// second block: 11:00 - 11:10, after a break of one hour
+		{Time: CustomTime(t0.Add(2 * time.Hour)), Duration: 10 * time.Minute, Project: "anchr"},
+		// third block: 13:00 - 13:30, switch back to previous project
+		{Time: CustomTime(t0.Add(4 * time.Hour)), Duration: 30 * time.Minute, Project: "wakapi", Branch: "master"},
+	}
+
+	sut := NewFocusDay("2024-01-08", durations, 25*time.Minute, 5*time.Minute)
+
+	assert.Equal(t, "2024-01-08", sut.Date)
+	assert.Equal(t, (100 * time.Minute).Seconds(), sut.Total)
+	assert.Equal(t, (90 * time.Minute).Seconds(), sut.FocusTime)
+	assert.Equal(t, time.Hour.Seconds(), sut.LongestSession)
+	assert.Equal(t, 3, sut.NumBlocks)
+	assert.Equal(t, 2, sut.NumSessions)
+	assert.Equal(t, 3, sut.ContextSwitches)
+	assert.Equal(t, 1.8, sut.Fragmentation)
+	assert.Equal(t, 81, sut.Score) // 90 % focus time, minus 5 * 1.8 switches per hour
+
+	assert.Len(t, sut.Sessions, 2)
+	assert.Equal(t, t0, sut.Sessions[0].Start)
+	assert.Equal(t, t0.Add(time.Hour), sut.Sessions[0].End)
+	assert.Equal(t, []string{"wakapi", "anchr"}, sut.Sessions[0].Projects)
+	assert.Equal(t, 2, sut.Sessions[0].ContextSwitches)
+	assert.Equal(t, 0, sut.Sessions[1].ContextSwitches)
+}
+
+func TestNewFocusDay_Empty(t *testing.T) {
+	sut := NewFocusDay("2024-01-08", Durations{}, 25*time.Minute, 5*time.Minute)
+
+	assert.Zero(t, sut.Total)
+	assert.Zero(t, sut.Score)
+	assert.Zero(t, sut.Fragmentation)
+	assert.Empty(t, sut.Sessions)
+}
+
+func TestNewFocusTotal(t *testing.T) {
+	days := []*FocusDay{
+		{Total: 3600, FocusTime: 3600, Score: 100, LongestSession: 3600, NumSessions: 1, NumBlocks: 1},
+		{Total: 0},
+		{Total: 7200, FocusTime: 1800, Score: 20, LongestSession: 1800, NumSessions: 1, NumBlocks: 5, ContextSwitches: 4},
+	}
+
+	sut := NewFocusTotal(days)
+
+	assert.Equal(t, 10800.0, sut.Total)
+	assert.Equal(t, 5400.0, sut.FocusTime)
+	assert.Equal(t, 60, sut.Score)
+	assert.Equal(t, 3600.0, sut.LongestSession)
+	assert.Equal(t, 2, sut.NumSessions)
+	assert.Equal(t, 4, sut.ContextSwitches)
+	assert.Equal(t, 2.0, sut.Fragmentation)
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"io"
+	"time"
+)
+
+// HeartbeatArchive is a compressed chunk of a user's raw heartbeats within a certain period (usually one month), which were moved out of the heartbeats table to keep it small.
+// Summaries and durations for archived periods remain in place, so raw heartbeats only need to be read from archives for the rare cases in which durations have to be computed live (e.g. custom timeouts).
+type HeartbeatArchive struct {
+	ID            uint       `gorm:"primary_key"`
+	User          *User      `gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID        string     `gorm:"not null; index:idx_heartbeat_archive_user_time"`
+	FromTime      CustomTime `gorm:"not null; timeScale:3; index:idx_heartbeat_archive_user_time"` // start of the archived period (inclusive)
+	ToTime        CustomTime `gorm:"not null; timeScale:3"`                                        // end of the archived period (exclusive)
+	FirstTime     CustomTime `gorm:"not null; timeScale:3"`                                        // time of the first heartbeat
+	LastTime      CustomTime `gorm:"not null; timeScale:3"`                                        // time of the last heartbeat
+	NumHeartbeats int
+	Data          []byte     // gzip-compressed stream of gob-encoded heartbeats, ordered by time
+	CreatedAt     CustomTime `gorm:"timeScale:3"`
+}
+
+// NewHeartbeatArchive compresses the given heartbeats, which must be ordered by time, into a new archive for the given period
+func NewHeartbeatArchive(userId string, from, to time.Time, heartbeats []*Heartbeat) (*HeartbeatArchive, error) {
+	if len(heartbeats) == 0 {
+		return nil, errors.New("no heartbeats to archive")
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := gob.NewEncoder(gz)
+
+	for _, h := range heartbeats {
+		hb := *h
+		hb.User = nil
+		if err := enc.Encode(&hb); err != nil {
+			return nil, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return &HeartbeatArchive{
+		UserID:        userId,
+		FromTime:      CustomTime(from),
+		ToTime:        CustomTime(to),
+		FirstTime:     heartbeats[0].Time,
+		LastTime:      heartbeats[len(heartbeats)-1].Time,
+		NumHeartbeats: len(heartbeats),
+		Data:          buf.Bytes(),
+		CreatedAt:     CustomTime(time.Now()),
+	}, nil
+}
+
+// Heartbeats decompresses all heartbeats contained in the archive
+func (a *HeartbeatArchive) Heartbeats() ([]*Heartbeat, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(a.Data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	dec := gob.NewDecoder(gz)
+	heartbeats := make([]*Heartbeat, 0, a.NumHeartbeats)
+
+	for {
+		var h Heartbeat
+		if err := dec.Decode(&h); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		heartbeats = append(heartbeats, &h)
+	}
+
+	return heartbeats, nil
+}
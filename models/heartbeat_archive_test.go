@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeatArchive_Heartbeats(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	heartbeats := []*Heartbeat{
+		{ID: 1, UserID: "testuser", User: &User{ID: "testuser"}, Entity: "/home/user/dev/main.go", Language: "Go", Project: "wakapi", Time: CustomTime(from.Add(1 * time.Hour)), IsWrite: true},
+		{ID: 2, UserID: "testuser", Entity: "/home/user/dev/README.md", Language: "Markdown", Project: "wakapi", Branch: "master", Time: CustomTime(from.Add(2 * time.Hour))},
+	}
+
+	sut, err := NewHeartbeatArchive("testuser", from, to, heartbeats)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, sut.NumHeartbeats)
+	assert.Equal(t, heartbeats[0].Time, sut.FirstTime)
+	assert.Equal(t, heartbeats[1].Time, sut.LastTime)
+	assert.NotNil(t, heartbeats[0].User) // original heartbeats must not be modified
+
+	result, err := sut.Heartbeats()
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+	assert.Nil(t, result[0].User)
+	assert.Equal(t, "/home/user/dev/main.go", result[0].Entity)
+	assert.True(t, result[0].IsWrite)
+	assert.Equal(t, "master", result[1].Branch)
+	assert.True(t, heartbeats[1].Time.T().Equal(result[1].Time.T()))
+}
+
+func TestHeartbeatArchive_Empty(t *testing.T) {
+	_, err := NewHeartbeatArchive("testuser", time.Now(), time.Now(), []*Heartbeat{})
+	assert.NotNil(t, err)
+}
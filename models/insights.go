@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	InsightWeekdays         = "weekdays"      // total and average coding time per day of the week
+	InsightDays             = "days"          // total coding time per day
+	InsightBestDay          = "best_day"      // day with the most coding time
+	InsightDailyAverage     = "daily_average" // average daily coding time, compared to the previous period of same length
+	InsightHours            = "hours"         // productivity by hour of day
+	InsightProjects         = "projects"      // coding time per project over time
+	InsightLanguages        = "languages"     // coding time per language over time
+	InsightEditors          = "editors"
+	InsightOperatingSystems = "operating_systems"
+	InsightMachines         = "machines"
+	InsightLabels           = "labels"
+	InsightCategories       = "categories"
+)
+
+// InsightEntityTypes maps insight types showing an entity's coding time over time to their summary type
+var InsightEntityTypes = map[string]uint8{
+	InsightProjects:         SummaryProject,
+	InsightLanguages:        SummaryLanguage,
+	InsightEditors:          SummaryEditor,
+	InsightOperatingSystems: SummaryOS,
+	InsightMachines:         SummaryMachine,
+	InsightLabels:           SummaryLabel,
+	InsightCategories:       SummaryCategory,
+}
+
+func InsightTypes() []string {
+	return []string{InsightWeekdays, InsightDays, InsightBestDay, InsightDailyAverage, InsightHours, InsightProjects, InsightLanguages, InsightEditors, InsightOperatingSystems, InsightMachines, InsightLabels, InsightCategories}
+}
+
+type InsightsParams struct {
+	Type string
+	From time.Time
+	To   time.Time
+}
+
+func (p *InsightsParams) IsEntityType() bool {
+	_, ok := InsightEntityTypes[p.Type]
+	return ok
+}
+
+func (p *InsightsParams) Hash() string {
+	// day granularity is sufficient, as insights are cached for an hour anyway
+	return fmt.Sprintf("%s_%s_%s", p.Type, p.From.Format(time.DateOnly), p.To.Format(time.DateOnly))
+}
+
+type Insights struct {
+	Type         string                `json:"type"`
+	From         time.Time             `json:"from"`
+	To           time.Time             `json:"to"`
+	Days         []*InsightsDay        `json:"days,omitempty"`
+	Weekdays     []*InsightsWeekday    `json:"weekdays,omitempty"`
+	BestDay      *InsightsDay          `json:"best_day,omitempty"`
+	DailyAverage *InsightsDailyAverage `json:"daily_average,omitempty"`
+	Hours        []*InsightsHour       `json:"hours,omitempty"`
+	Entities     []*InsightsEntity     `json:"entities,omitempty"`
+}
+
+type InsightsDay struct {
+	Date  string  `json:"date"`
+	Total float64 `json:"total"` // in seconds
+}
+
+type InsightsWeekday struct {
+	Weekday time.Weekday `json:"weekday"` // 0 is sunday
+	Total   float64      `json:"total"`   // in seconds
+	Average float64      `json:"average"` // in seconds, per occurrence of the weekday within the range
+	NumDays int          `json:"num_days"`
+}
+
+type InsightsDailyAverage struct {
+	Average           float64 `json:"average"`             // in seconds, per working day, including working days without any activity
+	AverageActiveDays float64 `json:"average_active_days"` // in seconds, only considering days with activity
+	NumDays           int     `json:"num_days"`
+	NumHolidays       int     `json:"num_holidays"` // non-working days according to the user's work calendar
+	NumActiveDays     int     `json:"num_active_days"`
+	PreviousAverage   float64 `json:"previous_average"` // in seconds, per working day, for the period of same length right before
+	ChangePercent     float64 `json:"change_percent"`   // relative change compared to the previous period, 0 if there was no previous activity
+}
+
+type InsightsHour struct {
+	Hour    int     `json:"hour"`
+	Total   float64 `json:"total"`   // in seconds
+	Average float64 `json:"average"` // in seconds, per day within the range
+}
+
+type InsightsEntity struct {
+	Key   string    `json:"key"`
+	Total float64   `json:"total"` // in seconds
+	Days  []float64 `json:"days"`  // in seconds, aligned with Insights.Days
+}
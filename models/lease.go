@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Lease is a time-limited, exclusive claim on a named resource (e.g. the scheduler leadership or a user's summary aggregation).
+// It is used to coordinate multiple wakapi instances sharing the same database. Leases are either released explicitly by their owner or simply expire,
+// so that another instance can take over if the owning one dies.
+type Lease struct {
+	Name      string     `gorm:"primary_key; type:varchar(255)"`
+	Owner     string     `gorm:"not null; type:varchar(64)"`
+	ExpiresAt CustomTime `gorm:"timeScale:3; index:idx_lease_expires"`
+}
+
+func (l *Lease) IsExpired() bool {
+	return time.Now().After(l.ExpiresAt.T())
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/duke-git/lancet/v2/mathutil"
+)
+
+// LineCounters holds the number of lines added and deleted as reported by heartbeats, which are summed up when aggregating heartbeats into durations and durations into summary items.
+// Like branch time, per-branch line counters are not persisted, but only available for live summaries filtered by a single project.
+type LineCounters struct {
+	LineAdditions int `json:"line_additions,omitempty" gorm:"default:0"`
+	LineDeletions int `json:"line_deletions,omitempty" gorm:"default:0"`
+}
+
+func NewLineCountersFromHeartbeat(h *Heartbeat) LineCounters {
+	return LineCounters{
+		LineAdditions: h.LineAdditions,
+		LineDeletions: h.LineDeletions,
+	}
+}
+
+func (c *LineCounters) Add(other LineCounters) {
+	c.LineAdditions += other.LineAdditions
+	c.LineDeletions += other.LineDeletions
+}
+
+// LineChanges returns the total number of changed lines, i.e. additions plus deletions
+func (c LineCounters) LineChanges() int {
+	return c.LineAdditions + c.LineDeletions
+}
+
+// NetLines returns the net churn, i.e. additions minus deletions, which is negative if more code was removed than added
+func (c LineCounters) NetLines() int {
+	return c.LineAdditions - c.LineDeletions
+}
+
+func (c LineCounters) HasLineChanges() bool {
+	return c.LineChanges() > 0
+}
+
+// LineChangesPerHour relates the number of changed lines to the given coding time
+func (c LineCounters) LineChangesPerHour(total time.Duration) float64 {
+	if total < time.Minute {
+		return 0
+	}
+	return mathutil.RoundToFloat(float64(c.LineChanges())/total.Hours(), 1)
+}
+
+// MarshalJSON adds the item's net churn next to its line additions and deletions, if there were any line changes at all
+func (s *SummaryItem) MarshalJSON() ([]byte, error) {
+	type alias SummaryItem
+	if !s.HasLineChanges() {
+		return json.Marshal((*alias)(s))
+	}
+	return json.Marshal(&struct {
+		*alias
+		NetLines int `json:"net_lines"`
+	}{alias: (*alias)(s), NetLines: s.NetLines()})
+}
+
+// TotalLinesBy sums up line counters across all items of the given summary type
+func (s *Summary) TotalLinesBy(entityType uint8) LineCounters {
+	var counters LineCounters
+	for _, item := range *s.GetByType(entityType) {
+		counters.Add(item.LineCounters)
+	}
+	return counters
+}
+
+// TotalLines sums up line counters of the summary across the same summary type as TotalTime
+func (s *Summary) TotalLines() LineCounters {
+	t, err := s.findFirstPresentType()
+	if err != nil {
+		return LineCounters{}
+	}
+	return s.TotalLinesBy(t)
+}
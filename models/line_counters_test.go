@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary_TotalLines(t *testing.T) {
+	sut := &Summary{
+		Projects: []*SummaryItem{
+			{Type: SummaryProject, Key: "wakapi", Total: 60, LineCounters: LineCounters{LineAdditions: 30, LineDeletions: 10}},
+			{Type: SummaryProject, Key: "anchr", Total: 120, LineCounters: LineCounters{LineAdditions: 5, LineDeletions: 25}},
+		},
+		Languages: []*SummaryItem{
+			{Type: SummaryLanguage, Key: "Go", Total: 180, LineCounters: LineCounters{LineAdditions: 35, LineDeletions: 35}},
+		},
+	}
+
+	lines := sut.TotalLines()
+	assert.Equal(t, 35, lines.LineAdditions)
+	assert.Equal(t, 35, lines.LineDeletions)
+	assert.Equal(t, 70, lines.LineChanges())
+	assert.Zero(t, lines.NetLines())
+	assert.Equal(t, -20, sut.Projects[1].NetLines())
+	assert.Equal(t, LineCounters{}, sut.TotalLinesBy(SummaryEditor))
+	assert.Equal(t, LineCounters{}, (&Summary{}).TotalLines())
+}
+
+func TestLineCounters_LineChangesPerHour(t *testing.T) {
+	sut := LineCounters{LineAdditions: 90, LineDeletions: 30}
+	assert.Equal(t, 80.0, sut.LineChangesPerHour(90*time.Minute))
+	assert.Zero(t, sut.LineChangesPerHour(30*time.Second))
+}
+
+func TestSummaryItem_MarshalJSON(t *testing.T) {
+	var result map[string]interface{}
+
+	data, _ := json.Marshal(&SummaryItem{Key: "wakapi", Total: 60, LineCounters: LineCounters{LineAdditions: 5, LineDeletions: 8}})
+	assert.Nil(t, json.Unmarshal(data, &result))
+	assert.Equal(t, "wakapi", result["key"])
+	assert.Equal(t, 5.0, result["line_additions"])
+	assert.Equal(t, 8.0, result["line_deletions"])
+	assert.Equal(t, -3.0, result["net_lines"])
+
+	result = nil
+	data, _ = json.Marshal(&SummaryItem{Key: "wakapi", Total: 60})
+	assert.Nil(t, json.Unmarshal(data, &result))
+	assert.Equal(t, "wakapi", result["key"])
+	assert.NotContains(t, result, "line_additions")
+	assert.NotContains(t, result, "net_lines")
+}
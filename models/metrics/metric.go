@@ -5,9 +5,8 @@ import (
 	"strings"
 )
 
-// Hand-crafted Prometheus metrics
-// Since we're only using very simple counters in this application,
-// we don't actually need the official client SDK as a dependency
+// Hand-crafted Prometheus metrics for user-facing statistics (coding time, heartbeats, ...)
+// Instrumentation of the application itself uses the official client SDK instead, see lib/metrics
 
 type Metrics []Metric
 
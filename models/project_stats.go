@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type ProjectStats struct {
 	UserId      string
 	Project     string
@@ -8,3 +10,9 @@ type ProjectStats struct {
 	First       CustomTime
 	Last        CustomTime
 }
+
+// ProjectContributor is a user who coded on a project of a given name, according to their persisted summaries
+type ProjectContributor struct {
+	UserId string
+	Total  time.Duration
+}
@@ -0,0 +1,31 @@
+package models
+
+const (
+	ReadmeCardLayoutDefault = "default"
+	ReadmeCardLayoutCompact = "compact"
+	ReadmeCardLayoutDonut   = "donut"
+
+	ReadmeCardThemeLight = "light"
+	ReadmeCardThemeDark  = "dark"
+
+	ReadmeCardDefaultLimit = 5
+	ReadmeCardMaxLimit     = 10
+)
+
+// ReadmeCard describes a natively rendered stats card (similar to github-readme-stats' "top languages" card), which can be embedded in README files
+type ReadmeCard struct {
+	Entity   uint8 // one of SummaryLanguage, SummaryProject, SummaryEditor
+	Interval *KeyedInterval
+	Layout   string
+	Theme    string
+	Limit    int
+	Title    string
+}
+
+func IsValidReadmeCardLayout(layout string) bool {
+	return layout == ReadmeCardLayoutDefault || layout == ReadmeCardLayoutCompact || layout == ReadmeCardLayoutDonut
+}
+
+func IsValidReadmeCardTheme(theme string) bool {
+	return theme == ReadmeCardThemeLight || theme == ReadmeCardThemeDark
+}
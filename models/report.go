@@ -8,4 +8,8 @@ type Report struct {
 	User           *User
 	Summary        *Summary
 	DailySummaries []*Summary
+	NumDays        int
+	NumWorkingDays int           // excluding weekends, holidays and vacations according to the user's work calendar
+	DailyAverage   time.Duration // per working day
+	Preset         *FilterPreset // filter preset the report is restricted to, if any
 }
@@ -0,0 +1,65 @@
+package models
+
+type SecurityEventType string
+
+const (
+	SecurityEventLoginNewIp     SecurityEventType = "login_new_ip"
+	SecurityEventPasswordChange SecurityEventType = "password_change"
+	SecurityEventPasswordReset  SecurityEventType = "password_reset"
+	SecurityEventApiKeyCreate   SecurityEventType = "api_key_create"
+	SecurityEventApiKeyDelete   SecurityEventType = "api_key_delete"
+	SecurityEventApiKeyReset    SecurityEventType = "api_key_reset"
+	SecurityEventWebAuthnAdd    SecurityEventType = "webauthn_add"
+	SecurityEventWebAuthnDelete SecurityEventType = "webauthn_delete"
+	SecurityEventSessionsRevoke SecurityEventType = "sessions_revoke"
+)
+
+// SecurityEvent is an entry in a user's account security log
+type SecurityEvent struct {
+	ID        uint              `gorm:"primary_key"`
+	User      *User             `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID    string            `json:"-" gorm:"not null; index:idx_security_event_user"`
+	Type      SecurityEventType `gorm:"type:varchar(32); index:idx_security_event_user_type"`
+	CreatedAt CustomTime        `gorm:"timeScale:3"` // filled by gorm
+	IP        string            `gorm:"type:varchar(64)"`
+	UserAgent string            `gorm:"type:varchar(255)"`
+	Details   string            `gorm:"type:varchar(255)"`
+}
+
+// Notify determines whether the user should be notified about this event by mail
+func (e *SecurityEvent) Notify() bool {
+	switch e.Type {
+	case SecurityEventLoginNewIp, SecurityEventPasswordChange, SecurityEventPasswordReset, SecurityEventApiKeyCreate, SecurityEventWebAuthnAdd:
+		return true
+	}
+	return false
+}
+
+// Description returns a human-readable summary of what happened
+func (e *SecurityEvent) Description() string {
+	switch e.Type {
+	case SecurityEventLoginNewIp:
+		return "New sign-in from a previously unknown IP address"
+	case SecurityEventPasswordChange:
+		return "Password changed"
+	case SecurityEventPasswordReset:
+		return "Password reset"
+	case SecurityEventApiKeyCreate:
+		return "API key created"
+	case SecurityEventApiKeyDelete:
+		return "API key deleted"
+	case SecurityEventApiKeyReset:
+		return "Primary API key reset"
+	case SecurityEventWebAuthnAdd:
+		return "Passkey added"
+	case SecurityEventWebAuthnDelete:
+		return "Passkey deleted"
+	case SecurityEventSessionsRevoke:
+		return "Logged out on all devices"
+	}
+	return string(e.Type)
+}
+
+func (e *SecurityEvent) Device() string {
+	return (&Session{UserAgent: e.UserAgent}).Device()
+}
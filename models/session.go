@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mileusna/useragent"
+)
+
+// Session is the server-side counterpart of a (stateless) auth cookie. Every cookie references a session by its id,
+// so sessions can be listed and revoked independently of the cookie's own expiry.
+type Session struct {
+	ID         string     `gorm:"primary_key"`
+	User       *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID     string     `json:"-" gorm:"not null; index:idx_session_user"`
+	CreatedAt  CustomTime `gorm:"timeScale:3"` // filled by gorm
+	LastSeenAt CustomTime `gorm:"timeScale:3"`
+	ExpiresAt  CustomTime `gorm:"timeScale:3; index:idx_session_expires"`
+	IP         string     `gorm:"type:varchar(64)"`
+	UserAgent  string     `gorm:"type:varchar(255)"`
+}
+
+func (s *Session) IsValid() bool {
+	return s.ID != "" && s.UserID != ""
+}
+
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt.T())
+}
+
+// Device returns a human-readable description of the browser and operating system the session was created from
+func (s *Session) Device() string {
+	ua := useragent.Parse(s.UserAgent)
+	switch {
+	case ua.Name != "" && ua.OS != "":
+		return fmt.Sprintf("%s on %s", ua.Name, ua.OS)
+	case ua.Name != "":
+		return ua.Name
+	case s.UserAgent != "":
+		return s.UserAgent
+	default:
+		return "Unknown device"
+	}
+}
@@ -25,6 +25,7 @@ const (
 	OidcIdTokenCookieKey      = config.CookieKeyOidcIdToken
 	OidcRefreshTokenCookieKey = config.CookieKeyOidcRefreshToken
 	OidcProviderCookieKey     = config.CookieKeyOidcProvider
+	OidcSessionCookieKey      = config.CookieKeyOidcSession
 	PersistentIntervalKey     = "wakapi_summary_interval"
 )
 
@@ -85,6 +86,20 @@ func (j *CustomTime) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// GobEncode is needed for heartbeats and users to be sent to other instances via the event bus
+func (j CustomTime) GobEncode() ([]byte, error) {
+	return j.T().GobEncode()
+}
+
+func (j *CustomTime) GobDecode(data []byte) error {
+	var t time.Time
+	if err := t.GobDecode(data); err != nil {
+		return err
+	}
+	*j = CustomTime(t)
+	return nil
+}
+
 func initHacks() {
 	postgresTimezoneHack = config.Get().Db.IsPostgres()
 	sqliteMode = config.Get().Db.IsSQLite()
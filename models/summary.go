@@ -12,18 +12,19 @@ import (
 )
 
 const (
-	NSummaryTypes   uint8 = 99
-	SummaryUnknown  uint8 = 98
-	SummaryProject  uint8 = 0
-	SummaryLanguage uint8 = 1
-	SummaryEditor   uint8 = 2
-	SummaryOS       uint8 = 3
-	SummaryMachine  uint8 = 4
-	SummaryLabel    uint8 = 5
-	SummaryBranch   uint8 = 6
-	SummaryEntity   uint8 = 7
-	SummaryCategory uint8 = 8
-	SummaryAiModel  uint8 = 9
+	NSummaryTypes    uint8 = 99
+	SummaryUnknown   uint8 = 98
+	SummaryProject   uint8 = 0
+	SummaryLanguage  uint8 = 1
+	SummaryEditor    uint8 = 2
+	SummaryOS        uint8 = 3
+	SummaryMachine   uint8 = 4
+	SummaryLabel     uint8 = 5
+	SummaryBranch    uint8 = 6
+	SummaryEntity    uint8 = 7
+	SummaryCategory  uint8 = 8
+	SummaryAiModel   uint8 = 9
+	SummaryAiSession uint8 = 10
 )
 
 const UnknownSummaryKey = "unknown"
@@ -53,6 +54,8 @@ type Summary struct {
 	Branches         SummaryItems `json:"branches" gorm:"-"` // branches are not persisted, but calculated at runtime in case a project Filter is applied
 	Entities         SummaryItems `json:"entities" gorm:"-"` // entities are not persisted, but calculated at runtime in case a project Filter is applied
 	Categories       SummaryItems `json:"categories" gorm:"-"`
+	AiModels         SummaryItems `json:"ai_models" gorm:"-"`   // not part of SummaryTypes, but only used for ai usage analytics, see AIStats
+	AiSessions       SummaryItems `json:"ai_sessions" gorm:"-"` // not part of SummaryTypes and never persisted, only derived from heartbeats for short ranges where ai usage analytics are shown, see AIStats
 	NumHeartbeats    int          `json:"-"`
 }
 
@@ -62,9 +65,11 @@ type SummaryItem struct {
 	ID        uint64        `json:"-" gorm:"primary_key"`
 	Summary   *Summary      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	SummaryID uint          `json:"-" gorm:"size:32"`
-	Type      uint8         `json:"-" gorm:"index:idx_type"`
-	Key       string        `json:"key" gorm:"size:255"`
+	Type      uint8         `json:"-" gorm:"index:idx_type; index:idx_type_key,priority:1"`
+	Key       string        `json:"key" gorm:"size:255; index:idx_type_key,priority:2"` // idx_type_key is for looking up a project's contributors
 	Total     time.Duration `json:"total" swaggertype:"primitive,integer"`
+	AICounters
+	LineCounters
 }
 
 type SummaryItemContainer struct {
@@ -91,7 +96,7 @@ func NativeSummaryTypes() []uint8 {
 }
 
 func PersistedSummaryTypes() []uint8 {
-	return []uint8{SummaryProject, SummaryLanguage, SummaryEditor, SummaryOS, SummaryMachine, SummaryCategory}
+	return []uint8{SummaryProject, SummaryLanguage, SummaryEditor, SummaryOS, SummaryMachine, SummaryCategory, SummaryAiModel}
 }
 
 func NewEmptySummary() *Summary {
@@ -105,6 +110,8 @@ func NewEmptySummary() *Summary {
 		Branches:         SummaryItems{},
 		Entities:         SummaryItems{},
 		Categories:       SummaryItems{},
+		AiModels:         SummaryItems{},
+		AiSessions:       SummaryItems{},
 	}
 }
 
@@ -118,6 +125,8 @@ func (s *Summary) Sorted() *Summary {
 	sort.Sort(sort.Reverse(s.Branches))
 	sort.Sort(sort.Reverse(s.Entities))
 	sort.Sort(sort.Reverse(s.Categories))
+	sort.Sort(sort.Reverse(s.AiModels))
+	sort.Sort(sort.Reverse(s.AiSessions))
 	return s
 }
 
@@ -159,6 +168,10 @@ func (s *Summary) GetByType(summaryType uint8) *SummaryItems {
 		return &s.Entities
 	case SummaryCategory:
 		return &s.Categories
+	case SummaryAiModel:
+		return &s.AiModels
+	case SummaryAiSession:
+		return &s.AiSessions
 	}
 	return nil
 }
@@ -192,6 +205,12 @@ func (s *Summary) SetByType(summaryType uint8, items *SummaryItems) {
 	case SummaryCategory:
 		s.Categories = *items
 		break
+	case SummaryAiModel:
+		s.AiModels = *items
+		break
+	case SummaryAiSession:
+		s.AiSessions = *items
+		break
 	}
 }
 
@@ -396,13 +415,17 @@ func (s *Summary) WithResolvedAliases(resolve AliasResolver) *Summary {
 			if key := resolve(item.Type, item.Key); key != item.Key {
 				if targetItem := findItem(key); targetItem != nil {
 					targetItem.Total += item.Total
+					targetItem.AICounters.Add(item.AICounters)
+					targetItem.LineCounters.Add(item.LineCounters)
 				} else {
 					itemsAliased = append(itemsAliased, &SummaryItem{
-						ID:        item.ID,
-						SummaryID: item.SummaryID,
-						Type:      item.Type,
-						Key:       key,
-						Total:     item.Total,
+						ID:           item.ID,
+						SummaryID:    item.SummaryID,
+						Type:         item.Type,
+						Key:          key,
+						Total:        item.Total,
+						AICounters:   item.AICounters,
+						LineCounters: item.LineCounters,
 					})
 				}
 			}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duke-git/lancet/v2/mathutil"
+)
+
+// SummaryComparison contrasts a summary with the one of another period (e.g. this week with last week), item by item
+type SummaryComparison struct {
+	From             time.Time         `json:"from"`
+	To               time.Time         `json:"to"`
+	PreviousFrom     time.Time         `json:"previous_from"`
+	PreviousTo       time.Time         `json:"previous_to"`
+	Total            *SummaryItemDelta `json:"total"`
+	Projects         SummaryItemDeltas `json:"projects"`
+	Languages        SummaryItemDeltas `json:"languages"`
+	Editors          SummaryItemDeltas `json:"editors"`
+	OperatingSystems SummaryItemDeltas `json:"operating_systems"`
+	Machines         SummaryItemDeltas `json:"machines"`
+	Labels           SummaryItemDeltas `json:"labels"`
+	Branches         SummaryItemDeltas `json:"branches"`
+	Entities         SummaryItemDeltas `json:"entities"`
+	Categories       SummaryItemDeltas `json:"categories"`
+}
+
+type SummaryItemDeltas []*SummaryItemDelta
+
+type SummaryItemDelta struct {
+	Key           string        `json:"key"`
+	Total         time.Duration `json:"total" swaggertype:"primitive,integer"`          // in seconds when serialized, like summary items
+	PreviousTotal time.Duration `json:"previous_total" swaggertype:"primitive,integer"` // in seconds when serialized
+	Delta         time.Duration `json:"delta" swaggertype:"primitive,integer"`          // in seconds when serialized, negative for a decrease
+	ChangePercent float64       `json:"change_percent"`                                 // relative change compared to the previous period, 0 if there was no previous activity
+}
+
+// NewSummaryComparison compares the summary for params to the one for previousParams, whereas both periods don't have to be adjacent or of same length
+func NewSummaryComparison(params *SummaryParams, summary *Summary, previousParams *SummaryParams, previousSummary *Summary) *SummaryComparison {
+	comparison := &SummaryComparison{
+		From:         params.From,
+		To:           params.To,
+		PreviousFrom: previousParams.From,
+		PreviousTo:   previousParams.To,
+		Total:        NewSummaryItemDelta("total", summary.TotalTime(), previousSummary.TotalTime()),
+	}
+	for _, t := range SummaryTypes() {
+		comparison.SetByType(t, newSummaryItemDeltas(*summary.GetByType(t), *previousSummary.GetByType(t)))
+	}
+	return comparison
+}
+
+func NewSummaryItemDelta(key string, total, previousTotal time.Duration) *SummaryItemDelta {
+	delta := &SummaryItemDelta{
+		Key:           key,
+		Total:         total,
+		PreviousTotal: previousTotal,
+		Delta:         total - previousTotal,
+	}
+	if previousTotal > 0 {
+		delta.ChangePercent = mathutil.RoundToFloat(float64(delta.Delta)/float64(previousTotal)*100, 1)
+	}
+	return delta
+}
+
+func (c *SummaryComparison) GetByType(summaryType uint8) SummaryItemDeltas {
+	switch summaryType {
+	case SummaryProject:
+		return c.Projects
+	case SummaryLanguage:
+		return c.Languages
+	case SummaryEditor:
+		return c.Editors
+	case SummaryOS:
+		return c.OperatingSystems
+	case SummaryMachine:
+		return c.Machines
+	case SummaryLabel:
+		return c.Labels
+	case SummaryBranch:
+		return c.Branches
+	case SummaryEntity:
+		return c.Entities
+	case SummaryCategory:
+		return c.Categories
+	}
+	return nil
+}
+
+func (c *SummaryComparison) SetByType(summaryType uint8, deltas SummaryItemDeltas) {
+	switch summaryType {
+	case SummaryProject:
+		c.Projects = deltas
+	case SummaryLanguage:
+		c.Languages = deltas
+	case SummaryEditor:
+		c.Editors = deltas
+	case SummaryOS:
+		c.OperatingSystems = deltas
+	case SummaryMachine:
+		c.Machines = deltas
+	case SummaryLabel:
+		c.Labels = deltas
+	case SummaryBranch:
+		c.Branches = deltas
+	case SummaryEntity:
+		c.Entities = deltas
+	case SummaryCategory:
+		c.Categories = deltas
+	}
+}
+
+// MarshalJSON serializes all durations in seconds to be consistent with the totals of summary items
+func (d *SummaryItemDelta) MarshalJSON() ([]byte, error) {
+	type alias SummaryItemDelta
+	return json.Marshal(&struct {
+		*alias
+		Total         int64 `json:"total"`
+		PreviousTotal int64 `json:"previous_total"`
+		Delta         int64 `json:"delta"`
+	}{
+		alias:         (*alias)(d),
+		Total:         int64(d.Total.Seconds()),
+		PreviousTotal: int64(d.PreviousTotal.Seconds()),
+		Delta:         int64(d.Total.Seconds()) - int64(d.PreviousTotal.Seconds()),
+	})
+}
+
+// IsNew returns whether the item had no activity in the previous period
+func (d *SummaryItemDelta) IsNew() bool {
+	return d.PreviousTotal == 0 && d.Total > 0
+}
+
+// AbsDelta returns the amount of time by which the total has changed, regardless of the direction
+func (d *SummaryItemDelta) AbsDelta() time.Duration {
+	if d.Delta < 0 {
+		return -d.Delta
+	}
+	return d.Delta
+}
+
+// newSummaryItemDeltas matches items of both periods by key, including those only present in either of them, sorted by current and then previous total
+func newSummaryItemDeltas(items, previousItems SummaryItems) SummaryItemDeltas {
+	totals := make(map[string]time.Duration, len(items))
+	previousTotals := make(map[string]time.Duration, len(previousItems))
+	keys := make([]string, 0, len(items)+len(previousItems))
+
+	for _, item := range items {
+		if _, ok := totals[item.Key]; !ok {
+			keys = append(keys, item.Key)
+		}
+		totals[item.Key] += item.TotalFixed()
+	}
+	for _, item := range previousItems {
+		if _, ok := totals[item.Key]; !ok {
+			if _, ok := previousTotals[item.Key]; !ok {
+				keys = append(keys, item.Key)
+			}
+		}
+		previousTotals[item.Key] += item.TotalFixed()
+	}
+
+	deltas := make(SummaryItemDeltas, 0, len(keys))
+	for _, key := range keys {
+		deltas = append(deltas, NewSummaryItemDelta(key, totals[key], previousTotals[key]))
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].Total != deltas[j].Total {
+			return deltas[i].Total > deltas[j].Total
+		}
+		if deltas[i].PreviousTotal != deltas[j].PreviousTotal {
+			return deltas[i].PreviousTotal > deltas[j].PreviousTotal
+		}
+		return strings.Compare(deltas[i].Key, deltas[j].Key) < 0
+	})
+	return deltas
+}
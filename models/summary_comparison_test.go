@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSummaryComparison(t *testing.T) {
+	now := time.Now()
+	params := &SummaryParams{From: now.AddDate(0, 0, -7), To: now}
+	previousParams := &SummaryParams{From: now.AddDate(0, 0, -14), To: now.AddDate(0, 0, -7)}
+
+	summary := NewEmptySummary()
+	summary.Projects = SummaryItems{
+		{Type: SummaryProject, Key: "wakapi", Total: 90 * 60},
+		{Type: SummaryProject, Key: "anchr", Total: 30 * 60},
+	}
+	summary.Languages = SummaryItems{{Type: SummaryLanguage, Key: "Go", Total: 120 * 60}}
+
+	previousSummary := NewEmptySummary()
+	previousSummary.Projects = SummaryItems{
+		{Type: SummaryProject, Key: "wakapi", Total: 60 * 60},
+		{Type: SummaryProject, Key: "website", Total: 60 * 60},
+	}
+	previousSummary.Languages = SummaryItems{{Type: SummaryLanguage, Key: "Go", Total: 120 * 60}}
+
+	sut := NewSummaryComparison(params, summary, previousParams, previousSummary)
+
+	assert.Equal(t, params.From, sut.From)
+	assert.Equal(t, previousParams.To, sut.PreviousTo)
+
+	assert.Equal(t, 2*time.Hour, sut.Total.Total)
+	assert.Equal(t, 2*time.Hour, sut.Total.PreviousTotal)
+	assert.Zero(t, sut.Total.Delta)
+	assert.Zero(t, sut.Total.ChangePercent)
+
+	assert.Len(t, sut.Projects, 3)
+	assert.Equal(t, "wakapi", sut.Projects[0].Key)
+	assert.Equal(t, 30*time.Minute, sut.Projects[0].Delta)
+	assert.Equal(t, 50.0, sut.Projects[0].ChangePercent)
+	assert.Equal(t, "anchr", sut.Projects[1].Key)
+	assert.True(t, sut.Projects[1].IsNew())
+	assert.Zero(t, sut.Projects[1].ChangePercent)
+	assert.Equal(t, "website", sut.Projects[2].Key)
+	assert.Equal(t, -1*time.Hour, sut.Projects[2].Delta)
+	assert.Equal(t, time.Hour, sut.Projects[2].AbsDelta())
+	assert.Equal(t, -100.0, sut.Projects[2].ChangePercent)
+
+	assert.Len(t, sut.Languages, 1)
+	assert.Zero(t, sut.Languages[0].Delta)
+	assert.Empty(t, sut.Editors)
+	assert.Equal(t, sut.Projects, sut.GetByType(SummaryProject))
+}
+
+func TestSummaryItemDelta_MarshalJSON(t *testing.T) {
+	sut := NewSummaryItemDelta("wakapi", 90*time.Minute, time.Hour)
+
+	data, err := json.Marshal(sut)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"key":"wakapi","total":5400,"previous_total":3600,"delta":1800,"change_percent":50}`, string(data))
+}
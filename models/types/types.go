@@ -1,8 +1,10 @@
 package types
 
 import (
-	"github.com/muety/wakapi/models"
+	"context"
 	"time"
+
+	"github.com/muety/wakapi/models"
 )
 
-type SummaryRetriever func(f, t time.Time, u *models.User, filters *models.Filters, duration *time.Duration) (*models.Summary, error)
+type SummaryRetriever func(ctx context.Context, f, t time.Time, u *models.User, filters *models.Filters, duration *time.Duration) (*models.Summary, error)
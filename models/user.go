@@ -32,6 +32,7 @@ type User struct {
 	Email                  string                `json:"email" gorm:"uniqueIndex:idx_user_email;size:255;default:null"`
 	Location               string                `json:"location"`
 	StartOfWeek            int                   `json:"start_of_week" gorm:"default:1"`
+	WorkingWeekdays        int                   `json:"-" gorm:"default:0"` // bit mask of working days of the week (bit 0 is sunday), 0 means every day is a working day
 	Password               string                `json:"-"`
 	CreatedAt              CustomTime            `swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"` // filled by gorm, see https://gorm.io/docs/conventions.html#CreatedAt
 	LastLoggedInAt         CustomTime            `swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"` // not filled by gorm
@@ -50,13 +51,15 @@ type User struct {
 	ResetToken             string                `json:"-"`
 	UnsubscribeToken       string                `json:"-"`
 	ReportsWeekly          bool                  `json:"-" gorm:"default:false; type:bool"`
+	ReportsPreset          string                `json:"-" gorm:"type:varchar(64)"` // slug of the filter preset to apply to weekly reports, if any
 	PublicLeaderboard      bool                  `json:"-" gorm:"default:false; type:bool"`
 	SubscribedUntil        *CustomTime           `json:"-" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
 	SubscriptionRenewal    *CustomTime           `json:"-" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
 	StripeCustomerId       string                `json:"-"`
 	InvitedBy              string                `json:"-"`
 	ExcludeUnknownProjects bool                  `json:"-"`
-	HeartbeatsTimeoutSec   int                   `json:"-" gorm:"default:600"` // https://github.com/muety/wakapi/issues/156
+	HeartbeatsTimeoutSec   int                   `json:"-" gorm:"default:600"`  // https://github.com/muety/wakapi/issues/156
+	FocusSessionLengthSec  int                   `json:"-" gorm:"default:1500"` // minimum length of uninterrupted coding to count as focus session
 	ReadmeStatsBaseUrl     string                `json:"-" gorm:"default:''"`
 	AuthType               string                `json:"auth_type" gorm:"default:local;uniqueIndex:idx_oidc;size:255"`
 	Sub                    string                `json:"sub" gorm:"uniqueIndex:idx_oidc;size:255;default:null"` // openid connect subject
@@ -104,6 +107,7 @@ type UserDataUpdate struct {
 	Location          string `schema:"location"`
 	StartOfWeek       int    `schema:"start_of_week"`
 	ReportsWeekly     bool   `schema:"reports_weekly"`
+	ReportsPreset     string `schema:"reports_preset"`
 	PublicLeaderboard bool   `schema:"public_leaderboard"`
 }
 
@@ -166,6 +170,22 @@ func (u *User) StartOfWeekDay() time.Weekday {
 	return time.Weekday(u.StartOfWeek)
 }
 
+// IsWorkingWeekday returns whether the given day of the week is one of the user's working days
+func (u *User) IsWorkingWeekday(day time.Weekday) bool {
+	return u.WorkingWeekdays == 0 || u.WorkingWeekdays&(1<<day) != 0
+}
+
+// SetWorkingWeekdays updates the user's working days of the week, where an empty list means every day is a working day
+func (u *User) SetWorkingWeekdays(days []time.Weekday) {
+	u.WorkingWeekdays = 0
+	for _, d := range days {
+		u.WorkingWeekdays |= 1 << (d % 7)
+	}
+	if u.WorkingWeekdays == 1<<7-1 {
+		u.WorkingWeekdays = 0
+	}
+}
+
 func (u *User) AvatarURL(urlTemplate string) string {
 	urlTemplate = strings.ReplaceAll(urlTemplate, "{username}", u.ID)
 	urlTemplate = strings.ReplaceAll(urlTemplate, "{email}", u.Email)
@@ -189,6 +209,17 @@ func (u *User) HeartbeatsTimeoutMin() int {
 	return int(u.HeartbeatsTimeout() / time.Minute)
 }
 
+func (u *User) FocusSessionLength() time.Duration {
+	if u.FocusSessionLengthSec > 0 {
+		return time.Duration(u.FocusSessionLengthSec) * time.Second
+	}
+	return DefaultFocusSessionLength
+}
+
+func (u *User) FocusSessionLengthMin() int {
+	return int(u.FocusSessionLength() / time.Minute)
+}
+
 // WakaTimeURL returns the user's effective WakaTime URL, i.e. a custom one (which could also point to another Wakapi instance) or fallback if not specified otherwise.
 func (u *User) WakaTimeURL(fallback string) string {
 	if u.WakatimeApiUrl != "" {
@@ -265,7 +296,7 @@ func (s *Signup) IsValid() bool {
 }
 
 func (r *UserDataUpdate) IsValid() bool {
-	return ValidateEmail(r.Email) && ValidateTimezone(r.Location) && ValidateStartOfWeek(r.StartOfWeek)
+	return ValidateEmail(r.Email) && ValidateTimezone(r.Location) && ValidateStartOfWeek(r.StartOfWeek) && r.ReportsPreset == NewFilterPresetSlug(r.ReportsPreset)
 }
 
 func ValidateUsername(username string) bool {
@@ -0,0 +1,94 @@
+package view
+
+import (
+	"sort"
+	"unicode"
+
+	"github.com/muety/wakapi/models"
+)
+
+type DashboardsViewModel struct {
+	SharedLoggedInViewModel
+	Dashboards []*models.Dashboard
+}
+
+type DashboardViewModel struct {
+	SharedLoggedInViewModel
+	Dashboard *models.Dashboard
+	Widgets   []*DashboardWidgetViewModel
+	OwnerID   string
+	ReadOnly  bool   // true when viewed through the dashboard's share link
+	ShareUrl  string // only set for the owner of a shared dashboard
+}
+
+type DashboardWidgetViewModel struct {
+	*models.DashboardWidget
+	Data  *models.DashboardWidgetData
+	Error string
+}
+
+type DashboardIntervalOption struct {
+	Key   string
+	Label string
+}
+
+func (s *DashboardsViewModel) WithSuccess(m string) *DashboardsViewModel {
+	s.SetSuccess(m)
+	return s
+}
+
+func (s *DashboardsViewModel) WithError(m string) *DashboardsViewModel {
+	s.SetError(m)
+	return s
+}
+
+func (s *DashboardViewModel) WithSuccess(m string) *DashboardViewModel {
+	s.SetSuccess(m)
+	return s
+}
+
+func (s *DashboardViewModel) WithError(m string) *DashboardViewModel {
+	s.SetError(m)
+	return s
+}
+
+func (s *DashboardViewModel) ChartTypes() []string {
+	return models.WidgetChartTypes()
+}
+
+// Intervals returns all intervals selectable for a widget, identified by their first machine-readable alias
+func (s *DashboardViewModel) Intervals() []*DashboardIntervalOption {
+	options := make([]*DashboardIntervalOption, 0, len(models.AllIntervals))
+	for _, i := range models.AllIntervals {
+		if key := (*i)[0]; !unicode.IsUpper(rune(key[0])) {
+			options = append(options, &DashboardIntervalOption{Key: key, Label: i.GetHumanReadable()})
+		}
+	}
+	return options
+}
+
+// FilterParams returns the names of all filter parameters selectable for a widget, in alphabetical order
+func (s *DashboardViewModel) FilterParams() []string {
+	params := make([]string, 0, len(models.FilterQueryParams))
+	for p := range models.FilterQueryParams {
+		params = append(params, p)
+	}
+	sort.Strings(params)
+	return params
+}
+
+// ChartData returns the data of all widgets that are rendered client-side
+func (s *DashboardViewModel) ChartData() []*models.DashboardWidgetData {
+	data := make([]*models.DashboardWidgetData, 0, len(s.Widgets))
+	for _, w := range s.Widgets {
+		if w.Data != nil && !w.IsActivityChart() {
+			data = append(data, w.Data)
+		}
+	}
+	return data
+}
+
+// FilterDescription returns a human-readable representation of the widget's filters, e.g. "project: wakapi, language: Go"
+func (w *DashboardWidgetViewModel) FilterDescription() string {
+	return describeFilters(w.Filters)
+}
@@ -0,0 +1,55 @@
+package view
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/muety/wakapi/models"
+)
+
+type FilterPresetViewModel struct {
+	*models.FilterPreset
+}
+
+func NewFilterPresetViewModels(presets []*models.FilterPreset) []*FilterPresetViewModel {
+	vms := make([]*FilterPresetViewModel, len(presets))
+	for i, p := range presets {
+		vms[i] = &FilterPresetViewModel{FilterPreset: p}
+	}
+	return vms
+}
+
+// FilterDescription returns a human-readable representation of the preset's filters, e.g. "project: wakapi, language: not Markdown"
+func (p *FilterPresetViewModel) FilterDescription() string {
+	return describeFilters(p.Filters)
+}
+
+func describeFilters(rawQuery string) string {
+	query, _ := url.ParseQuery(rawQuery)
+	params := make([]string, 0, len(query))
+	for p := range query {
+		params = append(params, p)
+	}
+	sort.Strings(params)
+
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		var included, excluded []string
+		for _, v := range query[p] {
+			if key, negated := strings.CutPrefix(v, models.FilterNegationPrefix); negated {
+				excluded = append(excluded, key)
+			} else {
+				included = append(included, v)
+			}
+		}
+		if len(included) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", strings.ReplaceAll(p, "_", " "), strings.Join(included, " or ")))
+		}
+		if len(excluded) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: not %s", strings.ReplaceAll(p, "_", " "), strings.Join(excluded, " or ")))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
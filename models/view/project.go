@@ -0,0 +1,112 @@
+package view
+
+import (
+	"time"
+
+	"github.com/muety/wakapi/models"
+)
+
+// number of most recent days to show daily totals for
+const projectRecentDays = 90
+
+type ProjectViewModel struct {
+	SharedLoggedInViewModel
+	Project       *models.ProjectStats
+	Summary       *models.Summary              // over the project's entire lifetime
+	Activity      *models.ActivityData         // daily totals over the project's lifetime (limited to the past few years)
+	ActivityChart string                       // svg heatmap of the past 12 months
+	Contributors  []*models.ProjectContributor // only set for shared projects
+}
+
+type ProjectItemsViewModel struct {
+	Title string
+	Items models.SummaryItems
+	Total time.Duration
+}
+
+func (s *ProjectViewModel) LangIcon(lang string) string {
+	return GetLanguageIcon(lang)
+}
+
+// Weeks returns the project's total coding time per calendar week
+func (s *ProjectViewModel) Weeks() []*models.ActivityDay {
+	if s.Activity == nil {
+		return []*models.ActivityDay{}
+	}
+	return s.Activity.Weekly(s.User.StartOfWeekDay())
+}
+
+// RecentDays returns the project's total coding time per day for the most recent days
+func (s *ProjectViewModel) RecentDays() []*models.ActivityDay {
+	if s.Activity == nil {
+		return []*models.ActivityDay{}
+	}
+	if n := len(s.Activity.Days); n > projectRecentDays {
+		return s.Activity.Days[n-projectRecentDays:]
+	}
+	return s.Activity.Days
+}
+
+func (s *ProjectViewModel) BestDay() *models.ActivityDay {
+	if s.Activity == nil {
+		return nil
+	}
+	return s.Activity.BestDay()
+}
+
+func (s *ProjectViewModel) NumActiveDays() int {
+	if s.Activity == nil {
+		return 0
+	}
+	return s.Activity.NumActiveDays()
+}
+
+// AverageActiveDay returns the average coding time per day with any activity on the project
+func (s *ProjectViewModel) AverageActiveDay() time.Duration {
+	if n := s.NumActiveDays(); n > 0 && s.Summary != nil {
+		return s.Summary.TotalTime() / time.Duration(n)
+	}
+	return 0
+}
+
+// Breakdowns returns the project's summary items per entity type in the order to be displayed
+func (s *ProjectViewModel) Breakdowns() []*ProjectItemsViewModel {
+	if s.Summary == nil {
+		return []*ProjectItemsViewModel{}
+	}
+	total := s.Summary.TotalTime()
+	return []*ProjectItemsViewModel{
+		{Title: "Branches", Items: s.Summary.Branches, Total: total},
+		{Title: "Files", Items: s.Summary.Entities, Total: total},
+		{Title: "Languages", Items: s.Summary.Languages, Total: total},
+		{Title: "Editors", Items: s.Summary.Editors, Total: total},
+		{Title: "Machines", Items: s.Summary.Machines, Total: total},
+		{Title: "Operating Systems", Items: s.Summary.OperatingSystems, Total: total},
+	}
+}
+
+func (s *ProjectViewModel) WithSuccess(m string) *ProjectViewModel {
+	s.SetSuccess(m)
+	return s
+}
+
+func (s *ProjectViewModel) WithError(m string) *ProjectViewModel {
+	s.SetError(m)
+	return s
+}
+
+// Percentage returns an item's share of the project's total coding time in percent
+func (p *ProjectItemsViewModel) Percentage(item *models.SummaryItem) float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(item.TotalFixed()) / float64(p.Total) * 100
+}
+
+// Head returns the items with the most coding time
+func (p *ProjectItemsViewModel) Head(n int) models.SummaryItems {
+	if len(p.Items) > n {
+		return p.Items[:n]
+	}
+	return p.Items
+}
@@ -11,6 +11,11 @@ type SettingsViewModel struct {
 	LanguageMappings      []*models.LanguageMapping
 	Aliases               []*SettingsVMCombinedAlias
 	Labels                []*SettingsVMCombinedLabel
+	WorkingWeekdays       []*SettingsVMWeekday
+	DaysOff               []*models.DayOff // manually added ones
+	UpcomingImportedDays  []*models.DayOff
+	NumImportedDaysOff    int
+	FilterPresets         []*FilterPresetViewModel
 	Projects              []string
 	SubscriptionPrice     string
 	DataRetentionMonths   int
@@ -21,6 +26,11 @@ type SettingsViewModel struct {
 	ApiKeys               []*SettingsApiKeys
 	WebAuthnCredentials   []*models.WebAuthnCredential
 	DisableWebAuthn       bool
+	Sessions              []*models.Session
+	CurrentSessionId      string
+	SecurityEvents        []*models.SecurityEvent
+	Diagnostics           []*models.Diagnostics
+	AdminDiagnostics      []*models.Diagnostics
 }
 
 type SettingsVMCombinedAlias struct {
@@ -34,6 +44,11 @@ type SettingsVMCombinedLabel struct {
 	Values []string
 }
 
+type SettingsVMWeekday struct {
+	Weekday time.Weekday
+	Working bool
+}
+
 type SettingsApiKeys struct {
 	Name     string
 	Value    string
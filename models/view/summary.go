@@ -1,6 +1,8 @@
 package view
 
 import (
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/duke-git/lancet/v2/slice"
@@ -18,9 +20,19 @@ type SummaryViewModel struct {
 	LanguageColors      map[string]string
 	OSColors            map[string]string
 	Timeline            []*TimelineViewModel
+	AIStats             *models.AIStats
+	Comparison          *models.SummaryComparison // only set if a comparison with another period was requested
+	DailyAverage        time.Duration             // per working day
+	NumWorkingDays      int
+	Focus               *models.FocusStats // only set for shorter ranges
 	HourlyBreakdown     []*HourlyBreakdownViewModel
 	HourlyBreakdownFrom time.Time
+	FilterPresets       []*FilterPresetViewModel
+	ActivePreset        *models.FilterPreset // only set if a preset was requested
+	Interval            string               // raw interval key, if any, e.g. "last_7_days"
+	FilterQuery         url.Values           // filters as requested, i.e. before resolving aliases and labels
 	RawQuery            string
+	BaseQuery           string // raw query without comparison parameters
 	UserFirstData       time.Time
 	DataRetentionMonths int
 }
@@ -34,8 +46,10 @@ type AvailableFilters struct {
 }
 
 type TimelineViewModel struct {
-	Date     time.Time       `json:"date"`
-	Projects []*TimelineItem `json:"projects"`
+	Date           time.Time       `json:"date"`
+	Projects       []*TimelineItem `json:"projects"`
+	AIInputTokens  int             `json:"ai_input_tokens"`
+	AIOutputTokens int             `json:"ai_output_tokens"`
 }
 
 type TimelineItem struct {
@@ -62,8 +76,11 @@ type HourlyBreakdownItem struct {
 func NewTimelineViewModel(summaries []*models.Summary) []*TimelineViewModel {
 	vm := make([]*TimelineViewModel, 0)
 	for _, summary := range summaries {
+		aiStats := summary.AIStats()
 		vm = append(vm, &TimelineViewModel{
-			Date: summary.FromTime.T(),
+			Date:           summary.FromTime.T(),
+			AIInputTokens:  aiStats.AIInputTokens,
+			AIOutputTokens: aiStats.AIOutputTokens,
 			Projects: slice.Map(summary.Projects, func(_ int, curProject *models.SummaryItem) *TimelineItem {
 				return &TimelineItem{
 					Name:     curProject.Key,
@@ -125,6 +142,15 @@ func (s SummaryViewModel) UserDataExpiring() bool {
 		time.Now().AddDate(0, -cfg.App.DataRetentionMonths, 0).After(s.UserFirstData)
 }
 
+// FilterValues returns the currently applied filters as comma-separated values per filter parameter, e.g. to save them as a preset
+func (s *SummaryViewModel) FilterValues() map[string]string {
+	values := make(map[string]string)
+	for param, v := range s.FilterQuery {
+		values[param] = strings.Join(v, ",")
+	}
+	return values
+}
+
 func (s *SummaryViewModel) WithSuccess(m string) *SummaryViewModel {
 	s.SetSuccess(m)
 	return s
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"io"
+
+	"github.com/muety/wakapi/lib/backup"
+	"github.com/muety/wakapi/migrations"
+	"gorm.io/gorm"
+)
+
+type BackupRepository struct {
+	BaseRepository
+}
+
+func NewBackupRepository(db *gorm.DB) *BackupRepository {
+	return &BackupRepository{BaseRepository: NewBaseRepository(db)}
+}
+
+func (r *BackupRepository) Create(w io.Writer, appVersion string) (*backup.Manifest, error) {
+	return backup.Create(r.db, migrations.Models(), w, appVersion)
+}
+
+func (r *BackupRepository) Restore(reader io.Reader, force bool) (*backup.Manifest, error) {
+	return backup.Restore(r.db, migrations.Models(), reader, force)
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"strings"
@@ -8,12 +9,22 @@ import (
 
 	"github.com/duke-git/lancet/v2/slice"
 	conf "github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/models"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
 )
 
 const chunkSize = 1024 // 4096 worked fine for mysql, but not for sqlite
 
+type primaryReadsKey struct{}
+
+// WithPrimaryReads makes all queries run with the given context go to the primary database, even if a read replica is configured.
+// Use it for code paths that read back data they have just written themselves (e.g. summary aggregation), which might not have been replicated, yet.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
 type BaseRepository struct {
 	db *gorm.DB
 }
@@ -48,6 +59,16 @@ func (r *BaseRepository) GetTableDDLSqlite(tableName string) (result string, err
 	return result, err
 }
 
+// replica returns a session for read-heavy queries that can tolerate replication lag, which is routed to the read replica, if one is configured
+func (r *BaseRepository) replica(ctx context.Context) *gorm.DB {
+	db := r.db.WithContext(ctx)
+	if primary, _ := ctx.Value(primaryReadsKey{}).(bool); primary {
+		return db
+	}
+	// without explicit read operation, raw queries not starting with 'select' (e.g. 'with ...') would be considered writes
+	return db.Clauses(dbresolver.Use(conf.DbReplicaResolver), dbresolver.Read)
+}
+
 func (r *BaseRepository) RunInTx(f func(tx *gorm.DB) error) error {
 	return r.db.Transaction(f)
 }
@@ -155,15 +176,28 @@ func streamRowsBatched[T any](rows *sql.Rows, channel chan []*T, db *gorm.DB, ba
 	}
 }
 
-func filteredQuery(q *gorm.DB, filterMap map[string][]string) *gorm.DB {
-	for col, vals := range filterMap {
-		q = q.Where(col+" in ?", slice.Map[string, string](vals, func(i int, val string) string {
-			// query for "unknown" projects, languages, etc.
-			if val == "-" {
-				return ""
-			}
-			return val
-		}))
+// filteredQuery restricts the query to rows whose columns match any of the included and none of the excluded values
+func filteredQuery(q *gorm.DB, filters models.ColumnFilters) *gorm.DB {
+	for col, vals := range filters.Included {
+		if len(vals) > 0 {
+			q = q.Where(col+" in ?", columnValues(vals))
+		}
+	}
+	for col, vals := range filters.Excluded {
+		if len(vals) > 0 {
+			// null never matches "not in", but is not among the excluded values either
+			q = q.Where("("+col+" not in ? or "+col+" is null)", columnValues(vals))
+		}
 	}
 	return q
 }
+
+// columnValues maps filter values to column values, i.e. "-" to the empty string to query for "unknown" projects, languages, etc.
+func columnValues(vals []string) []string {
+	return slice.Map(vals, func(_ int, val string) string {
+		if val == "-" {
+			return ""
+		}
+		return val
+	})
+}
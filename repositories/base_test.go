@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/muety/wakapi/config"
+)
+
+// setupTestDB creates a fresh, in-memory sqlite database with the schema of the given models
+func setupTestDB(t *testing.T, dst ...interface{}) *gorm.DB {
+	cfg := config.Empty()
+	cfg.Db.Dialect = config.SQLDialectSqlite
+	config.Set(cfg)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	sqlDb, err := db.DB()
+	require.NoError(t, err)
+	sqlDb.SetMaxOpenConns(1) // every connection would get its own in-memory database otherwise
+	t.Cleanup(func() { sqlDb.Close() })
+
+	require.NoError(t, db.AutoMigrate(dst...))
+	return db
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/models"
+	"gorm.io/gorm"
+)
+
+type DashboardRepository struct {
+	BaseRepository
+	config *config.Config
+}
+
+func NewDashboardRepository(db *gorm.DB) *DashboardRepository {
+	return &DashboardRepository{BaseRepository: NewBaseRepository(db), config: config.Get()}
+}
+
+func (r *DashboardRepository) GetById(id uint) (*models.Dashboard, error) {
+	dashboard := &models.Dashboard{}
+	if err := r.withWidgets().Where(&models.Dashboard{ID: id}).First(dashboard).Error; err != nil {
+		return dashboard, err
+	}
+	return dashboard, nil
+}
+
+func (r *DashboardRepository) GetByShareToken(token string) (*models.Dashboard, error) {
+	if token == "" {
+		return nil, errors.New("invalid input")
+	}
+	dashboard := &models.Dashboard{}
+	if err := r.withWidgets().Where(&models.Dashboard{ShareToken: token}).First(dashboard).Error; err != nil {
+		return dashboard, err
+	}
+	return dashboard, nil
+}
+
+func (r *DashboardRepository) GetByUser(userId string) ([]*models.Dashboard, error) {
+	if userId == "" {
+		return []*models.Dashboard{}, nil
+	}
+	var dashboards []*models.Dashboard
+	if err := r.withWidgets().
+		Where(&models.Dashboard{UserID: userId}).
+		Order("id asc").
+		Find(&dashboards).Error; err != nil {
+		return dashboards, err
+	}
+	return dashboards, nil
+}
+
+func (r *DashboardRepository) Insert(dashboard *models.Dashboard) (*models.Dashboard, error) {
+	if !dashboard.IsValid() {
+		return nil, errors.New("invalid dashboard")
+	}
+	result := r.db.Omit("Widgets").Create(dashboard)
+	if err := result.Error; err != nil {
+		return nil, err
+	}
+	return dashboard, nil
+}
+
+// Update saves a dashboard's own properties, but none of its widgets
+func (r *DashboardRepository) Update(dashboard *models.Dashboard) (*models.Dashboard, error) {
+	if !dashboard.IsValid() {
+		return nil, errors.New("invalid dashboard")
+	}
+	result := r.db.Model(dashboard).
+		Select("name", "shared", "share_token").
+		Updates(map[string]interface{}{
+			"name":        dashboard.Name,
+			"shared":      dashboard.Shared,
+			"share_token": dashboard.ShareToken,
+		})
+	if err := result.Error; err != nil {
+		return nil, err
+	}
+	return dashboard, nil
+}
+
+func (r *DashboardRepository) Delete(id uint) error {
+	// widgets are deleted explicitly, because foreign key cascades might not be enforced (e.g. on sqlite)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dashboard_id = ?", id).Delete(models.DashboardWidget{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(models.Dashboard{}).Error
+	})
+}
+
+func (r *DashboardRepository) InsertWidget(widget *models.DashboardWidget) (*models.DashboardWidget, error) {
+	if !widget.IsValid() {
+		return nil, errors.New("invalid widget")
+	}
+	result := r.db.Create(widget)
+	if err := result.Error; err != nil {
+		return nil, err
+	}
+	return widget, nil
+}
+
+// UpdateWidgetPositions persists the positions of all given widgets at once
+func (r *DashboardRepository) UpdateWidgetPositions(widgets []*models.DashboardWidget) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, w := range widgets {
+			if err := tx.Model(w).Update("position", w.Position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *DashboardRepository) DeleteWidget(id uint) error {
+	return r.db.
+		Where("id = ?", id).
+		Delete(models.DashboardWidget{}).Error
+}
+
+func (r *DashboardRepository) withWidgets() *gorm.DB {
+	return r.db.Preload("Widgets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position asc, id asc")
+	})
+}
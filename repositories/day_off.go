@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DayOffRepository struct {
+	BaseRepository
+	config *config.Config
+}
+
+func NewDayOffRepository(db *gorm.DB) *DayOffRepository {
+	return &DayOffRepository{BaseRepository: NewBaseRepository(db), config: config.Get()}
+}
+
+func (r *DayOffRepository) GetById(id uint) (*models.DayOff, error) {
+	dayOff := &models.DayOff{}
+	if err := r.db.Where(&models.DayOff{ID: id}).First(dayOff).Error; err != nil {
+		return dayOff, err
+	}
+	return dayOff, nil
+}
+
+func (r *DayOffRepository) GetByUser(userId string) ([]*models.DayOff, error) {
+	if userId == "" {
+		return []*models.DayOff{}, nil
+	}
+	var daysOff []*models.DayOff
+	if err := r.db.
+		Where(&models.DayOff{UserID: userId}).
+		Order("date asc").
+		Find(&daysOff).Error; err != nil {
+		return daysOff, err
+	}
+	return daysOff, nil
+}
+
+func (r *DayOffRepository) Insert(dayOff *models.DayOff) (*models.DayOff, error) {
+	if !dayOff.IsValid() {
+		return nil, errors.New("invalid day off")
+	}
+	result := r.db.Create(dayOff)
+	if err := result.Error; err != nil {
+		return nil, err
+	}
+	return dayOff, nil
+}
+
+// InsertBatch inserts all given days off, skipping those dates already marked off before
+func (r *DayOffRepository) InsertBatch(daysOff []*models.DayOff) error {
+	return insertDaysOff(r.db, daysOff)
+}
+
+func (r *DayOffRepository) Delete(id uint) error {
+	return r.db.
+		Where("id = ?", id).
+		Delete(models.DayOff{}).Error
+}
+
+func (r *DayOffRepository) DeleteByUserAndSource(userId, source string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("source = ?", source).
+		Delete(models.DayOff{}).Error
+}
+
+// ReplaceByUserAndSource atomically replaces all of the user's days off from the given source by the given ones, so a failed insert doesn't leave the previous ones deleted
+func (r *DayOffRepository) ReplaceByUserAndSource(userId, source string, daysOff []*models.DayOff) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("user_id = ?", userId).
+			Where("source = ?", source).
+			Delete(models.DayOff{}).Error; err != nil {
+			return err
+		}
+		return insertDaysOff(tx, daysOff)
+	})
+}
+
+func insertDaysOff(db *gorm.DB, daysOff []*models.DayOff) error {
+	for _, d := range daysOff {
+		if !d.IsValid() {
+			return errors.New("invalid day off")
+		}
+	}
+	if len(daysOff) == 0 {
+		return nil
+	}
+	return db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&daysOff, 500).Error
+}
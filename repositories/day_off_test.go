@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/models"
+)
+
+func TestDayOffRepository_ReplaceByUserAndSource(t *testing.T) {
+	db := setupTestDB(t, &models.User{}, &models.DayOff{})
+	sut := NewDayOffRepository(db)
+
+	user := &models.User{ID: "user1"}
+	require.NoError(t, db.Create(user).Error)
+
+	require.NoError(t, sut.InsertBatch([]*models.DayOff{
+		{UserID: user.ID, Date: "2024-01-01", Source: models.DayOffSourceManual},
+		{UserID: user.ID, Date: "2024-01-02", Source: models.DayOffSourceCalendar},
+	}))
+
+	dates := func() []string {
+		daysOff, err := sut.GetByUser(user.ID)
+		require.NoError(t, err)
+		result := make([]string, len(daysOff))
+		for i, d := range daysOff {
+			result[i] = d.Date
+		}
+		return result
+	}
+
+	// previously imported days off are kept if the new ones can't be inserted
+	err := sut.ReplaceByUserAndSource(user.ID, models.DayOffSourceCalendar, []*models.DayOff{
+		{UserID: user.ID, Date: "2024-01-03", Source: models.DayOffSourceCalendar},
+		{UserID: user.ID, Date: "invalid", Source: models.DayOffSourceCalendar},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"2024-01-01", "2024-01-02"}, dates())
+
+	err = sut.ReplaceByUserAndSource(user.ID, models.DayOffSourceCalendar, []*models.DayOff{
+		{UserID: user.ID, Date: "2024-01-03", Source: models.DayOffSourceCalendar},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2024-01-01", "2024-01-03"}, dates())
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/muety/wakapi/models"
 	"gorm.io/gorm"
 )
@@ -13,6 +15,42 @@ func NewDiagnosticsRepository(db *gorm.DB) *DiagnosticsRepository {
 	return &DiagnosticsRepository{BaseRepository: NewBaseRepository(db)}
 }
 
+func (r *DiagnosticsRepository) GetAll(limit int) ([]*models.Diagnostics, error) {
+	var diagnostics []*models.Diagnostics
+	if err := r.db.
+		Order("created_at desc").
+		Limit(limit).
+		Find(&diagnostics).Error; err != nil {
+		return nil, err
+	}
+	return diagnostics, nil
+}
+
+func (r *DiagnosticsRepository) GetByUser(userId string, limit int) ([]*models.Diagnostics, error) {
+	var diagnostics []*models.Diagnostics
+	if err := r.db.
+		Where("user_id = ?", userId).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&diagnostics).Error; err != nil {
+		return nil, err
+	}
+	return diagnostics, nil
+}
+
 func (r *DiagnosticsRepository) Insert(diagnostics *models.Diagnostics) (*models.Diagnostics, error) {
 	return diagnostics, r.db.Create(diagnostics).Error
 }
+
+func (r *DiagnosticsRepository) DeleteByUser(userId string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Delete(models.Diagnostics{}).Error
+}
+
+func (r *DiagnosticsRepository) DeleteBefore(t time.Time) (int64, error) {
+	result := r.db.
+		Where("created_at < ? or created_at is null", models.CustomTime(t.Local())). // diagnostics from before the created_at column was added have none
+		Delete(models.Diagnostics{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/models"
+)
+
+func TestDiagnosticsRepository_DeleteBefore(t *testing.T) {
+	db := setupTestDB(t, &models.User{}, &models.Diagnostics{})
+	sut := NewDiagnosticsRepository(db)
+
+	now := time.Now()
+	for _, d := range []*models.Diagnostics{
+		{Plugin: "old", CreatedAt: models.CustomTime(now.AddDate(0, 0, -31))},
+		{Plugin: "legacy", CreatedAt: models.CustomTime(now)},
+		{Plugin: "recent", CreatedAt: models.CustomTime(now.AddDate(0, 0, -1))},
+	} {
+		_, err := sut.Insert(d)
+		require.NoError(t, err)
+	}
+	// diagnostics persisted before created_at was introduced
+	require.NoError(t, db.Model(&models.Diagnostics{}).Where("plugin = ?", "legacy").Update("created_at", nil).Error)
+
+	n, err := sut.DeleteBefore(now.AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	result, err := sut.GetAll(10)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "recent", result[0].Plugin)
+}
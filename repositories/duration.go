@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"time"
 
 	"github.com/duke-git/lancet/v2/condition"
@@ -61,18 +62,18 @@ func (r *DurationRepository) StreamByUserBatched(user *models.User, batchSize in
 }
 
 func (r *DurationRepository) GetAllWithin(from, to time.Time, user *models.User) ([]*models.Duration, error) {
-	return r.GetAllWithinByFilters(from, to, user, map[string][]string{})
+	return r.GetAllWithinByFilters(context.Background(), from, to, user, models.NewColumnFilters())
 }
 
-func (r *DurationRepository) GetAllWithinByFilters(from, to time.Time, user *models.User, filterMap map[string][]string) ([]*models.Duration, error) {
+func (r *DurationRepository) GetAllWithinByFilters(ctx context.Context, from, to time.Time, user *models.User, filters models.ColumnFilters) ([]*models.Duration, error) {
 	var durations []*models.Duration
 
-	q := r.db.Model(&models.Duration{}).Where(&models.Duration{UserID: user.ID})
+	q := r.replica(ctx).Model(&models.Duration{}).Where(&models.Duration{UserID: user.ID})
 	q = r.queryAddTimeFilterBetween(q, from.Local(), to.Local())
 	q = r.queryAddTimeSorting(q, false)
 
-	if len(filterMap) > 0 {
-		q = filteredQuery(q, filterMap)
+	if !filters.IsEmpty() {
+		q = filteredQuery(q, filters)
 	}
 
 	if err := q.Find(&durations).Error; err != nil {
@@ -111,6 +112,16 @@ func (r *DurationRepository) DeleteByUserBefore(user *models.User, t time.Time)
 	return nil
 }
 
+func (r *DurationRepository) DeleteByUserAfter(user *models.User, t time.Time) error {
+	if err := r.db.
+		Where("user_id = ?", user.ID).
+		Where("time >= ?", models.CustomTime(t.Local())).
+		Delete(models.Duration{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
 func (r *DurationRepository) queryAddTimeFilterBetween(q *gorm.DB, from, to time.Time) *gorm.DB {
 	return q.
 		Where("time >= ?", models.CustomTime(from.Local())).
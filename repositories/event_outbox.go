@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/muety/wakapi/models"
+)
+
+type EventOutboxRepository struct {
+	BaseRepository
+}
+
+func NewEventOutboxRepository(db *gorm.DB) *EventOutboxRepository {
+	return &EventOutboxRepository{BaseRepository: NewBaseRepository(db)}
+}
+
+func (r *EventOutboxRepository) Insert(entry *models.EventOutboxEntry) error {
+	return r.db.Create(entry).Error
+}
+
+// GetAfter returns entries with an id greater than the given one, ordered by id
+func (r *EventOutboxRepository) GetAfter(id uint64, limit int) ([]*models.EventOutboxEntry, error) {
+	var entries []*models.EventOutboxEntry
+	if err := r.db.
+		Where("id > ?", id).
+		Order("id asc").
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *EventOutboxRepository) GetLatestId() (uint64, error) {
+	var id uint64
+	if err := r.db.
+		Model(&models.EventOutboxEntry{}).
+		Select("coalesce(max(id), 0)").
+		Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *EventOutboxRepository) DeleteBefore(t time.Time) (int64, error) {
+	result := r.db.
+		Where("created_at < ?", models.CustomTime(t)).
+		Delete(models.EventOutboxEntry{})
+	return result.RowsAffected, result.Error
+}
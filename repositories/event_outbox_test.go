@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/models"
+)
+
+func TestEventOutboxRepository_DeleteBefore(t *testing.T) {
+	db := setupTestDB(t, &models.EventOutboxEntry{})
+	sut := NewEventOutboxRepository(db)
+
+	now := time.Now()
+	for _, e := range []*models.EventOutboxEntry{
+		{Instance: "instance1", Payload: []byte("old"), CreatedAt: models.CustomTime(now.Add(-2 * time.Hour))},
+		{Instance: "instance1", Payload: []byte("fresh"), CreatedAt: models.CustomTime(now)},
+	} {
+		require.NoError(t, sut.Insert(e))
+	}
+
+	n, err := sut.DeleteBefore(now.Add(-1 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	result, err := sut.GetAfter(0, 10)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, []byte("fresh"), result[0].Payload)
+}
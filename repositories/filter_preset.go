@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/models"
+	"gorm.io/gorm"
+)
+
+type FilterPresetRepository struct {
+	BaseRepository
+	config *config.Config
+}
+
+func NewFilterPresetRepository(db *gorm.DB) *FilterPresetRepository {
+	return &FilterPresetRepository{BaseRepository: NewBaseRepository(db), config: config.Get()}
+}
+
+func (r *FilterPresetRepository) GetById(id uint) (*models.FilterPreset, error) {
+	preset := &models.FilterPreset{}
+	if err := r.db.Where(&models.FilterPreset{ID: id}).First(preset).Error; err != nil {
+		return preset, err
+	}
+	return preset, nil
+}
+
+func (r *FilterPresetRepository) GetByUser(userId string) ([]*models.FilterPreset, error) {
+	if userId == "" {
+		return []*models.FilterPreset{}, nil
+	}
+	var presets []*models.FilterPreset
+	if err := r.db.
+		Where(&models.FilterPreset{UserID: userId}).
+		Order("name asc").
+		Find(&presets).Error; err != nil {
+		return presets, err
+	}
+	return presets, nil
+}
+
+func (r *FilterPresetRepository) Insert(preset *models.FilterPreset) (*models.FilterPreset, error) {
+	if !preset.IsValid() {
+		return nil, errors.New("invalid filter preset")
+	}
+	result := r.db.Create(preset)
+	if err := result.Error; err != nil {
+		return nil, err
+	}
+	return preset, nil
+}
+
+func (r *FilterPresetRepository) Delete(id uint) error {
+	return r.db.
+		Where("id = ?", id).
+		Delete(models.FilterPreset{}).Error
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -65,16 +66,16 @@ func (r *HeartbeatRepository) GetLatestByOriginAndUser(origin string, user *mode
 func (r *HeartbeatRepository) GetWithin(from, to time.Time, user *models.User) ([]*models.Heartbeat, error) {
 	// https://stackoverflow.com/a/20765152/3112139
 	var heartbeats []*models.Heartbeat
-	if err := r.buildTimeFilteredQuery(user.ID, from.Local(), to.Local()).Find(&heartbeats).Error; err != nil {
+	if err := r.buildTimeFilteredQuery(r.db, user.ID, from.Local(), to.Local()).Find(&heartbeats).Error; err != nil {
 		return nil, err
 	}
 	return heartbeats, nil
 }
 
-func (r *HeartbeatRepository) StreamWithin(from, to time.Time, user *models.User) (chan *models.Heartbeat, error) {
+func (r *HeartbeatRepository) StreamWithin(ctx context.Context, from, to time.Time, user *models.User) (chan *models.Heartbeat, error) {
 	out := make(chan *models.Heartbeat)
 
-	rows, err := r.buildTimeFilteredQuery(user.ID, from.Local(), to.Local()).Rows()
+	rows, err := r.buildTimeFilteredQuery(r.replica(ctx), user.ID, from.Local(), to.Local()).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -88,7 +89,7 @@ func (r *HeartbeatRepository) StreamWithin(from, to time.Time, user *models.User
 func (r *HeartbeatRepository) StreamWithinBatched(from, to time.Time, user *models.User, batchSize int) (chan []*models.Heartbeat, error) {
 	out := make(chan []*models.Heartbeat)
 
-	rows, err := r.buildTimeFilteredQuery(user.ID, from.Local(), to.Local()).Rows()
+	rows, err := r.buildTimeFilteredQuery(r.db, user.ID, from.Local(), to.Local()).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -99,12 +100,12 @@ func (r *HeartbeatRepository) StreamWithinBatched(from, to time.Time, user *mode
 	return out, nil
 }
 
-func (r *HeartbeatRepository) GetAllWithinByFilters(from, to time.Time, user *models.User, filterMap map[string][]string) ([]*models.Heartbeat, error) {
+func (r *HeartbeatRepository) GetAllWithinByFilters(from, to time.Time, user *models.User, filters models.ColumnFilters) ([]*models.Heartbeat, error) {
 	// https://stackoverflow.com/a/20765152/3112139
 	var heartbeats []*models.Heartbeat
 
-	q := r.buildTimeFilteredQuery(user.ID, from.Local(), to.Local())
-	q = filteredQuery(q, filterMap)
+	q := r.buildTimeFilteredQuery(r.db, user.ID, from.Local(), to.Local())
+	q = filteredQuery(q, filters)
 
 	if err := q.Find(&heartbeats).Error; err != nil {
 		return nil, err
@@ -112,11 +113,11 @@ func (r *HeartbeatRepository) GetAllWithinByFilters(from, to time.Time, user *mo
 	return heartbeats, nil
 }
 
-func (r *HeartbeatRepository) StreamWithinByFilters(from, to time.Time, user *models.User, filterMap map[string][]string) (chan *models.Heartbeat, error) {
+func (r *HeartbeatRepository) StreamWithinByFilters(from, to time.Time, user *models.User, filters models.ColumnFilters) (chan *models.Heartbeat, error) {
 	out := make(chan *models.Heartbeat)
 
-	q := r.buildTimeFilteredQuery(user.ID, from.Local(), to.Local())
-	q = filteredQuery(q, filterMap)
+	q := r.buildTimeFilteredQuery(r.db, user.ID, from.Local(), to.Local())
+	q = filteredQuery(q, filters)
 
 	rows, err := q.Rows()
 	if err != nil {
@@ -130,12 +131,12 @@ func (r *HeartbeatRepository) StreamWithinByFilters(from, to time.Time, user *mo
 	return out, nil
 }
 
-func (r *HeartbeatRepository) GetLatestByFilters(user *models.User, filterMap map[string][]string) (*models.Heartbeat, error) {
+func (r *HeartbeatRepository) GetLatestByFilters(user *models.User, filters models.ColumnFilters) (*models.Heartbeat, error) {
 	var heartbeat *models.Heartbeat
 
 	q := r.db.Model(&models.Heartbeat{}).Where(&models.Heartbeat{UserID: user.ID})
 	q = r.queryAddTimeSorting(q, true)
-	q = filteredQuery(q, filterMap)
+	q = filteredQuery(q, filters)
 
 	if err := q.Limit(1).Scan(&heartbeat).Error; err != nil {
 		return nil, err
@@ -288,7 +289,7 @@ func (r *HeartbeatRepository) GetUserProjectStats(user *models.User, from, to ti
 		"from ranked " +
 		"where rn = 1"
 
-	if err := r.db.
+	if err := r.replica(context.Background()).
 		Raw(query, args...).
 		Scan(&projectStats).Error; err != nil {
 		return nil, err
@@ -311,8 +312,8 @@ func (r *HeartbeatRepository) GetUserAgentsByUser(user *models.User) ([]*models.
 	return results, nil
 }
 
-func (r *HeartbeatRepository) buildTimeFilteredQuery(userId string, from, to time.Time) *gorm.DB {
-	query := r.db.Model(&models.Heartbeat{}).Where(&models.Heartbeat{UserID: userId})
+func (r *HeartbeatRepository) buildTimeFilteredQuery(db *gorm.DB, userId string, from, to time.Time) *gorm.DB {
+	query := db.Model(&models.Heartbeat{}).Where(&models.Heartbeat{UserID: userId})
 	query = r.queryAddTimeFilterBetween(query, from, to)
 	query = r.queryAddTimeSorting(query, false)
 	return query
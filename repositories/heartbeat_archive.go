@@ -0,0 +1,317 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/duke-git/lancet/v2/maputil"
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/utils"
+	"gorm.io/gorm"
+)
+
+// number of archives to decompress at once when aggregating archived heartbeats
+const archiveBatchSize = 12
+
+type HeartbeatArchiveRepository struct {
+	BaseRepository
+}
+
+func NewHeartbeatArchiveRepository(db *gorm.DB) *HeartbeatArchiveRepository {
+	return &HeartbeatArchiveRepository{BaseRepository: NewBaseRepository(db)}
+}
+
+// Archive moves all of the user's heartbeats within the given period from the heartbeats table into a new archive. Returns nil if there were no heartbeats to archive.
+func (r *HeartbeatArchiveRepository) Archive(user *models.User, from, to time.Time) (*models.HeartbeatArchive, error) {
+	var heartbeats []*models.Heartbeat
+	if err := r.db.
+		Where(&models.Heartbeat{UserID: user.ID}).
+		Where("time >= ?", models.CustomTime(from.Local())).
+		Where("time < ?", models.CustomTime(to.Local())).
+		Order("time asc").
+		Find(&heartbeats).Error; err != nil {
+		return nil, err
+	}
+	if len(heartbeats) == 0 {
+		return nil, nil
+	}
+
+	// heartbeats are deleted by id, so any inserted in the meantime will simply be archived next time
+	ids := slice.Map(heartbeats, func(_ int, h *models.Heartbeat) uint64 { return h.ID })
+
+	// the same heartbeats might have been archived before already and then inserted again later on (e.g. by re-running a data import)
+	archived, err := r.getHashesWithin(user, from, to)
+	if err != nil {
+		return nil, err
+	}
+	heartbeats = slice.Filter(heartbeats, func(_ int, h *models.Heartbeat) bool {
+		return h.Hash == "" || !archived[h.Hash]
+	})
+
+	var archive *models.HeartbeatArchive
+	if len(heartbeats) > 0 {
+		if archive, err = models.NewHeartbeatArchive(user.ID, from, to, heartbeats); err != nil {
+			return nil, err
+		}
+	}
+
+	// only write within the transaction, as sqlite can't upgrade a reading transaction while another connection is writing
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if archive != nil {
+			if err := tx.Create(archive).Error; err != nil {
+				return err
+			}
+		}
+		for _, chunk := range slice.Chunk(ids, chunkSize) {
+			if err := tx.Where("id IN ?", chunk).Delete(&models.Heartbeat{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// GetByUserWithin returns all of the user's archives that overlap with the given period, ordered by time
+func (r *HeartbeatArchiveRepository) GetByUserWithin(user *models.User, from, to time.Time) ([]*models.HeartbeatArchive, error) {
+	var archives []*models.HeartbeatArchive
+	if err := r.db.
+		Where(&models.HeartbeatArchive{UserID: user.ID}).
+		Where("from_time < ?", models.CustomTime(to.Local())).
+		Where("to_time > ?", models.CustomTime(from.Local())).
+		Order("from_time asc, id asc").
+		Find(&archives).Error; err != nil {
+		return nil, err
+	}
+	return archives, nil
+}
+
+// GetRangeByUser returns the time of the first and last archived heartbeat of the user or nil, if none were archived
+func (r *HeartbeatArchiveRepository) GetRangeByUser(user *models.User) (*models.RangeByUser, error) {
+	var first, last []*models.HeartbeatArchive
+	if err := r.db.
+		Select("first_time").
+		Where(&models.HeartbeatArchive{UserID: user.ID}).
+		Order("first_time asc").
+		Limit(1).
+		Find(&first).Error; err != nil || len(first) == 0 {
+		return nil, err
+	}
+	if err := r.db.
+		Select("last_time").
+		Where(&models.HeartbeatArchive{UserID: user.ID}).
+		Order("last_time desc").
+		Limit(1).
+		Find(&last).Error; err != nil || len(last) == 0 {
+		return nil, err
+	}
+	return &models.RangeByUser{User: user.ID, First: first[0].FirstTime, Last: last[0].LastTime}, nil
+}
+
+// GetFirstAll returns the time of every user's first archived heartbeat
+func (r *HeartbeatArchiveRepository) GetFirstAll() ([]*models.TimeByUser, error) {
+	var result []*models.TimeByUser
+	err := r.db.
+		Model(&models.HeartbeatArchive{}).
+		Select(utils.QuoteSql(r.db, "user_id as %s, min(first_time) as %s", "user", "time")).
+		Group("user_id").
+		Scan(&result).Error
+	return result, err
+}
+
+// GetArchivedUntil returns the end of the latest archived period of the user or zero time, if nothing was archived, yet
+func (r *HeartbeatArchiveRepository) GetArchivedUntil(user *models.User) (time.Time, error) {
+	var archives []*models.HeartbeatArchive
+	if err := r.db.
+		Select("to_time").
+		Where(&models.HeartbeatArchive{UserID: user.ID}).
+		Order("to_time desc").
+		Limit(1).
+		Find(&archives).Error; err != nil || len(archives) == 0 {
+		return time.Time{}, err
+	}
+	return archives[0].ToTime.T(), nil
+}
+
+func (r *HeartbeatArchiveRepository) CountByUser(user *models.User) (int64, error) {
+	var count int64
+	if err := r.db.
+		Model(&models.HeartbeatArchive{}).
+		Select("coalesce(sum(num_heartbeats), 0)").
+		Where(&models.HeartbeatArchive{UserID: user.ID}).
+		Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *HeartbeatArchiveRepository) CountByUsers(users []*models.User) ([]*models.CountByUser, error) {
+	var counts []*models.CountByUser
+
+	userIds := slice.Map(users, func(_ int, u *models.User) string { return u.ID })
+	if len(userIds) == 0 {
+		return counts, nil
+	}
+
+	if err := r.db.
+		Model(&models.HeartbeatArchive{}).
+		Select(utils.QuoteSql(r.db, "user_id as %s, sum(num_heartbeats) as %s", "user", "count")).
+		Where("user_id in ?", userIds).
+		Group("user_id").
+		Find(&counts).Error; err != nil {
+		return counts, err
+	}
+
+	return counts, nil
+}
+
+// GetEntitySetByUser returns all distinct values of the given entity type among the user's archived heartbeats
+func (r *HeartbeatArchiveRepository) GetEntitySetByUser(entityType uint8, userId string) ([]string, error) {
+	values := make(map[string]bool)
+	err := r.forEachHeartbeat(r.db.Where(&models.HeartbeatArchive{UserID: userId}), func(h *models.Heartbeat) {
+		if key := h.GetKey(entityType); key != models.UnknownSummaryKey {
+			values[key] = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return maputil.Keys(values), nil
+}
+
+// GetUserProjectStats aggregates the user's archived heartbeats within the given period per project, equivalent to HeartbeatRepository.GetUserProjectStats
+func (r *HeartbeatArchiveRepository) GetUserProjectStats(user *models.User, from, to time.Time) ([]*models.ProjectStats, error) {
+	projectStats := make(map[string]*models.ProjectStats)
+	languageCounts := make(map[string]map[string]int64)
+
+	query := r.db.
+		Where(&models.HeartbeatArchive{UserID: user.ID}).
+		Where("to_time >= ?", models.CustomTime(from.Local())).
+		Where("from_time <= ?", models.CustomTime(to.Local()))
+
+	err := r.forEachHeartbeat(query, func(h *models.Heartbeat) {
+		if t := h.Time.T(); h.Project == "" || h.Language == "" || t.Before(from) || t.After(to) {
+			return
+		}
+
+		stats, ok := projectStats[h.Project]
+		if !ok {
+			stats = &models.ProjectStats{UserId: user.ID, Project: h.Project, First: h.Time, Last: h.Time}
+			projectStats[h.Project] = stats
+			languageCounts[h.Project] = make(map[string]int64)
+		}
+		stats.Count++
+		if h.Time.T().Before(stats.First.T()) {
+			stats.First = h.Time
+		}
+		if h.Time.T().After(stats.Last.T()) {
+			stats.Last = h.Time
+		}
+		languageCounts[h.Project][h.Language]++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.ProjectStats, 0, len(projectStats))
+	for project, stats := range projectStats {
+		var maxCount int64
+		for language, count := range languageCounts[project] {
+			if count > maxCount || (count == maxCount && language < stats.TopLanguage) {
+				stats.TopLanguage, maxCount = language, count
+			}
+		}
+		results = append(results, stats)
+	}
+	return results, nil
+}
+
+// GetUserAgentsByUser returns all distinct user agents among the user's archived heartbeats, equivalent to HeartbeatRepository.GetUserAgentsByUser
+func (r *HeartbeatArchiveRepository) GetUserAgentsByUser(user *models.User) ([]*models.UserAgent, error) {
+	userAgents := make(map[models.UserAgent]*models.UserAgent)
+	err := r.forEachHeartbeat(r.db.Where(&models.HeartbeatArchive{UserID: user.ID}), func(h *models.Heartbeat) {
+		if h.UserAgent == "" {
+			return
+		}
+
+		key := models.UserAgent{Value: h.UserAgent, Os: h.OperatingSystem, Editor: h.Editor, AIModel: h.AIModel}
+		ua, ok := userAgents[key]
+		if !ok {
+			ua = &models.UserAgent{Value: key.Value, Os: key.Os, Editor: key.Editor, AIModel: key.AIModel, FirstSeen: h.Time.T(), LastSeen: h.Time.T()}
+			userAgents[key] = ua
+		}
+		if h.Time.T().Before(ua.FirstSeen) {
+			ua.FirstSeen = h.Time.T()
+		}
+		if h.Time.T().After(ua.LastSeen) {
+			ua.LastSeen = h.Time.T()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return maputil.Values(userAgents), nil
+}
+
+func (r *HeartbeatArchiveRepository) DeleteBefore(t time.Time) error {
+	return r.db.
+		Where("to_time <= ?", models.CustomTime(t.Local())).
+		Delete(models.HeartbeatArchive{}).Error
+}
+
+func (r *HeartbeatArchiveRepository) DeleteByUser(user *models.User) error {
+	return r.db.
+		Where("user_id = ?", user.ID).
+		Delete(models.HeartbeatArchive{}).Error
+}
+
+func (r *HeartbeatArchiveRepository) DeleteByUserBefore(user *models.User, t time.Time) error {
+	return r.db.
+		Where("user_id = ?", user.ID).
+		Where("to_time <= ?", models.CustomTime(t.Local())).
+		Delete(models.HeartbeatArchive{}).Error
+}
+
+func (r *HeartbeatArchiveRepository) getHashesWithin(user *models.User, from, to time.Time) (map[string]bool, error) {
+	var archives []*models.HeartbeatArchive
+	if err := r.db.
+		Where(&models.HeartbeatArchive{UserID: user.ID}).
+		Where("from_time < ?", models.CustomTime(to.Local())).
+		Where("to_time > ?", models.CustomTime(from.Local())).
+		Find(&archives).Error; err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]bool)
+	for _, a := range archives {
+		heartbeats, err := a.Heartbeats()
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range heartbeats {
+			hashes[h.Hash] = true
+		}
+	}
+	return hashes, nil
+}
+
+// forEachHeartbeat decompresses the archives matched by the given query batch by batch and passes each of their heartbeats to the given function
+func (r *HeartbeatArchiveRepository) forEachHeartbeat(query *gorm.DB, f func(h *models.Heartbeat)) error {
+	var archives []*models.HeartbeatArchive
+	return query.FindInBatches(&archives, archiveBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, a := range archives {
+			heartbeats, err := a.Heartbeats()
+			if err != nil {
+				return err
+			}
+			for _, h := range heartbeats {
+				f(h)
+			}
+		}
+		return nil
+	}).Error
+}
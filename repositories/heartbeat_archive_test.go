@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/models"
+)
+
+func setupArchiveTestData(t *testing.T) (*HeartbeatArchiveRepository, *models.User, time.Time) {
+	db := setupTestDB(t, &models.User{}, &models.HeartbeatArchive{})
+	user := &models.User{ID: "user1"}
+	require.NoError(t, db.Create(user).Error)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	at := func(days int) models.CustomTime { return models.CustomTime(from.AddDate(0, 0, days)) }
+
+	for _, heartbeats := range [][]*models.Heartbeat{
+		{
+			{Project: "wakapi", Language: "Go", Editor: "vscode", OperatingSystem: "Linux", UserAgent: "ua1", Time: at(1)},
+			{Project: "wakapi", Language: "Go", Editor: "vscode", OperatingSystem: "Linux", UserAgent: "ua1", Time: at(2)},
+			{Project: "wakapi", Language: "HTML", Editor: "vscode", OperatingSystem: "Linux", UserAgent: "ua1", Time: at(3)},
+			{Project: "anchr", Language: "", Editor: "goland", OperatingSystem: "Linux", UserAgent: "ua2", Time: at(4)},
+		},
+		{
+			{Project: "wakapi", Language: "HTML", Editor: "vscode", OperatingSystem: "Linux", UserAgent: "ua1", Time: at(40)},
+			{Project: "anchr", Language: "Javascript", Editor: "goland", OperatingSystem: "Linux", UserAgent: "", Time: at(41)},
+		},
+	} {
+		archive, err := models.NewHeartbeatArchive(user.ID, heartbeats[0].Time.T(), heartbeats[len(heartbeats)-1].Time.T().Add(time.Second), heartbeats)
+		require.NoError(t, err)
+		require.NoError(t, db.Create(archive).Error)
+	}
+
+	return NewHeartbeatArchiveRepository(db), user, from
+}
+
+func TestHeartbeatArchiveRepository_GetEntitySetByUser(t *testing.T) {
+	sut, user, _ := setupArchiveTestData(t)
+
+	projects, err := sut.GetEntitySetByUser(models.SummaryProject, user.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"wakapi", "anchr"}, projects)
+
+	languages, err := sut.GetEntitySetByUser(models.SummaryLanguage, user.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Go", "HTML", "Javascript"}, languages)
+
+	projects, err = sut.GetEntitySetByUser(models.SummaryProject, "user2")
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+}
+
+func TestHeartbeatArchiveRepository_GetUserProjectStats(t *testing.T) {
+	sut, user, from := setupArchiveTestData(t)
+
+	stats, err := sut.GetUserProjectStats(user, time.Time{}, from.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Project > stats[j].Project })
+
+	assert.Equal(t, "wakapi", stats[0].Project)
+	assert.Equal(t, int64(4), stats[0].Count)
+	assert.Equal(t, "Go", stats[0].TopLanguage) // ties are resolved alphabetically
+	assert.Equal(t, from.AddDate(0, 0, 1), stats[0].First.T())
+	assert.Equal(t, from.AddDate(0, 0, 40), stats[0].Last.T())
+
+	assert.Equal(t, "anchr", stats[1].Project)
+	assert.Equal(t, int64(1), stats[1].Count) // heartbeats without language are not counted
+	assert.Equal(t, "Javascript", stats[1].TopLanguage)
+
+	stats, err = sut.GetUserProjectStats(user, from.AddDate(0, 0, 2), from.AddDate(0, 0, 3))
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].Count)
+}
+
+func TestHeartbeatArchiveRepository_GetUserAgentsByUser(t *testing.T) {
+	sut, user, from := setupArchiveTestData(t)
+
+	userAgents, err := sut.GetUserAgentsByUser(user)
+	require.NoError(t, err)
+	require.Len(t, userAgents, 2)
+	sort.Slice(userAgents, func(i, j int) bool { return userAgents[i].Value < userAgents[j].Value })
+
+	assert.Equal(t, "ua1", userAgents[0].Value)
+	assert.Equal(t, "vscode", userAgents[0].Editor)
+	assert.Equal(t, from.AddDate(0, 0, 1), userAgents[0].FirstSeen)
+	assert.Equal(t, from.AddDate(0, 0, 40), userAgents[0].LastSeen)
+	assert.Equal(t, "ua2", userAgents[1].Value)
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/models"
+)
+
+func TestHeartbeatRepository_GetAllWithinByFilters(t *testing.T) {
+	db := setupTestDB(t, &models.User{}, &models.Heartbeat{})
+	sut := NewHeartbeatRepository(db)
+
+	user := &models.User{ID: "user1"}
+	require.NoError(t, db.Create(user).Error)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	for i, h := range []*models.Heartbeat{
+		{Project: "wakapi", Language: "Go", Hash: "1"},
+		{Project: "wakapi", Language: "HTML", Hash: "2"},
+		{Project: "anchr", Language: "Go", Hash: "3"},
+		{Project: "", Language: "Go", Hash: "4"},
+		{Project: "legacy", Language: "Go", Hash: "5"},
+	} {
+		h.UserID = user.ID
+		h.Time = models.CustomTime(from.Add(time.Duration(i) * time.Minute))
+		require.NoError(t, db.Create(h).Error)
+	}
+	// heartbeats persisted before a column was introduced
+	require.NoError(t, db.Model(&models.Heartbeat{}).Where("hash = ?", "5").Update("language", nil).Error)
+
+	to := from.Add(time.Hour)
+	hashes := func(filters models.ColumnFilters) []string {
+		heartbeats, err := sut.GetAllWithinByFilters(from, to, user, filters)
+		require.NoError(t, err)
+		result := make([]string, len(heartbeats))
+		for i, h := range heartbeats {
+			result[i] = h.Hash
+		}
+		return result
+	}
+
+	filters := models.NewColumnFilters()
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4", "5"}, hashes(filters))
+
+	filters.Included["project"] = []string{"wakapi", "-"}
+	assert.ElementsMatch(t, []string{"1", "2", "4"}, hashes(filters))
+
+	filters.Excluded["language"] = []string{"HTML"}
+	assert.ElementsMatch(t, []string{"1", "4"}, hashes(filters))
+
+	filters = models.NewColumnFilters()
+	filters.Excluded["language"] = []string{"HTML"}
+	filters.Excluded["project"] = []string{"anchr", "-"}
+	assert.ElementsMatch(t, []string{"1", "5"}, hashes(filters))
+}
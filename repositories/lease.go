@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/muety/wakapi/models"
+)
+
+type LeaseRepository struct {
+	BaseRepository
+}
+
+func NewLeaseRepository(db *gorm.DB) *LeaseRepository {
+	return &LeaseRepository{BaseRepository: NewBaseRepository(db)}
+}
+
+func (r *LeaseRepository) GetByName(name string) (*models.Lease, error) {
+	lease := &models.Lease{}
+	if err := r.db.Where(&models.Lease{Name: name}).First(lease).Error; err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// Acquire attempts to claim (or renew) the lease of the given name for the given owner and returns whether the owner holds the lease afterward.
+// Only uses plain updates and inserts, which are atomic on every supported dialect, instead of vendor-specific locking mechanisms.
+func (r *LeaseRepository) Acquire(name, owner string, ttl time.Duration) (bool, error) {
+	now := models.CustomTime(time.Now())
+	expiresAt := models.CustomTime(now.T().Add(ttl))
+
+	// take over existing lease if either already owned or expired
+	if err := r.db.
+		Model(&models.Lease{}).
+		Where("name = ?", name).
+		Where("(owner = ? OR expires_at < ?)", owner, now).
+		Updates(map[string]interface{}{"owner": owner, "expires_at": expiresAt}).Error; err != nil {
+		return false, err
+	}
+
+	// create lease if not existing, yet
+	if err := r.db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Lease{Name: name, Owner: owner, ExpiresAt: expiresAt}).Error; err != nil {
+		return false, err
+	}
+
+	// affected rows are not reliable across dialects (e.g. mysql won't count unchanged rows), so check actual owner instead
+	lease, err := r.GetByName(name)
+	if err != nil {
+		return false, err
+	}
+	return lease.Owner == owner, nil
+}
+
+func (r *LeaseRepository) Release(name, owner string) error {
+	return r.db.
+		Where("name = ?", name).
+		Where("owner = ?", owner).
+		Delete(models.Lease{}).Error
+}
+
+func (r *LeaseRepository) DeleteExpired() (int64, error) {
+	result := r.db.
+		Where("expires_at < ?", models.CustomTime(time.Now())).
+		Delete(models.Lease{})
+	return result.RowsAffected, result.Error
+}
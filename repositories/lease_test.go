@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/models"
+)
+
+func TestLeaseRepository_Acquire(t *testing.T) {
+	sut := NewLeaseRepository(setupTestDB(t, &models.Lease{}))
+
+	// acquire
+	ok, err := sut.Acquire("scheduler", "instance1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	lease, err := sut.GetByName("scheduler")
+	require.NoError(t, err)
+	assert.Equal(t, "instance1", lease.Owner)
+	assert.False(t, lease.IsExpired())
+	expiresAt := lease.ExpiresAt.T()
+
+	// renew by same holder
+	time.Sleep(10 * time.Millisecond)
+	ok, err = sut.Acquire("scheduler", "instance1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	lease, err = sut.GetByName("scheduler")
+	require.NoError(t, err)
+	assert.True(t, lease.ExpiresAt.T().After(expiresAt))
+
+	// rejected for second holder
+	ok, err = sut.Acquire("scheduler", "instance2", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	lease, err = sut.GetByName("scheduler")
+	require.NoError(t, err)
+	assert.Equal(t, "instance1", lease.Owner)
+
+	// other leases are independent
+	ok, err = sut.Acquire("aggregation", "instance2", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestLeaseRepository_Acquire_TakeOverExpired(t *testing.T) {
+	sut := NewLeaseRepository(setupTestDB(t, &models.Lease{}))
+
+	ok, err := sut.Acquire("scheduler", "instance1", -time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = sut.Acquire("scheduler", "instance2", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	lease, err := sut.GetByName("scheduler")
+	require.NoError(t, err)
+	assert.Equal(t, "instance2", lease.Owner)
+	assert.False(t, lease.IsExpired())
+
+	ok, err = sut.Acquire("scheduler", "instance1", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestLeaseRepository_ReleaseAndDeleteExpired(t *testing.T) {
+	sut := NewLeaseRepository(setupTestDB(t, &models.Lease{}))
+
+	_, err := sut.Acquire("scheduler", "instance1", time.Minute)
+	require.NoError(t, err)
+	_, err = sut.Acquire("aggregation", "instance1", -time.Minute)
+	require.NoError(t, err)
+
+	// release by non-holder is a no-op
+	require.NoError(t, sut.Release("scheduler", "instance2"))
+	_, err = sut.GetByName("scheduler")
+	require.NoError(t, err)
+
+	n, err := sut.DeleteExpired()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = sut.GetByName("aggregation")
+	assert.Error(t, err)
+
+	require.NoError(t, sut.Release("scheduler", "instance1"))
+	_, err = sut.GetByName("scheduler")
+	assert.Error(t, err)
+}
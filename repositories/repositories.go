@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"gorm.io/gorm"
 
+	"github.com/muety/wakapi/lib/backup"
 	"github.com/muety/wakapi/models"
 )
 
@@ -33,15 +36,15 @@ type IHeartbeatRepository interface {
 	InsertBatch([]*models.Heartbeat) error
 	GetAll() ([]*models.Heartbeat, error)
 	GetWithin(time.Time, time.Time, *models.User) ([]*models.Heartbeat, error)
-	GetAllWithinByFilters(time.Time, time.Time, *models.User, map[string][]string) ([]*models.Heartbeat, error)
-	GetLatestByFilters(*models.User, map[string][]string) (*models.Heartbeat, error)
+	GetAllWithinByFilters(time.Time, time.Time, *models.User, models.ColumnFilters) ([]*models.Heartbeat, error)
+	GetLatestByFilters(*models.User, models.ColumnFilters) (*models.Heartbeat, error)
 	GetFirstAll() ([]*models.TimeByUser, error)
 	GetLastAll() ([]*models.TimeByUser, error)
 	GetRangeByUser(*models.User) (*models.RangeByUser, error)
 	GetLatestByUser(*models.User) (*models.Heartbeat, error)
 	GetLatestByOriginAndUser(string, *models.User) (*models.Heartbeat, error)
-	StreamWithin(time.Time, time.Time, *models.User) (chan *models.Heartbeat, error)
-	StreamWithinByFilters(time.Time, time.Time, *models.User, map[string][]string) (chan *models.Heartbeat, error)
+	StreamWithin(context.Context, time.Time, time.Time, *models.User) (chan *models.Heartbeat, error)
+	StreamWithinByFilters(time.Time, time.Time, *models.User, models.ColumnFilters) (chan *models.Heartbeat, error)
 	StreamWithinBatched(time.Time, time.Time, *models.User, int) (chan []*models.Heartbeat, error)
 	Count(bool) (int64, error)
 	CountByUser(*models.User) (int64, error)
@@ -54,22 +57,50 @@ type IHeartbeatRepository interface {
 	GetUserAgentsByUser(user *models.User) ([]*models.UserAgent, error)
 }
 
+type IHeartbeatArchiveRepository interface {
+	IBaseRepository
+	Archive(*models.User, time.Time, time.Time) (*models.HeartbeatArchive, error)
+	GetByUserWithin(*models.User, time.Time, time.Time) ([]*models.HeartbeatArchive, error)
+	GetRangeByUser(*models.User) (*models.RangeByUser, error)
+	GetFirstAll() ([]*models.TimeByUser, error)
+	GetArchivedUntil(*models.User) (time.Time, error)
+	CountByUser(*models.User) (int64, error)
+	CountByUsers([]*models.User) ([]*models.CountByUser, error)
+	GetEntitySetByUser(uint8, string) ([]string, error)
+	GetUserProjectStats(*models.User, time.Time, time.Time) ([]*models.ProjectStats, error)
+	GetUserAgentsByUser(*models.User) ([]*models.UserAgent, error)
+	DeleteBefore(time.Time) error
+	DeleteByUser(*models.User) error
+	DeleteByUserBefore(*models.User, time.Time) error
+}
+
 type IDurationRepository interface {
 	IBaseRepository
 	InsertBatch([]*models.Duration) error
 	GetAll() ([]*models.Duration, error)
 	GetAllWithin(time.Time, time.Time, *models.User) ([]*models.Duration, error)
-	GetAllWithinByFilters(time.Time, time.Time, *models.User, map[string][]string) ([]*models.Duration, error)
+	GetAllWithinByFilters(context.Context, time.Time, time.Time, *models.User, models.ColumnFilters) ([]*models.Duration, error)
 	StreamAllBatched(int) (chan []*models.Duration, error)
 	StreamByUserBatched(*models.User, int) (chan []*models.Duration, error)
 	GetLatestByUser(*models.User) (*models.Duration, error)
 	DeleteByUser(*models.User) error
 	DeleteByUserBefore(*models.User, time.Time) error
+	DeleteByUserAfter(*models.User, time.Time) error
+}
+
+type IBackupRepository interface {
+	IBaseRepository
+	Create(io.Writer, string) (*backup.Manifest, error)
+	Restore(io.Reader, bool) (*backup.Manifest, error)
 }
 
 type IDiagnosticsRepository interface {
 	IBaseRepository
+	GetAll(int) ([]*models.Diagnostics, error)
+	GetByUser(string, int) ([]*models.Diagnostics, error)
 	Insert(diagnostics *models.Diagnostics) (*models.Diagnostics, error)
+	DeleteByUser(string) error
+	DeleteBefore(time.Time) (int64, error)
 }
 
 type IKeyValueRepository interface {
@@ -103,14 +134,47 @@ type IProjectLabelRepository interface {
 	Delete(uint) error
 }
 
+type IDayOffRepository interface {
+	IBaseRepository
+	GetById(uint) (*models.DayOff, error)
+	GetByUser(string) ([]*models.DayOff, error)
+	Insert(*models.DayOff) (*models.DayOff, error)
+	InsertBatch([]*models.DayOff) error
+	Delete(uint) error
+	DeleteByUserAndSource(string, string) error
+	ReplaceByUserAndSource(string, string, []*models.DayOff) error
+}
+
+type IDashboardRepository interface {
+	IBaseRepository
+	GetById(uint) (*models.Dashboard, error)
+	GetByShareToken(string) (*models.Dashboard, error)
+	GetByUser(string) ([]*models.Dashboard, error)
+	Insert(*models.Dashboard) (*models.Dashboard, error)
+	Update(*models.Dashboard) (*models.Dashboard, error)
+	Delete(uint) error
+	InsertWidget(*models.DashboardWidget) (*models.DashboardWidget, error)
+	UpdateWidgetPositions([]*models.DashboardWidget) error
+	DeleteWidget(uint) error
+}
+
+type IFilterPresetRepository interface {
+	IBaseRepository
+	GetById(uint) (*models.FilterPreset, error)
+	GetByUser(string) ([]*models.FilterPreset, error)
+	Insert(*models.FilterPreset) (*models.FilterPreset, error)
+	Delete(uint) error
+}
+
 type ISummaryRepository interface {
 	IBaseRepository
 	Insert(*models.Summary) error
 	InsertWithRetry(*models.Summary) error
 	GetAll() ([]*models.Summary, error)
-	GetByUserWithin(*models.User, time.Time, time.Time) ([]*models.Summary, error)
+	GetByUserWithin(context.Context, *models.User, time.Time, time.Time) ([]*models.Summary, error)
 	GetLastByUser() ([]*models.TimeByUser, error)
 	GetLastBySingleUser(string) (time.Time, error)
+	GetProjectContributors([]string, string, int) ([]*models.ProjectContributor, error)
 	DeleteByUser(string) error
 	DeleteByUserBefore(string, time.Time) error
 	DeleteByUserAfter(string, time.Time) error
@@ -164,3 +228,40 @@ type IWebAuthnRepository interface {
 	Update(*models.WebAuthnCredential) error
 	Delete(*models.WebAuthnCredential) error
 }
+
+type ISessionRepository interface {
+	IBaseRepository
+	GetById(string) (*models.Session, error)
+	GetByUser(string) ([]*models.Session, error)
+	Insert(*models.Session) (*models.Session, error)
+	Touch(*models.Session) error
+	Delete(string) error
+	DeleteByUser(string) error
+	DeleteByUserExcept(string, string) error
+	DeleteExpired() (int64, error)
+}
+
+type ILeaseRepository interface {
+	IBaseRepository
+	GetByName(string) (*models.Lease, error)
+	Acquire(string, string, time.Duration) (bool, error)
+	Release(string, string) error
+	DeleteExpired() (int64, error)
+}
+
+type IEventOutboxRepository interface {
+	IBaseRepository
+	Insert(*models.EventOutboxEntry) error
+	GetAfter(uint64, int) ([]*models.EventOutboxEntry, error)
+	GetLatestId() (uint64, error)
+	DeleteBefore(time.Time) (int64, error)
+}
+
+type ISecurityEventRepository interface {
+	IBaseRepository
+	GetByUser(string, int) ([]*models.SecurityEvent, error)
+	CountByUserAndType(string, models.SecurityEventType) (int64, error)
+	ExistsByUserAndTypeAndIp(string, models.SecurityEventType, string) (bool, error)
+	Insert(*models.SecurityEvent) (*models.SecurityEvent, error)
+	DeleteBefore(time.Time) (int64, error)
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/muety/wakapi/models"
+)
+
+type SecurityEventRepository struct {
+	BaseRepository
+}
+
+func NewSecurityEventRepository(db *gorm.DB) *SecurityEventRepository {
+	return &SecurityEventRepository{BaseRepository: NewBaseRepository(db)}
+}
+
+func (r *SecurityEventRepository) GetByUser(userId string, limit int) ([]*models.SecurityEvent, error) {
+	var events []*models.SecurityEvent
+	if err := r.db.
+		Where(&models.SecurityEvent{UserID: userId}).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *SecurityEventRepository) CountByUserAndType(userId string, eventType models.SecurityEventType) (int64, error) {
+	var count int64
+	if err := r.db.
+		Model(&models.SecurityEvent{}).
+		Where(&models.SecurityEvent{UserID: userId, Type: eventType}).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *SecurityEventRepository) ExistsByUserAndTypeAndIp(userId string, eventType models.SecurityEventType, ip string) (bool, error) {
+	var count int64
+	if err := r.db.
+		Model(&models.SecurityEvent{}).
+		Where(&models.SecurityEvent{UserID: userId, Type: eventType, IP: ip}).
+		Limit(1).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *SecurityEventRepository) Insert(event *models.SecurityEvent) (*models.SecurityEvent, error) {
+	if err := r.db.Create(event).Error; err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (r *SecurityEventRepository) DeleteBefore(t time.Time) (int64, error) {
+	result := r.db.
+		Where("created_at < ?", models.CustomTime(t.Local())).
+		Delete(models.SecurityEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/models"
+)
+
+func TestSecurityEventRepository_GetByUser(t *testing.T) {
+	sut := NewSecurityEventRepository(setupTestDB(t, &models.User{}, &models.SecurityEvent{}))
+
+	now := time.Now()
+	for i, e := range []*models.SecurityEvent{
+		{UserID: "user1", Type: models.SecurityEventPasswordChange, CreatedAt: models.CustomTime(now.Add(-2 * time.Hour))},
+		{UserID: "user1", Type: models.SecurityEventApiKeyCreate, CreatedAt: models.CustomTime(now.Add(-1 * time.Hour))},
+		{UserID: "user1", Type: models.SecurityEventApiKeyDelete, CreatedAt: models.CustomTime(now)},
+		{UserID: "user2", Type: models.SecurityEventPasswordChange, CreatedAt: models.CustomTime(now)},
+	} {
+		_, err := sut.Insert(e)
+		require.NoError(t, err, i)
+	}
+
+	result, err := sut.GetByUser("user1", 2)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, models.SecurityEventApiKeyDelete, result[0].Type) // most recent first
+	assert.Equal(t, models.SecurityEventApiKeyCreate, result[1].Type)
+}
+
+func TestSecurityEventRepository_CountAndExists(t *testing.T) {
+	sut := NewSecurityEventRepository(setupTestDB(t, &models.User{}, &models.SecurityEvent{}))
+
+	for _, e := range []*models.SecurityEvent{
+		{UserID: "user1", Type: models.SecurityEventLoginNewIp, IP: "127.0.0.1"},
+		{UserID: "user1", Type: models.SecurityEventLoginNewIp, IP: "127.0.0.2"},
+		{UserID: "user1", Type: models.SecurityEventPasswordChange, IP: "127.0.0.3"},
+		{UserID: "user2", Type: models.SecurityEventLoginNewIp, IP: "127.0.0.3"},
+	} {
+		_, err := sut.Insert(e)
+		require.NoError(t, err)
+	}
+
+	count, err := sut.CountByUserAndType("user1", models.SecurityEventLoginNewIp)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	exists, err := sut.ExistsByUserAndTypeAndIp("user1", models.SecurityEventLoginNewIp, "127.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = sut.ExistsByUserAndTypeAndIp("user1", models.SecurityEventLoginNewIp, "127.0.0.3")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestSecurityEventRepository_DeleteBefore(t *testing.T) {
+	sut := NewSecurityEventRepository(setupTestDB(t, &models.User{}, &models.SecurityEvent{}))
+
+	now := time.Now()
+	for _, e := range []*models.SecurityEvent{
+		{UserID: "user1", Type: models.SecurityEventPasswordChange, CreatedAt: models.CustomTime(now.AddDate(0, -13, 0))},
+		{UserID: "user2", Type: models.SecurityEventPasswordChange, CreatedAt: models.CustomTime(now.AddDate(0, -12, -1))},
+		{UserID: "user1", Type: models.SecurityEventPasswordChange, CreatedAt: models.CustomTime(now)},
+	} {
+		_, err := sut.Insert(e)
+		require.NoError(t, err)
+	}
+
+	n, err := sut.DeleteBefore(now.AddDate(0, -12, 0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	result, err := sut.GetByUser("user1", 10)
+	require.NoError(t, err)
+	assert.Len(t, result, 1)
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/muety/wakapi/models"
+)
+
+type SessionRepository struct {
+	BaseRepository
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{BaseRepository: NewBaseRepository(db)}
+}
+
+func (r *SessionRepository) GetById(id string) (*models.Session, error) {
+	session := &models.Session{}
+	if err := r.db.Where(&models.Session{ID: id}).First(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r *SessionRepository) GetByUser(userId string) ([]*models.Session, error) {
+	if userId == "" {
+		return []*models.Session{}, nil
+	}
+	var sessions []*models.Session
+	if err := r.db.
+		Where(&models.Session{UserID: userId}).
+		Where("expires_at > ?", models.CustomTime(time.Now())).
+		Order("last_seen_at desc").
+		Find(&sessions).Error; err != nil {
+		return sessions, err
+	}
+	return sessions, nil
+}
+
+func (r *SessionRepository) Insert(session *models.Session) (*models.Session, error) {
+	if !session.IsValid() {
+		return nil, errors.New("invalid session")
+	}
+	if err := r.db.Create(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r *SessionRepository) Touch(session *models.Session) error {
+	return r.db.Model(session).Update("last_seen_at", session.LastSeenAt).Error
+}
+
+func (r *SessionRepository) Delete(id string) error {
+	return r.db.
+		Where("id = ?", id).
+		Delete(models.Session{}).Error
+}
+
+func (r *SessionRepository) DeleteByUser(userId string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Delete(models.Session{}).Error
+}
+
+func (r *SessionRepository) DeleteByUserExcept(userId, id string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("id != ?", id).
+		Delete(models.Session{}).Error
+}
+
+func (r *SessionRepository) DeleteExpired() (int64, error) {
+	result := r.db.
+		Where("expires_at <= ?", models.CustomTime(time.Now())).
+		Delete(models.Session{})
+	return result.RowsAffected, result.Error
+}
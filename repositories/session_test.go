@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/models"
+)
+
+func newTestSession(id, userId string, expiresIn time.Duration, lastSeen time.Time) *models.Session {
+	return &models.Session{
+		ID:         id,
+		UserID:     userId,
+		LastSeenAt: models.CustomTime(lastSeen),
+		ExpiresAt:  models.CustomTime(time.Now().Add(expiresIn)),
+	}
+}
+
+func TestSessionRepository_InsertAndGet(t *testing.T) {
+	sut := NewSessionRepository(setupTestDB(t, &models.User{}, &models.Session{}))
+
+	_, err := sut.Insert(&models.Session{ID: "s1"})
+	assert.Error(t, err) // missing user
+
+	_, err = sut.Insert(newTestSession("s1", "user1", time.Hour, time.Now()))
+	require.NoError(t, err)
+
+	result, err := sut.GetById("s1")
+	require.NoError(t, err)
+	assert.Equal(t, "user1", result.UserID)
+
+	_, err = sut.GetById("s2")
+	assert.Error(t, err)
+}
+
+func TestSessionRepository_GetByUser(t *testing.T) {
+	sut := NewSessionRepository(setupTestDB(t, &models.User{}, &models.Session{}))
+
+	now := time.Now()
+	for _, s := range []*models.Session{
+		newTestSession("s1", "user1", time.Hour, now.Add(-2*time.Hour)),
+		newTestSession("s2", "user1", time.Hour, now.Add(-1*time.Hour)),
+		newTestSession("s3", "user1", -time.Hour, now), // expired
+		newTestSession("s4", "user2", time.Hour, now),
+	} {
+		_, err := sut.Insert(s)
+		require.NoError(t, err)
+	}
+
+	result, err := sut.GetByUser("user1")
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "s2", result[0].ID) // most recently seen first
+	assert.Equal(t, "s1", result[1].ID)
+
+	result, err = sut.GetByUser("")
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestSessionRepository_Touch(t *testing.T) {
+	sut := NewSessionRepository(setupTestDB(t, &models.User{}, &models.Session{}))
+
+	session := newTestSession("s1", "user1", time.Hour, time.Now().Add(-time.Hour))
+	_, err := sut.Insert(session)
+	require.NoError(t, err)
+
+	session.LastSeenAt = models.CustomTime(time.Now())
+	require.NoError(t, sut.Touch(session))
+
+	result, err := sut.GetById("s1")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), result.LastSeenAt.T(), time.Minute)
+}
+
+func TestSessionRepository_DeleteByUserExcept(t *testing.T) {
+	sut := NewSessionRepository(setupTestDB(t, &models.User{}, &models.Session{}))
+
+	for _, s := range []*models.Session{
+		newTestSession("s1", "user1", time.Hour, time.Now()),
+		newTestSession("s2", "user1", time.Hour, time.Now()),
+		newTestSession("s3", "user1", time.Hour, time.Now()),
+		newTestSession("s4", "user2", time.Hour, time.Now()),
+	} {
+		_, err := sut.Insert(s)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, sut.DeleteByUserExcept("user1", "s2"))
+
+	result, err := sut.GetByUser("user1")
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "s2", result[0].ID)
+
+	result, err = sut.GetByUser("user2")
+	require.NoError(t, err)
+	assert.Len(t, result, 1) // other users unaffected
+
+	require.NoError(t, sut.DeleteByUser("user1"))
+	result, err = sut.GetByUser("user1")
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestSessionRepository_DeleteExpired(t *testing.T) {
+	sut := NewSessionRepository(setupTestDB(t, &models.User{}, &models.Session{}))
+
+	for _, s := range []*models.Session{
+		newTestSession("s1", "user1", time.Hour, time.Now()),
+		newTestSession("s2", "user1", -time.Minute, time.Now()),
+		newTestSession("s3", "user2", -time.Hour, time.Now()),
+	} {
+		_, err := sut.Insert(s)
+		require.NoError(t, err)
+	}
+
+	n, err := sut.DeleteExpired()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	_, err = sut.GetById("s1")
+	assert.NoError(t, err)
+	_, err = sut.GetById("s2")
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"strings"
@@ -30,7 +31,7 @@ func (r *SummaryRepository) GetAll() ([]*models.Summary, error) {
 		return nil, err
 	}
 
-	if err := r.populateItems(summaries, []clause.Interface{}); err != nil {
+	if err := r.populateItems(context.Background(), summaries, []clause.Interface{}); err != nil {
 		return nil, err
 	}
 
@@ -87,6 +88,11 @@ func (r *SummaryRepository) Insert(summary *models.Summary) error {
 			itemsToCreate = append(itemsToCreate, item)
 		}
 
+		for _, item := range summary.AiModels {
+			item.SummaryID = summary.ID
+			itemsToCreate = append(itemsToCreate, item)
+		}
+
 		if len(itemsToCreate) > 0 {
 			if err := tx.Create(itemsToCreate).Error; err != nil {
 				return err
@@ -101,7 +107,7 @@ func (r *SummaryRepository) Insert(summary *models.Summary) error {
 	return nil
 }
 
-func (r *SummaryRepository) GetByUserWithin(user *models.User, from, to time.Time) ([]*models.Summary, error) {
+func (r *SummaryRepository) GetByUserWithin(ctx context.Context, user *models.User, from, to time.Time) ([]*models.Summary, error) {
 	var summaries []*models.Summary
 
 	queryConditions := []clause.Interface{
@@ -110,7 +116,7 @@ func (r *SummaryRepository) GetByUserWithin(user *models.User, from, to time.Tim
 		clause.Where{Exprs: r.db.Statement.BuildCondition("to_time <= ?", to.Local())},
 	}
 
-	q := r.db.Model(&models.Summary{}).
+	q := r.replica(ctx).Model(&models.Summary{}).
 		Order("from_time asc")
 
 	for _, c := range queryConditions {
@@ -122,7 +128,7 @@ func (r *SummaryRepository) GetByUserWithin(user *models.User, from, to time.Tim
 		return nil, err
 	}
 
-	if err := r.populateItems(summaries, queryConditions); err != nil {
+	if err := r.populateItems(ctx, summaries, queryConditions); err != nil {
 		return nil, err
 	}
 
@@ -156,6 +162,34 @@ func (r *SummaryRepository) GetLastBySingleUser(userId string) (time.Time, error
 	return result.T(), nil
 }
 
+// GetProjectContributors returns the total coding time of the (at most limit) top users, who either share their projects publicly or are given by userId, on projects with any of the given names
+func (r *SummaryRepository) GetProjectContributors(projects []string, userId string, limit int) ([]*models.ProjectContributor, error) {
+	type userTotal struct {
+		UserId string
+		Total  int64 // in seconds, like summary items
+	}
+	var results []*userTotal
+
+	if err := r.replica(context.Background()).
+		Model(&models.SummaryItem{}).
+		Select("summaries.user_id as user_id, sum(summary_items.total) as total").
+		Joins("inner join summaries on summaries.id = summary_items.summary_id").
+		Joins("inner join users on users.id = summaries.user_id").
+		Where("summary_items.type = ?", models.SummaryProject).
+		Where(utils.QuoteSql(r.db, "summary_items.%s in ?", "key"), projects).
+		Where("users.share_projects = ? or users.id = ?", true, userId).
+		Group("summaries.user_id").
+		Order("total desc").
+		Limit(limit).
+		Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return slice.Map(results, func(_ int, t *userTotal) *models.ProjectContributor {
+		return &models.ProjectContributor{UserId: t.UserId, Total: time.Duration(t.Total) * time.Second}
+	}), nil
+}
+
 func (r *SummaryRepository) DeleteByUser(userId string) error {
 	if err := r.db.
 		Where("user_id = ?", userId).
@@ -186,14 +220,14 @@ func (r *SummaryRepository) DeleteByUserAfter(userId string, t time.Time) error
 }
 
 // inplace
-func (r *SummaryRepository) populateItems(summaries []*models.Summary, conditions []clause.Interface) error {
+func (r *SummaryRepository) populateItems(ctx context.Context, summaries []*models.Summary, conditions []clause.Interface) error {
 	var items []*models.SummaryItem
 
 	summaryMap := slice.GroupWith[*models.Summary, uint](summaries, func(s *models.Summary) uint {
 		return s.ID
 	})
 
-	q := r.db.Model(&models.SummaryItem{}).
+	q := r.replica(ctx).Model(&models.SummaryItem{}).
 		Select("summary_items.*").
 		Joins("cross join summaries").
 		Where("summary_items.summary_id = summaries.id").
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/muety/wakapi/models"
+)
+
+func TestSummaryRepository_GetProjectContributors(t *testing.T) {
+	db := setupTestDB(t, &models.User{}, &models.Summary{}, &models.SummaryItem{})
+	sut := NewSummaryRepository(db)
+
+	for _, u := range []*models.User{
+		{ID: "user1", ShareProjects: false},
+		{ID: "user2", ShareProjects: true},
+		{ID: "user3", ShareProjects: true},
+		{ID: "user4", ShareProjects: false},
+	} {
+		require.NoError(t, db.Create(u).Error)
+	}
+
+	now := time.Now()
+	for userId, items := range map[string][]*models.SummaryItem{
+		"user1": {{Type: models.SummaryProject, Key: "wakapi", Total: 600}, {Type: models.SummaryProject, Key: "wakapi-web", Total: 600}},
+		"user2": {{Type: models.SummaryProject, Key: "wakapi", Total: 3600}, {Type: models.SummaryLanguage, Key: "wakapi", Total: 3600}},
+		"user3": {{Type: models.SummaryProject, Key: "wakapi", Total: 1800}},
+		"user4": {{Type: models.SummaryProject, Key: "wakapi", Total: 7200}},
+	} {
+		require.NoError(t, sut.Insert(&models.Summary{
+			UserID:    userId,
+			FromTime:  models.CustomTime(now.Add(-1 * time.Hour)),
+			ToTime:    models.CustomTime(now),
+			Projects:  itemsOfType(items, models.SummaryProject),
+			Languages: itemsOfType(items, models.SummaryLanguage),
+		}))
+	}
+
+	result, err := sut.GetProjectContributors([]string{"wakapi", "wakapi-web"}, "user1", 10)
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+	assert.Equal(t, "user2", result[0].UserId)
+	assert.Equal(t, 3600*time.Second, result[0].Total)
+	assert.Equal(t, "user3", result[1].UserId)
+	assert.Equal(t, "user1", result[2].UserId)
+	assert.Equal(t, 1200*time.Second, result[2].Total)
+
+	result, err = sut.GetProjectContributors([]string{"wakapi", "wakapi-web"}, "user1", 2)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "user3", result[1].UserId)
+}
+
+func itemsOfType(items []*models.SummaryItem, entityType uint8) []*models.SummaryItem {
+	result := make([]*models.SummaryItem, 0, len(items))
+	for _, item := range items {
+		if item.Type == entityType {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -154,6 +154,7 @@ func (r *UserRepository) Update(user *models.User) (*models.User, error) {
 		"location":                 user.Location,
 		"start_of_week":            user.StartOfWeek,
 		"reports_weekly":           user.ReportsWeekly,
+		"reports_preset":           user.ReportsPreset,
 		"public_leaderboard":       user.PublicLeaderboard,
 		"subscribed_until":         user.SubscribedUntil,
 		"subscription_renewal":     user.SubscriptionRenewal,
@@ -1,23 +1,37 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"github.com/duke-git/lancet/v2/datetime"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	conf "github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/helpers"
 	"github.com/muety/wakapi/middlewares"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/services"
 	"github.com/muety/wakapi/utils"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
 var userWithExtPattern *regexp.Regexp
 
+var activityFilterParams = map[string]uint8{
+	"project":          models.SummaryProject,
+	"language":         models.SummaryLanguage,
+	"editor":           models.SummaryEditor,
+	"operating_system": models.SummaryOS,
+	"machine":          models.SummaryMachine,
+	"label":            models.SummaryLabel,
+}
+
 func init() {
-	userWithExtPattern = regexp.MustCompile(`\.svg$`)
+	userWithExtPattern = regexp.MustCompile(`\.(svg|json)$`)
 }
 
 type ActivityApiHandler struct {
@@ -45,6 +59,23 @@ func (h *ActivityApiHandler) RegisterRoutes(router chi.Router) {
 	router.Mount("/activity", r)
 }
 
+// @Summary Get activity chart
+// @Description Renders a heatmap of the user's coding activity, either per day (similar to GitHub's contribution timeline) or per hour of day and weekday. Served as svg or, when requested with .json extension, as raw data. Requires the user to share their activity chart, unless requesting own data. For other users' data, the time range is limited to the number of days they chose to share.
+// @ID get-activity-chart
+// @Tags activity
+// @Produce image/svg+xml,json
+// @Param userWithExt path string true "User ID to fetch data for, followed by either .svg or .json"
+// @Param type query string false "Chart type" Enums(daily, hourly)
+// @Param year query int false "Calendar year to show"
+// @Param interval query string false "Interval to show (defaults to last 12 months)"
+// @Param from query string false "Start date of a custom range (e.g. 2023-06-01)"
+// @Param to query string false "End date of a custom range (e.g. 2024-06-01)"
+// @Param project query string false "Project to filter by (prefix with '!' to exclude, e.g. '!wakapi')"
+// @Param language query string false "Language to filter by (prefix with '!' to exclude)"
+// @Param dark query bool false "Use dark theme"
+// @Param noattr query bool false "Hide attribution"
+// @Success 200 {object} models.ActivityData
+// @Router /activity/chart/{userWithExt} [get]
 func (h *ActivityApiHandler) GetActivityChart(w http.ResponseWriter, r *http.Request) {
 	authorizedUser := middlewares.GetPrincipal(r)
 
@@ -52,7 +83,7 @@ func (h *ActivityApiHandler) GetActivityChart(w http.ResponseWriter, r *http.Req
 	// https://github.com/go-chi/chi/issues/758
 	// https://github.com/go-chi/chi/pull/811
 	userWithExt := chi.URLParam(r, "userWithExt")
-	if !strings.HasSuffix(userWithExt, ".svg") {
+	if !userWithExtPattern.MatchString(userWithExt) {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write([]byte(conf.ErrNotFound))
 		return
@@ -63,17 +94,45 @@ func (h *ActivityApiHandler) GetActivityChart(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if authorizedUser == nil || authorizedUser.ID != requestedUser.ID {
-		if !requestedUser.ShareActivityChart {
-			w.WriteHeader(http.StatusForbidden)
+	isSameUser := authorizedUser != nil && authorizedUser.ID == requestedUser.ID
+	if !isSameUser && !requestedUser.ShareActivityChart {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if !isSameUser && requestedUser.ShareDataMaxDays == 0 {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("user did not opt in to share data"))
+		return
+	}
+
+	params, err := h.parseActivityParams(r, requestedUser, isSameUser)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !isSameUser && !h.canShareFilters(requestedUser, params.Filters) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("user did not opt in to share entity-specific data"))
+		return
+	}
+
+	skipCache := utils.IsNoCache(r, 6*time.Hour)
+
+	if strings.HasSuffix(userWithExt, ".json") {
+		data, err := h.activityService.GetData(r.Context(), requestedUser, params, skipCache)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			conf.Log().Request(r).Error("failed to get activity data for user", "userID", requestedUser.ID, "error", err)
 			return
 		}
+		w.Header().Set("Cache-Control", "max-age=21600") // 6 hours
+		helpers.RespondJSON(w, r, http.StatusOK, data)
+		return
 	}
 
-	paramDark := r.URL.Query().Has("dark") && r.URL.Query().Get("dark") != "false"
-	paramNoAttr := r.URL.Query().Has("noattr") && r.URL.Query().Get("noattr") != "false" // no attribution (no wakapi logo in bottom left corner)
-
-	chart, err := h.activityService.GetChart(requestedUser, models.IntervalPast12Months, paramDark, paramNoAttr, utils.IsNoCache(r, 6*time.Hour))
+	chart, err := h.activityService.GetChart(r.Context(), requestedUser, params, skipCache)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		conf.Log().Request(r).Error("failed to get activity chart for user", "userID", requestedUser.ID, "error", err)
@@ -85,3 +144,89 @@ func (h *ActivityApiHandler) GetActivityChart(w http.ResponseWriter, r *http.Req
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(chart))
 }
+
+func (h *ActivityApiHandler) parseActivityParams(r *http.Request, user *models.User, isSameUser bool) (*models.ActivityParams, error) {
+	query := r.URL.Query()
+
+	params := &models.ActivityParams{
+		Type:            query.Get("type"),
+		DarkTheme:       query.Has("dark") && query.Get("dark") != "false",
+		HideAttribution: query.Has("noattr") && query.Get("noattr") != "false", // no attribution (no wakapi logo in bottom left corner)
+		Filters:         &models.Filters{},
+	}
+
+	if params.Type == "" {
+		params.Type = models.ActivityChartDaily
+	}
+	if params.Type != models.ActivityChartDaily && params.Type != models.ActivityChartHourly {
+		return nil, errors.New("invalid chart type")
+	}
+
+	var err error
+	var isCustomRange bool
+	now := time.Now().In(user.TZ())
+
+	switch {
+	case query.Get("year") != "":
+		year, err := strconv.Atoi(query.Get("year"))
+		if err != nil || year < 1970 || year > now.Year() {
+			return nil, errors.New("invalid year")
+		}
+		params.From = time.Date(year, 1, 1, 0, 0, 0, 0, user.TZ())
+		params.To = params.From.AddDate(1, 0, 0)
+		if params.To.After(now) {
+			params.To = now
+		}
+	case query.Get("from") != "" || query.Get("to") != "":
+		isCustomRange = true
+		if params.From, err = helpers.ParseDateTimeTZ(query.Get("from"), user.TZ()); err != nil {
+			return nil, errors.New("missing or invalid 'from' parameter")
+		}
+		if params.To, err = helpers.ParseDateTimeTZ(query.Get("to"), user.TZ()); err != nil {
+			return nil, errors.New("missing or invalid 'to' parameter")
+		}
+	case query.Get("interval") != "":
+		if err, params.From, params.To = helpers.ResolveIntervalRawTZ(query.Get("interval"), user.TZ(), user.StartOfWeekDay()); err != nil {
+			return nil, err
+		}
+	default:
+		_, params.From, params.To = helpers.ResolveIntervalTZ(models.IntervalPast12Months, user.TZ(), user.StartOfWeekDay())
+	}
+
+	if params.To.After(now) {
+		params.To = now
+	}
+	// others only get to see as many days as the user opted in to share (negative value means no limit), starting from the first full day
+	if minStart := params.To.AddDate(0, 0, -user.ShareDataMaxDays); !isSameUser && user.ShareDataMaxDays > 0 && params.From.Before(minStart) {
+		params.From = datetime.BeginOfDay(minStart)
+		if params.From.Before(minStart) {
+			params.From = params.From.AddDate(0, 0, 1)
+		}
+	}
+	if !params.From.Before(params.To) {
+		return nil, errors.New("invalid time range")
+	}
+	if limit := datetime.BeginOfDay(params.To).AddDate(0, 0, -models.MaxActivityDays+1); params.From.Before(limit) {
+		if isCustomRange {
+			return nil, fmt.Errorf("time range must not exceed %d days", models.MaxActivityDays)
+		}
+		params.From = limit
+	}
+
+	for param, entity := range activityFilterParams {
+		if q := query.Get(param); q != "" {
+			params.Filters.WithQueryValue(entity, q)
+		}
+	}
+
+	return params, nil
+}
+
+func (h *ActivityApiHandler) canShareFilters(user *models.User, filters *models.Filters) bool {
+	return (!filters.HasType(models.SummaryProject) || user.ShareProjects) &&
+		(!filters.HasType(models.SummaryLanguage) || user.ShareLanguages) &&
+		(!filters.HasType(models.SummaryEditor) || user.ShareEditors) &&
+		(!filters.HasType(models.SummaryOS) || user.ShareOSs) &&
+		(!filters.HasType(models.SummaryMachine) || user.ShareMachines) &&
+		(!filters.HasType(models.SummaryLabel) || user.ShareLabels)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityApiHandler_parseActivityParams_ShareDataMaxDays(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: "user1", Location: "UTC", ShareDataMaxDays: 30}
+	sut := NewActivityApiHandler(nil, nil)
+
+	r := httptest.NewRequest("GET", "/api/activity/chart/user1.json?from=2023-01-01&to=2024-01-01", nil)
+
+	// own data is not limited
+	params, err := sut.parseActivityParams(r, user, true)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), params.From)
+
+	// others only see the last 30 days
+	params, err = sut.parseActivityParams(r, user, false)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 12, 2, 0, 0, 0, 0, time.UTC), params.From)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), params.To)
+
+	// negative value means no limit
+	user.ShareDataMaxDays = -1
+	params, err = sut.parseActivityParams(r, user, false)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), params.From)
+}
+
+func TestActivityApiHandler_parseActivityParams_MaxActivityDays(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: "user1", Location: "UTC", ShareDataMaxDays: -1}
+	sut := NewActivityApiHandler(nil, nil)
+
+	// custom ranges beyond the limit are rejected, also for the user themselves
+	r := httptest.NewRequest("GET", "/api/activity/chart/user1.json?from=1970-01-01&to=2024-01-01", nil)
+	_, err := sut.parseActivityParams(r, user, true)
+	assert.NotNil(t, err)
+	_, err = sut.parseActivityParams(r, user, false)
+	assert.NotNil(t, err)
+
+	// intervals are cut to the most recent days
+	r = httptest.NewRequest("GET", "/api/activity/chart/user1.json?interval=any", nil)
+	params, err := sut.parseActivityParams(r, user, false)
+	assert.Nil(t, err)
+	assert.True(t, params.To.Sub(params.From) <= models.MaxActivityDays*24*time.Hour)
+}
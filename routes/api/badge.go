@@ -1,11 +1,14 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"github.com/duke-git/lancet/v2/maputil"
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/go-chi/chi/v5"
 	conf "github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/middlewares"
 	"github.com/muety/wakapi/models"
 	v1 "github.com/muety/wakapi/models/compat/shields/v1"
@@ -13,30 +16,34 @@ import (
 	"github.com/muety/wakapi/services"
 	"github.com/muety/wakapi/utils"
 	"github.com/narqo/go-badge"
-	"github.com/patrickmn/go-cache"
 	"net/http"
 	"time"
 )
 
 type BadgeHandler struct {
-	config      *conf.Config
-	cache       *cache.Cache
-	userSrvc    services.IUserService
-	summarySrvc services.ISummaryService
+	config           *conf.Config
+	cache            cache.Cache
+	userSrvc         services.IUserService
+	summarySrvc      services.ISummaryService
+	badgeSrvc        services.IBadgeService
+	filterPresetSrvc services.IFilterPresetService
 }
 
-func NewBadgeHandler(userService services.IUserService, summaryService services.ISummaryService) *BadgeHandler {
+func NewBadgeHandler(userService services.IUserService, summaryService services.ISummaryService, badgeService services.IBadgeService, filterPresetService services.IFilterPresetService) *BadgeHandler {
 	return &BadgeHandler{
-		config:      conf.Get(),
-		cache:       cache.New(time.Hour, time.Hour),
-		userSrvc:    userService,
-		summarySrvc: summaryService,
+		config:           conf.Get(),
+		cache:            cache.New("badges", time.Hour, time.Hour),
+		userSrvc:         userService,
+		summarySrvc:      summaryService,
+		badgeSrvc:        badgeService,
+		filterPresetSrvc: filterPresetService,
 	}
 }
 
 func (h *BadgeHandler) RegisterRoutes(router chi.Router) {
 	r := chi.NewRouter()
 	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).WithOptionalFor("/api/badge/").Handler)
+	r.Get("/{user}/metric/{metric}", h.GetMetric)
 	r.Get("/{user}/*", h.Get)
 	router.Mount("/badge", r)
 }
@@ -49,7 +56,19 @@ func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	interval, filters, err := routeutils.GetBadgeParams(r.URL.Path, authorizedUser, user)
+	var interval *models.KeyedInterval
+	var filters *models.Filters
+	if presetSlug := r.URL.Query().Get(models.FilterPresetQueryParam); presetSlug != "" {
+		preset, presetErr := h.filterPresetSrvc.GetByUserAndSlug(user.ID, presetSlug)
+		if presetErr != nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("filter preset not found"))
+			return
+		}
+		interval, filters, err = routeutils.GetBadgePresetParams(r.URL.Path, preset, authorizedUser, user)
+	} else {
+		interval, filters, err = routeutils.GetBadgeParams(r.URL.Path, authorizedUser, user)
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusForbidden)
 		w.Write([]byte(err.Error()))
@@ -71,7 +90,7 @@ func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
 		Filters: filters,
 	}
 
-	summary, err, status := routeutils.LoadUserSummaryByParams(h.summarySrvc, params)
+	summary, err, status := routeutils.LoadUserSummaryByParams(r.Context(), h.summarySrvc, params)
 	if err != nil {
 		w.WriteHeader(status)
 		w.Write([]byte(err.Error()))
@@ -95,6 +114,128 @@ func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
 	respondSvg(w, badgeSvg)
 }
 
+// @Summary Get a custom badge
+// @Description Renders a badge for one of several metrics, either as native svg or as json compatible with [Shields.io](https://shields.io/endpoint). Requires public data access to be allowed, unless requesting own data.
+// @ID get-badge-metric
+// @Tags badges
+// @Produce json,image/svg+xml
+// @Param user path string true "User ID to fetch data for"
+// @Param metric path string true "Metric to display" Enums(total, top_language, language_share, streak, daily_average, last_active)
+// @Param interval query string false "Interval to aggregate data for (ignored for streak and last_active)" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time)
+// @Param filter query string false "Filter to apply (e.g. 'project:wakapi' or 'language:Go', prefix the value with '!' to exclude, e.g. 'language:!Markdown')"
+// @Param preset query string false "Slug of one of the user's filter presets to apply instead of filter (e.g. 'client-a')"
+// @Param language query string false "Language to compute the share for (required for language_share)"
+// @Param label query string false "Custom label"
+// @Param color query string false "Message color (hex code or shields.io color name)"
+// @Param label_color query string false "Label color (hex code or shields.io color name)"
+// @Param style query string false "Badge style" Enums(flat, plastic, for-the-badge)
+// @Param format query string false "Output format" Enums(svg, json)
+// @Success 200 {object} v1.BadgeData
+// @Router /badge/{user}/metric/{metric} [get]
+func (h *BadgeHandler) GetMetric(w http.ResponseWriter, r *http.Request) {
+	authorizedUser := middlewares.GetPrincipal(r)
+	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "user"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	metric, ok := models.ParseBadgeMetric(chi.URLParam(r, "metric"))
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid metric"))
+		return
+	}
+
+	query := r.URL.Query()
+	isSameUser := authorizedUser != nil && authorizedUser.ID == user.ID
+
+	intervalRaw := query.Get("interval")
+	if metric.IsTimeless() {
+		intervalRaw = (*models.IntervalToday)[0]
+	}
+
+	var interval *models.KeyedInterval
+	var filters *models.Filters
+	if presetSlug := query.Get(models.FilterPresetQueryParam); presetSlug != "" {
+		preset, presetErr := h.filterPresetSrvc.GetByUserAndSlug(user.ID, presetSlug)
+		if presetErr != nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("filter preset not found"))
+			return
+		}
+		interval, filters, err = routeutils.ResolveBadgePreset(preset, intervalRaw, authorizedUser, user)
+	} else {
+		interval, filters, err = routeutils.ResolveBadgeParams(intervalRaw, query.Get("filter"), authorizedUser, user)
+	}
+	if err == nil && metric.RequiresLanguages() && !user.ShareLanguages && !isSameUser {
+		err = errors.New("user did not opt in to share entity-specific data")
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	style := query.Get("style")
+	if style != "" && !utils.IsValidBadgeStyle(style) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid style"))
+		return
+	}
+
+	def := &models.BadgeDefinition{
+		Metric:     metric,
+		Interval:   interval,
+		Filters:    filters,
+		Language:   query.Get("language"),
+		Label:      query.Get("label"),
+		Color:      query.Get("color"),
+		LabelColor: query.Get("label_color"),
+		Style:      style,
+	}
+	if !isSameUser && user.ShareDataMaxDays > 0 {
+		def.MaxDays = user.ShareDataMaxDays
+	}
+	if metric == models.BadgeMetricLanguageShare && def.Language == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("missing language"))
+		return
+	}
+
+	cacheKey := fmt.Sprintf("metric_%s_%v_%s", user.ID, isSameUser, r.URL.String())
+	noCache := utils.IsNoCache(r, 1*time.Hour)
+
+	var badgeData *v1.BadgeData
+	if cacheResult, ok := h.cache.Get(cacheKey); ok && !noCache {
+		badgeData = cacheResult.(*v1.BadgeData)
+	} else {
+		message, err := h.badgeSrvc.GetMessage(r.Context(), user, def)
+		if err != nil {
+			conf.Log().Request(r).Error("failed to compute badge metric", "userID", user.ID, "metric", metric, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(conf.ErrInternalServerError))
+			return
+		}
+		badgeData = v1.NewBadgeDataFromDefinition(def, message)
+		h.cache.SetDefault(cacheKey, badgeData)
+	}
+
+	if query.Get("format") == "json" {
+		w.Header().Set("Cache-Control", "max-age=3600")
+		helpers.RespondJSON(w, r, http.StatusOK, badgeData)
+		return
+	}
+
+	badgeSvg, err := utils.RenderBadge(badgeData.Label, badgeData.Message, badgeData.Color, badgeData.LabelColor, badgeData.Style)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		return
+	}
+	respondSvg(w, badgeSvg)
+}
+
 func respondSvg(w http.ResponseWriter, data []byte) {
 	w.Header().Set("Content-Type", "image/svg+xml")
 	w.Header().Set("Cache-Control", "max-age=3600")
@@ -15,6 +15,8 @@ import (
 	"github.com/muety/wakapi/mocks"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/routes"
+	"github.com/muety/wakapi/services"
+	"github.com/muety/wakapi/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -55,7 +57,14 @@ func TestBadgeHandler_Get(t *testing.T) {
 	summaryServiceMock := new(mocks.SummaryServiceMock)
 	summaryServiceMock.On("Aliased", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), &user1, mock.AnythingOfType("types.SummaryRetriever"), mock.AnythingOfType("*models.Filters"), mock.AnythingOfType("*time.Duration"), mock.Anything).Return(&summary1, nil)
 
-	badgeHandler := NewBadgeHandler(userServiceMock, summaryServiceMock)
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+
+	filterPresetServiceMock := new(mocks.FilterPresetServiceMock)
+	filterPresetServiceMock.On("GetByUserAndSlug", "user1", "go-only").Return(&models.FilterPreset{UserID: "user1", Name: "Go only", Slug: "go-only", Interval: "week", Filters: "language=go"}, nil)
+	filterPresetServiceMock.On("GetByUserAndSlug", "user1", "client-a").Return(&models.FilterPreset{UserID: "user1", Name: "Client A", Slug: "client-a", Filters: "project=foo&language=go"}, nil)
+	filterPresetServiceMock.On("GetByUserAndSlug", "user1", "unknown").Return(nil, services.ErrFilterPresetNotFound)
+
+	badgeHandler := NewBadgeHandler(userServiceMock, summaryServiceMock, services.NewBadgeService(summaryServiceMock, heartbeatServiceMock), filterPresetServiceMock)
 	badgeHandler.RegisterRoutes(apiRouter)
 
 	t.Run("when requesting badge", func(t *testing.T) {
@@ -119,6 +128,147 @@ func TestBadgeHandler_Get(t *testing.T) {
 
 			assert.False(t, strings.HasPrefix(string(data), "<svg"))
 		})
+
+		t.Run("should not return badge if excluded entity type not shared", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/badge/{user}/interval:year/project:!foo", nil)
+			req = routes.WithUrlParam(req, "user", "user1")
+
+			router.ServeHTTP(rec, req)
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		})
+
+		t.Run("should return badge for preset", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/badge/{user}/?preset=go-only", nil)
+			req = routes.WithUrlParam(req, "user", "user1")
+
+			router.ServeHTTP(rec, req)
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+
+		t.Run("should not return badge for preset if entity type not shared", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/badge/{user}/interval:week?preset=client-a", nil)
+			req = routes.WithUrlParam(req, "user", "user1")
+
+			router.ServeHTTP(rec, req)
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		})
+
+		t.Run("should not return badge for unknown preset", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/badge/{user}/?preset=unknown", nil)
+			req = routes.WithUrlParam(req, "user", "user1")
+
+			router.ServeHTTP(rec, req)
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		})
+	})
+}
+
+func TestBadgeHandler_GetMetric(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewSharedDataMiddleware())
+	router.Mount("/api", apiRouter)
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", "user1").Return(&user1, nil)
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("Aliased", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), &user1, mock.AnythingOfType("types.SummaryRetriever"), mock.AnythingOfType("*models.Filters"), mock.AnythingOfType("*time.Duration"), mock.Anything).Return(&summary1, nil)
+	dailySummaries := make([]*models.Summary, 30)
+	for i := range dailySummaries {
+		dailySummaries[i] = &summary1
+	}
+	isSharedDaysAgo := mock.MatchedBy(func(t time.Time) bool { return t.Equal(utils.BeginOfToday(user1.TZ()).AddDate(0, 0, -29)) })
+	summaryServiceMock.On("Daily", isSharedDaysAgo, mock.AnythingOfType("time.Time"), &user1, mock.AnythingOfType("*models.Filters"), mock.AnythingOfType("*time.Duration")).Return(dailySummaries, nil)
+
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+
+	filterPresetServiceMock := new(mocks.FilterPresetServiceMock)
+	filterPresetServiceMock.On("GetByUserAndSlug", "user1", "go-only").Return(&models.FilterPreset{UserID: "user1", Name: "Go only", Slug: "go-only", Interval: "week", Filters: "language=go"}, nil)
+	filterPresetServiceMock.On("GetByUserAndSlug", "user1", "client-a").Return(&models.FilterPreset{UserID: "user1", Name: "Client A", Slug: "client-a", Filters: "project=foo&language=go"}, nil)
+	filterPresetServiceMock.On("GetByUserAndSlug", "user1", "unknown").Return(nil, services.ErrFilterPresetNotFound)
+
+	badgeHandler := NewBadgeHandler(userServiceMock, summaryServiceMock, services.NewBadgeService(summaryServiceMock, heartbeatServiceMock), filterPresetServiceMock)
+	badgeHandler.RegisterRoutes(apiRouter)
+
+	request := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(rec, req)
+		res := rec.Result()
+		defer res.Body.Close()
+		data, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(data)
+	}
+
+	t.Run("should return top language as shields.io json", func(t *testing.T) {
+		status, body := request("/api/badge/user1/metric/top_language?interval=week&format=json&label_color=blue&style=for-the-badge")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"message":"go"`)
+		assert.Contains(t, body, `"label":"top language"`)
+		assert.Contains(t, body, `"labelColor":"blue"`)
+		assert.Contains(t, body, `"style":"for-the-badge"`)
+	})
+
+	t.Run("should return language share as svg", func(t *testing.T) {
+		status, body := request("/api/badge/user1/metric/language_share?interval=week&language=Go&style=plastic&label=share")
+		assert.Equal(t, http.StatusOK, status)
+		assert.True(t, strings.HasPrefix(body, "<svg"))
+		assert.Contains(t, body, "Go 100.0%")
+		assert.Contains(t, body, `height="18"`)
+	})
+
+	t.Run("should return streak capped by shared days", func(t *testing.T) {
+		status, body := request("/api/badge/user1/metric/streak?format=json")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"message":"30 days"`)
+	})
+
+	t.Run("should not return badge for invalid metric or style", func(t *testing.T) {
+		status, _ := request("/api/badge/user1/metric/foo")
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = request("/api/badge/user1/metric/total?style=foo")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("should not return badge if entity type not shared", func(t *testing.T) {
+		status, body := request("/api/badge/user1/metric/top_language?filter=project:foo")
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.False(t, strings.HasPrefix(body, "<svg"))
+	})
+
+	t.Run("should apply preset", func(t *testing.T) {
+		status, body := request("/api/badge/user1/metric/top_language?preset=go-only&format=json")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"message":"go"`)
+
+		status, _ = request("/api/badge/user1/metric/top_language?preset=client-a")
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, _ = request("/api/badge/user1/metric/top_language?preset=unknown")
+		assert.Equal(t, http.StatusNotFound, status)
 	})
 }
 
@@ -144,6 +294,7 @@ func TestBadgeHandler_EntityPattern(t *testing.T) {
 		{test: pathPrefix + "project:wakapi v2", key: "project", val: "wakapi v2"},         // with blank space
 		{test: pathPrefix + "project:project", key: "project", val: "project"},
 		{test: pathPrefix + "project:Anchr-Android_v2.0", key: "project", val: "Anchr-Android_v2.0"}, // all the way
+		{test: pathPrefix + "language:!Markdown", key: "language", val: "!Markdown"},                 // exclusion
 	}
 
 	sut := regexp.MustCompile(`(project|os|editor|language|machine|label):([^:?&/]+)`) // see entityFilterPattern in badge_utils.go
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/go-chi/chi/v5"
 	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/middlewares"
 	"net/http"
 
 	conf "github.com/muety/wakapi/config"
@@ -26,7 +27,11 @@ func NewDiagnosticsApiHandler(userService services.IUserService, diagnosticsServ
 }
 
 func (h *DiagnosticsApiHandler) RegisterRoutes(router chi.Router) {
-	router.Post("/plugins/errors", h.Post)
+	router.Group(func(r chi.Router) {
+		// authentication is optional to stay compatible with older plugins, anonymous diagnostics are only visible to admins
+		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).WithOptionalFor("/api/plugins/errors").Handler)
+		r.Post("/plugins/errors", h.Post)
+	})
 }
 
 // @Summary Push a new diagnostics object
@@ -34,6 +39,7 @@ func (h *DiagnosticsApiHandler) RegisterRoutes(router chi.Router) {
 // @Tags diagnostics
 // @Accept json
 // @Param diagnostics body models.Diagnostics true "A single diagnostics object sent by WakaTime CLI"
+// @Security ApiKeyAuth
 // @Success 201
 // @Router /plugins/errors [post]
 func (h *DiagnosticsApiHandler) Post(w http.ResponseWriter, r *http.Request) {
@@ -46,6 +52,11 @@ func (h *DiagnosticsApiHandler) Post(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user := middlewares.GetPrincipal(r); user != nil {
+		diagnostics.UserID = &user.ID
+	}
+	diagnostics.UserAgent = r.UserAgent()
+
 	if _, err := h.diagnosticsSrvc.Create(&diagnostics); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(conf.ErrInternalServerError))
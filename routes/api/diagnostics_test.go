@@ -0,0 +1,63 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	routeutils "github.com/muety/wakapi/routes/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testDiagnosticsBody = `{"platform": "linux", "architecture": "amd64", "plugin": "vscode-wakatime/24.0.0", "cli_version": "v1.90.0", "logs": "something went wrong", "stacktrace": "panic: oops"}`
+const testDiagnosticsUserAgent = "wakatime/v1.90.0 (linux-6.6.0-x86_64) go1.22.0 vscode/1.90.0 vscode-wakatime/24.0.0"
+
+func TestDiagnosticsHandler_Post(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: "testuser"}
+
+	t.Run("should associate diagnostics with authenticated user", func(t *testing.T) {
+		diagnosticsServiceMock := new(mocks.DiagnosticsServiceMock)
+		handler := NewDiagnosticsApiHandler(new(mocks.UserServiceMock), diagnosticsServiceMock)
+
+		req := httptest.NewRequest(http.MethodPost, "/plugins/errors", bytes.NewBufferString(testDiagnosticsBody))
+		req.Header.Set("User-Agent", testDiagnosticsUserAgent)
+		req = req.WithContext(context.WithValue(req.Context(), config.KeySharedData, config.NewSharedData()))
+		routeutils.SetPrincipal(req, user)
+
+		diagnosticsServiceMock.On("Create", mock.MatchedBy(func(d *models.Diagnostics) bool {
+			return d.UserID != nil && *d.UserID == user.ID && d.UserAgent == testDiagnosticsUserAgent && d.Plugin == "vscode-wakatime/24.0.0" && d.StackTrace == "panic: oops"
+		})).Return(&models.Diagnostics{}, nil)
+
+		rec := httptest.NewRecorder()
+		handler.Post(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		diagnosticsServiceMock.AssertExpectations(t)
+	})
+
+	t.Run("should accept anonymous diagnostics", func(t *testing.T) {
+		diagnosticsServiceMock := new(mocks.DiagnosticsServiceMock)
+		handler := NewDiagnosticsApiHandler(new(mocks.UserServiceMock), diagnosticsServiceMock)
+
+		req := httptest.NewRequest(http.MethodPost, "/plugins/errors", bytes.NewBufferString(testDiagnosticsBody))
+		req = req.WithContext(context.WithValue(req.Context(), config.KeySharedData, config.NewSharedData()))
+
+		diagnosticsServiceMock.On("Create", mock.MatchedBy(func(d *models.Diagnostics) bool {
+			return d.UserID == nil
+		})).Return(&models.Diagnostics{}, nil)
+
+		rec := httptest.NewRecorder()
+		handler.Post(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		diagnosticsServiceMock.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/middlewares"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/services"
+)
+
+type FocusApiHandler struct {
+	config    *conf.Config
+	userSrvc  services.IUserService
+	focusSrvc services.IFocusService
+}
+
+func NewFocusApiHandler(userService services.IUserService, focusService services.IFocusService) *FocusApiHandler {
+	return &FocusApiHandler{
+		userSrvc:  userService,
+		focusSrvc: focusService,
+		config:    conf.Get(),
+	}
+}
+
+func (h *FocusApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.Get)
+
+	router.Mount("/focus", r)
+}
+
+// @Summary Retrieve focus stats
+// @Description Derives focus sessions (uninterrupted coding of at least the user's configured minimum session length), context switches between projects or branches, a daily focus score and fragmentation metrics. Ranges are limited to 92 days.
+// @ID get-focus
+// @Tags summary
+// @Produce json
+// @Param interval query string false "Interval identifier" Enums(today, yesterday, week, month, 7_days, last_7_days, 30_days, last_30_days)
+// @Param from query string false "Start date (e.g. '2021-02-07')"
+// @Param to query string false "End date (e.g. '2021-02-08')"
+// @Param project query string false "Project to filter by (prefix with '!' to exclude, e.g. '!wakapi')"
+// @Param language query string false "Language to filter by (prefix with '!' to exclude)"
+// @Param editor query string false "Editor to filter by (prefix with '!' to exclude)"
+// @Param operating_system query string false "OS to filter by (prefix with '!' to exclude)"
+// @Param machine query string false "Machine to filter by (prefix with '!' to exclude)"
+// @Param label query string false "Project label to filter by (prefix with '!' to exclude)"
+// @Param recompute query bool false "Whether to recompute the stats or use cache"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.FocusStats
+// @Router /focus [get]
+func (h *FocusApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	summaryParams, err := helpers.ParseSummaryParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if summaryParams.RangeDays() > models.MaxFocusRangeDays {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("requested time range too broad"))
+		return
+	}
+
+	stats, err := h.focusSrvc.GetStats(r.Context(), summaryParams.User, &models.FocusParams{
+		From:    summaryParams.From,
+		To:      summaryParams.To,
+		Filters: summaryParams.Filters,
+	}, summaryParams.Recompute)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		conf.Log().Request(r).Error("failed to get focus stats for user", "userID", summaryParams.User.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, stats)
+}
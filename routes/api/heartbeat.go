@@ -9,6 +9,7 @@ import (
 
 	conf "github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/lib/metrics"
 	"github.com/muety/wakapi/middlewares"
 	customMiddleware "github.com/muety/wakapi/middlewares/custom"
 	"github.com/muety/wakapi/models"
@@ -146,6 +147,7 @@ func (h *HeartbeatApiHandler) Post(w http.ResponseWriter, r *http.Request) {
 			conf.Log().Request(r).Error("failed to batch-insert heartbeats", "error", err)
 			return
 		}
+		metrics.HeartbeatsIngested.Add(float64(len(validHeartbeats)))
 
 		if !user.HasData {
 			user.HasData = true
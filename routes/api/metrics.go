@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
@@ -13,6 +14,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	conf "github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/lib/cache"
+	instrumentation "github.com/muety/wakapi/lib/metrics"
 	"github.com/muety/wakapi/middlewares"
 	"github.com/muety/wakapi/models"
 	v1 "github.com/muety/wakapi/models/compat/wakatime/v1"
@@ -20,6 +23,8 @@ import (
 	"github.com/muety/wakapi/repositories"
 	"github.com/muety/wakapi/services"
 	"github.com/muety/wakapi/utils"
+	"github.com/prometheus/common/expfmt"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -69,6 +74,8 @@ type MetricsHandler struct {
 	leaderboardSrvc services.ILeaderboardService
 	keyValueSrvc    services.IKeyValueService
 	metricsRepo     *repositories.MetricsRepository
+	cache           *cache.MemoryCache
+	computeGroup    singleflight.Group
 }
 
 func NewMetricsHandler(userService services.IUserService, summaryService services.ISummaryService, heartbeatService services.IHeartbeatService, leaderboardService services.ILeaderboardService, keyValueService services.IKeyValueService, metricsRepo *repositories.MetricsRepository) *MetricsHandler {
@@ -80,6 +87,7 @@ func NewMetricsHandler(userService services.IUserService, summaryService service
 		keyValueSrvc:    keyValueService,
 		metricsRepo:     metricsRepo,
 		config:          conf.Get(),
+		cache:           cache.NewMemoryCache(time.Duration(conf.Get().Security.MetricsCacheTTLSec)*time.Second, 10*time.Minute),
 	}
 }
 
@@ -107,7 +115,7 @@ func (h *MetricsHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	var metrics mm.Metrics
 
-	if userMetrics, err := h.getUserMetrics(reqUser); err != nil {
+	if userMetrics, err := h.cached("user_"+reqUser.ID, func() (*mm.Metrics, error) { return h.getUserMetrics(reqUser) }); err != nil {
 		conf.Log().Request(r).Error("error occurred", "error", err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(conf.ErrInternalServerError))
@@ -118,8 +126,12 @@ func (h *MetricsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	for _, m := range *h.getRuntimeMetrics() {
+		metrics = append(metrics, m)
+	}
+
 	if reqUser.IsAdmin {
-		if adminMetrics, err := h.getAdminMetrics(reqUser); err != nil {
+		if adminMetrics, err := h.cached("admin", func() (*mm.Metrics, error) { return h.getAdminMetrics(reqUser) }); err != nil {
 			conf.Log().Request(r).Error("error occurred", "error", err.Error())
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte(conf.ErrInternalServerError))
@@ -135,12 +147,52 @@ func (h *MetricsHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("content-type", "text/plain; charset=utf-8")
 	w.Write([]byte(metrics.Print()))
+
+	// application instrumentation (http, database, caches, queues, go runtime, ...) is instance-wide, thus only exposed to admins
+	if reqUser.IsAdmin {
+		families, err := instrumentation.Registry.Gather()
+		if err != nil {
+			conf.Log().Request(r).Error("failed to gather instrumentation metrics", "error", err)
+		}
+		encoder := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+		for _, mf := range families {
+			if err := encoder.Encode(mf); err != nil {
+				conf.Log().Request(r).Error("failed to encode instrumentation metrics", "error", err)
+				return
+			}
+		}
+	}
+}
+
+// cached returns previously computed metrics for the given key, if not yet expired, and computes them otherwise.
+// concurrent scrapes for the same key share a single computation.
+func (h *MetricsHandler) cached(key string, compute func() (*mm.Metrics, error)) (*mm.Metrics, error) {
+	if h.config.Security.MetricsCacheTTLSec <= 0 {
+		return compute()
+	}
+
+	if result, found := h.cache.Get(key); found {
+		return result.(*mm.Metrics), nil
+	}
+
+	result, err, _ := h.computeGroup.Do(key, func() (interface{}, error) {
+		metrics, err := compute()
+		if err != nil {
+			return nil, err
+		}
+		h.cache.SetDefault(key, metrics)
+		return metrics, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*mm.Metrics), nil
 }
 
 func (h *MetricsHandler) getUserMetrics(user *models.User) (*mm.Metrics, error) {
 	var metrics mm.Metrics
 
-	summaryAllTime, err := h.summarySrvc.Aliased(time.Time{}, time.Now(), user, h.summarySrvc.Retrieve, nil, nil, false)
+	summaryAllTime, err := h.summarySrvc.Aliased(context.Background(), time.Time{}, time.Now(), user, h.summarySrvc.Retrieve, nil, nil, false)
 	if err != nil {
 		conf.Log().Error("failed to retrieve all time summary for metric", "userID", user.ID, "error", err)
 		return nil, err
@@ -148,7 +200,7 @@ func (h *MetricsHandler) getUserMetrics(user *models.User) (*mm.Metrics, error)
 
 	from, to := helpers.MustResolveIntervalRawTZ("today", user.TZ(), user.StartOfWeekDay())
 
-	summaryToday, err := h.summarySrvc.Aliased(from, to, user, h.summarySrvc.Retrieve, nil, nil, false)
+	summaryToday, err := h.summarySrvc.Aliased(context.Background(), from, to, user, h.summarySrvc.Retrieve, nil, nil, false)
 	if err != nil {
 		conf.Log().Error("failed to retrieve today's summary for metric", "userID", user.ID, "error", err)
 		return nil, err
@@ -266,6 +318,26 @@ func (h *MetricsHandler) getUserMetrics(user *models.User) (*mm.Metrics, error)
 		Labels: nil,
 	})
 
+	// Database metrics
+	dbSize, err := h.metricsRepo.GetDatabaseSize()
+	if err != nil {
+		slog.Warn("failed to get database size", "error", err)
+	}
+
+	metrics = append(metrics, &mm.GaugeMetric{
+		Name:   MetricsPrefix + "_db_total_bytes",
+		Desc:   DescDatabaseSize,
+		Value:  dbSize,
+		Labels: []mm.Label{},
+	})
+
+	return &metrics, nil
+}
+
+// getRuntimeMetrics returns metrics that are cheap to obtain and therefore never cached
+func (h *MetricsHandler) getRuntimeMetrics() *mm.Metrics {
+	var metrics mm.Metrics
+
 	// Runtime metrics
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
@@ -347,19 +419,6 @@ func (h *MetricsHandler) getUserMetrics(user *models.User) (*mm.Metrics, error)
 		Labels: []mm.Label{},
 	})
 
-	// Database metrics
-	dbSize, err := h.metricsRepo.GetDatabaseSize()
-	if err != nil {
-		slog.Warn("failed to get database size", "error", err)
-	}
-
-	metrics = append(metrics, &mm.GaugeMetric{
-		Name:   MetricsPrefix + "_db_total_bytes",
-		Desc:   DescDatabaseSize,
-		Value:  dbSize,
-		Labels: []mm.Label{},
-	})
-
 	// Miscellaneous
 	for _, qm := range conf.GetQueueMetrics() {
 		metrics = append(metrics, &mm.GaugeMetric{
@@ -377,7 +436,7 @@ func (h *MetricsHandler) getUserMetrics(user *models.User) (*mm.Metrics, error)
 		})
 	}
 
-	return &metrics, nil
+	return &metrics
 }
 
 func (h *MetricsHandler) getAdminMetrics(user *models.User) (*mm.Metrics, error) {
@@ -464,7 +523,7 @@ func (h *MetricsHandler) getAdminMetrics(user *models.User) (*mm.Metrics, error)
 
 	for i := range activeUsers {
 		wp.Submit(func() {
-			summary, err := h.summarySrvc.Aliased(from, to, activeUsers[i], h.summarySrvc.Retrieve, nil, nil, false) // only using aliased because aliased has caching
+			summary, err := h.summarySrvc.Aliased(context.Background(), from, to, activeUsers[i], h.summarySrvc.Retrieve, nil, nil, false) // only using aliased because aliased has caching
 			if err != nil {
 				conf.Log().Error("failed to get total time for user as part of metrics", "userID", activeUsers[i].ID, "error", err)
 				return
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	conf "github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/middlewares"
+	"github.com/muety/wakapi/models"
+	routeutils "github.com/muety/wakapi/routes/utils"
+	"github.com/muety/wakapi/services"
+	"github.com/muety/wakapi/utils"
+)
+
+type ReadmeCardApiHandler struct {
+	config            *conf.Config
+	userService       services.IUserService
+	readmeCardService services.IReadmeCardService
+}
+
+func NewReadmeCardApiHandler(userService services.IUserService, readmeCardService services.IReadmeCardService) *ReadmeCardApiHandler {
+	return &ReadmeCardApiHandler{
+		readmeCardService: readmeCardService,
+		userService:       userService,
+		config:            conf.Get(),
+	}
+}
+
+func (h *ReadmeCardApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(
+		middlewares.NewAuthenticateMiddleware(h.userService).WithOptionalFor("/api/readme/").Handler,
+		middleware.Compress(9, "image/svg+xml"),
+	)
+	r.Get("/{user}/{entity}", h.GetCard)
+
+	router.Mount("/readme", r)
+}
+
+// @Summary Get a readme stats card
+// @Description Renders an svg card showing a user's top languages, projects or editors, e.g. for embedding in a GitHub profile readme. Requires public data access to be allowed, unless requesting own data.
+// @ID get-readme-card
+// @Tags badges
+// @Produce image/svg+xml
+// @Param user path string true "User ID to fetch data for"
+// @Param entity path string true "Type of entity to show" Enums(languages, projects, editors)
+// @Param interval query string false "Interval to aggregate data for" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time)
+// @Param layout query string false "Card layout" Enums(default, compact, donut)
+// @Param theme query string false "Color theme" Enums(light, dark)
+// @Param limit query int false "Maximum number of entries to show (max. 10)"
+// @Param title query string false "Custom card title"
+// @Success 200 {string} string
+// @Router /readme/{user}/{entity} [get]
+func (h *ReadmeCardApiHandler) GetCard(w http.ResponseWriter, r *http.Request) {
+	authorizedUser := middlewares.GetPrincipal(r)
+	requestedUser, err := h.userService.GetUserById(chi.URLParam(r, "user"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	isSameUser := authorizedUser != nil && authorizedUser.ID == requestedUser.ID
+
+	var entity uint8
+	var permitEntity bool
+	switch chi.URLParam(r, "entity") {
+	case "languages":
+		entity, permitEntity = models.SummaryLanguage, requestedUser.ShareLanguages
+	case "projects":
+		entity, permitEntity = models.SummaryProject, requestedUser.ShareProjects
+	case "editors":
+		entity, permitEntity = models.SummaryEditor, requestedUser.ShareEditors
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	interval, _, err := routeutils.ResolveBadgeParams(r.URL.Query().Get("interval"), "", authorizedUser, requestedUser)
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !permitEntity && !isSameUser {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("user did not opt in to share entity-specific data"))
+		return
+	}
+
+	card := &models.ReadmeCard{
+		Entity:   entity,
+		Interval: interval,
+		Layout:   r.URL.Query().Get("layout"),
+		Theme:    r.URL.Query().Get("theme"),
+		Title:    r.URL.Query().Get("title"),
+		Limit:    models.ReadmeCardDefaultLimit,
+	}
+	if card.Layout == "" {
+		card.Layout = models.ReadmeCardLayoutDefault
+	}
+	if card.Theme == "" {
+		card.Theme = models.ReadmeCardThemeLight
+	}
+	if !models.IsValidReadmeCardLayout(card.Layout) || !models.IsValidReadmeCardTheme(card.Theme) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid layout or theme"))
+		return
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit <= models.ReadmeCardMaxLimit {
+		card.Limit = limit
+	}
+
+	result, err := h.readmeCardService.GetCard(r.Context(), requestedUser, card, utils.IsNoCache(r, 6*time.Hour))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		conf.Log().Request(r).Error("failed to get readme card for user", "userID", requestedUser.ID, "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "max-age=21600") // 6 hours
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(result))
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/middlewares"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReadmeCardApiHandler_GetCard(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewSharedDataMiddleware())
+	router.Mount("/api", apiRouter)
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", "user1").Return(&user1, nil)
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("Aliased", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), &user1, mock.AnythingOfType("types.SummaryRetriever"), mock.AnythingOfType("*models.Filters"), mock.AnythingOfType("*time.Duration"), mock.Anything).Return(&summary1, nil)
+
+	cardHandler := NewReadmeCardApiHandler(userServiceMock, services.NewReadmeCardService(summaryServiceMock))
+	cardHandler.RegisterRoutes(apiRouter)
+
+	request := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(rec, req)
+		res := rec.Result()
+		defer res.Body.Close()
+		data, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(data)
+	}
+
+	t.Run("should return languages card in every layout", func(t *testing.T) {
+		for _, layout := range []string{"default", "compact", "donut"} {
+			status, body := request("/api/readme/user1/languages?interval=week&theme=dark&layout=" + layout)
+			assert.Equal(t, http.StatusOK, status)
+			assert.True(t, strings.HasPrefix(body, "<?xml"))
+			assert.Contains(t, body, "Top Languages (This Week)")
+			assert.Contains(t, body, "100.0%")
+			assert.Contains(t, body, "#1A202C")
+		}
+	})
+
+	t.Run("should not return card if entity type not shared", func(t *testing.T) {
+		status, _ := request("/api/readme/user1/projects?interval=week")
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("should not return card if shared interval exceeded", func(t *testing.T) {
+		status, _ := request("/api/readme/user1/languages?interval=last_year")
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("should not return card for invalid layout or entity", func(t *testing.T) {
+		status, _ := request("/api/readme/user1/languages?layout=foo")
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = request("/api/readme/user1/machines")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
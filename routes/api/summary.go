@@ -8,20 +8,23 @@ import (
 
 	conf "github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/middlewares"
+	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/services"
 )
 
 type SummaryApiHandler struct {
-	config      *conf.Config
-	userSrvc    services.IUserService
-	summarySrvc services.ISummaryService
+	config           *conf.Config
+	userSrvc         services.IUserService
+	summarySrvc      services.ISummaryService
+	filterPresetSrvc services.IFilterPresetService
 }
 
-func NewSummaryApiHandler(userService services.IUserService, summaryService services.ISummaryService) *SummaryApiHandler {
+func NewSummaryApiHandler(userService services.IUserService, summaryService services.ISummaryService, filterPresetService services.IFilterPresetService) *SummaryApiHandler {
 	return &SummaryApiHandler{
-		summarySrvc: summaryService,
-		userSrvc:    userService,
-		config:      conf.Get(),
+		summarySrvc:      summaryService,
+		userSrvc:         userService,
+		filterPresetSrvc: filterPresetService,
+		config:           conf.Get(),
 	}
 }
 
@@ -41,22 +44,58 @@ func (h *SummaryApiHandler) RegisterRoutes(router chi.Router) {
 // @Param from query string false "Start date (e.g. '2021-02-07')"
 // @Param to query string false "End date (e.g. '2021-02-08')"
 // @Param recompute query bool false "Whether to recompute the summary from raw heartbeat or use cache"
-// @Param project query string false "Project to filter by"
-// @Param language query string false "Language to filter by"
-// @Param editor query string false "Editor to filter by"
-// @Param operating_system query string false "OS to filter by"
-// @Param machine query string false "Machine to filter by"
-// @Param label query string false "Project label to filter by"
+// @Param project query string false "Project to filter by (prefix with '!' to exclude, e.g. '!wakapi')"
+// @Param language query string false "Language to filter by (prefix with '!' to exclude)"
+// @Param editor query string false "Editor to filter by (prefix with '!' to exclude)"
+// @Param operating_system query string false "OS to filter by (prefix with '!' to exclude)"
+// @Param machine query string false "Machine to filter by (prefix with '!' to exclude)"
+// @Param label query string false "Project label to filter by (prefix with '!' to exclude)"
+// @Param preset query string false "Slug of a saved filter preset (e.g. 'client-a') to apply its filters and, unless given explicitly, its interval"
+// @Param compare query string false "Period to compare against, either 'previous' for the period right before or an interval identifier. If given (or compare_from and compare_to), a models.SummaryComparison is returned instead."
+// @Param compare_from query string false "Start date of the period to compare against (e.g. '2021-01-31')"
+// @Param compare_to query string false "End date of the period to compare against (e.g. '2021-02-01')"
 // @Security ApiKeyAuth
 // @Success 200 {object} models.Summary
 // @Router /summary [get]
 func (h *SummaryApiHandler) Get(w http.ResponseWriter, r *http.Request) {
-	summary, err, status := routeutils.LoadUserSummary(h.summarySrvc, r)
+	if _, err, status := routeutils.ApplyFilterPreset(r, h.filterPresetSrvc, middlewares.GetPrincipal(r), "interval"); err != nil {
+		w.WriteHeader(status)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	summaryParams, err := helpers.ParseSummaryParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	comparisonParams, err := helpers.ParseSummaryComparisonParams(r, summaryParams)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	summary, err, status := routeutils.LoadUserSummaryByParams(r.Context(), h.summarySrvc, summaryParams)
+	if err != nil {
+		w.WriteHeader(status)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	if comparisonParams == nil {
+		helpers.RespondJSON(w, r, http.StatusOK, summary)
+		return
+	}
+
+	previousSummary, err, status := routeutils.LoadUserSummaryByParams(r.Context(), h.summarySrvc, comparisonParams)
 	if err != nil {
 		w.WriteHeader(status)
 		w.Write([]byte(err.Error()))
 		return
 	}
 
-	helpers.RespondJSON(w, r, http.StatusOK, summary)
+	helpers.RespondJSON(w, r, http.StatusOK, models.NewSummaryComparison(summaryParams, summary, comparisonParams, previousSummary))
 }
@@ -1,12 +1,14 @@
 package v1
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/models/types"
 	routeutils "github.com/muety/wakapi/routes/utils"
 
@@ -14,21 +16,20 @@ import (
 	"github.com/muety/wakapi/models"
 	v1 "github.com/muety/wakapi/models/compat/shields/v1"
 	"github.com/muety/wakapi/services"
-	"github.com/patrickmn/go-cache"
 )
 
 type BadgeHandler struct {
 	config      *conf.Config
 	userSrvc    services.IUserService
 	summarySrvc services.ISummaryService
-	cache       *cache.Cache
+	cache       cache.Cache
 }
 
 func NewBadgeHandler(summaryService services.ISummaryService, userService services.IUserService) *BadgeHandler {
 	return &BadgeHandler{
 		summarySrvc: summaryService,
 		userSrvc:    userService,
-		cache:       cache.New(time.Hour, time.Hour),
+		cache:       cache.New("shields_badges", time.Hour, time.Hour),
 		config:      conf.Get(),
 	}
 }
@@ -45,7 +46,7 @@ func (h *BadgeHandler) RegisterRoutes(router chi.Router) {
 // @Produce json
 // @Param user path string true "User ID to fetch data for"
 // @Param interval path string true "Interval to aggregate data for" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time)
-// @Param filter path string true "Filter to apply (e.g. 'project:wakapi' or 'language:Go')"
+// @Param filter path string true "Filter to apply (e.g. 'project:wakapi' or 'language:Go', prefix the value with '!' to exclude, e.g. 'language:!Markdown')"
 // @Success 200 {object} v1.BadgeData
 // @Router /compat/shields/v1/{user}/{interval}/{filter} [get]
 func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -76,7 +77,7 @@ func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
 		Filters: filters,
 	}
 
-	summary, err, status := routeutils.LoadUserSummaryByParams(h.summarySrvc, params)
+	summary, err, status := routeutils.LoadUserSummaryByParams(r.Context(), h.summarySrvc, params)
 	if err != nil {
 		w.WriteHeader(status)
 		w.Write([]byte(err.Error()))
@@ -88,7 +89,7 @@ func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
 	helpers.RespondJSON(w, r, http.StatusOK, vm)
 }
 
-func (h *BadgeHandler) loadUserSummary(user *models.User, interval *models.IntervalKey, filters *models.Filters) (*models.Summary, error, int) {
+func (h *BadgeHandler) loadUserSummary(ctx context.Context, user *models.User, interval *models.IntervalKey, filters *models.Filters) (*models.Summary, error, int) {
 	err, from, to := helpers.ResolveIntervalTZ(interval, user.TZ(), user.StartOfWeekDay())
 	if err != nil {
 		return nil, err, http.StatusBadRequest
@@ -106,6 +107,7 @@ func (h *BadgeHandler) loadUserSummary(user *models.User, interval *models.Inter
 	}
 
 	summary, err := h.summarySrvc.Aliased(
+		ctx,
 		summaryParams.From,
 		summaryParams.To,
 		summaryParams.User,
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"github.com/go-chi/chi/v5"
 	conf "github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/helpers"
@@ -52,7 +53,7 @@ func (h *AllTimeHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return // response was already sent by util function
 	}
 
-	summary, err, status := h.loadUserSummary(user, helpers.ParseSummaryFilters(r).WithSelectFilteredOnly())
+	summary, err, status := h.loadUserSummary(r.Context(), user, helpers.ParseSummaryFilters(r).WithSelectFilteredOnly())
 	if err != nil {
 		w.WriteHeader(status)
 		w.Write([]byte(err.Error()))
@@ -63,7 +64,7 @@ func (h *AllTimeHandler) Get(w http.ResponseWriter, r *http.Request) {
 	helpers.RespondJSON(w, r, http.StatusOK, vm)
 }
 
-func (h *AllTimeHandler) loadUserSummary(user *models.User, filters *models.Filters) (*models.Summary, error, int) {
+func (h *AllTimeHandler) loadUserSummary(ctx context.Context, user *models.User, filters *models.Filters) (*models.Summary, error, int) {
 	summaryParams := &models.SummaryParams{
 		From:      utils.UnixEra(),
 		To:        time.Now(),
@@ -77,6 +78,7 @@ func (h *AllTimeHandler) loadUserSummary(user *models.User, filters *models.Filt
 	}
 
 	summary, err := h.summarySrvc.Aliased(
+		ctx,
 		summaryParams.From,
 		summaryParams.To,
 		summaryParams.User,
@@ -0,0 +1,142 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/go-chi/chi/v5"
+	conf "github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/middlewares"
+	"github.com/muety/wakapi/models"
+	v1 "github.com/muety/wakapi/models/compat/wakatime/v1"
+	"github.com/muety/wakapi/services"
+	"github.com/muety/wakapi/utils"
+)
+
+type InsightsHandler struct {
+	config       *conf.Config
+	userSrvc     services.IUserService
+	insightsSrvc services.IInsightsService
+}
+
+func NewInsightsHandler(userService services.IUserService, insightsService services.IInsightsService) *InsightsHandler {
+	return &InsightsHandler{
+		userSrvc:     userService,
+		insightsSrvc: insightsService,
+		config:       conf.Get(),
+	}
+}
+
+func (h *InsightsHandler) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(
+			middlewares.NewAuthenticateMiddleware(h.userSrvc).WithOptionalFor("/").Handler,
+		)
+		r.Get("/v1/users/{user}/insights/{insight_type}/{range}", h.Get)
+		r.Get("/compat/wakatime/v1/users/{user}/insights/{insight_type}/{range}", h.Get)
+		r.Get("/v1/users/{user}/insights/{insight_type}", h.Get)
+		r.Get("/compat/wakatime/v1/users/{user}/insights/{insight_type}", h.Get)
+	})
+}
+
+// @Summary Retrieve insights for a given user
+// @Description Mimics https://wakatime.com/developers#insights. At most the past five years are considered.
+// @ID get-wakatime-insights
+// @Tags wakatime
+// @Produce json
+// @Param user path string true "User ID to fetch data for (or 'current')"
+// @Param insight_type path string true "Type of insight" Enums(weekdays, days, best_day, daily_average, hours, projects, languages, editors, operating_systems, machines, labels, categories)
+// @Param range path string false "Range interval identifier" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time)
+// @Security ApiKeyAuth
+// @Success 200 {object} v1.InsightsViewModel
+// @Router /compat/wakatime/v1/users/{user}/insights/{insight_type}/{range} [get]
+func (h *InsightsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userParam := chi.URLParam(r, "user")
+	insightParam := chi.URLParam(r, "insight_type")
+	rangeParam := chi.URLParam(r, "range")
+
+	authorizedUser := middlewares.GetPrincipal(r)
+	if authorizedUser != nil && userParam == "current" {
+		userParam = authorizedUser.ID
+	}
+
+	requestedUser, err := h.userSrvc.GetUserById(userParam)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("user not found"))
+		return
+	}
+
+	if !slice.Contain(models.InsightTypes(), insightParam) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid insight type"))
+		return
+	}
+
+	// see stats handler
+	if rangeParam == "" {
+		if _, userRange := helpers.ResolveMaximumRange(requestedUser.ShareDataMaxDays); userRange != nil {
+			rangeParam = (*userRange)[1]
+		} else {
+			rangeParam = (*models.IntervalPast7Days)[1]
+		}
+	}
+
+	err, rangeFrom, rangeTo := helpers.ResolveIntervalRawTZ(rangeParam, requestedUser.TZ(), requestedUser.StartOfWeekDay())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid range"))
+		return
+	}
+
+	isSameUser := authorizedUser != nil && requestedUser.ID == authorizedUser.ID
+
+	minStart := rangeTo.AddDate(0, 0, -requestedUser.ShareDataMaxDays)
+	if !isSameUser && rangeFrom.Before(minStart) && requestedUser.ShareDataMaxDays >= 0 {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("requested time range too broad"))
+		return
+	}
+
+	if !isSameUser && !h.canShareInsight(requestedUser, insightParam) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("user did not opt in to share entity-specific data"))
+		return
+	}
+
+	params := &models.InsightsParams{Type: insightParam, From: rangeFrom, To: rangeTo}
+	insights, err := h.insightsSrvc.Get(r.Context(), requestedUser, params, utils.IsNoCache(r, 1*time.Hour))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to get insights for user", "userID", requestedUser.ID, "type", insightParam, "error", err)
+		return
+	}
+
+	vm := v1.NewInsightsFrom(insights, requestedUser)
+	vm.Data.Range = rangeParam
+	vm.Data.HumanReadableRange = helpers.MustParseInterval(rangeParam).GetHumanReadable()
+
+	helpers.RespondJSON(w, r, http.StatusOK, vm)
+}
+
+// canShareInsight checks whether the requested insight type only reveals data the user opted in to share publicly
+func (h *InsightsHandler) canShareInsight(user *models.User, insightType string) bool {
+	switch insightType {
+	case models.InsightProjects:
+		return user.ShareProjects
+	case models.InsightLanguages:
+		return user.ShareLanguages
+	case models.InsightEditors:
+		return user.ShareEditors
+	case models.InsightOperatingSystems:
+		return user.ShareOSs
+	case models.InsightMachines:
+		return user.ShareMachines
+	case models.InsightLabels:
+		return user.ShareLabels
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -15,16 +16,20 @@ import (
 )
 
 type StatsHandler struct {
-	config      *conf.Config
-	userSrvc    services.IUserService
-	summarySrvc services.ISummaryService
+	config       *conf.Config
+	userSrvc     services.IUserService
+	summarySrvc  services.ISummaryService
+	insightsSrvc services.IInsightsService
+	dayOffSrvc   services.IDayOffService
 }
 
-func NewStatsHandler(userService services.IUserService, summaryService services.ISummaryService) *StatsHandler {
+func NewStatsHandler(userService services.IUserService, summaryService services.ISummaryService, insightsService services.IInsightsService, dayOffService services.IDayOffService) *StatsHandler {
 	return &StatsHandler{
-		userSrvc:    userService,
-		summarySrvc: summaryService,
-		config:      conf.Get(),
+		userSrvc:     userService,
+		summarySrvc:  summaryService,
+		insightsSrvc: insightsService,
+		dayOffSrvc:   dayOffService,
+		config:       conf.Get(),
 	}
 }
 
@@ -51,12 +56,12 @@ func (h *StatsHandler) RegisterRoutes(router chi.Router) {
 // @Produce json
 // @Param user path string true "User ID to fetch data for (or 'current')"
 // @Param range path string false "Range interval identifier" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time)
-// @Param project query string false "Project to filter by"
-// @Param language query string false "Language to filter by"
-// @Param editor query string false "Editor to filter by"
-// @Param operating_system query string false "OS to filter by"
-// @Param machine query string false "Machine to filter by"
-// @Param label query string false "Project label to filter by"
+// @Param project query string false "Project to filter by (prefix with '!' to exclude, e.g. '!wakapi')"
+// @Param language query string false "Language to filter by (prefix with '!' to exclude)"
+// @Param editor query string false "Editor to filter by (prefix with '!' to exclude)"
+// @Param operating_system query string false "OS to filter by (prefix with '!' to exclude)"
+// @Param machine query string false "Machine to filter by (prefix with '!' to exclude)"
+// @Param label query string false "Project label to filter by (prefix with '!' to exclude)"
 // @Security ApiKeyAuth
 // @Success 200 {object} v1.StatsViewModel
 // @Router /compat/wakatime/v1/users/{user}/stats/{range} [get]
@@ -102,7 +107,8 @@ func (h *StatsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	summary, err, status := h.loadUserSummary(requestedUser, rangeFrom, rangeTo, helpers.ParseSummaryFilters(r))
+	filters := helpers.ParseSummaryFilters(r)
+	summary, err, status := h.loadUserSummary(r.Context(), requestedUser, rangeFrom, rangeTo, filters)
 	if err != nil {
 		w.WriteHeader(status)
 		w.Write([]byte(err.Error()))
@@ -117,12 +123,30 @@ func (h *StatsHandler) Get(w http.ResponseWriter, r *http.Request) {
 	}
 	summary.User = requestedUser
 
-	stats := v1.NewStatsFrom(summary, &models.Filters{})
+	// ai sessions are only derived for the stats' ai block, i.e. not for summaries in general
+	if withSessions, err := h.summarySrvc.WithAiSessions(r.Context(), summary, requestedUser, filters); err == nil {
+		summary = withSessions
+	} else {
+		conf.Log().Request(r).Error("failed to load ai sessions for stats", "userID", requestedUser.ID, "error", err)
+	}
+
+	calendar, err := h.dayOffSrvc.GetCalendar(requestedUser)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to get work calendar for stats", "userID", requestedUser.ID, "error", err)
+	}
+
+	stats := v1.NewStatsFrom(summary, &models.Filters{}, calendar)
 	stats.Data.Range = rangeParam
 	stats.Data.HumanReadableRange = helpers.MustParseInterval(rangeParam).GetHumanReadable()
 	stats.Data.IsCodingActivityVisible = requestedUser.ShareDataMaxDays != 0
 	stats.Data.IsOtherUsageVisible = requestedUser.AnyDataShared()
 
+	if insights, err := h.insightsSrvc.Get(r.Context(), requestedUser, &models.InsightsParams{Type: models.InsightBestDay, From: rangeFrom, To: rangeTo}, false); err == nil {
+		stats.Data.BestDay = v1.NewInsightsFrom(insights, requestedUser).Data.BestDay
+	} else {
+		conf.Log().Request(r).Error("failed to get best day for stats", "userID", requestedUser.ID, "error", err)
+	}
+
 	if authorizedUser == nil || requestedUser.ID != authorizedUser.ID {
 		// post filter stats according to user's given sharing permissions
 		if !requestedUser.ShareEditors {
@@ -133,6 +157,9 @@ func (h *StatsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		}
 		if !requestedUser.ShareProjects {
 			stats.Data.Projects = make([]*v1.SummariesEntry, 0)
+			if stats.Data.AI != nil {
+				stats.Data.AI.Projects = models.SummaryItems{}
+			}
 		}
 		if !requestedUser.ShareOSs {
 			stats.Data.OperatingSystems = make([]*v1.SummariesEntry, 0)
@@ -145,7 +172,7 @@ func (h *StatsHandler) Get(w http.ResponseWriter, r *http.Request) {
 	helpers.RespondJSON(w, r, http.StatusOK, stats)
 }
 
-func (h *StatsHandler) loadUserSummary(user *models.User, start, end time.Time, filters *models.Filters) (*models.Summary, error, int) {
+func (h *StatsHandler) loadUserSummary(ctx context.Context, user *models.User, start, end time.Time, filters *models.Filters) (*models.Summary, error, int) {
 	overallParams := &models.SummaryParams{
 		From:      start,
 		To:        end,
@@ -153,7 +180,7 @@ func (h *StatsHandler) loadUserSummary(user *models.User, start, end time.Time,
 		Recompute: false,
 	}
 
-	summary, err := h.summarySrvc.Aliased(overallParams.From, overallParams.To, user, h.summarySrvc.Retrieve, filters, nil, false)
+	summary, err := h.summarySrvc.Aliased(ctx, overallParams.From, overallParams.To, user, h.summarySrvc.Retrieve, filters, nil, false)
 	if err != nil {
 		return nil, err, http.StatusInternalServerError
 	}
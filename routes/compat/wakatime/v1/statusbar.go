@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -71,20 +72,20 @@ func (h *StatusBarHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	summary, status, err := h.loadUserSummary(user, rangeFrom, rangeTo)
+	summary, status, err := h.loadUserSummary(r.Context(), user, rangeFrom, rangeTo)
 	if err != nil {
 		w.WriteHeader(status)
 		w.Write([]byte(err.Error()))
 		return
 	}
-	summariesView := v1.NewSummariesFrom([]*models.Summary{summary})
+	summariesView := v1.NewSummariesFrom([]*models.Summary{summary}, nil) // daily average is not part of the status bar response
 	helpers.RespondJSON(w, r, http.StatusOK, StatusBarViewModel{
 		CachedAt: time.Now(),
 		Data:     *summariesView.Data[0],
 	})
 }
 
-func (h *StatusBarHandler) loadUserSummary(user *models.User, start, end time.Time) (*models.Summary, int, error) {
+func (h *StatusBarHandler) loadUserSummary(ctx context.Context, user *models.User, start, end time.Time) (*models.Summary, int, error) {
 	summaryParams := &models.SummaryParams{
 		From:      start,
 		To:        end,
@@ -97,7 +98,7 @@ func (h *StatusBarHandler) loadUserSummary(user *models.User, start, end time.Ti
 		retrieveSummary = h.summarySrvc.Summarize
 	}
 
-	summary, err := h.summarySrvc.Aliased(summaryParams.From, summaryParams.To, summaryParams.User, retrieveSummary, nil, nil, summaryParams.Recompute)
+	summary, err := h.summarySrvc.Aliased(ctx, summaryParams.From, summaryParams.To, summaryParams.User, retrieveSummary, nil, nil, summaryParams.Recompute)
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
@@ -20,16 +20,20 @@ import (
 )
 
 type SummariesHandler struct {
-	config      *conf.Config
-	userSrvc    services.IUserService
-	summarySrvc services.ISummaryService
+	config           *conf.Config
+	userSrvc         services.IUserService
+	summarySrvc      services.ISummaryService
+	dayOffSrvc       services.IDayOffService
+	filterPresetSrvc services.IFilterPresetService
 }
 
-func NewSummariesHandler(userService services.IUserService, summaryService services.ISummaryService) *SummariesHandler {
+func NewSummariesHandler(userService services.IUserService, summaryService services.ISummaryService, dayOffService services.IDayOffService, filterPresetService services.IFilterPresetService) *SummariesHandler {
 	return &SummariesHandler{
-		userSrvc:    userService,
-		summarySrvc: summaryService,
-		config:      conf.Get(),
+		userSrvc:         userService,
+		summarySrvc:      summaryService,
+		dayOffSrvc:       dayOffService,
+		filterPresetSrvc: filterPresetService,
+		config:           conf.Get(),
 	}
 }
 
@@ -54,12 +58,13 @@ func (h *SummariesHandler) RegisterRoutes(router chi.Router) {
 // @Param range query string false "Range interval identifier" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time)
 // @Param start query string false "Start date (e.g. '2021-02-07')"
 // @Param end query string false "End date (e.g. '2021-02-08')"
-// @Param project query string false "Project to filter by"
-// @Param language query string false "Language to filter by"
-// @Param editor query string false "Editor to filter by"
-// @Param operating_system query string false "OS to filter by"
-// @Param machine query string false "Machine to filter by"
-// @Param label query string false "Project label to filter by"
+// @Param project query string false "Project to filter by (prefix with '!' to exclude, e.g. '!wakapi')"
+// @Param language query string false "Language to filter by (prefix with '!' to exclude)"
+// @Param editor query string false "Editor to filter by (prefix with '!' to exclude)"
+// @Param operating_system query string false "OS to filter by (prefix with '!' to exclude)"
+// @Param machine query string false "Machine to filter by (prefix with '!' to exclude)"
+// @Param label query string false "Project label to filter by (prefix with '!' to exclude)"
+// @Param preset query string false "Slug of a saved filter preset (e.g. 'client-a') to apply its filters and, unless given explicitly, its interval as range"
 // @Security ApiKeyAuth
 // @Success 200 {object} v1.SummariesViewModel
 // @Router /compat/wakatime/v1/users/{user}/summaries [get]
@@ -69,6 +74,12 @@ func (h *SummariesHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return // response was already sent by util function
 	}
 
+	if _, err, status := routeutils.ApplyFilterPreset(r, h.filterPresetSrvc, user, "range"); err != nil {
+		w.WriteHeader(status)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
 	summaries, err, status := h.loadUserSummaries(r, user)
 	if err != nil {
 		w.WriteHeader(status)
@@ -76,7 +87,12 @@ func (h *SummariesHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	vm := v1.NewSummariesFrom(summaries)
+	calendar, err := h.dayOffSrvc.GetCalendar(user)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to get work calendar for summaries", "userID", user.ID, "error", err)
+	}
+
+	vm := v1.NewSummariesFrom(summaries, calendar)
 	helpers.RespondJSON(w, r, http.StatusOK, vm)
 }
 
@@ -140,14 +156,22 @@ func (h *SummariesHandler) loadUserSummaries(r *http.Request, user *models.User)
 	// filtering
 	filters := helpers.ParseSummaryFilters(r)
 
+	// ai sessions are derived from raw heartbeats and thus only for short ranges
+	withAiSessions := len(intervals) <= models.MaxAiSessionDays
+
 	for i, interval := range intervals {
-		summary, err := h.summarySrvc.Aliased(interval[0], interval[1], user, h.summarySrvc.Retrieve, filters, nil, end.After(time.Now()))
+		summary, err := h.summarySrvc.Aliased(r.Context(), interval[0], interval[1], user, h.summarySrvc.Retrieve, filters, nil, end.After(time.Now()))
 		if err != nil {
 			return nil, err, http.StatusInternalServerError
 		}
 		// wakatime returns requested instead of actual summary range
 		summary.FromTime = models.CustomTime(interval[0])
 		summary.ToTime = models.CustomTime(interval[1].Add(-1 * time.Second))
+		if withAiSessions {
+			if summary, err = h.summarySrvc.WithAiSessions(r.Context(), summary, user, filters); err != nil {
+				return nil, err, http.StatusInternalServerError
+			}
+		}
 		summaries[i] = summary
 	}
 
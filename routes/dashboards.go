@@ -0,0 +1,396 @@
+package routes
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/middlewares"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/models/view"
+	routeutils "github.com/muety/wakapi/routes/utils"
+	"github.com/muety/wakapi/services"
+)
+
+type DashboardsHandler struct {
+	config           *conf.Config
+	userService      services.IUserService
+	dashboardService services.IDashboardService
+}
+
+func NewDashboardsHandler(userService services.IUserService, dashboardService services.IDashboardService) *DashboardsHandler {
+	return &DashboardsHandler{
+		config:           conf.Get(),
+		userService:      userService,
+		dashboardService: dashboardService,
+	}
+}
+
+func (h *DashboardsHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(
+		middlewares.NewAuthenticateMiddleware(h.userService).
+			WithRedirectTarget(defaultErrorRedirectTarget()).
+			WithRedirectErrorMessage("unauthorized").
+			WithOptionalFor("/dashboards/shared/").Handler,
+	)
+	r.Get("/", h.GetIndex)
+	r.Post("/", h.PostIndex)
+	r.Get("/{id}", h.GetDashboard)
+	r.Post("/{id}", h.PostDashboard)
+	r.Get("/shared/{token}", h.GetShared)
+
+	router.Mount("/dashboards", r)
+}
+
+func (h *DashboardsHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+	if err := templates[conf.DashboardsTemplate].Execute(w, h.buildViewModel(r, w)); err != nil {
+		conf.Log().Request(r).Error("failed to get dashboards page", "error", err)
+	}
+}
+
+func (h *DashboardsHandler) PostIndex(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		templates[conf.DashboardsTemplate].Execute(w, h.buildViewModel(r, w).WithError("missing form values"))
+		return
+	}
+
+	var result actionResult
+	switch action := r.PostForm.Get("action"); action {
+	case "create_dashboard":
+		result = h.actionCreateDashboard(w, r)
+	case "delete_dashboard":
+		result = h.actionDeleteDashboard(w, r)
+	default:
+		slog.Warn("failed to dispatch action", "action", action)
+		result = actionResult{http.StatusBadRequest, "", "unknown action requests", nil}
+	}
+
+	// action responded itself
+	if result.code == -1 {
+		return
+	}
+
+	w.WriteHeader(result.code)
+	if result.error != "" {
+		templates[conf.DashboardsTemplate].Execute(w, h.buildViewModel(r, w).WithError(result.error))
+		return
+	}
+	templates[conf.DashboardsTemplate].Execute(w, h.buildViewModel(r, w).WithSuccess(result.success))
+}
+
+func (h *DashboardsHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	dashboard, status, err := h.loadOwnDashboard(r)
+	if err != nil {
+		w.WriteHeader(status)
+		templates[conf.DashboardTemplate].Execute(w, h.newDashboardViewModel(user).WithError(err.Error()))
+		return
+	}
+
+	vm := routeutils.WithSessionMessages(h.buildDashboardViewModel(r, user, dashboard, false), r, w)
+	if err := templates[conf.DashboardTemplate].Execute(w, vm); err != nil {
+		conf.Log().Request(r).Error("failed to get dashboard page", "error", err)
+	}
+}
+
+func (h *DashboardsHandler) PostDashboard(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	dashboard, status, err := h.loadOwnDashboard(r)
+	if err != nil {
+		w.WriteHeader(status)
+		templates[conf.DashboardTemplate].Execute(w, h.newDashboardViewModel(user).WithError(err.Error()))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		templates[conf.DashboardTemplate].Execute(w, h.buildDashboardViewModel(r, user, dashboard, false).WithError("missing form values"))
+		return
+	}
+
+	var result actionResult
+	switch action := r.PostForm.Get("action"); action {
+	case "rename_dashboard":
+		result = h.actionRenameDashboard(r, dashboard)
+	case "share_dashboard":
+		result = h.actionShareDashboard(r, dashboard)
+	case "add_widget":
+		result = h.actionAddWidget(r, dashboard)
+	case "move_widget":
+		result = h.actionMoveWidget(r, dashboard)
+	case "delete_widget":
+		result = h.actionDeleteWidget(r, dashboard)
+	default:
+		slog.Warn("failed to dispatch action", "action", action)
+		result = actionResult{http.StatusBadRequest, "", "unknown action requests", nil}
+	}
+
+	w.WriteHeader(result.code)
+	vm := h.buildDashboardViewModel(r, user, dashboard, false)
+	if result.error != "" {
+		templates[conf.DashboardTemplate].Execute(w, vm.WithError(result.error))
+		return
+	}
+	templates[conf.DashboardTemplate].Execute(w, vm.WithSuccess(result.success))
+}
+
+// GetShared renders a dashboard read-only for anyone who knows its share link, based on its owner's data
+func (h *DashboardsHandler) GetShared(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r) // optional, i.e. nil for anonymous visitors
+	dashboard, err := h.dashboardService.GetShared(chi.URLParam(r, "token"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		templates[conf.DashboardTemplate].Execute(w, h.newDashboardViewModel(user).WithError("dashboard not found"))
+		return
+	}
+
+	owner, err := h.userService.GetUserById(dashboard.UserID)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to get dashboard owner", "dashboardID", dashboard.ID, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		templates[conf.DashboardTemplate].Execute(w, h.newDashboardViewModel(user).WithError(criticalError))
+		return
+	}
+
+	vm := h.buildDashboardViewModel(r, owner, dashboard, true)
+	vm.User = user
+	if err := templates[conf.DashboardTemplate].Execute(w, vm); err != nil {
+		conf.Log().Request(r).Error("failed to get shared dashboard page", "error", err)
+	}
+}
+
+func (h *DashboardsHandler) actionCreateDashboard(w http.ResponseWriter, r *http.Request) actionResult {
+	user := middlewares.GetPrincipal(r)
+
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	if name == "" || len(name) > 255 {
+		return actionResult{http.StatusBadRequest, "", "invalid dashboard name", nil}
+	}
+
+	dashboard, err := h.dashboardService.Create(user, name)
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", fmt.Sprintf("failed to create dashboard (%v)", err), nil}
+	}
+
+	routeutils.SetSuccess(r, w, "dashboard created successfully")
+	http.Redirect(w, r, fmt.Sprintf("%s/dashboards/%d", h.config.Server.BasePath, dashboard.ID), http.StatusFound)
+	return actionResult{-1, "", "", nil}
+}
+
+func (h *DashboardsHandler) actionDeleteDashboard(w http.ResponseWriter, r *http.Request) actionResult {
+	user := middlewares.GetPrincipal(r)
+
+	id, err := strconv.Atoi(r.PostFormValue("dashboard_id"))
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "could not delete dashboard", nil}
+	}
+
+	dashboard, err := h.dashboardService.GetById(uint(id))
+	if err != nil || dashboard == nil {
+		return actionResult{http.StatusNotFound, "", "dashboard not found", nil}
+	} else if dashboard.UserID != user.ID {
+		return actionResult{http.StatusForbidden, "", "not allowed to delete dashboard", nil}
+	}
+
+	if err := h.dashboardService.Delete(dashboard); err != nil {
+		conf.Log().Request(r).Error("failed to delete dashboard", "dashboardID", dashboard.ID, "error", err)
+		return actionResult{http.StatusInternalServerError, "", "could not delete dashboard", nil}
+	}
+	return actionResult{http.StatusOK, "dashboard deleted successfully", "", nil}
+}
+
+func (h *DashboardsHandler) actionRenameDashboard(r *http.Request, dashboard *models.Dashboard) actionResult {
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	if name == "" || len(name) > 255 {
+		return actionResult{http.StatusBadRequest, "", "invalid dashboard name", nil}
+	}
+	if _, err := h.dashboardService.Rename(dashboard, name); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "could not rename dashboard", nil}
+	}
+	return actionResult{http.StatusOK, "dashboard renamed successfully", "", nil}
+}
+
+func (h *DashboardsHandler) actionShareDashboard(r *http.Request, dashboard *models.Dashboard) actionResult {
+	shared, err := strconv.ParseBool(r.PostFormValue("shared"))
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+	}
+	if _, err := h.dashboardService.SetShared(dashboard, shared); err != nil {
+		conf.Log().Request(r).Error("failed to update dashboard sharing", "dashboardID", dashboard.ID, "error", err)
+		return actionResult{http.StatusInternalServerError, "", "could not update sharing settings", nil}
+	}
+	if shared {
+		return actionResult{http.StatusOK, "dashboard is now shared via link", "", nil}
+	}
+	return actionResult{http.StatusOK, "dashboard is not shared anymore", "", nil}
+}
+
+func (h *DashboardsHandler) actionAddWidget(r *http.Request, dashboard *models.Dashboard) actionResult {
+	summaryType, err := strconv.Atoi(r.PostFormValue("summary_type"))
+	if err != nil {
+		summaryType = int(models.SummaryProject)
+	}
+
+	// filter values are given per entity type as comma-separated lists, each of which matches any of its values
+	filters := url.Values{}
+	for param := range models.FilterQueryParams {
+		for _, v := range strings.Split(r.PostFormValue(param), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				filters.Add(param, v)
+			}
+		}
+	}
+
+	widget := &models.DashboardWidget{
+		Title:       strings.TrimSpace(r.PostFormValue("title")),
+		ChartType:   r.PostFormValue("chart_type"),
+		SummaryType: uint8(summaryType),
+		Interval:    r.PostFormValue("interval"),
+		Filters:     filters.Encode(),
+	}
+	if !widget.IsValid() {
+		return actionResult{http.StatusBadRequest, "", "invalid widget", nil}
+	}
+
+	if _, err := h.dashboardService.AddWidget(dashboard, widget); err != nil {
+		return actionResult{http.StatusBadRequest, "", fmt.Sprintf("failed to add widget (%v)", err), nil}
+	}
+	return actionResult{http.StatusOK, "widget added successfully", "", nil}
+}
+
+func (h *DashboardsHandler) actionMoveWidget(r *http.Request, dashboard *models.Dashboard) actionResult {
+	id, err := strconv.Atoi(r.PostFormValue("widget_id"))
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "could not move widget", nil}
+	}
+
+	offset := 1
+	if r.PostFormValue("direction") == "up" {
+		offset = -1
+	}
+
+	if err := h.dashboardService.MoveWidget(dashboard, uint(id), offset); err != nil {
+		return actionResult{http.StatusBadRequest, "", "could not move widget", nil}
+	}
+	return actionResult{http.StatusOK, "widget moved successfully", "", nil}
+}
+
+func (h *DashboardsHandler) actionDeleteWidget(r *http.Request, dashboard *models.Dashboard) actionResult {
+	id, err := strconv.Atoi(r.PostFormValue("widget_id"))
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "could not delete widget", nil}
+	}
+
+	if err := h.dashboardService.DeleteWidget(dashboard, uint(id)); err != nil {
+		return actionResult{http.StatusBadRequest, "", "could not delete widget", nil}
+	}
+	return actionResult{http.StatusOK, "widget deleted successfully", "", nil}
+}
+
+// loadOwnDashboard returns the dashboard given by the request's path, if it belongs to the requesting user, along with an http status and error otherwise
+func (h *DashboardsHandler) loadOwnDashboard(r *http.Request) (*models.Dashboard, int, error) {
+	user := middlewares.GetPrincipal(r)
+	if user == nil {
+		return nil, http.StatusUnauthorized, fmt.Errorf("unauthorized")
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid dashboard")
+	}
+
+	dashboard, err := h.dashboardService.GetById(uint(id))
+	if err != nil || dashboard == nil || dashboard.UserID != user.ID {
+		return nil, http.StatusNotFound, fmt.Errorf("dashboard not found")
+	}
+	return dashboard, http.StatusOK, nil
+}
+
+func (h *DashboardsHandler) buildViewModel(r *http.Request, w http.ResponseWriter) *view.DashboardsViewModel {
+	user := middlewares.GetPrincipal(r)
+	if user == nil { // this should actually never occur, because of auth middleware
+		w.WriteHeader(http.StatusUnauthorized)
+		return &view.DashboardsViewModel{
+			SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+				SharedViewModel: view.NewSharedViewModel(h.config, &view.Messages{Error: "unauthorized"}),
+			},
+		}
+	}
+
+	vm := &view.DashboardsViewModel{
+		SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+			SharedViewModel: view.NewSharedViewModel(h.config, nil),
+			User:            user,
+		},
+		Dashboards: []*models.Dashboard{},
+	}
+
+	dashboards, err := h.dashboardService.GetByUser(user.ID)
+	if err != nil {
+		conf.Log().Request(r).Error("error while fetching dashboards", "userID", user.ID, "error", err)
+		return vm.WithError(criticalError)
+	}
+	vm.Dashboards = dashboards
+
+	return routeutils.WithSessionMessages(vm, r, w)
+}
+
+// buildDashboardViewModel computes the data of all of a dashboard's widgets based on the given user's, i.e. the dashboard owner's, coding activity
+func (h *DashboardsHandler) buildDashboardViewModel(r *http.Request, owner *models.User, dashboard *models.Dashboard, readOnly bool) *view.DashboardViewModel {
+	vm := h.newDashboardViewModel(owner)
+	vm.Dashboard = dashboard
+	vm.OwnerID = owner.ID
+	vm.ReadOnly = readOnly
+	if !readOnly && dashboard.Shared {
+		vm.ShareUrl = fmt.Sprintf("%s/dashboards/shared/%s", h.config.Server.GetPublicUrl(), dashboard.ShareToken)
+	}
+
+	for _, widget := range dashboard.Widgets {
+		widgetVm := &view.DashboardWidgetViewModel{DashboardWidget: widget}
+		if data, err := h.dashboardService.GetWidgetData(r.Context(), owner, widget); err == nil {
+			widgetVm.Data = data
+		} else {
+			conf.Log().Request(r).Error("failed to load dashboard widget data", "widgetID", widget.ID, "error", err)
+			widgetVm.Error = "failed to load data"
+		}
+		vm.Widgets = append(vm.Widgets, widgetVm)
+	}
+
+	return vm
+}
+
+func (h *DashboardsHandler) newDashboardViewModel(user *models.User) *view.DashboardViewModel {
+	return &view.DashboardViewModel{
+		SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+			SharedViewModel: view.NewSharedViewModel(h.config, nil),
+			User:            user,
+		},
+		Widgets: []*view.DashboardWidgetViewModel{},
+	}
+}
@@ -33,15 +33,19 @@ type LoginHandler struct {
 	mailSrvc     services.IMailService
 	keyValueSrvc services.IKeyValueService
 	webAuthnSrvc services.IWebAuthnService
+	sessionSrvc  services.ISessionService
+	securitySrvc services.ISecurityEventService
 }
 
-func NewLoginHandler(userService services.IUserService, mailService services.IMailService, keyValueService services.IKeyValueService, webAuthnService services.IWebAuthnService) *LoginHandler {
+func NewLoginHandler(userService services.IUserService, mailService services.IMailService, keyValueService services.IKeyValueService, webAuthnService services.IWebAuthnService, sessionService services.ISessionService, securityEventService services.ISecurityEventService) *LoginHandler {
 	return &LoginHandler{
 		config:       conf.Get(),
 		userSrvc:     userService,
 		mailSrvc:     mailService,
 		keyValueSrvc: keyValueService,
 		webAuthnSrvc: webAuthnService,
+		sessionSrvc:  sessionService,
+		securitySrvc: securityEventService,
 	}
 }
 
@@ -160,7 +164,9 @@ func (h *LoginHandler) PostLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.finishUserLogin(user, r, w, true)
+	if !h.finishUserLogin(user, r, w, "password", false) {
+		return
+	}
 	http.Redirect(w, r, fmt.Sprintf("%s/summary", h.config.Server.BasePath), http.StatusFound)
 }
 
@@ -172,11 +178,17 @@ func (h *LoginHandler) PostLogout(w http.ResponseWriter, r *http.Request) {
 	if user := middlewares.GetPrincipal(r); user != nil {
 		h.userSrvc.FlushUserCache(user.ID)
 	}
+	if session := routeutils.GetSession(r); session != nil {
+		if err := h.sessionSrvc.Delete(session); err != nil {
+			conf.Log().Request(r).Error("failed to delete session", "error", err)
+		}
+	}
 	routeutils.ClearSession(r, w)                                                // clear all session data
 	http.SetCookie(w, h.config.GetClearCookie(models.AuthCookieKey))             // clear auth token
 	http.SetCookie(w, h.config.GetClearCookie(models.OidcIdTokenCookieKey))      // clear oidc id token
 	http.SetCookie(w, h.config.GetClearCookie(models.OidcRefreshTokenCookieKey)) // clear oidc refresh token
 	http.SetCookie(w, h.config.GetClearCookie(models.OidcProviderCookieKey))     // clear oidc provider cookie
+	http.SetCookie(w, h.config.GetClearCookie(models.OidcSessionCookieKey))      // clear oidc session cookie
 	http.Redirect(w, r, fmt.Sprintf("%s/", h.config.Server.BasePath), http.StatusFound)
 }
 
@@ -357,6 +369,14 @@ func (h *LoginHandler) PostSetPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// log out everywhere after a password reset
+	if err := h.sessionSrvc.DeleteByUser(user.ID, ""); err != nil {
+		conf.Log().Request(r).Error("failed to revoke sessions after password reset", "user", user.ID, "error", err)
+	}
+	if _, err := h.securitySrvc.Log(user, models.SecurityEventPasswordReset, middleware.GetClientIP(r.Context()), r.UserAgent(), ""); err != nil {
+		conf.Log().Request(r).Error("failed to log security event", "user", user.ID, "error", err)
+	}
+
 	routeutils.SetSuccess(r, w, "password updated successfully")
 	http.Redirect(w, r, fmt.Sprintf("%s/login", h.config.Server.BasePath), http.StatusFound)
 }
@@ -518,7 +538,9 @@ func (h *LoginHandler) GetOidcCallback(w http.ResponseWriter, r *http.Request) {
 	}
 	http.SetCookie(w, h.config.CreateCookie(models.OidcProviderCookieKey, provider.Name))
 
-	h.finishUserLogin(user, r, w, false)
+	if !h.finishUserLogin(user, r, w, provider.Name, true) {
+		return
+	}
 	http.Redirect(w, r, fmt.Sprintf("%s/summary", h.config.Server.BasePath), http.StatusFound)
 }
 
@@ -616,7 +638,9 @@ func (h *LoginHandler) PostLoginWebAuthn(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	h.finishUserLogin(user, r, w, true)
+	if !h.finishUserLogin(user, r, w, "passkey", false) {
+		return
+	}
 	http.Redirect(w, r, fmt.Sprintf("%s/summary", h.config.Server.BasePath), http.StatusFound)
 }
 
@@ -657,20 +681,39 @@ func (h *LoginHandler) getOidcProvider(w http.ResponseWriter, r *http.Request) *
 	return provider
 }
 
-func (h *LoginHandler) finishUserLogin(user *models.User, r *http.Request, w http.ResponseWriter, setAuthCookie bool) {
-	if setAuthCookie {
-		cookie, err := routeutils.CreateAuthCookie(user.ID)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			conf.Log().Request(r).Error("failed to encode secure cookie", "error", err)
-			templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError("internal server error"))
-			return
-		}
-		http.SetCookie(w, cookie)
+// finishUserLogin creates a server-side session for the freshly authenticated user and sets the corresponding cookie.
+// Password and passkey logins are authenticated by the session cookie itself, while oidc logins are authenticated
+// by their id token and only reference the session, so it can be listed and revoked like any other.
+func (h *LoginHandler) finishUserLogin(user *models.User, r *http.Request, w http.ResponseWriter, method string, viaOidc bool) bool {
+	session, err := h.sessionSrvc.Create(user, middleware.GetClientIP(r.Context()), r.UserAgent())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		conf.Log().Request(r).Error("failed to create session", "error", err)
+		templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError("internal server error"))
+		return false
+	}
+
+	var cookie *http.Cookie
+	if viaOidc {
+		cookie, err = routeutils.CreateOidcSessionCookie(session)
+	} else {
+		cookie, err = routeutils.CreateAuthCookie(session)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		conf.Log().Request(r).Error("failed to encode secure cookie", "error", err)
+		templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError("internal server error"))
+		return false
+	}
+	http.SetCookie(w, cookie)
+
+	if _, err := h.securitySrvc.LogLogin(user, method, middleware.GetClientIP(r.Context()), r.UserAgent()); err != nil {
+		conf.Log().Request(r).Error("failed to log security event", "user", user.ID, "error", err)
 	}
 
 	user.LastLoggedInAt = models.CustomTime(time.Now())
 	h.userSrvc.Update(user)
+	return true
 }
 
 func (h *LoginHandler) coalesceExistingUser(username string) string {
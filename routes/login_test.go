@@ -36,6 +36,8 @@ type LoginHandlerTestSuite struct {
 	UserService           *mocks.UserServiceMock
 	KeyValueService       *mocks.KeyValueServiceMock
 	WebAuthnService       *mocks.WebAuthnServiceMock
+	SessionService        *mocks.SessionServiceMock
+	SecurityService       *mocks.SecurityEventServiceMock
 	Cfg                   *config.Config
 	Sut                   *LoginHandler
 	OidcUserNew           *mockoidc.MockUser
@@ -96,7 +98,12 @@ func (suite *LoginHandlerTestSuite) BeforeTest(suiteName, testName string) {
 	suite.UserService = new(mocks.UserServiceMock)
 	suite.KeyValueService = new(mocks.KeyValueServiceMock)
 	suite.WebAuthnService = new(mocks.WebAuthnServiceMock)
+	suite.SessionService = new(mocks.SessionServiceMock)
+	suite.SecurityService = new(mocks.SecurityEventServiceMock)
 	suite.UserService.On("Count").Return(1, nil).Maybe()
+	suite.SessionService.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(&models.Session{ID: "test-session", UserID: testUserExistingId}, nil).Maybe()
+	suite.SecurityService.On("LogLogin", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	suite.SecurityService.On("Log", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
 
 	cfg := config.Empty()
 	cfg.Security.CookieKeyBytes = securecookie.GenerateRandomKey(128)
@@ -111,7 +118,7 @@ func (suite *LoginHandlerTestSuite) BeforeTest(suiteName, testName string) {
 	suite.resetOidcMockTtl()
 	suite.setupOidcProvider(testProvider)
 
-	suite.Sut = NewLoginHandler(suite.UserService, nil, suite.KeyValueService, suite.WebAuthnService)
+	suite.Sut = NewLoginHandler(suite.UserService, nil, suite.KeyValueService, suite.WebAuthnService, suite.SessionService, suite.SecurityService)
 	Init() // load templates
 }
 
@@ -492,6 +499,11 @@ func (suite *LoginHandlerTestSuite) TestGetOidcLoginCallback_Success() {
 	testutils.AssertContainsHeaderMatching(suite.T(), w.Header(), "Set-Cookie", func(value string) bool {
 		return strings.Contains(value, "oidc_refresh_token=")
 	}, "OIDC refresh token not set in response")
+	testutils.AssertContainsHeaderMatching(suite.T(), w.Header(), "Set-Cookie", func(value string) bool {
+		return strings.Contains(value, "oidc_session=")
+	}, "OIDC session cookie not set in response")
+	suite.assertCookieAbsent(w, config.CookieKeyAuth)
+	suite.SessionService.AssertCalled(suite.T(), "Create", suite.TestUser, mock.Anything, mock.Anything)
 }
 
 func (suite *LoginHandlerTestSuite) TestGetOidcLoginCallback_Success_CreateUser() {
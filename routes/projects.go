@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"github.com/duke-git/lancet/v2/datetime"
 	"github.com/go-chi/chi/v5"
 	conf "github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/middlewares"
@@ -10,6 +11,7 @@ import (
 	"github.com/muety/wakapi/services"
 	"github.com/muety/wakapi/utils"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -19,14 +21,18 @@ type ProjectsHandler struct {
 	userService      services.IUserService
 	heartbeatService services.IHeartbeatService
 	projectService   services.IProjectService
+	summaryService   services.ISummaryService
+	activityService  services.IActivityService
 }
 
-func NewProjectsHandler(userService services.IUserService, heartbeatService services.IHeartbeatService, projectService services.IProjectService) *ProjectsHandler {
+func NewProjectsHandler(userService services.IUserService, heartbeatService services.IHeartbeatService, projectService services.IProjectService, summaryService services.ISummaryService, activityService services.IActivityService) *ProjectsHandler {
 	return &ProjectsHandler{
 		config:           conf.Get(),
 		userService:      userService,
 		heartbeatService: heartbeatService,
 		projectService:   projectService,
+		summaryService:   summaryService,
+		activityService:  activityService,
 	}
 }
 
@@ -38,6 +44,7 @@ func (h *ProjectsHandler) RegisterRoutes(router chi.Router) {
 			WithRedirectErrorMessage("unauthorized").Handler,
 	)
 	r.Get("/", h.GetIndex)
+	r.Get("/{project}", h.GetProject)
 
 	router.Mount("/projects", r)
 }
@@ -51,6 +58,15 @@ func (h *ProjectsHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *ProjectsHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+	if err := templates[conf.ProjectTemplate].Execute(w, h.buildProjectViewModel(r, w)); err != nil {
+		conf.Log().Request(r).Error("failed to get project page", "error", err)
+	}
+}
+
 func (h *ProjectsHandler) buildViewModel(r *http.Request, w http.ResponseWriter) *view.ProjectsViewModel {
 	user := middlewares.GetPrincipal(r)
 	if user == nil { // this should actually never occur, because of auth middleware
@@ -89,3 +105,106 @@ func (h *ProjectsHandler) buildViewModel(r *http.Request, w http.ResponseWriter)
 	}
 	return routeutils.WithSessionMessages(vm, r, w)
 }
+
+func (h *ProjectsHandler) buildProjectViewModel(r *http.Request, w http.ResponseWriter) *view.ProjectViewModel {
+	user := middlewares.GetPrincipal(r)
+	if user == nil { // this should actually never occur, because of auth middleware
+		w.WriteHeader(http.StatusUnauthorized)
+		return h.buildProjectViewModel(r, w).WithError("unauthorized")
+	}
+
+	vm := &view.ProjectViewModel{
+		SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+			SharedViewModel: view.NewSharedViewModel(h.config, nil),
+			User:            user,
+		},
+		Contributors: []*models.ProjectContributor{},
+	}
+
+	projectName, err := url.PathUnescape(chi.URLParam(r, "project"))
+	if err != nil || projectName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return vm.WithError("invalid project")
+	}
+
+	project, err := h.findProject(user, projectName)
+	if err != nil {
+		conf.Log().Request(r).Error("error while fetching project stats", "userID", user.ID, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return vm.WithError(criticalError)
+	}
+	if project == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return vm.WithError("project not found")
+	}
+	vm.Project = project
+
+	now := time.Now().In(user.TZ())
+	from := datetime.BeginOfDay(project.First.T().In(user.TZ()))
+
+	summary, err, status := routeutils.LoadUserSummaryByParams(r.Context(), h.summaryService, &models.SummaryParams{
+		From:    from,
+		To:      now,
+		User:    user,
+		Filters: models.NewFiltersWith(models.SummaryProject, projectName),
+	})
+	if err != nil {
+		w.WriteHeader(status)
+		return vm.WithError(err.Error())
+	}
+	vm.Summary = summary
+
+	// activity data is optional, i.e. the page is still rendered without timeline or heatmap in case of errors
+	activityFrom := from
+	if minFrom := now.AddDate(-5, 0, 0); activityFrom.Before(minFrom) {
+		activityFrom = minFrom
+	}
+	if activityFrom.Before(now) {
+		activity, err := h.activityService.GetData(r.Context(), user, &models.ActivityParams{
+			From:    activityFrom,
+			To:      now,
+			Type:    models.ActivityChartDaily,
+			Filters: models.NewFiltersWith(models.SummaryProject, projectName),
+		}, false)
+		if err != nil {
+			conf.Log().Request(r).Error("failed to get project activity data", "userID", user.ID, "project", projectName, "error", err)
+		}
+		vm.Activity = activity
+	}
+
+	chart, err := h.activityService.GetChart(r.Context(), user, &models.ActivityParams{
+		From:            now.AddDate(0, -12, 0),
+		To:              now,
+		Type:            models.ActivityChartDaily,
+		Filters:         models.NewFiltersWith(models.SummaryProject, projectName),
+		DarkTheme:       true,
+		HideAttribution: true,
+	}, false)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to get project activity chart", "userID", user.ID, "project", projectName, "error", err)
+	}
+	vm.ActivityChart = chart
+
+	contributors, err := h.projectService.GetProjectContributors(user, projectName)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to get project contributors", "userID", user.ID, "project", projectName, "error", err)
+	} else {
+		vm.Contributors = contributors
+	}
+
+	return routeutils.WithSessionMessages(vm, r, w)
+}
+
+// findProject looks up the stats of the user's project with the given name, including today's and archived activity, returns nil if there is none
+func (h *ProjectsHandler) findProject(user *models.User, projectName string) (*models.ProjectStats, error) {
+	projects, err := h.projectService.GetUserProjectStats(user, time.Time{}, time.Time{}, projectName, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		if p.Project == projectName {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
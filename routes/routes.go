@@ -2,6 +2,7 @@ package routes
 
 import (
 	"html/template"
+	"net/url"
 	"strings"
 
 	"github.com/duke-git/lancet/v2/strutil"
@@ -67,6 +68,7 @@ func DefaultTemplateFuncs() template.FuncMap {
 		"htmlSafe": func(html string) template.HTML {
 			return template.HTML(html)
 		},
+		"pathEscape": url.PathEscape,
 		"urlSafe": func(s string) template.URL {
 			return template.URL(s)
 		},
@@ -5,22 +5,26 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 	"uuid"
 
 	"github.com/duke-git/lancet/v2/condition"
 	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/gorilla/schema"
 
 	conf "github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/lib/metrics"
 	"github.com/muety/wakapi/middlewares"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/models/view"
@@ -42,10 +46,15 @@ type SettingsHandler struct {
 	aggregationSrvc     services.IAggregationService
 	languageMappingSrvc services.ILanguageMappingService
 	projectLabelSrvc    services.IProjectLabelService
+	dayOffSrvc          services.IDayOffService
+	filterPresetSrvc    services.IFilterPresetService
 	keyValueSrvc        services.IKeyValueService
 	mailSrvc            services.IMailService
 	apiKeySrvc          services.IApiKeyService
 	WebAuthnSrvc        services.IWebAuthnService
+	sessionSrvc         services.ISessionService
+	securityEventSrvc   services.ISecurityEventService
+	diagnosticsSrvc     services.IDiagnosticsService
 	httpClient          *http.Client
 	aggregationLocks    map[string]bool
 }
@@ -61,6 +70,11 @@ type actionResult struct {
 
 const valueInviteCode = "invite_code"
 
+const securityLogLimit = 50
+const diagnosticsLimit = 25
+const importedDaysOffLimit = 10
+const maxUploadSize = 1 << 20 // 1 mb
+
 var credentialsDecoder = schema.NewDecoder()
 
 func NewSettingsHandler(
@@ -72,10 +86,15 @@ func NewSettingsHandler(
 	aggregationService services.IAggregationService,
 	languageMappingService services.ILanguageMappingService,
 	projectLabelService services.IProjectLabelService,
+	dayOffService services.IDayOffService,
+	filterPresetService services.IFilterPresetService,
 	keyValueService services.IKeyValueService,
 	mailService services.IMailService,
 	apiKeyService services.IApiKeyService,
 	webAuthnService services.IWebAuthnService,
+	sessionService services.ISessionService,
+	securityEventService services.ISecurityEventService,
+	diagnosticsService services.IDiagnosticsService,
 ) *SettingsHandler {
 	return &SettingsHandler{
 		config:              conf.Get(),
@@ -84,6 +103,8 @@ func NewSettingsHandler(
 		aggregationSrvc:     aggregationService,
 		languageMappingSrvc: languageMappingService,
 		projectLabelSrvc:    projectLabelService,
+		dayOffSrvc:          dayOffService,
+		filterPresetSrvc:    filterPresetService,
 		userSrvc:            userService,
 		heartbeatSrvc:       heartbeatService,
 		durationSrvc:        durationService,
@@ -91,6 +112,9 @@ func NewSettingsHandler(
 		mailSrvc:            mailService,
 		apiKeySrvc:          apiKeyService,
 		WebAuthnSrvc:        webAuthnService,
+		sessionSrvc:         sessionService,
+		securityEventSrvc:   securityEventService,
+		diagnosticsSrvc:     diagnosticsService,
 		httpClient:          &http.Client{Timeout: 10 * time.Second},
 		aggregationLocks:    make(map[string]bool),
 	}
@@ -125,7 +149,15 @@ func (h *SettingsHandler) PostIndex(w http.ResponseWriter, r *http.Request) {
 		loadTemplates()
 	}
 
-	if err := r.ParseForm(); err != nil {
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") { // file uploads
+		r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+		err = r.ParseMultipartForm(maxUploadSize)
+	} else {
+		err = r.ParseForm()
+	}
+
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		err = templates[conf.SettingsTemplate].Execute(w, h.buildViewModel(r, w, nil).WithError("missing form values"))
 		if err != nil {
@@ -183,6 +215,20 @@ func (h *SettingsHandler) dispatchAction(action string) action {
 		return h.actionAddLabel
 	case "delete_label":
 		return h.actionDeleteLabel
+	case "update_working_days":
+		return h.actionUpdateWorkingDays
+	case "add_day_off":
+		return h.actionAddDayOff
+	case "delete_day_off":
+		return h.actionDeleteDayOff
+	case "add_filter_preset":
+		return h.actionAddFilterPreset
+	case "delete_filter_preset":
+		return h.actionDeleteFilterPreset
+	case "import_calendar":
+		return h.actionImportCalendar
+	case "clear_calendar":
+		return h.actionClearCalendar
 	case "delete_mapping":
 		return h.actionDeleteLanguageMapping
 	case "add_mapping":
@@ -207,6 +253,8 @@ func (h *SettingsHandler) dispatchAction(action string) action {
 		return h.actionUpdateExcludeUnknownProjects
 	case "update_heartbeats_timeout":
 		return h.actionUpdateHeartbeatsTimeout
+	case "update_focus_session_length":
+		return h.actionUpdateFocusSessionLength
 	case "update_readme_stats_base_url":
 		return h.actionUpdateReadmeStatsBaseUrl
 	case "add_api_key":
@@ -217,6 +265,12 @@ func (h *SettingsHandler) dispatchAction(action string) action {
 		return h.actionWebAuthnAdd
 	case "webauthn_delete":
 		return h.actionWebAuthnDelete
+	case "delete_session":
+		return h.actionDeleteSession
+	case "delete_all_sessions":
+		return h.actionDeleteAllSessions
+	case "clear_diagnostics":
+		return h.actionClearDiagnostics
 	}
 	return nil
 }
@@ -277,10 +331,17 @@ func (h *SettingsHandler) actionUpdateUser(w http.ResponseWriter, r *http.Reques
 		return actionResult{http.StatusBadRequest, "", "cannot unset email while subscription is active", nil}
 	}
 
+	if payload.ReportsPreset != "" {
+		if _, err := h.filterPresetSrvc.GetByUserAndSlug(user.ID, payload.ReportsPreset); err != nil {
+			return actionResult{http.StatusBadRequest, "", "unknown filter preset for reports", nil}
+		}
+	}
+
 	user.Email = payload.Email
 	user.Location = payload.Location
 	user.StartOfWeek = payload.StartOfWeek
 	user.ReportsWeekly = payload.ReportsWeekly
+	user.ReportsPreset = payload.ReportsPreset
 	user.PublicLeaderboard = payload.PublicLeaderboard
 
 	if _, err := h.userSrvc.Update(user); err != nil {
@@ -331,6 +392,16 @@ func (h *SettingsHandler) actionChangePassword(w http.ResponseWriter, r *http.Re
 		return actionResult{http.StatusInternalServerError, "", conf.ErrInternalServerError, nil}
 	}
 
+	// keep the current session, but log out all other devices
+	var currentSessionId string
+	if session := routeutils.GetSession(r); session != nil {
+		currentSessionId = session.ID
+	}
+	if err := h.sessionSrvc.DeleteByUser(user.ID, currentSessionId); err != nil {
+		conf.Log().Request(r).Error("failed to revoke sessions after password change", "user", user.ID, "error", err)
+	}
+	h.logSecurityEvent(r, user, models.SecurityEventPasswordChange, "")
+
 	return actionResult{http.StatusOK, "password was updated successfully", "", nil}
 }
 
@@ -374,6 +445,7 @@ func (h *SettingsHandler) actionResetApiKey(w http.ResponseWriter, r *http.Reque
 	if _, err := h.userSrvc.ResetApiKey(user); err != nil {
 		return actionResult{http.StatusInternalServerError, "", conf.ErrInternalServerError, nil}
 	}
+	h.logSecurityEvent(r, user, models.SecurityEventApiKeyReset, "")
 
 	msg := fmt.Sprintf("your new api key is: %s", user.ApiKey)
 	return actionResult{http.StatusOK, msg, "", nil}
@@ -455,6 +527,29 @@ func (h *SettingsHandler) actionUpdateHeartbeatsTimeout(w http.ResponseWriter, r
 	return actionResult{http.StatusOK, "Done. To apply this change to already existing data, please regenerate your summaries.", "", nil}
 }
 
+func (h *SettingsHandler) actionUpdateFocusSessionLength(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	var err error
+	user := middlewares.GetPrincipal(r)
+	defer h.userSrvc.FlushCache()
+
+	val, err := strconv.ParseInt(r.PostFormValue("focus_session_length"), 0, 0)
+	dur := time.Duration(val) * time.Minute
+	if err != nil || dur < models.MinFocusSessionLength || dur > models.MaxFocusSessionLength {
+		return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+	}
+	user.FocusSessionLengthSec = int(dur.Seconds())
+
+	if _, err := h.userSrvc.Update(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "Done. Focus sessions are now computed according to the new minimum length.", "", nil}
+}
+
 func (h *SettingsHandler) actionUpdateReadmeStatsBaseUrl(w http.ResponseWriter, r *http.Request) actionResult {
 	if h.config.IsDev() {
 		loadTemplates()
@@ -605,6 +700,177 @@ func (h *SettingsHandler) actionDeleteLabel(w http.ResponseWriter, r *http.Reque
 	return actionResult{http.StatusNotFound, "", "label not found", nil}
 }
 
+func (h *SettingsHandler) actionUpdateWorkingDays(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	defer h.userSrvc.FlushUserCache(user.ID)
+
+	weekdays := make([]time.Weekday, 0, len(r.PostForm["working_weekdays"]))
+	for _, val := range r.PostForm["working_weekdays"] {
+		weekday, err := strconv.Atoi(val)
+		if err != nil || weekday < 0 || weekday > 6 {
+			return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+		}
+		weekdays = append(weekdays, time.Weekday(weekday))
+	}
+	if len(weekdays) == 0 {
+		return actionResult{http.StatusBadRequest, "", "please select at least one working day", nil}
+	}
+	user.SetWorkingWeekdays(weekdays)
+
+	if _, err := h.userSrvc.Update(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "working days updated", "", nil}
+}
+
+func (h *SettingsHandler) actionAddDayOff(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+
+	from, err := time.ParseInLocation(time.DateOnly, r.PostFormValue("date_from"), user.TZ())
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "invalid date", nil}
+	}
+	to := from
+	if dateTo := r.PostFormValue("date_to"); dateTo != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, dateTo, user.TZ()); err != nil {
+			return actionResult{http.StatusBadRequest, "", "invalid date", nil}
+		}
+	}
+
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	if utf8.RuneCountInString(name) > models.MaxDayOffNameLength {
+		return actionResult{http.StatusBadRequest, "", "name too long", nil}
+	}
+
+	n, err := h.dayOffSrvc.CreateRange(user, from, to, name)
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", fmt.Sprintf("could not add days off – %v", err), nil}
+	}
+	if n == 1 {
+		return actionResult{http.StatusOK, "day off added successfully", "", nil}
+	}
+	return actionResult{http.StatusOK, fmt.Sprintf("%d days off added successfully", n), "", nil}
+}
+
+func (h *SettingsHandler) actionDeleteDayOff(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	id, err := strconv.Atoi(r.PostFormValue("day_off_id"))
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "could not delete day off", nil}
+	}
+
+	dayOff, err := h.dayOffSrvc.GetById(uint(id))
+	if err != nil || dayOff == nil {
+		return actionResult{http.StatusNotFound, "", "day off not found", nil}
+	} else if dayOff.UserID != user.ID {
+		return actionResult{http.StatusForbidden, "", "not allowed to delete day off", nil}
+	}
+
+	if err := h.dayOffSrvc.Delete(dayOff); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "could not delete day off", nil}
+	}
+	return actionResult{http.StatusOK, "day off deleted successfully", "", nil}
+}
+
+func (h *SettingsHandler) actionAddFilterPreset(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	if name == "" || len(name) > 255 {
+		return actionResult{http.StatusBadRequest, "", "invalid preset name", nil}
+	}
+
+	// filter values are given per entity type as comma-separated lists, each of which matches any of its values
+	query := url.Values{}
+	for param := range models.FilterQueryParams {
+		for _, v := range strings.Split(r.PostFormValue(param), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				query.Add(param, v)
+			}
+		}
+	}
+
+	preset, err := h.filterPresetSrvc.Create(user, name, r.PostFormValue("interval"), models.NewFiltersFromQuery(query))
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", fmt.Sprintf("could not save filter preset – %v", err), nil}
+	}
+	return actionResult{http.StatusOK, fmt.Sprintf("filter preset saved successfully, use it as '?preset=%s'", preset.Slug), "", nil}
+}
+
+func (h *SettingsHandler) actionDeleteFilterPreset(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	id, err := strconv.Atoi(r.PostFormValue("filter_preset_id"))
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "could not delete filter preset", nil}
+	}
+
+	preset, err := h.filterPresetSrvc.GetById(uint(id))
+	if err != nil || preset == nil {
+		return actionResult{http.StatusNotFound, "", "filter preset not found", nil}
+	} else if preset.UserID != user.ID {
+		return actionResult{http.StatusForbidden, "", "not allowed to delete filter preset", nil}
+	}
+
+	if err := h.filterPresetSrvc.Delete(preset); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "could not delete filter preset", nil}
+	}
+	return actionResult{http.StatusOK, "filter preset deleted successfully", "", nil}
+}
+
+func (h *SettingsHandler) actionImportCalendar(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+
+	file, _, err := r.FormFile("calendar_file")
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "missing calendar file", nil}
+	}
+	defer file.Close()
+
+	n, err := h.dayOffSrvc.ImportCalendar(user, file)
+	if err != nil {
+		conf.Log().Request(r).Warn("failed to import calendar", "user", user.ID, "error", err)
+		return actionResult{http.StatusBadRequest, "", fmt.Sprintf("Failed to import calendar – %v", err), nil}
+	}
+	return actionResult{http.StatusOK, fmt.Sprintf("imported %d days off from calendar", n), "", nil}
+}
+
+func (h *SettingsHandler) actionClearCalendar(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	if err := h.dayOffSrvc.DeleteImported(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "could not remove imported calendar", nil}
+	}
+	return actionResult{http.StatusOK, "imported calendar removed successfully", "", nil}
+}
+
 func (h *SettingsHandler) actionDeleteLanguageMapping(w http.ResponseWriter, r *http.Request) actionResult {
 	if h.config.IsDev() {
 		loadTemplates()
@@ -741,6 +1007,7 @@ func (h *SettingsHandler) actionImportWakatime(w http.ResponseWriter, r *http.Re
 		}
 		if importError != nil {
 			conf.Log().Error("wakatime import for user failed", "userID", user.ID, "error", importError)
+			metrics.ImportDuration.WithLabelValues(imports.OriginWakatime, metrics.ResultFailure).Observe(time.Since(start).Seconds())
 			return
 		}
 
@@ -756,7 +1023,9 @@ func (h *SettingsHandler) actionImportWakatime(w http.ResponseWriter, r *http.Re
 		insert := func(batch []*models.Heartbeat) {
 			if err := h.heartbeatSrvc.InsertBatch(batch); err != nil {
 				slog.Warn("failed to insert imported heartbeat, already existing?", "error", err)
+				return
 			}
+			metrics.HeartbeatsImported.WithLabelValues(imports.OriginWakatime).Add(float64(len(batch)))
 		}
 
 		for hb := range stream {
@@ -774,6 +1043,7 @@ func (h *SettingsHandler) actionImportWakatime(w http.ResponseWriter, r *http.Re
 
 		countAfter, _ := h.heartbeatSrvc.CountByUser(user)
 		slog.Info("downloaded heartbeats for user", "count", count, "userID", user.ID, "importedCount", countAfter-countBefore)
+		metrics.ImportDuration.WithLabelValues(imports.OriginWakatime, metrics.ResultSuccess).Observe(time.Since(start).Seconds())
 
 		h.regenerateSummaries(user)
 
@@ -851,6 +1121,20 @@ func (h *SettingsHandler) actionClearData(w http.ResponseWriter, r *http.Request
 	return actionResult{http.StatusAccepted, "deletion in progress, this may take a couple of seconds", "", nil}
 }
 
+func (h *SettingsHandler) actionClearDiagnostics(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	if err := h.diagnosticsSrvc.DeleteByUser(user.ID); err != nil {
+		conf.Log().Request(r).Error("failed to clear diagnostics", "user", user.ID, "error", err)
+		return actionResult{http.StatusInternalServerError, "", "could not delete diagnostics", nil}
+	}
+
+	return actionResult{http.StatusOK, "deleted plugin diagnostics", "", nil}
+}
+
 func (h *SettingsHandler) actionDeleteUser(w http.ResponseWriter, r *http.Request) actionResult {
 	if h.config.IsDev() {
 		loadTemplates()
@@ -953,14 +1237,16 @@ func (h *SettingsHandler) actionAddApiKey(w http.ResponseWriter, r *http.Request
 		loadTemplates()
 	}
 
+	user := middlewares.GetPrincipal(r)
 	apiKey := uuid.NewV4().String()
 
-	if _, err := h.apiKeySrvc.Create(&models.ApiKey{
-		User:     middlewares.GetPrincipal(r),
+	_, err := h.apiKeySrvc.Create(&models.ApiKey{
+		User:     user,
 		Label:    r.PostFormValue("api_name"),
 		ApiKey:   apiKey,
 		ReadOnly: r.PostFormValue("api_readonly") == "true",
-	}); err != nil {
+	})
+	if err != nil {
 		return actionResult{http.StatusInternalServerError, "", conf.ErrInternalServerError, nil}
 	}
 
@@ -1046,6 +1332,7 @@ func (h *SettingsHandler) actionWebAuthnAdd(w http.ResponseWriter, r *http.Reque
 	if err != nil {
 		return actionResult{http.StatusInternalServerError, "", "could not store webauthn credential", nil}
 	}
+	h.logSecurityEvent(r, user, models.SecurityEventWebAuthnAdd, authenticatorName)
 
 	return actionResult{http.StatusOK, "webauthn authenticator added successfully", "", nil}
 }
@@ -1072,9 +1359,58 @@ func (h *SettingsHandler) actionWebAuthnDelete(w http.ResponseWriter, r *http.Re
 	if err := h.WebAuthnSrvc.DeleteCredential(credential); err != nil {
 		return actionResult{http.StatusInternalServerError, "", "could not delete webauthn credential", nil}
 	}
+	h.logSecurityEvent(r, user, models.SecurityEventWebAuthnDelete, credentialName)
 	return actionResult{http.StatusOK, "webauthn authenticator deleted successfully", "", nil}
 }
 
+func (h *SettingsHandler) actionDeleteSession(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	sessionId := r.PostFormValue("session_id")
+
+	sessions, err := h.sessionSrvc.GetByUser(user.ID)
+	if err != nil {
+		return actionResult{http.StatusInternalServerError, "", "could not delete session", nil}
+	}
+
+	for _, s := range sessions {
+		if s.ID == sessionId {
+			if err := h.sessionSrvc.Delete(s); err != nil {
+				return actionResult{http.StatusInternalServerError, "", "could not delete session", nil}
+			}
+			if current := routeutils.GetSession(r); current != nil && current.ID == s.ID {
+				routeutils.SetSuccess(r, w, "you were logged out")
+				http.SetCookie(w, h.config.GetClearCookie(models.AuthCookieKey))
+				http.Redirect(w, r, h.config.Server.BasePath, http.StatusFound)
+				return actionResult{-1, "", "", nil}
+			}
+			return actionResult{http.StatusOK, "session logged out successfully", "", nil}
+		}
+	}
+	return actionResult{http.StatusNotFound, "", "session not found", nil}
+}
+
+func (h *SettingsHandler) actionDeleteAllSessions(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	if err := h.sessionSrvc.DeleteByUser(user.ID, ""); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "could not delete sessions", nil}
+	}
+	h.logSecurityEvent(r, user, models.SecurityEventSessionsRevoke, "")
+
+	h.userSrvc.FlushUserCache(user.ID)
+	routeutils.SetSuccess(r, w, "you were logged out on all devices")
+	http.SetCookie(w, h.config.GetClearCookie(models.AuthCookieKey))
+	http.Redirect(w, r, h.config.Server.BasePath, http.StatusFound)
+	return actionResult{-1, "", "", nil}
+}
+
 func (h *SettingsHandler) buildViewModel(r *http.Request, w http.ResponseWriter, args *map[string]interface{}) *view.SettingsViewModel {
 	user := middlewares.GetPrincipal(r)
 
@@ -1142,6 +1478,50 @@ func (h *SettingsHandler) buildViewModel(r *http.Request, w http.ResponseWriter,
 		return strings.Compare(combinedLabels[i].Key, combinedLabels[j].Key) < 0
 	})
 
+	// working days
+	daysOff, err := h.dayOffSrvc.GetByUser(user.ID)
+	if err != nil {
+		conf.Log().Request(r).Error("error while fetching user's days off", "user", user.ID, "error", err)
+		return &view.SettingsViewModel{
+			SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+				SharedViewModel: view.NewSharedViewModel(h.config, &view.Messages{Error: criticalError}),
+				User:            user,
+			},
+		}
+	}
+
+	manualDaysOff, importedDaysOff := make([]*models.DayOff, 0), make([]*models.DayOff, 0)
+	var numImportedDaysOff int
+	today := utils.BeginOfToday(user.TZ()).Format(time.DateOnly)
+	for _, d := range daysOff {
+		if d.Source != models.DayOffSourceCalendar {
+			manualDaysOff = append(manualDaysOff, d)
+			continue
+		}
+		numImportedDaysOff++
+		if d.Date >= today && len(importedDaysOff) < importedDaysOffLimit {
+			importedDaysOff = append(importedDaysOff, d)
+		}
+	}
+
+	workingWeekdays := make([]*view.SettingsVMWeekday, 7)
+	for i := range workingWeekdays {
+		weekday := (user.StartOfWeekDay() + time.Weekday(i)) % 7
+		workingWeekdays[i] = &view.SettingsVMWeekday{Weekday: weekday, Working: user.IsWorkingWeekday(weekday)}
+	}
+
+	// filter presets
+	filterPresets, err := h.filterPresetSrvc.GetByUser(user.ID)
+	if err != nil {
+		conf.Log().Request(r).Error("error while fetching user's filter presets", "user", user.ID, "error", err)
+		return &view.SettingsViewModel{
+			SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+				SharedViewModel: view.NewSharedViewModel(h.config, &view.Messages{Error: criticalError}),
+				User:            user,
+			},
+		}
+	}
+
 	// projects
 	projects, err := routeutils.GetEffectiveProjectsList(user, h.heartbeatSrvc, h.aliasSrvc)
 	if err != nil {
@@ -1213,6 +1593,54 @@ func (h *SettingsHandler) buildViewModel(r *http.Request, w http.ResponseWriter,
 		}
 	}
 
+	// sessions
+	sessions, err := h.sessionSrvc.GetByUser(user.ID)
+	if err != nil {
+		conf.Log().Request(r).Error("error while fetching user's sessions", "user", user.ID, "error", err)
+		return &view.SettingsViewModel{
+			SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+				SharedViewModel: view.NewSharedViewModel(h.config, &view.Messages{Error: criticalError}),
+				User:            user,
+			},
+		}
+	}
+	var currentSessionId string
+	if session := routeutils.GetSession(r); session != nil {
+		currentSessionId = session.ID
+	}
+
+	// security log
+	securityEvents, err := h.securityEventSrvc.GetByUser(user.ID, securityLogLimit)
+	if err != nil {
+		conf.Log().Request(r).Error("error while fetching user's security events", "user", user.ID, "error", err)
+		return &view.SettingsViewModel{
+			SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+				SharedViewModel: view.NewSharedViewModel(h.config, &view.Messages{Error: criticalError}),
+				User:            user,
+			},
+		}
+	}
+
+	// plugin diagnostics
+	diagnostics, err := h.diagnosticsSrvc.GetByUser(user.ID, diagnosticsLimit)
+	if err != nil {
+		conf.Log().Request(r).Error("error while fetching user's diagnostics", "user", user.ID, "error", err)
+		return &view.SettingsViewModel{
+			SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+				SharedViewModel: view.NewSharedViewModel(h.config, &view.Messages{Error: criticalError}),
+				User:            user,
+			},
+		}
+	}
+
+	// diagnostics of all users, including anonymous ones, are only visible to admins
+	var adminDiagnostics []*models.Diagnostics
+	if user.IsAdmin {
+		if adminDiagnostics, err = h.diagnosticsSrvc.GetAll(diagnosticsLimit); err != nil {
+			conf.Log().Request(r).Error("error while fetching all diagnostics", "user", user.ID, "error", err)
+		}
+	}
+
 	// readme card params
 	readmeCardTitle := "Wakapi.dev Stats"
 	if err, maxRange := helpers.ResolveMaximumRange(user.ShareDataMaxDays); err == nil {
@@ -1227,6 +1655,11 @@ func (h *SettingsHandler) buildViewModel(r *http.Request, w http.ResponseWriter,
 		LanguageMappings:      mappings,
 		Aliases:               combinedAliases,
 		Labels:                combinedLabels,
+		WorkingWeekdays:       workingWeekdays,
+		DaysOff:               manualDaysOff,
+		UpcomingImportedDays:  importedDaysOff,
+		NumImportedDaysOff:    numImportedDaysOff,
+		FilterPresets:         view.NewFilterPresetViewModels(filterPresets),
 		Projects:              projects,
 		UserFirstData:         firstData,
 		SubscriptionPrice:     subscriptionPrice,
@@ -1237,11 +1670,22 @@ func (h *SettingsHandler) buildViewModel(r *http.Request, w http.ResponseWriter,
 		WebAuthnCredentials:   user.Credentials,
 		ReadmeCardCustomTitle: readmeCardTitle,
 		DisableWebAuthn:       h.config.Security.DisableWebAuthn,
+		Sessions:              sessions,
+		CurrentSessionId:      currentSessionId,
+		SecurityEvents:        securityEvents,
+		Diagnostics:           diagnostics,
+		AdminDiagnostics:      adminDiagnostics,
 	}
 
 	return routeutils.WithSessionMessages(vm, r, w)
 }
 
+func (h *SettingsHandler) logSecurityEvent(r *http.Request, user *models.User, eventType models.SecurityEventType, details string) {
+	if _, err := h.securityEventSrvc.Log(user, eventType, middleware.GetClientIP(r.Context()), r.UserAgent(), details); err != nil {
+		conf.Log().Request(r).Error("failed to log security event", "user", user.ID, "event", eventType, "error", err)
+	}
+}
+
 func (h *SettingsHandler) toggleAggregationLock(userId string, locked bool) {
 	h.aggregationLocks[userId] = locked
 }
@@ -76,6 +76,10 @@ func NewSubscriptionHandler(
 	onUserDelete := eventBus.Subscribe(0, conf.EventUserDelete)
 	go func(sub *hub.Subscription) {
 		for m := range sub.Receiver {
+			if conf.IsRemoteEvent(m) {
+				continue
+			}
+
 			user := m.Fields[conf.FieldPayload].(*models.User)
 			if !user.HasActiveSubscription() {
 				continue
@@ -1,10 +1,13 @@
 package routes
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/duke-git/lancet/v2/datetime"
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/go-chi/chi/v5"
 	conf "github.com/muety/wakapi/config"
@@ -23,22 +26,28 @@ const (
 )
 
 type SummaryHandler struct {
-	config         *conf.Config
-	userSrvc       services.IUserService
-	summarySrvc    services.ISummaryService
-	durationSrvc   services.IDurationService
-	aliasSrvc      services.IAliasService
-	heartbeatsSrvc services.IHeartbeatService
+	config           *conf.Config
+	userSrvc         services.IUserService
+	summarySrvc      services.ISummaryService
+	durationSrvc     services.IDurationService
+	aliasSrvc        services.IAliasService
+	heartbeatsSrvc   services.IHeartbeatService
+	dayOffSrvc       services.IDayOffService
+	focusSrvc        services.IFocusService
+	filterPresetSrvc services.IFilterPresetService
 }
 
-func NewSummaryHandler(summaryService services.ISummaryService, userService services.IUserService, heartbeatsService services.IHeartbeatService, durationService services.IDurationService, aliasService services.IAliasService) *SummaryHandler {
+func NewSummaryHandler(summaryService services.ISummaryService, userService services.IUserService, heartbeatsService services.IHeartbeatService, durationService services.IDurationService, aliasService services.IAliasService, dayOffService services.IDayOffService, focusService services.IFocusService, filterPresetService services.IFilterPresetService) *SummaryHandler {
 	return &SummaryHandler{
-		summarySrvc:    summaryService,
-		userSrvc:       userService,
-		heartbeatsSrvc: heartbeatsService,
-		durationSrvc:   durationService,
-		aliasSrvc:      aliasService,
-		config:         conf.Get(),
+		summarySrvc:      summaryService,
+		userSrvc:         userService,
+		heartbeatsSrvc:   heartbeatsService,
+		durationSrvc:     durationService,
+		aliasSrvc:        aliasService,
+		dayOffSrvc:       dayOffService,
+		focusSrvc:        focusService,
+		filterPresetSrvc: filterPresetService,
+		config:           conf.Get(),
 	}
 }
 
@@ -59,26 +68,37 @@ func (h *SummaryHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rawQuery := r.URL.RawQuery
+	requestQuery := r.URL.Query()
+
+	// expand a filter preset (e.g. "?preset=client-a") into the query, while keeping the original query for links on the page
+	preset, err, status := su.ApplyFilterPreset(r, h.filterPresetSrvc, middlewares.GetPrincipal(r), "interval")
+	if err != nil {
+		w.WriteHeader(status)
+		templates[conf.SummaryTemplate].Execute(w, h.buildViewModel(r, w).WithError(err.Error()))
+		return
+	}
+
 	q := r.URL.Query()
 	if q.Get("interval") == "" && q.Get("from") == "" {
 		// If the PersistentIntervalKey cookie is set, redirect to the correct summary page,
 		// preserving any other query params (e.g. a project filter)
 		if intervalCookie, _ := r.Cookie(models.PersistentIntervalKey); intervalCookie != nil {
-			q.Set("interval", intervalCookie.Value)
-			http.Redirect(w, r, fmt.Sprintf("%s/summary?%s", h.config.Server.BasePath, q.Encode()), http.StatusFound)
+			requestQuery.Set("interval", intervalCookie.Value)
+			http.Redirect(w, r, fmt.Sprintf("%s/summary?%s", h.config.Server.BasePath, requestQuery.Encode()), http.StatusFound)
 			return
 		}
 
 		q.Set("interval", "today")
 		r.URL.RawQuery = q.Encode()
-	} else if q.Get("interval") != "" {
-		// Send a Set-Cookie header to persist the interval
-		headerValue := fmt.Sprintf("%s=%s", models.PersistentIntervalKey, q.Get("interval"))
+	} else if requestQuery.Get("interval") != "" {
+		// Send a Set-Cookie header to persist the interval (unless only given by a preset)
+		headerValue := fmt.Sprintf("%s=%s", models.PersistentIntervalKey, requestQuery.Get("interval"))
 		w.Header().Add("Set-Cookie", headerValue)
 	}
 
 	summaryParams, _ := helpers.ParseSummaryParams(r)
-	summary, err, status := su.LoadUserSummaryByParams(h.summarySrvc, summaryParams)
+	filterQuery := summaryParams.Filters.Query() // before being altered by alias resolution
+	summary, err, status := su.LoadUserSummaryByParams(r.Context(), h.summarySrvc, summaryParams)
 	if err != nil {
 		conf.Log().Request(r).Error("failed to load summary", "error", err)
 		w.WriteHeader(status)
@@ -86,7 +106,7 @@ func (h *SummaryHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// retrieved for showing all available filters
-	summaryWithoutFilter, err, status := su.LoadUserSummaryWithoutFilter(h.summarySrvc, summaryParams)
+	summaryWithoutFilter, err, status := su.LoadUserSummaryWithoutFilter(r.Context(), h.summarySrvc, summaryParams)
 	if err != nil {
 		conf.Log().Request(r).Error("failed to load summary", "error", err)
 		w.WriteHeader(status)
@@ -102,6 +122,25 @@ func (h *SummaryHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// period comparison (e.g. this week vs. last week)
+	var comparison *models.SummaryComparison
+	comparisonParams, err := helpers.ParseSummaryComparisonParams(r, summaryParams)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		templates[conf.SummaryTemplate].Execute(w, h.buildViewModel(r, w).WithError(err.Error()))
+		return
+	}
+	if comparisonParams != nil {
+		previousSummary, err, status := su.LoadUserSummaryByParams(r.Context(), h.summarySrvc, comparisonParams)
+		if err != nil {
+			conf.Log().Request(r).Error("failed to load summary for comparison", "error", err)
+			w.WriteHeader(status)
+			templates[conf.SummaryTemplate].Execute(w, h.buildViewModel(r, w).WithError(err.Error()))
+			return
+		}
+		comparison = models.NewSummaryComparison(summaryParams, summary, comparisonParams, previousSummary)
+	}
+
 	// user first data
 	firstData, err := h.heartbeatsSrvc.GetFirstByUser(user)
 	if err != nil {
@@ -114,7 +153,7 @@ func (h *SummaryHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 	// timeline data (daily stats)
 	var timeline []*view.TimelineViewModel
 	if rangeDays := summaryParams.RangeDays(); rangeDays >= dailyStatsMinRangeDays && rangeDays <= dailyStatsMaxRangeDays {
-		dailyStatsSummaries, err := h.fetchSplitSummaries(summaryParams)
+		dailyStatsSummaries, err := h.fetchSplitSummaries(r.Context(), summaryParams)
 		if err != nil {
 			conf.Log().Request(r).Error("failed to load timeline stats", "error", err)
 		} else {
@@ -122,13 +161,38 @@ func (h *SummaryHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// daily average (only for multi-day ranges, excluding weekends, holidays and vacations)
+	var dailyAverage time.Duration
+	var numWorkingDays int
+	averageFrom := summaryParams.From
+	if firstDay := datetime.BeginOfDay(firstData.In(user.TZ())); firstDay.After(averageFrom) {
+		averageFrom = firstDay // e.g. for "all time" interval
+	}
+	if rangeDays := int(summaryParams.To.Sub(averageFrom).Hours() / 24); rangeDays > 1 {
+		calendar, err := h.dayOffSrvc.GetCalendar(user)
+		if err != nil {
+			conf.Log().Request(r).Error("failed to load work calendar", "user", user.ID, "error", err)
+		}
+		if numWorkingDays = calendar.CountWorkingDays(averageFrom.In(user.TZ()), rangeDays); numWorkingDays > 0 {
+			dailyAverage = summary.TotalTime() / time.Duration(numWorkingDays)
+		}
+	}
+
+	// focus sessions (computed from raw durations, thus only for shorter ranges)
+	var focus *models.FocusStats
+	if summaryParams.RangeDays() <= dailyStatsMaxRangeDays {
+		if focus, err = h.focusSrvc.GetStats(r.Context(), user, &models.FocusParams{From: summaryParams.From, To: summaryParams.To, Filters: summaryParams.Filters}, false); err != nil {
+			conf.Log().Request(r).Error("failed to load focus stats", "user", user.ID, "error", err)
+		}
+	}
+
 	// hourly breakdown data
 	var hourlyBreakdown view.HourlyBreakdownsViewModel
 	hourlyBreakdownFrom := summaryParams.From
 	if summaryParams.RangeDays() > 1 { // get at most 24 hours of hourly breakdown
 		hourlyBreakdownFrom = summaryParams.To.Add(-24 * time.Hour)
 	}
-	if durations, err := h.durationSrvc.Get(hourlyBreakdownFrom, summaryParams.To, summaryParams.User, summaryParams.Filters, nil, false); err == nil {
+	if durations, err := h.durationSrvc.Get(r.Context(), hourlyBreakdownFrom, summaryParams.To, summaryParams.User, summaryParams.Filters, nil, false); err == nil {
 		// for excessively many small segments, plotting is too performance-heavy and will freeze the browser (see https://github.com/muety/wakapi/issues/871)
 		// and the chart would be unreadable anyway, so we simply disable it
 		if len(durations) <= 200 {
@@ -141,6 +205,18 @@ func (h *SummaryHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 		conf.Log().Request(r).Error("failed to load hourly breakdown stats", "error", err)
 	}
 
+	// ai sessions are only derived where they are shown, i.e. for the ai section
+	if withSessions, err := h.summarySrvc.WithAiSessions(r.Context(), summary, summaryParams.User, summaryParams.Filters); err == nil {
+		summary = withSessions
+	} else {
+		conf.Log().Request(r).Error("failed to load ai sessions", "error", err)
+	}
+
+	filterPresets, err := h.filterPresetSrvc.GetByUser(user.ID)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to load filter presets", "user", user.ID, "error", err)
+	}
+
 	vm := view.SummaryViewModel{
 		SharedLoggedInViewModel: view.SharedLoggedInViewModel{
 			SharedViewModel: view.NewSharedViewModel(h.config, nil),
@@ -153,11 +229,21 @@ func (h *SummaryHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 		LanguageColors:      su.FilterColors(h.config.App.GetLanguageColors(), summary.Languages),
 		OSColors:            su.FilterColors(h.config.App.GetOSColors(), summary.OperatingSystems),
 		RawQuery:            rawQuery,
+		BaseQuery:           h.stripComparisonParams(rawQuery),
 		UserFirstData:       firstData,
 		DataRetentionMonths: h.config.App.DataRetentionMonths,
 		Timeline:            timeline,
+		AIStats:             summary.AIStats(),
+		Comparison:          comparison,
+		DailyAverage:        dailyAverage,
+		NumWorkingDays:      numWorkingDays,
+		Focus:               focus,
 		HourlyBreakdown:     hourlyBreakdown,
 		HourlyBreakdownFrom: hourlyBreakdownFrom,
+		FilterPresets:       view.NewFilterPresetViewModels(filterPresets),
+		ActivePreset:        preset,
+		Interval:            q.Get("interval"),
+		FilterQuery:         filterQuery,
 	}
 
 	templates[conf.SummaryTemplate].Execute(w, vm)
@@ -173,11 +259,11 @@ func (h *SummaryHandler) buildViewModel(r *http.Request, w http.ResponseWriter)
 	}, r, w)
 }
 
-func (h *SummaryHandler) fetchSplitSummaries(params *models.SummaryParams) ([]*models.Summary, error) {
+func (h *SummaryHandler) fetchSplitSummaries(ctx context.Context, params *models.SummaryParams) ([]*models.Summary, error) {
 	summaries := make([]*models.Summary, 0)
 	intervals := utils.SplitRangeByDays(params.From, params.To)
 	for _, interval := range intervals {
-		curSummary, err := h.summarySrvc.Aliased(interval[0], interval[1], params.User, h.summarySrvc.Retrieve, params.Filters, nil, false)
+		curSummary, err := h.summarySrvc.Aliased(ctx, interval[0], interval[1], params.User, h.summarySrvc.Retrieve, params.Filters, nil, false)
 		if err != nil {
 			return nil, err
 		}
@@ -186,6 +272,15 @@ func (h *SummaryHandler) fetchSplitSummaries(params *models.SummaryParams) ([]*m
 	return summaries, nil
 }
 
+// stripComparisonParams removes all comparison-related parameters from the given query to allow for (un-)setting them
+func (h *SummaryHandler) stripComparisonParams(rawQuery string) string {
+	q, _ := url.ParseQuery(rawQuery)
+	q.Del("compare")
+	q.Del("compare_from")
+	q.Del("compare_to")
+	return q.Encode()
+}
+
 // extractAvailableFilters extracts available filter names from a summary's various item collections.
 func (h *SummaryHandler) extractAvailableFilters(summary *models.Summary) view.AvailableFilters {
 	return view.AvailableFilters{
@@ -24,18 +24,28 @@ func init() {
 }
 
 func GetBadgeParams(reqPath string, authorizedUser, requestedUser *models.User) (*models.KeyedInterval, *models.Filters, error) {
+	var intervalRaw, filterRaw string
+	if groups := intervalReg.FindStringSubmatch(reqPath); len(groups) > 1 {
+		intervalRaw = groups[1]
+	}
+	if groups := entityFilterReg.FindStringSubmatch(reqPath); len(groups) > 2 {
+		filterRaw = groups[0]
+	}
+	return ResolveBadgeParams(intervalRaw, filterRaw, authorizedUser, requestedUser)
+}
+
+// ResolveBadgeParams resolves a raw interval (e.g. "last_7_days") and a raw filter (e.g. "project:wakapi" or "project:!wakapi" to exclude) and checks whether the requested data may be shared publicly
+func ResolveBadgeParams(intervalRaw, filterRaw string, authorizedUser, requestedUser *models.User) (*models.KeyedInterval, *models.Filters, error) {
 	isSameUser := authorizedUser != nil && authorizedUser.ID == requestedUser.ID
 
 	var filterEntity, filterKey string
-	if groups := entityFilterReg.FindStringSubmatch(reqPath); len(groups) > 2 {
+	if groups := entityFilterReg.FindStringSubmatch(filterRaw); len(groups) > 2 {
 		filterEntity, filterKey = groups[1], groups[2]
 	}
 
 	var intervalKey = models.IntervalPast30Days
-	if groups := intervalReg.FindStringSubmatch(reqPath); len(groups) > 1 {
-		if i, err := helpers.ParseInterval(groups[1]); err == nil {
-			intervalKey = i
-		}
+	if i, err := helpers.ParseInterval(intervalRaw); err == nil {
+		intervalKey = i
 	}
 
 	_, rangeFrom, rangeTo := helpers.ResolveIntervalTZ(intervalKey, requestedUser.TZ(), requestedUser.StartOfWeekDay())
@@ -55,22 +65,22 @@ func GetBadgeParams(reqPath string, authorizedUser, requestedUser *models.User)
 	switch filterEntity {
 	case "project":
 		permitEntity = requestedUser.ShareProjects
-		filters = models.NewFiltersWith(models.SummaryProject, filterKey)
+		filters = models.NewFiltersWithQueryValue(models.SummaryProject, filterKey)
 	case "os":
 		permitEntity = requestedUser.ShareOSs
-		filters = models.NewFiltersWith(models.SummaryOS, filterKey)
+		filters = models.NewFiltersWithQueryValue(models.SummaryOS, filterKey)
 	case "editor":
 		permitEntity = requestedUser.ShareEditors
-		filters = models.NewFiltersWith(models.SummaryEditor, filterKey)
+		filters = models.NewFiltersWithQueryValue(models.SummaryEditor, filterKey)
 	case "language":
 		permitEntity = requestedUser.ShareLanguages
-		filters = models.NewFiltersWith(models.SummaryLanguage, filterKey)
+		filters = models.NewFiltersWithQueryValue(models.SummaryLanguage, filterKey)
 	case "machine":
 		permitEntity = requestedUser.ShareMachines
-		filters = models.NewFiltersWith(models.SummaryMachine, filterKey)
+		filters = models.NewFiltersWithQueryValue(models.SummaryMachine, filterKey)
 	case "label":
 		permitEntity = requestedUser.ShareLabels
-		filters = models.NewFiltersWith(models.SummaryLabel, filterKey)
+		filters = models.NewFiltersWithQueryValue(models.SummaryLabel, filterKey)
 		// branches are intentionally omitted here, as only relevant in combination with a project filter
 	default:
 		// non-entity-specific request, just a general, in-total query
@@ -84,3 +94,49 @@ func GetBadgeParams(reqPath string, authorizedUser, requestedUser *models.User)
 
 	return interval, filters, nil
 }
+
+// GetBadgePresetParams is like GetBadgeParams, but takes the filters (and, unless given in the path, the interval) from the requested user's filter preset
+func GetBadgePresetParams(reqPath string, preset *models.FilterPreset, authorizedUser, requestedUser *models.User) (*models.KeyedInterval, *models.Filters, error) {
+	var intervalRaw string
+	if groups := intervalReg.FindStringSubmatch(reqPath); len(groups) > 1 {
+		intervalRaw = groups[1]
+	}
+	return ResolveBadgePreset(preset, intervalRaw, authorizedUser, requestedUser)
+}
+
+// ResolveBadgePreset resolves the filters and interval of a filter preset, whereas an explicitly given raw interval takes precedence, and checks whether the filtered data may be shared publicly
+func ResolveBadgePreset(preset *models.FilterPreset, intervalRaw string, authorizedUser, requestedUser *models.User) (*models.KeyedInterval, *models.Filters, error) {
+	isSameUser := authorizedUser != nil && authorizedUser.ID == requestedUser.ID
+
+	if intervalRaw == "" {
+		intervalRaw = preset.Interval
+	}
+	interval, _, err := ResolveBadgeParams(intervalRaw, "", authorizedUser, requestedUser)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filters := preset.ParsedFilters()
+	if !isSameUser && !canShareFilters(filters, requestedUser) {
+		return nil, nil, errors.New("user did not opt in to share entity-specific data")
+	}
+
+	return interval, filters, nil
+}
+
+func canShareFilters(filters *models.Filters, user *models.User) bool {
+	permitted := map[uint8]bool{
+		models.SummaryProject:  user.ShareProjects,
+		models.SummaryOS:       user.ShareOSs,
+		models.SummaryEditor:   user.ShareEditors,
+		models.SummaryLanguage: user.ShareLanguages,
+		models.SummaryMachine:  user.ShareMachines,
+		models.SummaryLabel:    user.ShareLabels,
+	}
+	for _, t := range models.SummaryTypes() {
+		if filters.HasType(t) && !permitted[t] {
+			return false
+		}
+	}
+	return true
+}
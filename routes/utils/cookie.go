@@ -10,49 +10,65 @@ import (
 )
 
 type cookieKeyData struct {
-	Username string `json:"username,omitempty"`
-	Expiry   int64  `json:"expiry,omitempty"`
+	Username  string `json:"username,omitempty"`
+	SessionId string `json:"session_id,omitempty"`
+	Expiry    int64  `json:"expiry,omitempty"`
 }
 
-func CreateAuthCookie(username string) (*http.Cookie, error) {
-	config := conf.Get()
+func CreateAuthCookie(session *models.Session) (*http.Cookie, error) {
+	return createSessionCookie(models.AuthCookieKey, session)
+}
 
-	var expiry time.Time
-	if config.Security.CookieMaxAgeSec > 0 {
-		expiry = time.Now().Add(time.Duration(config.Security.CookieMaxAgeSec) * time.Second) // expiration time is set, encode it in the cookie, so they cannot be used after expiry
-	} else {
-		expiry = time.Now().Add(2 * time.Hour) // cookies only last for the session, so we set the expiry to 2h, which should last long enough for most sessions
-	}
+// CreateOidcSessionCookie creates a cookie referencing the server-side session of an oidc login.
+// Unlike the auth cookie, it does not authenticate a user on its own, but only in combination with a valid id token.
+func CreateOidcSessionCookie(session *models.Session) (*http.Cookie, error) {
+	return createSessionCookie(models.OidcSessionCookieKey, session)
+}
+
+func ExtractCookieAuth(r *http.Request) (username *string, sessionId *string, err error) {
+	return extractSessionCookie(r, models.AuthCookieKey)
+}
+
+func ExtractOidcSessionCookie(r *http.Request) (username *string, sessionId *string, err error) {
+	return extractSessionCookie(r, models.OidcSessionCookieKey)
+}
+
+func createSessionCookie(key string, session *models.Session) (*http.Cookie, error) {
+	config := conf.Get()
 
 	cookieData := cookieKeyData{
-		Username: username,
-		Expiry:   expiry.Unix(),
+		Username:  session.UserID,
+		SessionId: session.ID,
+		Expiry:    session.ExpiresAt.T().Unix(), // encode expiration time in the cookie, so they cannot be used after expiry
 	}
-	encoded, err := conf.GetAuthCookie().Encode(models.AuthCookieKey, cookieData)
+	encoded, err := conf.GetAuthCookie().Encode(key, cookieData)
 	if err != nil {
 		return nil, err
 	}
 
-	return config.CreateCookie(models.AuthCookieKey, encoded), nil
+	return config.CreateCookie(key, encoded), nil
 }
 
-func ExtractCookieAuth(r *http.Request) (username *string, err error) {
-	cookie, err := r.Cookie(models.AuthCookieKey)
+func extractSessionCookie(r *http.Request, key string) (username *string, sessionId *string, err error) {
+	cookie, err := r.Cookie(key)
 	if err != nil {
-		return nil, errors.New("missing authentication")
+		return nil, nil, errors.New("missing authentication")
 	}
 
 	var cookieData cookieKeyData
-	if err := conf.GetAuthCookie().Decode(models.AuthCookieKey, cookie.Value, &cookieData); err != nil {
-		return nil, errors.New("cookie is invalid")
+	if err := conf.GetAuthCookie().Decode(key, cookie.Value, &cookieData); err != nil {
+		return nil, nil, errors.New("cookie is invalid")
 	}
 
 	if cookieData.Username == "" {
-		return nil, errors.New("missing username")
+		return nil, nil, errors.New("missing username")
+	}
+	if cookieData.SessionId == "" {
+		return nil, nil, errors.New("missing session") // cookies issued before server-side sessions were introduced
 	}
 	if time.Now().After(time.Unix(cookieData.Expiry, 0)) {
-		return nil, errors.New("cookie is expired")
+		return nil, nil, errors.New("cookie is expired")
 	}
 
-	return &cookieData.Username, nil
+	return &cookieData.Username, &cookieData.SessionId, nil
 }
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/services"
+)
+
+// ApplyFilterPreset expands the filter preset referenced by the request's "preset" query parameter (if any) into the request's query. The preset's filters are
+// added for every filter parameter not given explicitly and its interval is set as intervalParam (e.g. "interval" or "range"), unless the request specifies a time range itself.
+func ApplyFilterPreset(r *http.Request, presetService services.IFilterPresetService, user *models.User, intervalParam string) (*models.FilterPreset, error, int) {
+	query := r.URL.Query()
+	slug := query.Get(models.FilterPresetQueryParam)
+	if slug == "" || user == nil {
+		return nil, nil, http.StatusOK
+	}
+
+	preset, err := presetService.GetByUserAndSlug(user.ID, slug)
+	if errors.Is(err, services.ErrFilterPresetNotFound) {
+		return nil, err, http.StatusNotFound
+	}
+	if err != nil {
+		return nil, err, http.StatusInternalServerError
+	}
+
+	for param, values := range preset.ParsedFilters().Query() {
+		if query.Get(param) == "" {
+			query[param] = values
+		}
+	}
+	if preset.Interval != "" && query.Get(intervalParam) == "" && query.Get("start") == "" && query.Get("from") == "" {
+		query.Set(intervalParam, preset.Interval)
+	}
+
+	r.URL.RawQuery = query.Encode()
+	return preset, nil, http.StatusOK
+}
@@ -28,3 +28,25 @@ func GetPrincipal(r *http.Request) *models.User {
 	}
 	return nil
 }
+
+func SetSession(r *http.Request, session *models.Session) {
+	if p := r.Context().Value(config.KeySharedData).(*config.SharedData); p != nil {
+		p.Set(config.MiddlewareKeySession, session)
+	}
+}
+
+// GetSession returns the login session of the current request, if authenticated by cookie
+func GetSession(r *http.Request) *models.Session {
+	sharedData := r.Context().Value(config.KeySharedData)
+	if sharedData == nil {
+		return nil
+	}
+	if p := sharedData.(*config.SharedData); p != nil {
+		val := p.MustGet(config.MiddlewareKeySession)
+		if val == nil {
+			return nil
+		}
+		return val.(*models.Session)
+	}
+	return nil
+}
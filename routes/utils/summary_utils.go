@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -15,16 +16,17 @@ func LoadUserSummary(ss services.ISummaryService, r *http.Request) (*models.Summ
 	if err != nil {
 		return nil, err, http.StatusBadRequest
 	}
-	return LoadUserSummaryByParams(ss, summaryParams)
+	return LoadUserSummaryByParams(r.Context(), ss, summaryParams)
 }
 
-func LoadUserSummaryByParams(ss services.ISummaryService, params *models.SummaryParams) (*models.Summary, error, int) {
+func LoadUserSummaryByParams(ctx context.Context, ss services.ISummaryService, params *models.SummaryParams) (*models.Summary, error, int) {
 	var retrieveSummary types.SummaryRetriever = ss.Retrieve
 	if params.Recompute {
 		retrieveSummary = ss.Summarize
 	}
 
 	summary, err := ss.Aliased(
+		ctx,
 		params.From,
 		params.To,
 		params.User,
@@ -44,13 +46,14 @@ func LoadUserSummaryByParams(ss services.ISummaryService, params *models.Summary
 	return summary, nil, http.StatusOK
 }
 
-func LoadUserSummaryWithoutFilter(ss services.ISummaryService, params *models.SummaryParams) (*models.Summary, error, int) {
+func LoadUserSummaryWithoutFilter(ctx context.Context, ss services.ISummaryService, params *models.SummaryParams) (*models.Summary, error, int) {
 	var retrieveSummary types.SummaryRetriever = ss.Retrieve
 	if params.Recompute {
 		retrieveSummary = ss.Summarize
 	}
 
 	summary, err := ss.Aliased(
+		ctx,
 		params.From,
 		params.To,
 		params.User,
@@ -34,9 +34,14 @@ type WebAuthnTestSuite struct {
 	UserService            *mocks.UserServiceMock
 	WebauthnService        *mocks.WebAuthnServiceMock
 	ProjectLabelService    *mocks.ProjectLabelServiceMock
+	DayOffService          *mocks.DayOffServiceMock
+	FilterPresetService    *mocks.FilterPresetServiceMock
 	ApiKeyService          *mocks.MockApiKeyService
 	HeartbeatService       *mocks.HeartbeatServiceMock
 	LanguageMappingService *mocks.LanguageMappingServiceMock
+	SessionService         *mocks.SessionServiceMock
+	SecurityService        *mocks.SecurityEventServiceMock
+	DiagnosticsService     *mocks.DiagnosticsServiceMock
 	UserNonLocal           *models.User
 	UserA                  *models.User
 	UserB                  *models.User
@@ -65,10 +70,15 @@ func (suite *WebAuthnTestSuite) BeforeTest(suiteName, testName string) {
 	suite.WebauthnService = new(mocks.WebAuthnServiceMock)
 	suite.HeartbeatService = new(mocks.HeartbeatServiceMock)
 	suite.ProjectLabelService = new(mocks.ProjectLabelServiceMock)
+	suite.DayOffService = new(mocks.DayOffServiceMock)
+	suite.FilterPresetService = new(mocks.FilterPresetServiceMock)
 	suite.ApiKeyService = new(mocks.MockApiKeyService)
 	suite.LanguageMappingService = new(mocks.LanguageMappingServiceMock)
-	suite.SettingsHandler = NewSettingsHandler(suite.UserService, suite.HeartbeatService, nil, nil, suite.AliasService, nil, suite.LanguageMappingService, suite.ProjectLabelService, nil, nil, suite.ApiKeyService, suite.WebauthnService)
-	suite.LoginHandler = NewLoginHandler(suite.UserService, nil, nil, suite.WebauthnService)
+	suite.SessionService = new(mocks.SessionServiceMock)
+	suite.SecurityService = new(mocks.SecurityEventServiceMock)
+	suite.DiagnosticsService = new(mocks.DiagnosticsServiceMock)
+	suite.SettingsHandler = NewSettingsHandler(suite.UserService, suite.HeartbeatService, nil, nil, suite.AliasService, nil, suite.LanguageMappingService, suite.ProjectLabelService, suite.DayOffService, suite.FilterPresetService, nil, nil, suite.ApiKeyService, suite.WebauthnService, suite.SessionService, suite.SecurityService, suite.DiagnosticsService)
+	suite.LoginHandler = NewLoginHandler(suite.UserService, nil, nil, suite.WebauthnService, suite.SessionService, suite.SecurityService)
 	Init() // load templates
 
 	suite.mockSettingsViewDefaults()
@@ -93,16 +103,34 @@ func (suite *WebAuthnTestSuite) mockSettingsViewDefaults() {
 	suite.AliasService.On("GetByUser", mock.Anything).Return([]*models.Alias{}, nil).Maybe()
 	suite.AliasService.On("GetByUserAndType", mock.Anything, mock.Anything).Return([]*models.Alias{}, nil).Maybe()
 	suite.ProjectLabelService.On("GetByUserGroupedInverted", mock.Anything).Return(map[string][]*models.ProjectLabel{}, nil).Maybe()
+	suite.DayOffService.On("GetByUser", mock.Anything).Return([]*models.DayOff{}, nil).Maybe()
+	suite.FilterPresetService.On("GetByUser", mock.Anything).Return([]*models.FilterPreset{}, nil).Maybe()
 	suite.HeartbeatService.On("GetEntitySetByUser", mock.Anything, mock.Anything).Return([]string{}, nil).Maybe()
 	suite.HeartbeatService.On("GetFirstByUser", mock.Anything).Return(time.Time{}, nil).Maybe()
 	suite.ApiKeyService.On("GetByUser", mock.Anything).Return([]*models.ApiKey{}, nil).Maybe()
 	suite.WebauthnService.On("LoadCredentialIntoUser", mock.Anything).Return(nil).Maybe()
 	suite.UserService.On("Count").Return(1, nil).Maybe()
+	suite.SessionService.On("GetByUser", mock.Anything).Return([]*models.Session{}, nil).Maybe()
+	suite.SecurityService.On("GetByUser", mock.Anything, mock.Anything).Return([]*models.SecurityEvent{}, nil).Maybe()
+	suite.SecurityService.On("LogLogin", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	suite.SecurityService.On("Log", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	suite.DiagnosticsService.On("GetByUser", mock.Anything, mock.Anything).Return([]*models.Diagnostics{}, nil).Maybe()
+}
+
+func (suite *WebAuthnTestSuite) mockSession(user *models.User) {
+	session := &models.Session{
+		ID:        "session_" + user.ID,
+		UserID:    user.ID,
+		ExpiresAt: models.CustomTime(time.Now().Add(time.Hour)),
+	}
+	suite.SessionService.On("Create", user, mock.Anything, mock.Anything).Return(session, nil).Maybe()
+	suite.UserService.On("GetUserBySession", session.ID).Return(user, session, nil).Maybe()
 }
 
 func (suite *WebAuthnTestSuite) loginAsUser(user *models.User) []*http.Cookie {
 	suite.UserService.On("GetUserById", user.ID).Return(user, nil)
 	suite.UserService.On("Update", mock.Anything).Return(user, nil)
+	suite.mockSession(user)
 	return suite.getLoginCookies(user.ID, user.ID+"_password")
 }
 
@@ -151,6 +179,7 @@ func (suite *WebAuthnTestSuite) mockDeleteCredential(user *models.User, name str
 func (suite *WebAuthnTestSuite) TestWebauthn_RegisterDeniedForNonLocalUsers() {
 	suite.UserService.On("GetUserById", suite.UserNonLocal.ID).Return(suite.UserNonLocal, nil)
 	suite.UserService.On("Update", mock.AnythingOfType("*models.User")).Return(suite.UserNonLocal, nil)
+	suite.mockSession(suite.UserNonLocal)
 
 	cookies := suite.getLoginCookies(suite.UserNonLocal.ID, suite.UserNonLocal.ID+"_password")
 
@@ -235,6 +264,8 @@ func (suite *WebAuthnTestSuite) TestWebauthn_LoginFailureWithWrongAuthenticator(
 	suite.UserService.On("GetUserById", suite.UserB.ID).Return(suite.UserB, nil)
 	suite.UserService.On("Update", mock.MatchedBy(func(user *models.User) bool { return user.ID == suite.UserA.ID })).Return(suite.UserA, nil)
 	suite.UserService.On("Update", mock.MatchedBy(func(user *models.User) bool { return user.ID == suite.UserB.ID })).Return(suite.UserB, nil)
+	suite.mockSession(suite.UserA)
+	suite.mockSession(suite.UserB)
 	cookieUserA := suite.getLoginCookies(suite.UserA.ID, suite.UserA.ID+"_password")
 	cookieUserB := suite.getLoginCookies(suite.UserB.ID, suite.UserB.ID+"_password")
 
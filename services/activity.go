@@ -2,22 +2,20 @@ package services
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
 	"errors"
 	"fmt"
 	"math"
-	"sync"
 	"time"
 
 	svg "github.com/ajstarks/svgo/float"
-	"github.com/alitto/pond/v2"
 	"github.com/duke-git/lancet/v2/condition"
-	"github.com/duke-git/lancet/v2/datetime"
 	"github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/utils"
-	"github.com/patrickmn/go-cache"
 )
 
 const (
@@ -31,87 +29,153 @@ const (
 	colorMaxLight = "#047857"
 	textDark      = "#D1D5DB"
 	textLight     = "#37474F"
+	// upper bound for the range covered by a single chart, as daily charts require one summary retrieval per day
+	maxActivityDays = 5 * 366
 )
 
 type ActivityService struct {
-	config         *config.Config
-	cache          *cache.Cache
-	summaryService ISummaryService
+	config          *config.Config
+	cache           cache.Cache
+	summaryService  ISummaryService
+	durationService IDurationService
 }
 
-func NewActivityService(summaryService ISummaryService) *ActivityService {
+func NewActivityService(summaryService ISummaryService, durationService IDurationService) *ActivityService {
 	return &ActivityService{
-		config:         config.Get(),
-		cache:          cache.New(6*time.Hour, 6*time.Hour),
-		summaryService: summaryService,
+		config:          config.Get(),
+		cache:           cache.New("activity", 6*time.Hour, 6*time.Hour),
+		summaryService:  summaryService,
+		durationService: durationService,
 	}
 }
 
-// GetChart generates an activity chart for a given user and the given time interval, similar to GitHub's contribution timeline. See https://github.com/muety/wakapi/issues/12.
-// Please note: currently, only yearly charts ("last_12_months") are supported. However, we could fairly easily restructure this to support dynamic intervals.
-func (s *ActivityService) GetChart(user *models.User, interval *models.IntervalKey, darkTheme, hideAttribution, skipCache bool) (string, error) {
-	cacheKey := fmt.Sprintf("chart_%s_%s_%v_%v", user.ID, (*interval)[0], darkTheme, hideAttribution)
+// GetChart generates an activity chart for a given user and the given time range. Two types of charts are supported: a daily one, similar to GitHub's contribution timeline (see https://github.com/muety/wakapi/issues/12), and an hourly one, showing activity per hour of day and weekday.
+func (s *ActivityService) GetChart(ctx context.Context, user *models.User, params *models.ActivityParams, skipCache bool) (string, error) {
+	cacheKey := fmt.Sprintf("chart_%s_%s", user.ID, params.Hash())
 	if result, found := s.cache.Get(cacheKey); found && !skipCache {
 		return result.(string), nil
 	}
 
-	switch interval {
-	case models.IntervalPast12Months:
-		chart, err := s.getChartPastYear(user, darkTheme, hideAttribution)
-		if err == nil {
-			s.cache.SetDefault(cacheKey, chart) // TODO: cache compressed?
-		}
-		return chart, err
+	data, err := s.GetData(ctx, user, params, skipCache)
+	if err != nil {
+		return "", err
+	}
+
+	var chart string
+	switch params.Type {
+	case models.ActivityChartHourly:
+		chart = s.renderHourly(data, params.DarkTheme, params.HideAttribution)
+	default:
+		chart = s.renderDaily(data, params.DarkTheme, params.HideAttribution)
+	}
+
+	s.cache.SetDefault(cacheKey, chart) // TODO: cache compressed?
+	return chart, nil
+}
+
+// GetData computes the raw data behind an activity chart, i.e. total coding time per day or per hour of day and weekday
+func (s *ActivityService) GetData(ctx context.Context, user *models.User, params *models.ActivityParams, skipCache bool) (*models.ActivityData, error) {
+	if !params.From.Before(params.To) {
+		return nil, errors.New("invalid time range")
+	}
+	if params.To.Sub(params.From) > maxActivityDays*24*time.Hour {
+		return nil, errors.New("requested time range too broad")
+	}
+
+	cacheKey := fmt.Sprintf("data_%s_%s", user.ID, params.HashData())
+	if result, found := s.cache.Get(cacheKey); found && !skipCache {
+		return result.(*models.ActivityData), nil
+	}
+
+	var data *models.ActivityData
+	var err error
+
+	switch params.Type {
+	case models.ActivityChartHourly:
+		data, err = s.getDataHourly(ctx, user, params)
+	case models.ActivityChartDaily, "":
+		data, err = s.getDataDaily(ctx, user, params)
 	default:
-		return "", errors.New("unsupported interval")
+		err = errors.New("unsupported chart type")
 	}
+
+	if err == nil {
+		s.cache.SetDefault(cacheKey, data)
+	}
+	return data, err
 }
 
-func (s *ActivityService) getChartPastYear(user *models.User, darkTheme, hideAttribution bool) (string, error) {
-	err, from, to := helpers.ResolveIntervalTZ(models.IntervalPast12Months, user.TZ(), user.StartOfWeekDay())
-	// TODO: I am not sure if we have to handle startOfWeekDay here, but it seems like we do not need to.
-	from = datetime.BeginOfWeek(from, time.Monday)
+func (s *ActivityService) getDataDaily(ctx context.Context, user *models.User, params *models.ActivityParams) (*models.ActivityData, error) {
+	from, to := params.From.In(user.TZ()), params.To.In(user.TZ())
+
+	summaries, err := s.summaryService.Daily(ctx, from, to, user, s.cloneFilters(params.Filters), nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	intervals := utils.SplitRangeByDays(from, to)
-	summaries := make([]*models.Summary, len(intervals))
+	days := make([]*models.ActivityDay, len(intervals))
+	for i, interval := range intervals {
+		var total time.Duration
+		if i < len(summaries) {
+			total = summaries[i].TotalTime()
+		}
+		days[i] = &models.ActivityDay{Date: interval[0].Format(time.DateOnly), Total: total.Seconds()}
+	}
+
+	return &models.ActivityData{
+		From: from,
+		To:   to,
+		Type: models.ActivityChartDaily,
+		Days: days,
+	}, nil
+}
 
-	wp := pond.NewPool(utils.HalfCPUs())
-	mut := sync.RWMutex{}
+func (s *ActivityService) getDataHourly(ctx context.Context, user *models.User, params *models.ActivityParams) (*models.ActivityData, error) {
+	from, to := params.From.In(user.TZ()), params.To.In(user.TZ())
 
-	// fetch summaries
-	for i, interval := range intervals {
-		i := i // https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
-		interval := interval
-
-		wp.Submit(func() {
-			summary, err := s.summaryService.Retrieve(interval[0], interval[1], user, nil, nil)
-			if err != nil {
-				config.Log().Warn("failed to retrieve summary for activity chart", "userID", user.ID, "from", from, "to", to)
-				summary = models.NewEmptySummary()
-				summary.FromTime = models.CustomTime(from)
-				summary.ToTime = models.CustomTime(to)
-				summary.UserID = user.ID
-				summary.User = user
+	durations, err := s.durationService.Get(ctx, from, to, user, s.cloneFilters(params.Filters), nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var totals [gridRows][24]time.Duration
+	for _, d := range durations {
+		// split durations at full hours
+		for t1, end := d.Time.T().In(user.TZ()), d.TimeEnd().In(user.TZ()); t1.Before(end); {
+			t2 := t1.Truncate(time.Hour).Add(time.Hour)
+			if t2.After(end) {
+				t2 = end
 			}
+			totals[t1.Weekday()][t1.Hour()] += t2.Sub(t1)
+			t1 = t2
+		}
+	}
 
-			mut.Lock()
-			summaries[i] = summary
-			mut.Unlock()
-		})
+	hours := make([]*models.ActivityHour, 0, gridRows*24)
+	for wd := 0; wd < gridRows; wd++ {
+		for h := 0; h < 24; h++ {
+			hours = append(hours, &models.ActivityHour{Weekday: wd, Hour: h, Total: totals[wd][h].Seconds()})
+		}
 	}
 
-	wp.StopAndWait()
+	return &models.ActivityData{
+		From:  from,
+		To:    to,
+		Type:  models.ActivityChartHourly,
+		Hours: hours,
+	}, nil
+}
 
-	maxTotal := models.Summaries(summaries).MaxTotalTime()
+func (s *ActivityService) renderDaily(data *models.ActivityData, darkTheme, hideAttribution bool) string {
+	maxTotal := data.MaxTotal()
+	offset := (int(data.From.Weekday()) + 6) % 7 // rows start on monday
 
 	var (
 		colorRGBAMin         = utils.HexToRGBA(condition.Ternary[bool, string](darkTheme, colorMinDark, colorMinLight))
 		colorRGBAMax         = utils.HexToRGBA(condition.Ternary[bool, string](darkTheme, colorMaxDark, colorMaxLight))
 		colorText            = condition.Ternary[bool, string](darkTheme, textDark, textLight)
-		gridCols             = math.Ceil(float64(len(summaries)) / float64(gridRows))
+		gridCols             = math.Max(math.Ceil(float64(len(data.Days)+offset)/float64(gridRows)), 14)
 		w            float64 = gridCols*cellWidth + gridCols*cellSpacing
 		h            float64 = gridRows*cellHeight + 25 + 24 + 5 + 5 + gridRows*cellSpacing
 	)
@@ -121,32 +185,102 @@ func (s *ActivityService) getChartPastYear(user *models.User, darkTheme, hideAtt
 
 	canvas := svg.New(buf)
 	canvas.Start(w, h)
-	canvas.Style("text/css",
-		fmt.Sprintf("text { font-family: 'Source Sans 3', Roboto, Helvetica, Arial, sans-serif; font-size: 0.9rem; font-weight: 500; fill: %s; }", colorText),
-		fmt.Sprintf("rect { fill-opacity: 1; rx: 3px; ry: 3px; }"),
-		fmt.Sprintf("rect:hover { filter: brightness(0.9) }"),
+	s.writeStyle(canvas, colorText)
+
+	canvas.Text(0, 15, fmt.Sprintf("%s to %s", helpers.FormatDateHuman(data.From), helpers.FormatDateHuman(data.To)))
+
+	for i, day := range data.Days {
+		date, _ := time.ParseInLocation(time.DateOnly, day.Date, data.From.Location())
+		fillColor := utils.RGBAToHex(utils.FadeColors(colorRGBAMin, colorRGBAMax, s.ratio(day.Total, maxTotal)))
+		pos := i + offset
+
+		canvas.Group()
+		canvas.Title(fmt.Sprintf("%s on %s", helpers.FmtWakatimeDuration(time.Duration(day.Total)*time.Second), helpers.FormatDateHuman(date)))
+		canvas.Rect(float64(pos/gridRows)*(cellWidth+cellSpacing), 25+float64((pos%gridRows)*(cellHeight+cellSpacing)), cellWidth, cellHeight, fmt.Sprintf("fill: %s", fillColor))
+		canvas.Gend()
+	}
+
+	s.writeAttribution(canvas, w, h, hideAttribution)
+	canvas.End()
+
+	return buf.String()
+}
+
+func (s *ActivityService) renderHourly(data *models.ActivityData, darkTheme, hideAttribution bool) string {
+	const labelWidth, gridTop = 35, 45
+
+	maxTotal := data.MaxTotal()
+
+	var (
+		colorRGBAMin         = utils.HexToRGBA(condition.Ternary[bool, string](darkTheme, colorMinDark, colorMinLight))
+		colorRGBAMax         = utils.HexToRGBA(condition.Ternary[bool, string](darkTheme, colorMaxDark, colorMaxLight))
+		colorText            = condition.Ternary[bool, string](darkTheme, textDark, textLight)
+		w            float64 = labelWidth + 24*(cellWidth+cellSpacing)
+		h            float64 = gridTop + gridRows*(cellHeight+cellSpacing) + 24 + 5 + 5
 	)
 
-	canvas.Text(0, 15, fmt.Sprintf("%s to %s", helpers.FormatDateHuman(summaries[0].FromTime.T()), helpers.FormatDateHuman(summaries[len(summaries)-1].ToTime.T())))
+	buf := &bytes.Buffer{}
+
+	canvas := svg.New(buf)
+	canvas.Start(w, h)
+	s.writeStyle(canvas, colorText)
+
+	canvas.Text(0, 15, fmt.Sprintf("%s to %s", helpers.FormatDateHuman(data.From), helpers.FormatDateHuman(data.To)))
 
-	for i, s := range summaries {
-		total := s.TotalTime()
-		fillColor := utils.RGBAToHex(utils.FadeColors(colorRGBAMin, colorRGBAMax, float64(total)/float64(maxTotal)))
+	for hour := 0; hour < 24; hour += 3 {
+		canvas.Text(labelWidth+float64(hour)*(cellWidth+cellSpacing), gridTop-7, fmt.Sprintf("%02d", hour))
+	}
+
+	for row := 0; row < gridRows; row++ {
+		weekday := time.Weekday((row + 1) % 7) // rows start on monday
+		canvas.Text(0, gridTop+float64(row)*(cellHeight+cellSpacing)+15, weekday.String()[:3])
+	}
+
+	for _, hour := range data.Hours {
+		row := (hour.Weekday + 6) % 7
+		fillColor := utils.RGBAToHex(utils.FadeColors(colorRGBAMin, colorRGBAMax, s.ratio(hour.Total, maxTotal)))
 
 		canvas.Group()
-		canvas.Title(fmt.Sprintf("%s on %s", helpers.FmtWakatimeDuration(total), helpers.FormatDateHuman(s.FromTime.T())))
-		canvas.Rect(float64(i/gridRows)*(cellWidth+cellSpacing), 25+float64((i%gridRows)*(cellHeight+cellSpacing)), cellWidth, cellHeight, fmt.Sprintf("fill: %s", fillColor))
+		canvas.Title(fmt.Sprintf("%s on %ss at %02d:00", helpers.FmtWakatimeDuration(time.Duration(hour.Total)*time.Second), time.Weekday(hour.Weekday).String(), hour.Hour))
+		canvas.Rect(labelWidth+float64(hour.Hour)*(cellWidth+cellSpacing), gridTop+float64(row)*(cellHeight+cellSpacing), cellWidth, cellHeight, fmt.Sprintf("fill: %s", fillColor))
 		canvas.Gend()
 	}
 
+	s.writeAttribution(canvas, w, h, hideAttribution)
+	canvas.End()
+
+	return buf.String()
+}
+
+func (s *ActivityService) writeStyle(canvas *svg.SVG, colorText string) {
+	canvas.Style("text/css",
+		fmt.Sprintf("text { font-family: 'Source Sans 3', Roboto, Helvetica, Arial, sans-serif; font-size: 0.9rem; font-weight: 500; fill: %s; }", colorText),
+		fmt.Sprintf("rect { fill-opacity: 1; rx: 3px; ry: 3px; }"),
+		fmt.Sprintf("rect:hover { filter: brightness(0.9) }"),
+	)
+}
+
+func (s *ActivityService) writeAttribution(canvas *svg.SVG, w, h float64, hideAttribution bool) {
 	if !hideAttribution {
 		canvas.Group()
 		canvas.Title("Wakapi.dev")
 		canvas.Image(w-60, h-24, 60, 24, "https://wakapi.dev/assets/images/logo-gh.svg")
 		canvas.Gend()
 	}
+}
 
-	canvas.End()
+func (s *ActivityService) ratio(total, maxTotal float64) float64 {
+	if maxTotal == 0 {
+		return 0
+	}
+	return total / maxTotal
+}
 
-	return buf.String(), nil
+// filters are mutated while resolving aliases, so every concurrent retrieval needs its own copy
+func (s *ActivityService) cloneFilters(filters *models.Filters) *models.Filters {
+	if filters == nil {
+		return nil
+	}
+	clone := *filters
+	return &clone
 }
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ActivityServiceTestSuite struct {
+	suite.Suite
+	TestUser        *models.User
+	SummaryService  *mocks.SummaryServiceMock
+	DurationService *mocks.DurationServiceMock
+}
+
+func (suite *ActivityServiceTestSuite) SetupSuite() {
+	config.Set(config.Empty())
+	suite.TestUser = &models.User{ID: TestUserId, Location: "UTC"}
+}
+
+func (suite *ActivityServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.SummaryService = new(mocks.SummaryServiceMock)
+	suite.DurationService = new(mocks.DurationServiceMock)
+}
+
+func TestActivityServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ActivityServiceTestSuite))
+}
+
+func (suite *ActivityServiceTestSuite) TestActivityService_GetData_Daily() {
+	sut := NewActivityService(suite.SummaryService, suite.DurationService)
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	summaries := make([]*models.Summary, 10)
+	for i := range summaries {
+		summaries[i] = &models.Summary{Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: "wakapi", Total: 3600 * time.Duration(i%2)}}}
+	}
+	suite.SummaryService.On("Daily", from, from.AddDate(0, 0, 10), suite.TestUser, mock.Anything, mock.Anything).Return(summaries, nil)
+
+	data, err := sut.GetData(context.Background(), suite.TestUser, &models.ActivityParams{From: from, To: from.AddDate(0, 0, 10), Type: models.ActivityChartDaily}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), data.Days, 10)
+	assert.Equal(suite.T(), "2023-01-01", data.Days[0].Date)
+	assert.Equal(suite.T(), "2023-01-10", data.Days[9].Date)
+	assert.Equal(suite.T(), 3600.0, data.Days[5].Total)
+	assert.Equal(suite.T(), 0.0, data.Days[6].Total)
+	suite.SummaryService.AssertNumberOfCalls(suite.T(), "Daily", 1)
+}
+
+func (suite *ActivityServiceTestSuite) TestActivityService_GetData_Hourly() {
+	sut := NewActivityService(suite.SummaryService, suite.DurationService)
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC) // a sunday
+	durations := models.Durations{
+		{Time: models.CustomTime(from.Add(10*time.Hour + 30*time.Minute)), Duration: 1 * time.Hour}, // sunday 10:30 - 11:30
+		{Time: models.CustomTime(from.Add(24*time.Hour + 8*time.Hour)), Duration: 15 * time.Minute}, // monday 08:00 - 08:15
+	}
+	filters := models.NewFiltersWith(models.SummaryProject, "wakapi")
+	suite.DurationService.On("Get", from, from.AddDate(0, 0, 7), suite.TestUser, filters, (*time.Duration)(nil), false).Return(durations, nil)
+
+	data, err := sut.GetData(context.Background(), suite.TestUser, &models.ActivityParams{From: from, To: from.AddDate(0, 0, 7), Type: models.ActivityChartHourly, Filters: filters}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), data.Hours, 7*24)
+	assert.Equal(suite.T(), 1800.0, data.Hours[10].Total)  // sunday, 10 am
+	assert.Equal(suite.T(), 1800.0, data.Hours[11].Total)  // sunday, 11 am
+	assert.Equal(suite.T(), 900.0, data.Hours[24+8].Total) // monday, 8 am
+	assert.Equal(suite.T(), 0.0, data.Hours[24+9].Total)   // monday, 9 am
+	assert.Equal(suite.T(), 1800.0, data.MaxTotal())
+}
+
+func (suite *ActivityServiceTestSuite) TestActivityService_GetChart() {
+	sut := NewActivityService(suite.SummaryService, suite.DurationService)
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.DurationService.On("Get", mock.Anything, mock.Anything, suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
+
+	chart, err := sut.GetChart(context.Background(), suite.TestUser, &models.ActivityParams{From: from, To: from.AddDate(0, 1, 0), Type: models.ActivityChartHourly, HideAttribution: true}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), strings.Contains(chart, "<svg"))
+	assert.Contains(suite.T(), chart, "Mon")
+	assert.NotContains(suite.T(), chart, "Wakapi.dev")
+
+	_, err = sut.GetChart(context.Background(), suite.TestUser, &models.ActivityParams{From: from, To: from.AddDate(10, 0, 0), Type: models.ActivityChartDaily}, true)
+	assert.Error(suite.T(), err)
+}
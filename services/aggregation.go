@@ -9,12 +9,15 @@ import (
 	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
 	"github.com/muety/artifex/v2"
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/tracing"
 
 	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/repositories"
 )
 
 const (
 	aggregateIntervalDays int = 1
+	aggregationLeaseTTL       = 1 * time.Hour
 )
 
 var aggregationLock = sync.Mutex{}
@@ -25,19 +28,21 @@ type AggregationService struct {
 	summaryService        ISummaryService
 	heartbeatService      IHeartbeatService
 	durationService       IDurationService
+	leaseService          ILeaseService
 	inProgress            datastructure.Set[string]
 	queueDefault          *artifex.Dispatcher
 	queueSummaryWorkers   *artifex.Dispatcher
 	queuedDurationWorkers *artifex.Dispatcher
 }
 
-func NewAggregationService(userService IUserService, summaryService ISummaryService, heartbeatService IHeartbeatService, durationService IDurationService) *AggregationService {
+func NewAggregationService(userService IUserService, summaryService ISummaryService, heartbeatService IHeartbeatService, durationService IDurationService, leaseService ILeaseService) *AggregationService {
 	return &AggregationService{
 		config:                config.Get(),
 		userService:           userService,
 		summaryService:        summaryService,
 		heartbeatService:      heartbeatService,
 		durationService:       durationService,
+		leaseService:          leaseService,
 		inProgress:            datastructure.New[string](),
 		queueDefault:          config.GetDefaultQueue(),
 		queueSummaryWorkers:   config.GetQueue(config.QueueProcessing),
@@ -55,11 +60,11 @@ type AggregationJob struct {
 func (srv *AggregationService) Schedule() {
 	slog.Info("scheduling summary aggregation")
 
-	if _, err := srv.queueDefault.DispatchCron(func() {
+	if _, err := srv.queueDefault.DispatchCron(srv.leaseService.Exclusive("aggregation", func() {
 		if err := srv.AggregateSummaries(datastructure.New[string]()); err != nil {
 			config.Log().Error("failed to regenerate summaries", "error", err)
 		}
-	}, srv.config.App.GetAggregationTimeCron()); err != nil {
+	}), srv.config.App.GetAggregationTimeCron()); err != nil {
 		config.Log().Error("failed to schedule summary generation", "error", err)
 	}
 }
@@ -186,9 +191,13 @@ func (srv *AggregationService) AggregateDurations(userIds datastructure.Set[stri
 }
 
 func (srv *AggregationService) process(job AggregationJob) {
+	ctx, span := tracing.StartJob("aggregate_summaries", tracing.AttrUser.String(job.User.ID), tracing.AttrFrom.String(job.From.Format(time.RFC3339)), tracing.AttrTo.String(job.To.Format(time.RFC3339)))
+	defer span.End()
+	ctx = repositories.WithPrimaryReads(ctx) // durations were regenerated just before
+
 	// process single summary interval for single user
 	slog.Info("regenerating actual user summaries as part of summary aggregation", "user", job.User.ID, "from", job.From, "to", job.To)
-	if summary, err := srv.summaryService.Summarize(job.From, job.To, job.User, nil, nil); err != nil {
+	if summary, err := srv.summaryService.Summarize(ctx, job.From, job.To, job.User, nil, nil); err != nil {
 		config.Log().Error("failed to regenerate summary", "from", job.From, "to", job.To, "userID", job.User.ID, "error", err)
 	} else {
 		slog.Info("successfully generated summary", "from", job.From, "to", job.To, "userID", job.User.ID)
@@ -228,6 +237,7 @@ func generateUserJobs(user *models.User, from time.Time) (jobs []*AggregationJob
 	return jobs
 }
 
+// lockUsers locks the given users for aggregation, both within this instance and, by means of a lease, across all instances sharing the database
 func (srv *AggregationService) lockUsers(userIds datastructure.Set[string]) error {
 	aggregationLock.Lock()
 	defer aggregationLock.Unlock()
@@ -236,6 +246,22 @@ func (srv *AggregationService) lockUsers(userIds datastructure.Set[string]) erro
 			return errors.New("aggregation already in progress for at least of the request users")
 		}
 	}
+
+	acquired := make([]string, 0, len(userIds))
+	for uid := range userIds {
+		ok, err := srv.leaseService.Acquire(getAggregationLeaseName(uid), aggregationLeaseTTL)
+		if err == nil && !ok {
+			err = errors.New("aggregation already in progress for at least of the request users on another instance")
+		}
+		if err != nil {
+			for _, id := range acquired {
+				srv.leaseService.Release(getAggregationLeaseName(id))
+			}
+			return err
+		}
+		acquired = append(acquired, uid)
+	}
+
 	srv.inProgress = srv.inProgress.Union(userIds)
 	return nil
 }
@@ -245,9 +271,16 @@ func (srv *AggregationService) unlockUsers(userIds datastructure.Set[string]) {
 	defer aggregationLock.Unlock()
 	for uid := range userIds {
 		srv.inProgress.Delete(uid)
+		if err := srv.leaseService.Release(getAggregationLeaseName(uid)); err != nil {
+			config.Log().Error("failed to release aggregation lease", "userID", uid, "error", err)
+		}
 	}
 }
 
+func getAggregationLeaseName(userId string) string {
+	return "aggregation_" + userId
+}
+
 func getStartOfToday() time.Time {
 	now := time.Now()
 	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 1, now.Location())
@@ -20,11 +20,23 @@ type AliasService struct {
 }
 
 func NewAliasService(aliasRepo repositories.IAliasRepository) *AliasService {
-	return &AliasService{
+	srv := &AliasService{
 		config:     config.Get(),
 		eventBus:   config.EventBus(),
 		repository: aliasRepo,
 	}
+
+	sub1 := srv.eventBus.Subscribe(0, config.TopicAlias)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			// aliases were modified on another instance
+			if config.IsRemoteEvent(m) {
+				srv.MayInitializeUser(m.Fields[config.FieldUserId].(string))
+			}
+		}
+	}(&sub1)
+
+	return srv
 }
 
 var userAliases sync.Map
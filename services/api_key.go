@@ -2,20 +2,19 @@ package services
 
 import (
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/leandro-lugaresi/hub"
-	"github.com/patrickmn/go-cache"
 
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/repositories"
 )
 
 type ApiKeyService struct {
 	config     *config.Config
-	cache      *cache.Cache
+	cache      cache.Cache
 	eventBus   *hub.Hub
 	repository repositories.IApiKeyRepository
 }
@@ -25,7 +24,7 @@ func NewApiKeyService(apiKeyRepository repositories.IApiKeyRepository) *ApiKeySe
 		config:     config.Get(),
 		eventBus:   config.EventBus(),
 		repository: apiKeyRepository,
-		cache:      cache.New(24*time.Hour, 24*time.Hour),
+		cache:      cache.New("api_keys", 24*time.Hour, 24*time.Hour),
 	}
 
 	onApiKeyCreate := srv.eventBus.Subscribe(0, config.EventApiKeyCreate)
@@ -95,9 +94,5 @@ func (srv *ApiKeyService) notifyUpdate(apiKey *models.ApiKey, isDelete bool) {
 }
 
 func (srv *ApiKeyService) invalidateUserCache(userId string) {
-	for key := range srv.cache.Items() {
-		if strings.Contains(key, userId) {
-			srv.cache.Delete(key)
-		}
-	}
+	srv.cache.DeleteMatching(userId)
 }
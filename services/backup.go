@@ -0,0 +1,132 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/muety/artifex/v2"
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/backup"
+	"github.com/muety/wakapi/repositories"
+)
+
+const (
+	backupFilePrefix = "wakapi_backup_"
+	backupFileSuffix = ".jsonl.gz"
+)
+
+type BackupService struct {
+	config       *config.Config
+	leaseSrvc    ILeaseService
+	repository   repositories.IBackupRepository
+	queueDefault *artifex.Dispatcher
+}
+
+func NewBackupService(leaseService ILeaseService, backupRepository repositories.IBackupRepository) *BackupService {
+	return &BackupService{
+		config:       config.Get(),
+		leaseSrvc:    leaseService,
+		repository:   backupRepository,
+		queueDefault: config.GetDefaultQueue(),
+	}
+}
+
+func (srv *BackupService) Schedule() {
+	if srv.config.App.BackupTime == "" {
+		return
+	}
+
+	slog.Info("scheduling database backups", "dir", srv.config.App.BackupDir)
+	if _, err := srv.queueDefault.DispatchCron(srv.leaseSrvc.Exclusive("backup", srv.runBackup), srv.config.App.BackupTime); err != nil {
+		config.Log().Error("failed to dispatch database backups", "error", err)
+	}
+}
+
+func (srv *BackupService) Create(w io.Writer) (*backup.Manifest, error) {
+	return srv.repository.Create(w, srv.config.Version)
+}
+
+// CreateFile writes a new backup to the configured backup directory and deletes the oldest ones, so that at most backup_keep files are retained
+func (srv *BackupService) CreateFile() (string, *backup.Manifest, error) {
+	if err := os.MkdirAll(srv.config.App.BackupDir, 0o750); err != nil {
+		return "", nil, err
+	}
+
+	name := filepath.Join(srv.config.App.BackupDir, fmt.Sprintf("%s%s%s", backupFilePrefix, time.Now().UTC().Format("20060102T150405Z"), backupFileSuffix))
+	tmpName := name + ".tmp"
+
+	file, err := os.OpenFile(tmpName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return "", nil, err
+	}
+
+	manifest, err := srv.Create(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return "", nil, err
+	}
+
+	// only make the backup visible once complete, so that rotation never deletes a valid backup in favor of a broken one
+	if err := os.Rename(tmpName, name); err != nil {
+		os.Remove(tmpName)
+		return "", nil, err
+	}
+
+	if err := srv.rotate(); err != nil {
+		config.Log().Error("failed to delete old backups", "error", err)
+	}
+
+	return name, manifest, nil
+}
+
+func (srv *BackupService) Restore(r io.Reader, force bool) (*backup.Manifest, error) {
+	return srv.repository.Restore(r, force)
+}
+
+func (srv *BackupService) runBackup() {
+	slog.Info("writing database backup")
+	name, manifest, err := srv.CreateFile()
+	if err != nil {
+		config.Log().Error("failed to write database backup", "error", err)
+		return
+	}
+	slog.Info("finished writing database backup", "file", name, "tables", len(manifest.Tables), "rows", manifest.TotalRows())
+}
+
+func (srv *BackupService) rotate() error {
+	if srv.config.App.BackupKeep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(srv.config.App.BackupDir)
+	if err != nil {
+		return err
+	}
+
+	// file names contain a sortable timestamp
+	backups := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), backupFilePrefix) && strings.HasSuffix(e.Name(), backupFileSuffix) {
+			backups = append(backups, e.Name())
+		}
+	}
+	sort.Strings(backups)
+
+	for len(backups) > srv.config.App.BackupKeep {
+		if err := os.Remove(filepath.Join(srv.config.App.BackupDir, backups[0])); err != nil {
+			return err
+		}
+		slog.Info("deleted old database backup", "file", backups[0])
+		backups = backups[1:]
+	}
+	return nil
+}
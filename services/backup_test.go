@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/backup"
+	"github.com/muety/wakapi/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type BackupServiceTestSuite struct {
+	suite.Suite
+	BackupRepository *mocks.BackupRepositoryMock
+	LeaseService     *mocks.LeaseServiceMock
+	BackupDir        string
+}
+
+func (suite *BackupServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.BackupDir = suite.T().TempDir()
+	suite.BackupRepository = new(mocks.BackupRepositoryMock)
+	suite.LeaseService = new(mocks.LeaseServiceMock)
+
+	cfg := config.Empty()
+	cfg.Version = "1.0.0"
+	cfg.App.BackupDir = suite.BackupDir
+	cfg.App.BackupKeep = 2
+	config.Set(cfg)
+}
+
+func TestBackupServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(BackupServiceTestSuite))
+}
+
+func (suite *BackupServiceTestSuite) TestBackupService_CreateFile_Rotate() {
+	sut := NewBackupService(suite.LeaseService, suite.BackupRepository)
+
+	for _, name := range []string{"wakapi_backup_20240101T000000Z.jsonl.gz", "wakapi_backup_20240102T000000Z.jsonl.gz", "unrelated.txt"} {
+		assert.Nil(suite.T(), os.WriteFile(filepath.Join(suite.BackupDir, name), []byte{}, 0o640))
+	}
+
+	manifest := &backup.Manifest{Tables: map[string]int64{"users": 2}}
+	suite.BackupRepository.On("Create", mock.Anything, "1.0.0").Return(manifest, nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*os.File).WriteString("backup")
+	})
+
+	name, result, err := sut.CreateFile()
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), manifest, result)
+
+	data, err := os.ReadFile(name)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "backup", string(data))
+
+	entries, _ := os.ReadDir(suite.BackupDir)
+	var files []string
+	for _, e := range entries {
+		files = append(files, e.Name())
+	}
+	assert.ElementsMatch(suite.T(), []string{"wakapi_backup_20240102T000000Z.jsonl.gz", filepath.Base(name), "unrelated.txt"}, files)
+}
+
+func (suite *BackupServiceTestSuite) TestBackupService_CreateFile_Fails() {
+	sut := NewBackupService(suite.LeaseService, suite.BackupRepository)
+
+	assert.Nil(suite.T(), os.WriteFile(filepath.Join(suite.BackupDir, "wakapi_backup_20240101T000000Z.jsonl.gz"), []byte{}, 0o640))
+	suite.BackupRepository.On("Create", mock.Anything, "1.0.0").Return(nil, errors.New("connection lost"))
+
+	_, _, err := sut.CreateFile()
+	assert.Error(suite.T(), err)
+
+	// neither leaves behind partial backups nor deletes previous ones
+	entries, _ := os.ReadDir(suite.BackupDir)
+	assert.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), "wakapi_backup_20240101T000000Z.jsonl.gz", entries[0].Name())
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/utils"
+)
+
+const maxStreakDays = 365
+
+type BadgeService struct {
+	config           *config.Config
+	summaryService   ISummaryService
+	heartbeatService IHeartbeatService
+}
+
+func NewBadgeService(summaryService ISummaryService, heartbeatService IHeartbeatService) *BadgeService {
+	return &BadgeService{
+		config:           config.Get(),
+		summaryService:   summaryService,
+		heartbeatService: heartbeatService,
+	}
+}
+
+// GetMessage computes the (human-readable) value of the badge's metric for the given user
+func (srv *BadgeService) GetMessage(ctx context.Context, user *models.User, def *models.BadgeDefinition) (string, error) {
+	filters := def.Filters
+	if filters == nil {
+		filters = &models.Filters{}
+	}
+
+	switch def.Metric {
+	case models.BadgeMetricTotal, "":
+		summary, err := srv.retrieve(ctx, def.Interval.Start, def.Interval.End, user, filters.WithSelectFilteredOnly())
+		if err != nil {
+			return "", err
+		}
+		return helpers.FmtWakatimeDuration(summary.TotalTime()), nil
+
+	case models.BadgeMetricTopLanguage:
+		summary, err := srv.retrieve(ctx, def.Interval.Start, def.Interval.End, user, filters)
+		if err != nil {
+			return "", err
+		}
+		return summary.MaxByToString(models.SummaryLanguage), nil
+
+	case models.BadgeMetricLanguageShare:
+		if def.Language == "" {
+			return "", errors.New("missing language")
+		}
+		summary, err := srv.retrieve(ctx, def.Interval.Start, def.Interval.End, user, filters)
+		if err != nil {
+			return "", err
+		}
+		var share float64
+		if total := summary.TotalTimeBy(models.SummaryLanguage); total > 0 {
+			share = float64(srv.totalTimeByKeyFold(summary, def.Language)) / float64(total) * 100
+		}
+		return fmt.Sprintf("%s %.1f%%", def.Language, share), nil
+
+	case models.BadgeMetricDailyAverage:
+		summary, err := srv.retrieve(ctx, def.Interval.Start, def.Interval.End, user, filters.WithSelectFilteredOnly())
+		if err != nil {
+			return "", err
+		}
+		from := def.Interval.Start
+		if first, err := srv.heartbeatService.GetFirstByUser(user); err == nil && first.After(from) {
+			from = first // e.g. for "all time" interval
+		}
+		numDays := int(math.Ceil(def.Interval.End.Sub(from).Hours() / 24))
+		if numDays < 1 {
+			numDays = 1
+		}
+		return helpers.FmtWakatimeDuration(summary.TotalTime() / time.Duration(numDays)), nil
+
+	case models.BadgeMetricStreak:
+		streak, err := srv.countStreak(ctx, user, filters.WithSelectFilteredOnly(), def.MaxDays)
+		if err != nil {
+			return "", err
+		}
+		if streak == 1 {
+			return "1 day", nil
+		}
+		return fmt.Sprintf("%d days", streak), nil
+
+	case models.BadgeMetricLastActive:
+		var latest *models.Heartbeat
+		var err error
+		if filters.IsEmpty() {
+			latest, err = srv.heartbeatService.GetLatestByUser(user)
+		} else {
+			latest, err = srv.heartbeatService.GetLatestByFilters(user, filters)
+		}
+		if err != nil {
+			return "", err
+		}
+		if latest == nil || latest.Time.T().IsZero() {
+			return "never", nil
+		}
+		return helpers.FmtTimeAgo(latest.Time.T()), nil
+	}
+
+	return "", errors.New("unsupported metric")
+}
+
+// countStreak counts the number of consecutive days with coding activity up until today (or yesterday, if nothing was coded today yet)
+func (srv *BadgeService) countStreak(ctx context.Context, user *models.User, filters *models.Filters, maxDays int) (int, error) {
+	if maxDays <= 0 || maxDays > maxStreakDays {
+		maxDays = maxStreakDays
+	}
+
+	to := time.Now().In(user.TZ())
+	from := utils.BeginOfToday(user.TZ()).AddDate(0, 0, -(maxDays - 1))
+
+	summaries, err := srv.summaryService.Daily(ctx, from, to, user, filters, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var streak int
+	for i := len(summaries) - 1; i >= 0; i-- {
+		if summaries[i].TotalTime() == 0 {
+			if i == len(summaries)-1 {
+				continue // today doesn't break the streak (yet)
+			}
+			break
+		}
+		streak++
+	}
+
+	return streak, nil
+}
+
+func (srv *BadgeService) totalTimeByKeyFold(summary *models.Summary, language string) (total time.Duration) {
+	for _, item := range *summary.GetByType(models.SummaryLanguage) {
+		if strings.EqualFold(item.Key, language) {
+			total += item.TotalFixed()
+		}
+	}
+	return total
+}
+
+func (srv *BadgeService) retrieve(ctx context.Context, from, to time.Time, user *models.User, filters *models.Filters) (*models.Summary, error) {
+	return srv.summaryService.Aliased(ctx, from, to, user, srv.summaryService.Retrieve, filters, nil, false)
+}
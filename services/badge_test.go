@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type BadgeServiceTestSuite struct {
+	suite.Suite
+	TestUser         *models.User
+	SummaryService   *mocks.SummaryServiceMock
+	HeartbeatService *mocks.HeartbeatServiceMock
+}
+
+func (suite *BadgeServiceTestSuite) SetupSuite() {
+	config.Set(config.Empty())
+	suite.TestUser = &models.User{ID: TestUserId}
+}
+
+func (suite *BadgeServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.SummaryService = new(mocks.SummaryServiceMock)
+	suite.HeartbeatService = new(mocks.HeartbeatServiceMock)
+}
+
+func TestBadgeServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(BadgeServiceTestSuite))
+}
+
+func (suite *BadgeServiceTestSuite) TestBadgeService_CountStreak() {
+	sut := NewBadgeService(suite.SummaryService, suite.HeartbeatService)
+
+	// oldest first, no activity today (yet), three days of activity before
+	suite.SummaryService.On("Daily", mock.Anything, mock.Anything, suite.TestUser, mock.Anything, mock.Anything).Return(dailySummaries(1, 0, 1, 1, 1, 0), nil).Once()
+	streak, err := sut.countStreak(context.Background(), suite.TestUser, &models.Filters{}, 6)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 3, streak)
+
+	// activity today
+	suite.SummaryService.On("Daily", mock.Anything, mock.Anything, suite.TestUser, mock.Anything, mock.Anything).Return(dailySummaries(0, 1, 1), nil).Once()
+	streak, err = sut.countStreak(context.Background(), suite.TestUser, &models.Filters{}, 3)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 2, streak)
+
+	// no activity yesterday
+	suite.SummaryService.On("Daily", mock.Anything, mock.Anything, suite.TestUser, mock.Anything, mock.Anything).Return(dailySummaries(1, 0, 0), nil).Once()
+	streak, err = sut.countStreak(context.Background(), suite.TestUser, &models.Filters{}, 3)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 0, streak)
+
+	// summaries are retrieved at once for the entire interval
+	suite.SummaryService.AssertNumberOfCalls(suite.T(), "Daily", 3)
+	suite.SummaryService.AssertNotCalled(suite.T(), "Aliased", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func dailySummaries(minutes ...int) []*models.Summary {
+	summaries := make([]*models.Summary, len(minutes))
+	for i, m := range minutes {
+		summaries[i] = &models.Summary{Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: TestProject1, Total: time.Duration(m) * time.Minute / time.Second}}}
+	}
+	return summaries
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"uuid"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/duke-git/lancet/v2/maputil"
+	"github.com/duke-git/lancet/v2/slice"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/repositories"
+	"github.com/muety/wakapi/utils"
+)
+
+const widgetDataCacheTTL = 5 * time.Minute // widgets with rolling intervals are cached for as long as their bounds are rounded to
+
+type DashboardService struct {
+	config          *config.Config
+	cache           cache.Cache
+	repository      repositories.IDashboardRepository
+	summaryService  ISummaryService
+	activityService IActivityService
+}
+
+func NewDashboardService(dashboardRepository repositories.IDashboardRepository, summaryService ISummaryService, activityService IActivityService) *DashboardService {
+	return &DashboardService{
+		config:          config.Get(),
+		cache:           cache.New("dashboards", widgetDataCacheTTL, widgetDataCacheTTL),
+		repository:      dashboardRepository,
+		summaryService:  summaryService,
+		activityService: activityService,
+	}
+}
+
+func (srv *DashboardService) GetById(id uint) (*models.Dashboard, error) {
+	return srv.repository.GetById(id)
+}
+
+func (srv *DashboardService) GetByUser(userId string) ([]*models.Dashboard, error) {
+	return srv.repository.GetByUser(userId)
+}
+
+// GetShared returns the dashboard for the given share token, but only if it is (still) shared
+func (srv *DashboardService) GetShared(token string) (*models.Dashboard, error) {
+	dashboard, err := srv.repository.GetByShareToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if !dashboard.Shared {
+		return nil, errors.New("dashboard not shared")
+	}
+	return dashboard, nil
+}
+
+func (srv *DashboardService) Create(user *models.User, name string) (*models.Dashboard, error) {
+	dashboards, err := srv.repository.GetByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(dashboards) >= models.MaxDashboardsPerUser {
+		return nil, errors.New("maximum number of dashboards reached")
+	}
+	return srv.repository.Insert(&models.Dashboard{
+		UserID:     user.ID,
+		Name:       name,
+		ShareToken: srv.newShareToken(),
+		Widgets:    []*models.DashboardWidget{},
+	})
+}
+
+func (srv *DashboardService) Rename(dashboard *models.Dashboard, name string) (*models.Dashboard, error) {
+	dashboard.Name = name
+	return srv.repository.Update(dashboard)
+}
+
+// SetShared enables or disables the read-only link to a dashboard. Every time sharing is enabled, a new link is generated, so previously shared links stop working.
+func (srv *DashboardService) SetShared(dashboard *models.Dashboard, shared bool) (*models.Dashboard, error) {
+	if shared && !dashboard.Shared {
+		dashboard.ShareToken = srv.newShareToken()
+	}
+	dashboard.Shared = shared
+	return srv.repository.Update(dashboard)
+}
+
+func (srv *DashboardService) Delete(dashboard *models.Dashboard) error {
+	return srv.repository.Delete(dashboard.ID)
+}
+
+func (srv *DashboardService) AddWidget(dashboard *models.Dashboard, widget *models.DashboardWidget) (*models.DashboardWidget, error) {
+	if len(dashboard.Widgets) >= models.MaxWidgetsPerDashboard {
+		return nil, errors.New("maximum number of widgets reached")
+	}
+	widget.DashboardID = dashboard.ID
+	widget.Position = len(dashboard.Widgets)
+	if len(dashboard.Widgets) > 0 {
+		widget.Position = dashboard.Widgets[len(dashboard.Widgets)-1].Position + 1
+	}
+	result, err := srv.repository.InsertWidget(widget)
+	if err != nil {
+		return nil, err
+	}
+	dashboard.Widgets = append(dashboard.Widgets, result)
+	return result, nil
+}
+
+// MoveWidget swaps a widget with its predecessor (offset -1) or successor (offset 1) on the dashboard
+func (srv *DashboardService) MoveWidget(dashboard *models.Dashboard, widgetId uint, offset int) error {
+	i := -1
+	for k, w := range dashboard.Widgets {
+		if w.ID == widgetId {
+			i = k
+		}
+	}
+	if i < 0 {
+		return errors.New("widget not found")
+	}
+	j := i + offset
+	if j < 0 || j >= len(dashboard.Widgets) {
+		return nil
+	}
+
+	widgets := dashboard.Widgets
+	widgets[i], widgets[j] = widgets[j], widgets[i]
+	for k, w := range widgets {
+		w.Position = k
+	}
+	return srv.repository.UpdateWidgetPositions(widgets)
+}
+
+func (srv *DashboardService) DeleteWidget(dashboard *models.Dashboard, widgetId uint) error {
+	widget := dashboard.FindWidget(widgetId)
+	if widget == nil {
+		return errors.New("widget not found")
+	}
+	if err := srv.repository.DeleteWidget(widget.ID); err != nil {
+		return err
+	}
+	srv.cache.DeleteMatching(fmt.Sprintf("widget_data_%d_", widget.ID))
+	dashboard.Widgets = slice.Filter(dashboard.Widgets, func(_ int, w *models.DashboardWidget) bool {
+		return w.ID != widgetId
+	})
+	return nil
+}
+
+// GetWidgetData computes the data to render a widget's chart for the given user, i.e. the dashboard's owner
+func (srv *DashboardService) GetWidgetData(ctx context.Context, user *models.User, widget *models.DashboardWidget) (*models.DashboardWidgetData, error) {
+	err, from, to := helpers.ResolveIntervalRawTZ(widget.Interval, user.TZ(), user.StartOfWeekDay())
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("widget_data_%d_%d_%d", widget.ID, from.Truncate(widgetDataCacheTTL).Unix(), to.Truncate(widgetDataCacheTTL).Unix())
+	if result, found := srv.cache.Get(cacheKey); found {
+		return result.(*models.DashboardWidgetData), nil
+	}
+
+	data := &models.DashboardWidgetData{WidgetID: widget.ID, ChartType: widget.ChartType, From: from, To: to}
+
+	switch widget.ChartType {
+	case models.WidgetChartBar, models.WidgetChartPie:
+		summary, err := srv.summaryService.Aliased(ctx, from, to, user, srv.summaryService.Retrieve, widget.ParsedFilters(), nil, false)
+		if err != nil {
+			return nil, err
+		}
+		keys, totals := srv.topItems(*summary.GetByType(widget.SummaryType))
+		data.Labels = keys
+		data.Series = []*models.DashboardWidgetSeries{{Key: "total", Values: totals}}
+
+	case models.WidgetChartTimeline:
+		if limit := datetime.BeginOfDay(to).AddDate(0, 0, -models.MaxWidgetTimelineDays+1); from.Before(limit) {
+			data.From, from = limit, limit
+		}
+		if data.Labels, data.Series, err = srv.getTimeline(ctx, user, widget, from, to); err != nil {
+			return nil, err
+		}
+
+	case models.WidgetChartHeatmap, models.WidgetChartHourly:
+		if limit := datetime.BeginOfDay(to).AddDate(0, 0, -models.MaxWidgetActivityDays+1); from.Before(limit) {
+			data.From, from = limit, limit
+		}
+		params := &models.ActivityParams{
+			From:            from,
+			To:              to,
+			Filters:         widget.ParsedFilters(),
+			Type:            models.ActivityChartDaily,
+			DarkTheme:       true,
+			HideAttribution: true,
+		}
+		if widget.ChartType == models.WidgetChartHourly {
+			params.Type = models.ActivityChartHourly
+		}
+		if data.Svg, err = srv.activityService.GetChart(ctx, user, params, false); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, errors.New("unsupported chart type")
+	}
+
+	srv.cache.SetDefault(cacheKey, data)
+	return data, nil
+}
+
+// getTimeline returns the total time per day for each of the entities most coded on within the range
+func (srv *DashboardService) getTimeline(ctx context.Context, user *models.User, widget *models.DashboardWidget, from, to time.Time) ([]string, []*models.DashboardWidgetSeries, error) {
+	summaries, err := srv.summaryService.Daily(ctx, from, to, user, widget.ParsedFilters(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	intervals := utils.SplitRangeByDays(from, to)
+	if len(summaries) != len(intervals) {
+		return nil, nil, errors.New("number of daily summaries does not match number of days")
+	}
+
+	totals := make(map[string]*models.SummaryItem)
+	for _, summary := range summaries {
+		for _, item := range *summary.GetByType(widget.SummaryType) {
+			if _, ok := totals[item.Key]; !ok {
+				totals[item.Key] = &models.SummaryItem{Type: item.Type, Key: item.Key}
+			}
+			totals[item.Key].Total += item.Total
+		}
+	}
+	keys, _ := srv.topItems(maputil.Values(totals))
+
+	labels := make([]string, len(intervals))
+	series := make([]*models.DashboardWidgetSeries, len(keys))
+	seriesByKey := make(map[string]*models.DashboardWidgetSeries, len(keys))
+	for i, k := range keys {
+		series[i] = &models.DashboardWidgetSeries{Key: k, Values: make([]float64, len(intervals))}
+		seriesByKey[k] = series[i]
+	}
+
+	for i, interval := range intervals {
+		labels[i] = interval[0].Format(time.DateOnly)
+		for _, item := range *summaries[i].GetByType(widget.SummaryType) {
+			s, ok := seriesByKey[item.Key]
+			if !ok {
+				s, ok = seriesByKey[models.WidgetOtherKey]
+			}
+			if ok {
+				s.Values[i] += item.TotalFixed().Seconds()
+			}
+		}
+	}
+
+	return labels, series, nil
+}
+
+// topItems returns the keys and totals (in seconds) of the items with the most coding time, whereas all remaining ones are summed up as "other"
+func (srv *DashboardService) topItems(items models.SummaryItems) ([]string, []float64) {
+	sorted := make(models.SummaryItems, len(items))
+	copy(sorted, items)
+	sort.Sort(sort.Reverse(sorted))
+
+	keys := make([]string, 0, models.MaxWidgetItems+1)
+	totals := make([]float64, 0, models.MaxWidgetItems+1)
+	for i, item := range sorted {
+		if i < models.MaxWidgetItems {
+			keys = append(keys, item.Key)
+			totals = append(totals, item.TotalFixed().Seconds())
+			continue
+		}
+		if i == models.MaxWidgetItems {
+			keys = append(keys, models.WidgetOtherKey)
+			totals = append(totals, 0)
+		}
+		totals[len(totals)-1] += item.TotalFixed().Seconds()
+	}
+	return keys, totals
+}
+
+func (srv *DashboardService) newShareToken() string {
+	return uuid.NewV4().String()
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type DashboardServiceTestSuite struct {
+	suite.Suite
+	TestUser            *models.User
+	DashboardRepository *mocks.DashboardRepositoryMock
+	SummaryService      *mocks.SummaryServiceMock
+}
+
+func (suite *DashboardServiceTestSuite) SetupSuite() {
+	config.Set(config.Empty())
+	suite.TestUser = &models.User{ID: TestUserId, Location: "UTC"}
+}
+
+func (suite *DashboardServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.DashboardRepository = new(mocks.DashboardRepositoryMock)
+	suite.SummaryService = new(mocks.SummaryServiceMock)
+}
+
+func TestDashboardServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(DashboardServiceTestSuite))
+}
+
+func (suite *DashboardServiceTestSuite) TestDashboardService_Create() {
+	sut := NewDashboardService(suite.DashboardRepository, suite.SummaryService, nil)
+
+	suite.DashboardRepository.On("GetByUser", TestUserId).Return([]*models.Dashboard{}, nil).Once()
+	suite.DashboardRepository.On("Insert", mock.MatchedBy(func(d *models.Dashboard) bool {
+		return d.Name == "Clients" && d.UserID == TestUserId && d.ShareToken != "" && !d.Shared
+	})).Return(&models.Dashboard{ID: 1}, nil)
+
+	_, err := sut.Create(suite.TestUser, "Clients")
+	assert.Nil(suite.T(), err)
+
+	existing := make([]*models.Dashboard, models.MaxDashboardsPerUser)
+	suite.DashboardRepository.On("GetByUser", TestUserId).Return(existing, nil).Once()
+
+	_, err = sut.Create(suite.TestUser, "Too many")
+	assert.NotNil(suite.T(), err)
+	suite.DashboardRepository.AssertNumberOfCalls(suite.T(), "Insert", 1)
+}
+
+func (suite *DashboardServiceTestSuite) TestDashboardService_SetShared() {
+	sut := NewDashboardService(suite.DashboardRepository, suite.SummaryService, nil)
+	dashboard := &models.Dashboard{ID: 1, UserID: TestUserId, Name: "Clients", ShareToken: "initial"}
+	suite.DashboardRepository.On("Update", dashboard).Return(dashboard, nil)
+
+	sut.SetShared(dashboard, true)
+	assert.True(suite.T(), dashboard.Shared)
+	assert.NotEqual(suite.T(), "initial", dashboard.ShareToken)
+
+	token := dashboard.ShareToken
+	sut.SetShared(dashboard, true)
+	assert.Equal(suite.T(), token, dashboard.ShareToken)
+
+	sut.SetShared(dashboard, false)
+	assert.False(suite.T(), dashboard.Shared)
+}
+
+func (suite *DashboardServiceTestSuite) TestDashboardService_AddAndMoveWidgets() {
+	sut := NewDashboardService(suite.DashboardRepository, suite.SummaryService, nil)
+	newWidget := &models.DashboardWidget{ID: 2, ChartType: models.WidgetChartPie, Interval: "today"}
+	suite.DashboardRepository.On("InsertWidget", newWidget).Return(newWidget, nil)
+	suite.DashboardRepository.On("UpdateWidgetPositions", mock.Anything).Return(nil)
+
+	dashboard := &models.Dashboard{ID: 1, Widgets: []*models.DashboardWidget{{ID: 1, DashboardID: 1, Position: 0}}}
+
+	widget, err := sut.AddWidget(dashboard, newWidget)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), uint(1), widget.DashboardID)
+	assert.Equal(suite.T(), 1, widget.Position)
+	assert.Len(suite.T(), dashboard.Widgets, 2)
+
+	assert.Nil(suite.T(), sut.MoveWidget(dashboard, 2, -1))
+	assert.Equal(suite.T(), uint(2), dashboard.Widgets[0].ID)
+	assert.Equal(suite.T(), 0, dashboard.Widgets[0].Position)
+	assert.Equal(suite.T(), 1, dashboard.Widgets[1].Position)
+
+	assert.Nil(suite.T(), sut.MoveWidget(dashboard, 2, -1)) // already first
+	assert.Equal(suite.T(), uint(2), dashboard.Widgets[0].ID)
+
+	assert.NotNil(suite.T(), sut.MoveWidget(dashboard, 3, 1))
+}
+
+func (suite *DashboardServiceTestSuite) TestDashboardService_GetWidgetData_Pie() {
+	sut := NewDashboardService(suite.DashboardRepository, suite.SummaryService, nil)
+
+	summary := &models.Summary{Projects: models.SummaryItems{}}
+	for i := 1; i <= models.MaxWidgetItems+2; i++ {
+		summary.Projects = append(summary.Projects, &models.SummaryItem{Type: models.SummaryProject, Key: fmt.Sprintf("project-%02d", i), Total: time.Duration(i) * time.Minute / time.Second})
+	}
+	suite.SummaryService.On("Aliased", mock.Anything, mock.Anything, suite.TestUser, mock.Anything, mock.Anything, mock.Anything, false).Return(summary, nil)
+
+	widget := &models.DashboardWidget{ID: 1, ChartType: models.WidgetChartPie, SummaryType: models.SummaryProject, Interval: "week", Filters: "language=Go"}
+	data, err := sut.GetWidgetData(context.Background(), suite.TestUser, widget)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), models.WidgetChartPie, data.ChartType)
+	assert.Len(suite.T(), data.Labels, models.MaxWidgetItems+1)
+	assert.Equal(suite.T(), "project-12", data.Labels[0])
+	assert.Equal(suite.T(), models.WidgetOtherKey, data.Labels[models.MaxWidgetItems])
+	assert.Len(suite.T(), data.Series, 1)
+	assert.Equal(suite.T(), 720.0, data.Series[0].Values[0])
+	assert.Equal(suite.T(), 180.0, data.Series[0].Values[models.MaxWidgetItems]) // 1 min + 2 min
+
+	filters := suite.SummaryService.Calls[0].Arguments.Get(4).(*models.Filters)
+	assert.Equal(suite.T(), models.OrFilter{"Go"}, filters.Language)
+}
+
+func (suite *DashboardServiceTestSuite) TestDashboardService_GetWidgetData_Timeline() {
+	sut := NewDashboardService(suite.DashboardRepository, suite.SummaryService, nil)
+
+	summaries := make([]*models.Summary, models.MaxWidgetTimelineDays)
+	for i := range summaries {
+		summaries[i] = &models.Summary{Languages: models.SummaryItems{
+			{Type: models.SummaryLanguage, Key: "Go", Total: 30 * time.Minute / time.Second},
+			{Type: models.SummaryLanguage, Key: "Python", Total: 10 * time.Minute / time.Second},
+		}}
+	}
+	suite.SummaryService.On("Daily", mock.Anything, mock.Anything, suite.TestUser, mock.Anything, mock.Anything).Return(summaries, nil)
+
+	widget := &models.DashboardWidget{ID: 1, ChartType: models.WidgetChartTimeline, SummaryType: models.SummaryLanguage, Interval: "any"}
+	data, err := sut.GetWidgetData(context.Background(), suite.TestUser, widget)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), data.Labels, models.MaxWidgetTimelineDays)
+	assert.True(suite.T(), data.To.Sub(data.From) <= models.MaxWidgetTimelineDays*24*time.Hour)
+	assert.Len(suite.T(), data.Series, 2)
+	assert.Equal(suite.T(), "Go", data.Series[0].Key)
+	assert.Len(suite.T(), data.Series[0].Values, models.MaxWidgetTimelineDays)
+	assert.Equal(suite.T(), 1800.0, data.Series[0].Values[0])
+	assert.Equal(suite.T(), 600.0, data.Series[1].Values[0])
+	suite.SummaryService.AssertNumberOfCalls(suite.T(), "Daily", 1)
+	suite.SummaryService.AssertNotCalled(suite.T(), "Aliased", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *DashboardServiceTestSuite) TestDashboardService_GetWidgetData_Cached() {
+	sut := NewDashboardService(suite.DashboardRepository, suite.SummaryService, nil)
+
+	summary := &models.Summary{Projects: models.SummaryItems{{Type: models.SummaryProject, Key: "wakapi", Total: 60}}}
+	suite.SummaryService.On("Aliased", mock.Anything, mock.Anything, suite.TestUser, mock.Anything, mock.Anything, mock.Anything, false).Return(summary, nil)
+
+	widget := &models.DashboardWidget{ID: 1, ChartType: models.WidgetChartBar, SummaryType: models.SummaryProject, Interval: "year"}
+	data1, err := sut.GetWidgetData(context.Background(), suite.TestUser, widget)
+	assert.Nil(suite.T(), err)
+	data2, err := sut.GetWidgetData(context.Background(), suite.TestUser, widget)
+	assert.Nil(suite.T(), err)
+	assert.Same(suite.T(), data1, data2)
+	suite.SummaryService.AssertNumberOfCalls(suite.T(), "Aliased", 1)
+
+	widget.ID = 2
+	_, err = sut.GetWidgetData(context.Background(), suite.TestUser, widget)
+	assert.Nil(suite.T(), err)
+	suite.SummaryService.AssertNumberOfCalls(suite.T(), "Aliased", 2)
+}
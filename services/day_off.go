@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/leandro-lugaresi/hub"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/repositories"
+	"github.com/muety/wakapi/utils"
+)
+
+const (
+	maxDaysOffPerEvent  = 366      // e.g. to not have a mistakenly endless event block the entire calendar
+	maxDaysOffPerImport = 10 * 366 // to limit the size of a single uploaded calendar
+)
+
+type DayOffService struct {
+	config     *config.Config
+	cache      cache.Cache
+	eventBus   *hub.Hub
+	repository repositories.IDayOffRepository
+}
+
+func NewDayOffService(dayOffRepository repositories.IDayOffRepository) *DayOffService {
+	srv := &DayOffService{
+		config:     config.Get(),
+		eventBus:   config.EventBus(),
+		repository: dayOffRepository,
+		cache:      cache.New("days_off", 24*time.Hour, 24*time.Hour),
+	}
+
+	sub1 := srv.eventBus.Subscribe(0, config.EventDaysOffChanged)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			// days off were modified on another instance
+			if config.IsRemoteEvent(m) {
+				srv.cache.Delete(m.Fields[config.FieldUserId].(string))
+			}
+		}
+	}(&sub1)
+
+	return srv
+}
+
+func (srv *DayOffService) GetById(id uint) (*models.DayOff, error) {
+	return srv.repository.GetById(id)
+}
+
+func (srv *DayOffService) GetByUser(userId string) ([]*models.DayOff, error) {
+	if daysOff, found := srv.cache.Get(userId); found {
+		return daysOff.([]*models.DayOff), nil
+	}
+
+	daysOff, err := srv.repository.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Set(userId, daysOff, cache.DefaultExpiration)
+	return daysOff, nil
+}
+
+// GetCalendar returns the user's work calendar, combining their working days of the week with all of their days off
+func (srv *DayOffService) GetCalendar(user *models.User) (*models.WorkCalendar, error) {
+	daysOff, err := srv.GetByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewWorkCalendar(user, daysOff), nil
+}
+
+func (srv *DayOffService) Create(dayOff *models.DayOff) (*models.DayOff, error) {
+	result, err := srv.repository.Insert(dayOff)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.cache.Delete(result.UserID)
+	srv.notifyUpdate(result.UserID)
+	return result, nil
+}
+
+// CreateRange marks every day between from and to (both inclusive) as a day off, e.g. for a vacation, and returns the number of days
+func (srv *DayOffService) CreateRange(user *models.User, from, to time.Time, name string) (int, error) {
+	daysOff := make([]*models.DayOff, 0)
+	for t := datetime.BeginOfDay(from); !t.After(to); t = t.AddDate(0, 0, 1) {
+		if len(daysOff) >= maxDaysOffPerEvent {
+			return 0, errors.New("range too long")
+		}
+		daysOff = append(daysOff, &models.DayOff{UserID: user.ID, Date: t.Format(time.DateOnly), Name: name, Source: models.DayOffSourceManual})
+	}
+	if len(daysOff) == 0 {
+		return 0, errors.New("invalid range")
+	}
+
+	if err := srv.repository.InsertBatch(daysOff); err != nil {
+		return 0, err
+	}
+
+	srv.cache.Delete(user.ID)
+	srv.notifyUpdate(user.ID)
+	return len(daysOff), nil
+}
+
+// ImportCalendar replaces the user's previously imported days off by all days covered by any event of the given icalendar file and returns the number of imported days
+func (srv *DayOffService) ImportCalendar(user *models.User, r io.Reader) (int, error) {
+	events, err := utils.ParseICalEvents(r, user.TZ())
+	if err != nil {
+		return 0, err
+	}
+
+	dates := make(map[string]bool)
+	daysOff := make([]*models.DayOff, 0, len(events))
+
+	for _, e := range events {
+		name := utils.TruncateRunes(e.Summary, models.MaxDayOffNameLength)
+
+		start, end := e.Start.In(user.TZ()), e.End.In(user.TZ())
+		if !e.AllDay && end.After(start) {
+			end = end.Add(-1 * time.Nanosecond) // make exclusive end inclusive, e.g. for events until midnight
+		}
+
+		for i, t := 0, datetime.BeginOfDay(start); i < maxDaysOffPerEvent && (t.Before(end) || i == 0); i, t = i+1, t.AddDate(0, 0, 1) {
+			date := t.Format(time.DateOnly)
+			if dates[date] {
+				continue
+			}
+			if len(daysOff) >= maxDaysOffPerImport {
+				return 0, errors.New("calendar contains too many events")
+			}
+			dates[date] = true
+			daysOff = append(daysOff, &models.DayOff{UserID: user.ID, Date: date, Name: name, Source: models.DayOffSourceCalendar})
+		}
+	}
+
+	err = srv.repository.ReplaceByUserAndSource(user.ID, models.DayOffSourceCalendar, daysOff)
+
+	srv.cache.Delete(user.ID)
+	srv.notifyUpdate(user.ID)
+	return len(daysOff), err
+}
+
+func (srv *DayOffService) Delete(dayOff *models.DayOff) error {
+	if dayOff.UserID == "" {
+		return errors.New("no user id specified")
+	}
+	err := srv.repository.Delete(dayOff.ID)
+	srv.cache.Delete(dayOff.UserID)
+	srv.notifyUpdate(dayOff.UserID)
+	return err
+}
+
+// DeleteImported removes all days off that were imported from a calendar file before
+func (srv *DayOffService) DeleteImported(user *models.User) error {
+	err := srv.repository.DeleteByUserAndSource(user.ID, models.DayOffSourceCalendar)
+	srv.cache.Delete(user.ID)
+	srv.notifyUpdate(user.ID)
+	return err
+}
+
+func (srv *DayOffService) notifyUpdate(userId string) {
+	srv.eventBus.Publish(hub.Message{
+		Name:   config.EventDaysOffChanged,
+		Fields: map[string]interface{}{config.FieldUserId: userId},
+	})
+}
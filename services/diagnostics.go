@@ -1,6 +1,8 @@
 package services
 
 import (
+	"time"
+
 	"github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/repositories"
@@ -20,5 +22,25 @@ func NewDiagnosticsService(diagnosticsRepo repositories.IDiagnosticsRepository)
 
 func (srv *DiagnosticsService) Create(diagnostics *models.Diagnostics) (*models.Diagnostics, error) {
 	diagnostics.ID = 0
+	diagnostics.CreatedAt = models.CustomTime(time.Now())
+	if len(diagnostics.UserAgent) > 255 {
+		diagnostics.UserAgent = diagnostics.UserAgent[:255]
+	}
 	return srv.repository.Insert(diagnostics)
 }
+
+func (srv *DiagnosticsService) GetAll(limit int) ([]*models.Diagnostics, error) {
+	return srv.repository.GetAll(limit)
+}
+
+func (srv *DiagnosticsService) GetByUser(userId string, limit int) ([]*models.Diagnostics, error) {
+	return srv.repository.GetByUser(userId, limit)
+}
+
+func (srv *DiagnosticsService) DeleteByUser(userId string) error {
+	return srv.repository.DeleteByUser(userId)
+}
+
+func (srv *DiagnosticsService) DeleteBefore(t time.Time) (int64, error) {
+	return srv.repository.DeleteBefore(t)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"time"
@@ -14,8 +15,10 @@ import (
 	"github.com/leandro-lugaresi/hub"
 	"github.com/muety/artifex/v2"
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/tracing"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/repositories"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const heartbeatPadding = 0 * time.Second
@@ -50,6 +53,10 @@ func NewDurationService(durationRepository repositories.IDurationRepository, hea
 	sub1 := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate)
 	go func(sub *hub.Subscription) {
 		for m := range sub.Receiver {
+			if config.IsRemoteEvent(m) {
+				continue // durations are regenerated by the originating instance
+			}
+
 			heartbeat := m.Fields[config.FieldPayload].(*models.Heartbeat)
 			user := heartbeat.User
 
@@ -65,16 +72,20 @@ func NewDurationService(durationRepository repositories.IDurationRepository, hea
 	return srv
 }
 
-func (srv *DurationService) Get(from, to time.Time, user *models.User, filters *models.Filters, customTimeout *time.Duration, skipCache bool) (durations models.Durations, err error) {
+func (srv *DurationService) Get(ctx context.Context, from, to time.Time, user *models.User, filters *models.Filters, customTimeout *time.Duration, skipCache bool) (durations models.Durations, err error) {
+	ctx, span := tracing.Start(ctx, "DurationService.Get", summarySpanAttrs(from, to, user, filters)...)
+	defer func() { tracing.End(span, err) }()
+
 	// note about "multi-level" durations at different intervals:
 	// while durations themselves store the interval (aka. heartbeats timeout) they were computed for, we currently don't support actually storing durations at different intervals
 	// if an interval different from the user's preference is requested, recompute durations live from heartbeats and skip cache
 	effectiveTimeout := getEffectiveTimeout(user, customTimeout)
 	skipCache = skipCache || effectiveTimeout != user.HeartbeatsTimeout() || filters.IsProjectDetails() // related: https://github.com/muety/wakapi/issues/876
+	span.SetAttributes(attribute.Bool("wakapi.skip_cache", skipCache))
 
 	// recompute live
 	if skipCache {
-		durations, err = srv.getLive(from, to, user, effectiveTimeout, filters.IsProjectDetails())
+		durations, err = srv.getLive(ctx, from, to, user, effectiveTimeout, filters.IsProjectDetails())
 		if err != nil {
 			return nil, err
 		}
@@ -84,7 +95,7 @@ func (srv *DurationService) Get(from, to time.Time, user *models.User, filters *
 	}
 
 	// get cached
-	cached, err := srv.getCached(from, to, user, filters)
+	cached, err := srv.getCached(ctx, from, to, user, filters)
 	if err != nil {
 		config.Log().Error("failed to get cached durations", "user", user.ID, "from", from, "to", to, "error", err)
 		cached = models.Durations{}
@@ -98,7 +109,7 @@ func (srv *DurationService) Get(from, to time.Time, user *models.User, filters *
 			from = cached.Last().TimeEnd().Add(time.Second)
 		}
 
-		missing, err := srv.getLive(from, to, user, effectiveTimeout, filters.IsProjectDetails())
+		missing, err := srv.getLive(ctx, from, to, user, effectiveTimeout, filters.IsProjectDetails())
 		if err != nil {
 			return nil, err
 		}
@@ -123,15 +134,29 @@ func (srv *DurationService) Regenerate(user *models.User, forceAll bool) {
 	srv.pending.Add(user.ID)
 	defer srv.pending.Delete(user.ID)
 
+	ctx, span := tracing.StartJob("regenerate_durations", tracing.AttrUser.String(user.ID))
+	defer span.End()
+	ctx = repositories.WithPrimaryReads(ctx) // recently sent heartbeats might not have been replicated, yet
+
 	var from time.Time
 	latest, err := srv.repository.GetLatestByUser(user)
 	if err == nil && latest != nil && !forceAll {
 		from = latest.TimeEnd()
 	}
 
+	// durations of archived periods are kept as they are, because regenerating them would require decompressing all archived heartbeats
+	archivedUntil, err := srv.heartbeatService.GetArchivedUntil(user)
+	if err != nil {
+		config.Log().Error("failed to get archived period for user", "user", user.ID, "error", err)
+		return
+	}
+	if forceAll && !archivedUntil.IsZero() {
+		from = archivedUntil
+	}
+
 	slog.Info("generating ephemeral durations for user up until now", "user", user.ID, "from", from)
 
-	durations, err := srv.Get(from, time.Now(), user, nil, nil, forceAll)
+	durations, err := srv.Get(ctx, from, time.Now(), user, nil, nil, forceAll)
 	if err != nil {
 		config.Log().Error("failed to regenerate ephemeral durations for user up until now", "user", user.ID, "error", err)
 		return
@@ -140,7 +165,12 @@ func (srv *DurationService) Regenerate(user *models.User, forceAll bool) {
 		config.Log().Warn("got generated duration before requested min date", "user", user.ID, "time", durations[0].Time.T(), "group_hash", durations[0].GroupHash, "min_date", from)
 	}
 
-	if forceAll {
+	if forceAll && !archivedUntil.IsZero() {
+		if err := srv.repository.DeleteByUserAfter(user, archivedUntil); err != nil {
+			config.Log().Error("failed to delete old durations while generating ephemeral new ones", "user", user.ID, "error", err)
+			return
+		}
+	} else if forceAll {
 		if err := srv.repository.DeleteByUser(user); err != nil {
 			config.Log().Error("failed to delete old durations while generating ephemeral new ones", "user", user.ID, "error", err)
 			return
@@ -173,22 +203,25 @@ func (srv *DurationService) DeleteByUser(user *models.User) error {
 	return srv.repository.DeleteByUser(user)
 }
 
-func (srv *DurationService) getCached(from, to time.Time, user *models.User, filters *models.Filters) (models.Durations, error) {
+func (srv *DurationService) getCached(ctx context.Context, from, to time.Time, user *models.User, filters *models.Filters) (models.Durations, error) {
 	languageMappings, err := srv.languageMappingService.ResolveByUser(user.ID)
 	if err != nil {
 		return nil, err
 	}
-	durations, err := srv.repository.GetAllWithinByFilters(from, to, user, srv.filtersToColumnMap(filters))
+	durations, err := srv.repository.GetAllWithinByFilters(ctx, from, to, user, srv.filtersToColumnFilters(filters))
 	if err != nil {
 		return nil, err
 	}
 	return models.Durations(durations).Augmented(languageMappings).Sorted(), nil
 }
 
-func (srv *DurationService) getLive(from, to time.Time, user *models.User, interval time.Duration, includeEntities bool) (models.Durations, error) {
+func (srv *DurationService) getLive(ctx context.Context, from, to time.Time, user *models.User, interval time.Duration, includeEntities bool) (result models.Durations, err error) {
+	ctx, span := tracing.Start(ctx, "DurationService.getLive", summarySpanAttrs(from, to, user, nil)...)
+	defer func() { tracing.End(span, err) }()
+
 	heartbeatsTimeout := interval
 
-	heartbeats, err := srv.heartbeatService.StreamAllWithinRaw(from, to, user)
+	heartbeats, err := srv.heartbeatService.StreamAllWithinRaw(ctx, from, to, user)
 	if err != nil {
 		return nil, err
 	}
@@ -243,6 +276,8 @@ func (srv *DurationService) getLive(from, to time.Time, user *models.User, inter
 			latest = d1
 		} else {
 			latest.NumHeartbeats++
+			latest.AICounters.Add(models.NewAICountersFromHeartbeat(h))
+			latest.LineCounters.Add(models.NewLineCountersFromHeartbeat(h))
 			// TODO: think about how to fix this properly
 			// Problem: we don't consider entities (aka. file names) when distinguishing between durations, that is, in other words,
 			// durations essentially aggregate heartbeats by (a) by time (squash all heartbeats within <heartbeatsTimeout> and (b) by entity.
@@ -262,6 +297,8 @@ func (srv *DurationService) getLive(from, to time.Time, user *models.User, inter
 		durations[0].Duration = heartbeatPadding
 	}
 
+	span.SetAttributes(tracing.AttrCount.Int(count))
+
 	// note: no need to do language augmentation here, because already done while retrieving heartbeats
 	return models.Durations(durations).Sorted(), nil
 }
@@ -309,6 +346,8 @@ func (srv *DurationService) merge(d1, d2 models.Durations, user *models.User) (m
 			middleMerged := &(*middleLeft)
 			middleMerged.Duration += diff + middleRight.Duration
 			middleMerged.NumHeartbeats += middleRight.NumHeartbeats
+			middleMerged.AICounters.Add(middleRight.AICounters)
+			middleMerged.LineCounters.Add(middleRight.LineCounters)
 			middleMerged.Hashed()
 			merged = append(merged, middleMerged) // left and right are merged into one
 		} else {
@@ -326,21 +365,23 @@ func (srv *DurationService) merge(d1, d2 models.Durations, user *models.User) (m
 	return merged, nil
 }
 
-func (srv *DurationService) filtersToColumnMap(filters *models.Filters) map[string][]string {
-	columnMap := map[string][]string{}
+func (srv *DurationService) filtersToColumnFilters(filters *models.Filters) models.ColumnFilters {
+	columnFilters := models.NewColumnFilters()
 
 	if filters == nil {
-		return columnMap
+		return columnFilters
 	}
 
 	for _, t := range models.NativeSummaryTypes() {
-		f := filters.ResolveType(t)
-		if len(*f) > 0 {
-			columnMap[models.GetEntityColumn(t)] = *f
+		if f := *filters.ResolveType(t); len(f) > 0 {
+			columnFilters.Included[models.GetEntityColumn(t)] = f
+		}
+		if f := filters.ResolveExcludedType(t); len(f) > 0 {
+			columnFilters.Excluded[models.GetEntityColumn(t)] = f
 		}
 	}
 
-	return columnMap
+	return columnFilters
 }
 
 func getEffectiveTimeout(user *models.User, overrideTimeout *time.Duration) time.Duration {
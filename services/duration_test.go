@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"math/rand"
 	"strings"
 	"testing"
@@ -169,7 +170,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get() {
 	from, to = suite.TestStartTime.Add(-1*time.Hour), suite.TestStartTime.Add(-1*time.Minute)
 	suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice(filterHeartbeats(from, to, suite.TestHeartbeats)), nil)
 
-	durations, err = sut.Get(from, to, suite.TestUser, nil, nil, true)
+	durations, err = sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, true)
 
 	assert.Nil(suite.T(), err)
 	assert.Empty(suite.T(), durations)
@@ -178,7 +179,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get() {
 	from, to = suite.TestStartTime.Add(-1*time.Hour), suite.TestStartTime.Add(1*time.Second)
 	suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice(filterHeartbeats(from, to, suite.TestHeartbeats)), nil)
 
-	durations, err = sut.Get(from, to, suite.TestUser, nil, nil, true)
+	durations, err = sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, true)
 
 	assert.Nil(suite.T(), err)
 	assert.Len(suite.T(), durations, 1)
@@ -189,7 +190,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get() {
 	from, to = suite.TestStartTime, suite.TestStartTime.Add(1*time.Hour)
 	suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice(filterHeartbeats(from, to, suite.TestHeartbeats)), nil)
 
-	durations, err = sut.Get(from, to, suite.TestUser, nil, nil, true)
+	durations, err = sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, true)
 
 	assert.Nil(suite.T(), err)
 	assert.Len(suite.T(), durations, 3)
@@ -217,7 +218,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_Filtered() {
 	from, to = suite.TestStartTime.Add(-1*time.Hour), suite.TestStartTime.Add(1*time.Hour)
 	suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice(filterHeartbeats(from, to, suite.TestHeartbeats)), nil)
 
-	durations, err = sut.Get(from, to, suite.TestUser, models.NewFiltersWith(models.SummaryEditor, TestEditorGoland), nil, true)
+	durations, err = sut.Get(context.Background(), from, to, suite.TestUser, models.NewFiltersWith(models.SummaryEditor, TestEditorGoland), nil, true)
 	assert.Nil(suite.T(), err)
 	assert.Len(suite.T(), durations, 2)
 	assert.Equal(suite.T(), 30*time.Second, durations[0].Duration)
@@ -248,12 +249,61 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_Filtered_AiModel(
 	from, to := suite.TestStartTime.Add(-1*time.Hour), suite.TestStartTime.Add(1*time.Hour)
 	suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice([]*models.Heartbeat{h1, h2}), nil)
 
-	durations, err := sut.Get(from, to, suite.TestUser, models.NewFiltersWith(models.SummaryAiModel, TestAiModelClaude), nil, true)
+	durations, err := sut.Get(context.Background(), from, to, suite.TestUser, models.NewFiltersWith(models.SummaryAiModel, TestAiModelClaude), nil, true)
 	assert.Nil(suite.T(), err)
 	assert.Len(suite.T(), durations, 1)
 	assert.Equal(suite.T(), TestAiModelClaude, durations[0].AIModel)
 }
 
+func (suite *DurationServiceTestSuite) TestDurationService_Get_AICounters() {
+	sut := NewDurationService(suite.DurationRepository, suite.HeartbeatService, suite.UserService, suite.LanguageMappingService)
+
+	h1 := &models.Heartbeat{
+		UserID:           TestUserId,
+		Project:          TestProject1,
+		Language:         TestLanguageGo,
+		AIModel:          TestAiModelClaude,
+		AISession:        "session1",
+		AIInputTokens:    100,
+		AIOutputTokens:   20,
+		AILineChanges:    8,
+		HumanLineChanges: 2,
+		Time:             models.CustomTime(suite.TestStartTime),
+	}
+	h2 := &models.Heartbeat{
+		UserID:         TestUserId,
+		Project:        TestProject1,
+		Language:       TestLanguageGo,
+		AIModel:        TestAiModelClaude,
+		AISession:      "session1",
+		AIInputTokens:  50,
+		AIOutputTokens: 10,
+		AILineChanges:  4,
+		Time:           models.CustomTime(suite.TestStartTime.Add(30 * time.Second)),
+	}
+	h3 := &models.Heartbeat{
+		UserID:         TestUserId,
+		Project:        TestProject1,
+		Language:       TestLanguageGo,
+		AIModel:        TestAiModelClaude,
+		AISession:      "session2",
+		AIInputTokens:  10,
+		AIOutputTokens: 5,
+		Time:           models.CustomTime(suite.TestStartTime.Add(60 * time.Second)),
+	}
+
+	from, to := suite.TestStartTime.Add(-1*time.Hour), suite.TestStartTime.Add(1*time.Hour)
+	suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice([]*models.Heartbeat{h1, h2, h3}), nil)
+
+	durations, err := sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, true)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), durations, 1) // ai sessions don't split durations
+	assert.Equal(suite.T(), 160, durations[0].AIInputTokens)
+	assert.Equal(suite.T(), 35, durations[0].AIOutputTokens)
+	assert.Equal(suite.T(), 12, durations[0].AILineChanges)
+	assert.Equal(suite.T(), 2, durations[0].HumanLineChanges)
+}
+
 func (suite *DurationServiceTestSuite) TestDurationService_Get_ProjectDetails() {
 	// https:// github.com/muety/wakapi/issues/876
 	sut := NewDurationService(suite.DurationRepository, suite.HeartbeatService, suite.UserService, suite.LanguageMappingService)
@@ -269,7 +319,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_ProjectDetails()
 	suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice(filterHeartbeats(from, to, suite.TestHeartbeats)), nil)
 
 	testFilters := models.NewFiltersWith(models.SummaryEditor, TestEditorGoland).With(models.SummaryProject, TestProject1)
-	durations, err = sut.Get(from, to, suite.TestUser, testFilters, nil, true)
+	durations, err = sut.Get(context.Background(), from, to, suite.TestUser, testFilters, nil, true)
 	assert.Nil(suite.T(), err)
 	assert.Len(suite.T(), durations, 3)
 	assert.Equal(suite.T(), TestEntity1, durations[0].Entity) // first duration is split up into two parts, because of different filenames when requesting project details
@@ -295,7 +345,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_CustomTimeout() {
 	/* Test 1 */
 	call1 := suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice(filterHeartbeats(from, to, suite.TestHeartbeats)), nil)
 	suite.TestUser.HeartbeatsTimeoutSec = 60
-	durations, _ = sut.Get(from, to, suite.TestUser, nil, nil, true)
+	durations, _ = sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, true)
 
 	assert.Len(suite.T(), durations, 3)
 	assert.Equal(suite.T(), 30*time.Second, durations[0].Duration)
@@ -309,7 +359,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_CustomTimeout() {
 	/* Test 2 */
 	call2 := suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice(filterHeartbeats(from, to, suite.TestHeartbeats)), nil)
 	suite.TestUser.HeartbeatsTimeoutSec = 130
-	durations, _ = sut.Get(from, to, suite.TestUser, nil, nil, true)
+	durations, _ = sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, true)
 
 	assert.Len(suite.T(), durations, 3)
 	assert.Equal(suite.T(), 30*time.Second, durations[0].Duration)
@@ -323,7 +373,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_CustomTimeout() {
 	/* Test 3 */
 	call3 := suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice(filterHeartbeats(from, to, suite.TestHeartbeats)), nil)
 	suite.TestUser.HeartbeatsTimeoutSec = 140
-	durations, _ = sut.Get(from, to, suite.TestUser, nil, nil, true)
+	durations, _ = sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, true)
 
 	assert.Len(suite.T(), durations, 2)
 	assert.Equal(suite.T(), 180*time.Second, durations[0].Duration)
@@ -363,7 +413,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_Cached() {
 	suite.DurationRepository.On("GetAllWithinByFilters", from, to, suite.TestUser, mock.Anything).Return(testDurations, nil)
 	suite.HeartbeatService.On("StreamAllWithinRaw", toCached, to, suite.TestUser).Return(streamSlice(filterHeartbeats(toCached, to, suite.TestHeartbeats)), nil)
 
-	durations, err = sut.Get(from, to, suite.TestUser, nil, nil, false)
+	durations, err = sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, false)
 
 	assert.Nil(suite.T(), err)
 	assert.Len(suite.T(), durations, 3)
@@ -389,7 +439,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_CustomInterval()
 	suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice(filterHeartbeats(from, to, suite.TestHeartbeats)), nil)
 
 	customInterval := 15 * time.Minute
-	durations, err = sut.Get(from, to, suite.TestUser, nil, &customInterval, false)
+	durations, err = sut.Get(context.Background(), from, to, suite.TestUser, nil, &customInterval, false)
 
 	assert.Nil(suite.T(), err)
 	assert.Len(suite.T(), durations, 2)
@@ -417,7 +467,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_WithLanguageMappi
 	suite.DurationRepository.On("GetAllWithinByFilters", from, to, suite.TestUser, mock.Anything).Return(testDurations, nil)
 	suite.HeartbeatService.On("StreamAllWithinRaw", mock.Anything, mock.Anything, suite.TestUser).Return(streamSlice([]*models.Heartbeat{}), nil)
 
-	durations, err = sut.Get(from, to, suite.TestUser, nil, nil, false)
+	durations, err = sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, false)
 
 	assert.Nil(suite.T(), err)
 	assert.Len(suite.T(), durations, 1)
@@ -464,7 +514,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_WithLanguageMappi
 	from, to := suite.TestStartTime.Add(-1*time.Hour), suite.TestStartTime.Add(1*time.Hour)
 	suite.HeartbeatService.On("StreamAllWithinRaw", from, to, suite.TestUser).Return(streamSlice([]*models.Heartbeat{h1, h2, h3}), nil)
 
-	durations, err := sut.Get(from, to, suite.TestUser, nil, nil, true)
+	durations, err := sut.Get(context.Background(), from, to, suite.TestUser, nil, nil, true)
 
 	assert.Nil(suite.T(), err)
 	assert.Len(suite.T(), durations, 3)
@@ -590,6 +640,13 @@ func (suite *DurationServiceTestSuite) TestDuration_Hashed() {
 	d20.AIModel = "aimodel2"
 	d20.Hashed()
 	assert.NotEqual(suite.T(), d1.GroupHash, d20.GroupHash)
+
+	// different ai counters -> same hash
+	d22 := *d1
+	d22.AIInputTokens = 100
+	d22.AILineChanges = 10
+	d22.Hashed()
+	assert.Equal(suite.T(), d1.GroupHash, d22.GroupHash)
 }
 
 func filterHeartbeats(from, to time.Time, heartbeats []*models.Heartbeat) []*models.Heartbeat {
@@ -0,0 +1,216 @@
+package events
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/leandro-lugaresi/hub"
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/models"
+)
+
+// The bridge relays events between the in-process event bus of multiple wakapi instances, so that e.g. caches are invalidated on all of them.
+// Remote events are republished under their original name and marked with config.FieldRemote.
+// Heartbeats are not relayed one by one, but coalesced into a single config.EventHeartbeatsChanged event per user and flush interval.
+// Payloads are reduced to what's needed by remote subscribers, that is, user ids and heartbeats, to keep messages small and not spread any credentials.
+
+const (
+	// max. number of events buffered locally before being sent out, events will be dropped if exceeded
+	bridgeBufferSize = 4096
+	// interval in which coalesced heartbeat events are sent out
+	heartbeatFlushInterval = 5 * time.Second
+)
+
+var forwardedEvents = []string{
+	config.EventUserUpdate,
+	config.EventUserDelete,
+	config.EventHeartbeatCreate, // coalesced
+	config.EventProjectLabelCreate,
+	config.EventProjectLabelDelete,
+	config.EventAliasCreate,
+	config.EventAliasDelete,
+	config.EventLanguageMappingsChanged,
+	config.EventDaysOffChanged,
+	config.EventFilterPresetsChanged,
+	config.EventApiKeyCreate, // only with the user id, as the key itself is a credential
+	config.EventApiKeyDelete, // only with the user id, as the key itself is a credential
+}
+
+func init() {
+	gob.Register(&models.User{})
+	gob.Register(&models.Heartbeat{})
+}
+
+// Transport is a means to exchange (opaque) messages among all wakapi instances
+type Transport interface {
+	Publish(data []byte) error
+	// Listen starts to asynchronously receive messages published by any instance (including this one) and passes them to the given handler
+	Listen(handler func(data []byte)) error
+	Close() error
+}
+
+type envelope struct {
+	Instance string
+	Name     string
+	Fields   map[string]interface{}
+}
+
+// heartbeatBatch summarizes the heartbeats a user created since the last flush
+type heartbeatBatch struct {
+	latest *models.Heartbeat
+	count  int
+}
+
+type Bridge struct {
+	eventBus        *hub.Hub
+	transport       Transport
+	instanceId      string
+	subscription    hub.Subscription
+	flushInterval   time.Duration
+	heartbeats      map[string]*heartbeatBatch
+	heartbeatsMutex sync.Mutex
+	done            chan struct{}
+	closeOnce       sync.Once
+}
+
+func NewBridge(eventBus *hub.Hub, transport Transport, instanceId string) *Bridge {
+	return &Bridge{
+		eventBus:      eventBus,
+		transport:     transport,
+		instanceId:    instanceId,
+		flushInterval: heartbeatFlushInterval,
+		heartbeats:    map[string]*heartbeatBatch{},
+		done:          make(chan struct{}),
+	}
+}
+
+func (b *Bridge) Start() error {
+	if err := b.transport.Listen(b.receive); err != nil {
+		return err
+	}
+
+	b.subscription = b.eventBus.NonBlockingSubscribe(bridgeBufferSize, forwardedEvents...)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			if config.IsRemoteEvent(m) {
+				continue
+			}
+			if m.Name == config.EventHeartbeatCreate {
+				b.collectHeartbeat(m.Fields[config.FieldPayload].(*models.Heartbeat))
+				continue
+			}
+			b.send(m)
+		}
+	}(&b.subscription)
+
+	go func() {
+		ticker := time.NewTicker(b.flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-b.done:
+				return
+			case <-ticker.C:
+				b.flushHeartbeats()
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (b *Bridge) Close() error {
+	b.eventBus.Unsubscribe(b.subscription)
+	b.closeOnce.Do(func() {
+		close(b.done)
+		b.flushHeartbeats()
+	})
+	return b.transport.Close()
+}
+
+func (b *Bridge) collectHeartbeat(heartbeat *models.Heartbeat) {
+	b.heartbeatsMutex.Lock()
+	defer b.heartbeatsMutex.Unlock()
+
+	batch, ok := b.heartbeats[heartbeat.UserID]
+	if !ok {
+		batch = &heartbeatBatch{}
+		b.heartbeats[heartbeat.UserID] = batch
+	}
+	if batch.latest == nil || heartbeat.Time.T().After(batch.latest.Time.T()) {
+		batch.latest = heartbeat
+	}
+	batch.count++
+}
+
+func (b *Bridge) flushHeartbeats() {
+	b.heartbeatsMutex.Lock()
+	batches := b.heartbeats
+	b.heartbeats = map[string]*heartbeatBatch{}
+	b.heartbeatsMutex.Unlock()
+
+	for userId, batch := range batches {
+		b.send(hub.Message{
+			Name: config.EventHeartbeatsChanged,
+			Fields: map[string]interface{}{
+				config.FieldUserId:  userId,
+				config.FieldPayload: batch.latest,
+				config.FieldCount:   batch.count,
+			},
+		})
+	}
+}
+
+func (b *Bridge) send(m hub.Message) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&envelope{Instance: b.instanceId, Name: m.Name, Fields: sanitizeFields(m.Fields)}); err != nil {
+		config.Log().Error("failed to encode event", "name", m.Name, "error", err)
+		return
+	}
+	if err := b.transport.Publish(buf.Bytes()); err != nil {
+		config.Log().Error("failed to publish event to other instances", "name", m.Name, "error", err)
+	}
+}
+
+func (b *Bridge) receive(data []byte) {
+	var e envelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+		config.Log().Error("failed to decode remote event", "error", err)
+		return
+	}
+	if e.Instance == b.instanceId {
+		return
+	}
+
+	if e.Fields == nil {
+		e.Fields = map[string]interface{}{}
+	}
+	e.Fields[config.FieldRemote] = true
+
+	slog.Debug("received remote event", "name", e.Name, "instance", e.Instance)
+	b.eventBus.Publish(hub.Message{Name: e.Name, Fields: e.Fields})
+}
+
+func sanitizeFields(fields hub.Fields) map[string]interface{} {
+	result := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		switch value := v.(type) {
+		case string, int, bool:
+			result[k] = value
+		case *models.User:
+			result[k] = &models.User{ID: value.ID}
+		case *models.Heartbeat:
+			hb := *value
+			hb.User = nil
+			result[k] = &hb
+		case *models.ApiKey:
+			// never relayed, remote subscribers only invalidate per-user caches by config.FieldUserId
+		}
+		// other payloads (labels, mappings, ...) are omitted, remote subscribers only rely on the user id
+	}
+	return result
+}
@@ -0,0 +1,256 @@
+package events
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leandro-lugaresi/hub"
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryTransport simulates a message broker shared by multiple instances
+type memoryTransport struct {
+	handlers *[]func(data []byte)
+	mutex    *sync.Mutex
+}
+
+func newMemoryTransports(n int) []*memoryTransport {
+	handlers, mutex := make([]func(data []byte), 0), &sync.Mutex{}
+	transports := make([]*memoryTransport, n)
+	for i := range transports {
+		transports[i] = &memoryTransport{handlers: &handlers, mutex: mutex}
+	}
+	return transports
+}
+
+func (t *memoryTransport) Publish(data []byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, h := range *t.handlers {
+		go h(data)
+	}
+	return nil
+}
+
+func (t *memoryTransport) Listen(handler func(data []byte)) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	*t.handlers = append(*t.handlers, handler)
+	return nil
+}
+
+func (t *memoryTransport) Close() error {
+	return nil
+}
+
+func TestBridge_RelaysEvents(t *testing.T) {
+	config.Set(config.Empty())
+
+	transports := newMemoryTransports(2)
+	hub1, hub2 := hub.New(), hub.New()
+	bridge1, bridge2 := NewBridge(hub1, transports[0], "instance1"), NewBridge(hub2, transports[1], "instance2")
+	bridge1.flushInterval = 10 * time.Millisecond
+	require.Nil(t, bridge1.Start())
+	require.Nil(t, bridge2.Start())
+	defer bridge1.Close()
+	defer bridge2.Close()
+
+	sub1 := hub1.Subscribe(10, config.TopicHeartbeat, config.TopicUser, config.TopicAlias)
+	sub2 := hub2.Subscribe(10, config.TopicHeartbeat, config.TopicUser, config.TopicAlias)
+
+	user := &models.User{ID: "testuser", Password: "secret", ApiKey: "secret"}
+	heartbeat := &models.Heartbeat{User: user, UserID: user.ID, Project: "wakapi", Time: models.CustomTime(time.Now())}
+
+	hub1.Publish(hub.Message{Name: config.EventHeartbeatCreate, Fields: map[string]interface{}{config.FieldPayload: heartbeat}})
+	hub1.Publish(hub.Message{Name: config.EventUserUpdate, Fields: map[string]interface{}{config.FieldPayload: user}})
+	hub1.Publish(hub.Message{Name: config.EventAliasCreate, Fields: map[string]interface{}{config.FieldUserId: user.ID}})
+
+	received := receive(t, sub2.Receiver, 3)
+
+	assert.True(t, config.IsRemoteEvent(received[config.EventHeartbeatsChanged]))
+	assert.Equal(t, user.ID, received[config.EventHeartbeatsChanged].Fields[config.FieldUserId])
+	assert.Equal(t, 1, received[config.EventHeartbeatsChanged].Fields[config.FieldCount])
+	remoteHeartbeat := received[config.EventHeartbeatsChanged].Fields[config.FieldPayload].(*models.Heartbeat)
+	assert.Equal(t, user.ID, remoteHeartbeat.UserID)
+	assert.Equal(t, "wakapi", remoteHeartbeat.Project)
+	assert.Equal(t, heartbeat.Time.T().UnixMilli(), remoteHeartbeat.Time.T().UnixMilli())
+	assert.Nil(t, remoteHeartbeat.User)
+
+	remoteUser := received[config.EventUserUpdate].Fields[config.FieldPayload].(*models.User)
+	assert.Equal(t, user.ID, remoteUser.ID)
+	assert.Empty(t, remoteUser.Password)
+	assert.Empty(t, remoteUser.ApiKey)
+
+	assert.Equal(t, user.ID, received[config.EventAliasCreate].Fields[config.FieldUserId])
+
+	// local subscribers only get the original events, without echoes from other instances
+	local := receive(t, sub1.Receiver, 3)
+	for _, m := range local {
+		assert.False(t, config.IsRemoteEvent(m))
+	}
+	select {
+	case m := <-sub1.Receiver:
+		t.Errorf("unexpected event %s", m.Name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBridge_RelaysApiKeyEventsWithoutKey(t *testing.T) {
+	config.Set(config.Empty())
+
+	transports := newMemoryTransports(2)
+	hub1, hub2 := hub.New(), hub.New()
+	bridge1, bridge2 := NewBridge(hub1, transports[0], "instance1"), NewBridge(hub2, transports[1], "instance2")
+	require.Nil(t, bridge1.Start())
+	require.Nil(t, bridge2.Start())
+	defer bridge1.Close()
+	defer bridge2.Close()
+
+	sub2 := hub2.Subscribe(10, config.EventApiKeyCreate, config.EventApiKeyDelete)
+
+	apiKey := &models.ApiKey{ApiKey: "secret", UserID: "testuser", Label: "ci"}
+	hub1.Publish(hub.Message{Name: config.EventApiKeyCreate, Fields: map[string]interface{}{config.FieldPayload: apiKey, config.FieldUserId: apiKey.UserID}})
+	hub1.Publish(hub.Message{Name: config.EventApiKeyDelete, Fields: map[string]interface{}{config.FieldPayload: apiKey, config.FieldUserId: apiKey.UserID}})
+
+	received := receive(t, sub2.Receiver, 2)
+	for _, name := range []string{config.EventApiKeyCreate, config.EventApiKeyDelete} {
+		assert.True(t, config.IsRemoteEvent(received[name]))
+		assert.Equal(t, apiKey.UserID, received[name].Fields[config.FieldUserId])
+		assert.NotContains(t, received[name].Fields, config.FieldPayload)
+	}
+}
+
+func TestBridge_CoalescesHeartbeats(t *testing.T) {
+	config.Set(config.Empty())
+
+	transports := newMemoryTransports(2)
+	hub1, hub2 := hub.New(), hub.New()
+	bridge1, bridge2 := NewBridge(hub1, transports[0], "instance1"), NewBridge(hub2, transports[1], "instance2")
+	bridge1.flushInterval = time.Hour // flushed on close
+	require.Nil(t, bridge1.Start())
+	require.Nil(t, bridge2.Start())
+	defer bridge2.Close()
+
+	sub2 := hub2.Subscribe(10, config.TopicHeartbeat)
+
+	now := time.Now()
+	for _, hb := range []*models.Heartbeat{
+		{UserID: "user1", Project: "wakapi", Time: models.CustomTime(now.Add(-2 * time.Minute))},
+		{UserID: "user1", Project: "anchr", Time: models.CustomTime(now)},
+		{UserID: "user1", Project: "wakapi", Time: models.CustomTime(now.Add(-1 * time.Minute))},
+		{UserID: "user2", Project: "wakapi", Time: models.CustomTime(now)},
+	} {
+		hub1.Publish(hub.Message{Name: config.EventHeartbeatCreate, Fields: map[string]interface{}{config.FieldPayload: hb}})
+	}
+
+	time.Sleep(100 * time.Millisecond) // let bridge consume all local events
+	select {
+	case m := <-sub2.Receiver:
+		t.Errorf("unexpected event %s before flush", m.Name)
+	default:
+	}
+
+	require.Nil(t, bridge1.Close())
+
+	received := make(map[string]hub.Message, 2)
+	for len(received) < 2 {
+		select {
+		case m := <-sub2.Receiver:
+			assert.Equal(t, config.EventHeartbeatsChanged, m.Name)
+			received[m.Fields[config.FieldUserId].(string)] = m
+		case <-time.After(time.Second):
+			t.Fatalf("expected 2 events, got %d", len(received))
+		}
+	}
+
+	assert.Equal(t, 3, received["user1"].Fields[config.FieldCount])
+	assert.Equal(t, "anchr", received["user1"].Fields[config.FieldPayload].(*models.Heartbeat).Project)
+	assert.Equal(t, 1, received["user2"].Fields[config.FieldCount])
+}
+
+func TestBridge_SkipsLocalOnlyEvents(t *testing.T) {
+	config.Set(config.Empty())
+
+	transports := newMemoryTransports(2)
+	hub1, hub2 := hub.New(), hub.New()
+	bridge1, bridge2 := NewBridge(hub1, transports[0], "instance1"), NewBridge(hub2, transports[1], "instance2")
+	require.Nil(t, bridge1.Start())
+	require.Nil(t, bridge2.Start())
+	defer bridge1.Close()
+	defer bridge2.Close()
+
+	sub2 := hub2.Subscribe(10, config.EventWakatimeFailure)
+	hub1.Publish(hub.Message{Name: config.EventWakatimeFailure, Fields: map[string]interface{}{config.FieldUser: &models.User{ID: "testuser"}, config.FieldPayload: 100}})
+
+	select {
+	case m := <-sub2.Receiver:
+		t.Errorf("unexpected event %s", m.Name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDatabaseTransport_Listen(t *testing.T) {
+	config.Set(config.Empty())
+
+	repository := new(mocks.EventOutboxRepositoryMock)
+	repository.On("GetLatestId").Return(uint64(10), nil)
+	repository.On("GetAfter", uint64(10), outboxBatchSize).Return([]*models.EventOutboxEntry{
+		{ID: 11, Instance: "instance2", Payload: []byte("foo")},
+		{ID: 12, Instance: "instance1", Payload: []byte("bar")},
+	}, nil).Once()
+	polledAgain := make(chan bool, 1)
+	repository.On("GetAfter", uint64(12), outboxBatchSize).Return([]*models.EventOutboxEntry{}, nil).Run(func(args mock.Arguments) {
+		select {
+		case polledAgain <- true:
+		default:
+		}
+	})
+
+	sut := NewDatabaseTransport(repository, "instance1", 10*time.Millisecond)
+	defer sut.Close()
+
+	received := make(chan []byte, 10)
+	require.Nil(t, sut.Listen(func(data []byte) {
+		received <- data
+	}))
+
+	select {
+	case data := <-received:
+		assert.Equal(t, []byte("foo"), data)
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+
+	select {
+	case <-polledAgain:
+	case <-time.After(time.Second):
+		t.Fatal("outbox not polled again")
+	}
+	assert.Empty(t, received) // own messages are skipped
+
+	repository.On("Insert", mock.Anything).Return(nil)
+	assert.Nil(t, sut.Publish([]byte("baz")))
+	repository.AssertCalled(t, "Insert", mock.MatchedBy(func(e *models.EventOutboxEntry) bool {
+		return e.Instance == "instance1" && string(e.Payload) == "baz"
+	}))
+}
+
+// receive collects the given number of events, mapped by their name, as their order is not guaranteed
+func receive(t *testing.T, receiver <-chan hub.Message, n int) map[string]hub.Message {
+	messages := make(map[string]hub.Message, n)
+	for len(messages) < n {
+		select {
+		case m := <-receiver:
+			messages[m.Name] = m
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d events, got %d", n, len(messages))
+		}
+	}
+	return messages
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/repositories"
+)
+
+const (
+	outboxBatchSize = 500
+	outboxRetention = 10 * time.Minute
+	outboxCleanup   = 1 * time.Minute
+)
+
+// DatabaseTransport exchanges messages via a (polled) outbox table and thus works with every supported database
+type DatabaseTransport struct {
+	repository   repositories.IEventOutboxRepository
+	instanceId   string
+	pollInterval time.Duration
+	done         chan struct{}
+	closeOnce    sync.Once
+}
+
+func NewDatabaseTransport(repository repositories.IEventOutboxRepository, instanceId string, pollInterval time.Duration) *DatabaseTransport {
+	return &DatabaseTransport{
+		repository:   repository,
+		instanceId:   instanceId,
+		pollInterval: pollInterval,
+		done:         make(chan struct{}),
+	}
+}
+
+func (t *DatabaseTransport) Publish(data []byte) error {
+	return t.repository.Insert(&models.EventOutboxEntry{
+		Instance:  t.instanceId,
+		Payload:   data,
+		CreatedAt: models.CustomTime(time.Now()),
+	})
+}
+
+func (t *DatabaseTransport) Listen(handler func(data []byte)) error {
+	// only consider events published from now on
+	lastId, err := t.repository.GetLatestId()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		pollTicker, cleanupTicker := time.NewTicker(t.pollInterval), time.NewTicker(outboxCleanup)
+		defer pollTicker.Stop()
+		defer cleanupTicker.Stop()
+
+		for {
+			select {
+			case <-t.done:
+				return
+			case <-pollTicker.C:
+				lastId = t.poll(lastId, handler)
+			case <-cleanupTicker.C:
+				if _, err := t.repository.DeleteBefore(time.Now().Add(-outboxRetention)); err != nil {
+					config.Log().Error("failed to clean up event outbox", "error", err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (t *DatabaseTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+	return nil
+}
+
+func (t *DatabaseTransport) poll(lastId uint64, handler func(data []byte)) uint64 {
+	for {
+		entries, err := t.repository.GetAfter(lastId, outboxBatchSize)
+		if err != nil {
+			config.Log().Error("failed to poll event outbox", "error", err)
+			return lastId
+		}
+		for _, e := range entries {
+			if e.Instance != t.instanceId {
+				handler(e.Payload)
+			}
+			lastId = e.ID
+		}
+		if len(entries) < outboxBatchSize {
+			return lastId
+		}
+	}
+}
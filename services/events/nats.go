@@ -0,0 +1,45 @@
+package events
+
+import (
+	"github.com/nats-io/nats.go"
+)
+
+// NatsTransport exchanges messages via a nats server
+type NatsTransport struct {
+	conn         *nats.Conn
+	subject      string
+	subscription *nats.Subscription
+}
+
+func NewNatsTransport(url, subject string) (*NatsTransport, error) {
+	conn, err := nats.Connect(url, nats.Name("wakapi"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &NatsTransport{conn: conn, subject: subject}, nil
+}
+
+func (t *NatsTransport) Publish(data []byte) error {
+	return t.conn.Publish(t.subject, data)
+}
+
+func (t *NatsTransport) Listen(handler func(data []byte)) error {
+	sub, err := t.conn.Subscribe(t.subject, func(msg *nats.Msg) {
+		handler(msg.Data)
+	})
+	if err != nil {
+		return err
+	}
+	t.subscription = sub
+	return nil
+}
+
+func (t *NatsTransport) Close() error {
+	if t.subscription != nil {
+		if err := t.subscription.Unsubscribe(); err != nil {
+			return err
+		}
+	}
+	t.conn.Close()
+	return nil
+}
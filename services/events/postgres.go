@@ -0,0 +1,119 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/muety/wakapi/config"
+)
+
+const (
+	postgresChannel       = "wakapi_events"
+	postgresMaxPayload    = 7999 // notify payloads must be shorter than 8000 bytes by default
+	postgresRetryInterval = 5 * time.Second
+)
+
+// PostgresTransport exchanges messages using postgres' LISTEN / NOTIFY. Listening occupies one connection of the pool permanently.
+type PostgresTransport struct {
+	db     *sql.DB
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewPostgresTransport(db *sql.DB) *PostgresTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PostgresTransport{
+		db:     db,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (t *PostgresTransport) Publish(data []byte) error {
+	payload := base64.StdEncoding.EncodeToString(data) // payloads must be text
+	if len(payload) > postgresMaxPayload {
+		return fmt.Errorf("event payload too large (%d bytes)", len(payload))
+	}
+	_, err := t.db.ExecContext(t.ctx, "SELECT pg_notify($1, $2)", postgresChannel, payload)
+	return err
+}
+
+func (t *PostgresTransport) Listen(handler func(data []byte)) error {
+	conn, err := t.db.Conn(t.ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(t.ctx, "LISTEN "+postgresChannel); err != nil {
+		conn.Close()
+		return err
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		for {
+			err := t.listen(conn, handler)
+			conn.Close()
+			if errors.Is(t.ctx.Err(), context.Canceled) {
+				return
+			}
+			config.Log().Error("lost connection while listening for events, reconnecting", "error", err)
+
+			// reconnect
+			for {
+				select {
+				case <-t.ctx.Done():
+					return
+				case <-time.After(postgresRetryInterval):
+				}
+				if conn, err = t.db.Conn(t.ctx); err == nil {
+					if _, err = conn.ExecContext(t.ctx, "LISTEN "+postgresChannel); err == nil {
+						break
+					}
+					conn.Close()
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (t *PostgresTransport) Close() error {
+	t.cancel()
+	t.wg.Wait()
+	return nil
+}
+
+func (t *PostgresTransport) listen(conn *sql.Conn, handler func(data []byte)) error {
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("postgres event transport requires pgx driver")
+		}
+		return t.waitForNotifications(stdConn.Conn(), handler)
+	})
+}
+
+func (t *PostgresTransport) waitForNotifications(conn *pgx.Conn, handler func(data []byte)) error {
+	for {
+		notification, err := conn.WaitForNotification(t.ctx)
+		if err != nil {
+			return err
+		}
+		data, err := base64.StdEncoding.DecodeString(notification.Payload)
+		if err != nil {
+			config.Log().Error("failed to decode event notification", "error", err)
+			continue
+		}
+		handler(data)
+	}
+}
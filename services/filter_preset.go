@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/leandro-lugaresi/hub"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/repositories"
+)
+
+var ErrFilterPresetNotFound = errors.New("filter preset not found")
+
+type FilterPresetService struct {
+	config     *config.Config
+	cache      cache.Cache
+	eventBus   *hub.Hub
+	repository repositories.IFilterPresetRepository
+}
+
+func NewFilterPresetService(filterPresetRepository repositories.IFilterPresetRepository) *FilterPresetService {
+	srv := &FilterPresetService{
+		config:     config.Get(),
+		eventBus:   config.EventBus(),
+		repository: filterPresetRepository,
+		cache:      cache.New("filter_presets", 24*time.Hour, 24*time.Hour),
+	}
+
+	sub1 := srv.eventBus.Subscribe(0, config.EventFilterPresetsChanged)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			// presets were modified on another instance
+			if config.IsRemoteEvent(m) {
+				srv.cache.Delete(m.Fields[config.FieldUserId].(string))
+			}
+		}
+	}(&sub1)
+
+	return srv
+}
+
+func (srv *FilterPresetService) GetById(id uint) (*models.FilterPreset, error) {
+	return srv.repository.GetById(id)
+}
+
+func (srv *FilterPresetService) GetByUser(userId string) ([]*models.FilterPreset, error) {
+	if presets, found := srv.cache.Get(userId); found {
+		return presets.([]*models.FilterPreset), nil
+	}
+
+	presets, err := srv.repository.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Set(userId, presets, cache.DefaultExpiration)
+	return presets, nil
+}
+
+// GetByUserAndSlug returns the user's preset with the given slug (e.g. "client-a") or ErrFilterPresetNotFound
+func (srv *FilterPresetService) GetByUserAndSlug(userId, slug string) (*models.FilterPreset, error) {
+	presets, err := srv.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range presets {
+		if p.Slug == slug {
+			return p, nil
+		}
+	}
+	return nil, ErrFilterPresetNotFound
+}
+
+// Create saves the given filters and (optional) interval as a new preset, identified by a slug derived from its name
+func (srv *FilterPresetService) Create(user *models.User, name, interval string, filters *models.Filters) (*models.FilterPreset, error) {
+	if filters == nil || filters.IsEmpty() {
+		return nil, errors.New("no filters given")
+	}
+
+	presets, err := srv.GetByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(presets) >= models.MaxFilterPresetsPerUser {
+		return nil, errors.New("too many filter presets")
+	}
+
+	preset := &models.FilterPreset{
+		UserID:   user.ID,
+		Name:     name,
+		Slug:     models.NewFilterPresetSlug(name),
+		Interval: interval,
+		Filters:  filters.Query().Encode(),
+	}
+	if !preset.IsValid() {
+		return nil, errors.New("invalid filter preset")
+	}
+	for _, p := range presets {
+		if p.Slug == preset.Slug {
+			return nil, errors.New("a filter preset with that name already exists")
+		}
+	}
+
+	result, err := srv.repository.Insert(preset)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.cache.Delete(user.ID)
+	srv.notifyUpdate(user.ID)
+	return result, nil
+}
+
+func (srv *FilterPresetService) Delete(preset *models.FilterPreset) error {
+	if preset.UserID == "" {
+		return errors.New("no user id specified")
+	}
+	err := srv.repository.Delete(preset.ID)
+	srv.cache.Delete(preset.UserID)
+	srv.notifyUpdate(preset.UserID)
+	return err
+}
+
+func (srv *FilterPresetService) notifyUpdate(userId string) {
+	srv.eventBus.Publish(hub.Message{
+		Name:   config.EventFilterPresetsChanged,
+		Fields: map[string]interface{}{config.FieldUserId: userId},
+	})
+}
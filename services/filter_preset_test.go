@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type FilterPresetServiceTestSuite struct {
+	suite.Suite
+	TestUser               *models.User
+	FilterPresetRepository *mocks.FilterPresetRepositoryMock
+}
+
+func (suite *FilterPresetServiceTestSuite) SetupSuite() {
+	config.Set(config.Empty())
+	suite.TestUser = &models.User{ID: TestUserId}
+}
+
+func (suite *FilterPresetServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.FilterPresetRepository = new(mocks.FilterPresetRepositoryMock)
+}
+
+func TestFilterPresetServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(FilterPresetServiceTestSuite))
+}
+
+func (suite *FilterPresetServiceTestSuite) TestFilterPresetService_Create() {
+	sut := NewFilterPresetService(suite.FilterPresetRepository)
+
+	existing := []*models.FilterPreset{{ID: 1, UserID: TestUserId, Name: "OSS only", Slug: "oss-only", Filters: "label=oss"}}
+	suite.FilterPresetRepository.On("GetByUser", TestUserId).Return(existing, nil)
+	suite.FilterPresetRepository.On("Insert", mock.MatchedBy(func(p *models.FilterPreset) bool {
+		return p.Name == "Client A" && p.Slug == "client-a" && p.UserID == TestUserId && p.Interval == "week" && p.Filters == "language=Go&project=foo&project=bar"
+	})).Return(&models.FilterPreset{ID: 2, Slug: "client-a"}, nil)
+
+	result, err := sut.Create(suite.TestUser, "Client A", "week", models.NewFilterWithMultiple(models.SummaryProject, []string{"foo", "bar"}).With(models.SummaryLanguage, "Go"))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "client-a", result.Slug)
+
+	_, err = sut.Create(suite.TestUser, "OSS Only!", "", models.NewFiltersWith(models.SummaryLabel, "oss"))
+	assert.NotNil(suite.T(), err) // duplicate slug
+
+	_, err = sut.Create(suite.TestUser, "Nothing", "", &models.Filters{})
+	assert.NotNil(suite.T(), err)
+
+	_, err = sut.Create(suite.TestUser, "Invalid interval", "last_3_days", models.NewFiltersWith(models.SummaryLabel, "oss"))
+	assert.NotNil(suite.T(), err)
+
+	suite.FilterPresetRepository.AssertNumberOfCalls(suite.T(), "Insert", 1)
+}
+
+func (suite *FilterPresetServiceTestSuite) TestFilterPresetService_GetByUserAndSlug() {
+	sut := NewFilterPresetService(suite.FilterPresetRepository)
+
+	existing := []*models.FilterPreset{{ID: 1, UserID: TestUserId, Name: "OSS only", Slug: "oss-only", Filters: "label=oss"}}
+	suite.FilterPresetRepository.On("GetByUser", TestUserId).Return(existing, nil).Once()
+
+	result, err := sut.GetByUserAndSlug(TestUserId, "oss-only")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), uint(1), result.ID)
+
+	_, err = sut.GetByUserAndSlug(TestUserId, "client-a")
+	assert.ErrorIs(suite.T(), err, ErrFilterPresetNotFound)
+
+	suite.FilterPresetRepository.AssertNumberOfCalls(suite.T(), "GetByUser", 1) // cached
+}
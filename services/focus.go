@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/utils"
+)
+
+type FocusService struct {
+	config          *config.Config
+	cache           cache.Cache
+	durationService IDurationService
+}
+
+func NewFocusService(durationService IDurationService) *FocusService {
+	return &FocusService{
+		config:          config.Get(),
+		cache:           cache.New("focus", 1*time.Hour, 1*time.Hour),
+		durationService: durationService,
+	}
+}
+
+// GetStats derives focus sessions, context switches and fragmentation metrics per day from the user's durations within the given range
+func (s *FocusService) GetStats(ctx context.Context, user *models.User, params *models.FocusParams, skipCache bool) (*models.FocusStats, error) {
+	from, to := params.From.In(user.TZ()), params.To.In(user.TZ())
+	if !from.Before(to) {
+		return nil, errors.New("invalid time range")
+	}
+	if to.Sub(from) > models.MaxFocusRangeDays*24*time.Hour {
+		return nil, errors.New("requested time range too broad")
+	}
+
+	minSessionLength, breakTolerance := user.FocusSessionLength(), user.HeartbeatsTimeout()
+
+	// user's preferences are part of the key, so no need to invalidate when they're changed
+	cacheKey := fmt.Sprintf("%s_%s_%d_%d", user.ID, params.Hash(), int(minSessionLength.Seconds()), int(breakTolerance.Seconds()))
+	if result, found := s.cache.Get(cacheKey); found && !skipCache {
+		return result.(*models.FocusStats), nil
+	}
+
+	durations, err := s.durationService.Get(ctx, from, to, user, params.Filters, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	intervals := utils.SplitRangeByDays(from, to)
+	dailyDurations := make([]models.Durations, len(intervals))
+	for _, d := range durations {
+		t := d.Time.T().In(user.TZ())
+		for i, interval := range intervals {
+			if !t.Before(interval[0]) && t.Before(interval[1]) {
+				dailyDurations[i] = append(dailyDurations[i], d)
+				break
+			}
+		}
+	}
+
+	days := make([]*models.FocusDay, len(intervals))
+	for i, interval := range intervals {
+		days[i] = models.NewFocusDay(interval[0].Format(time.DateOnly), dailyDurations[i], minSessionLength, breakTolerance)
+	}
+
+	stats := &models.FocusStats{
+		From:             from,
+		To:               to,
+		MinSessionLength: minSessionLength.Seconds(),
+		Total:            models.NewFocusTotal(days),
+		Days:             days,
+	}
+
+	s.cache.SetDefault(cacheKey, stats)
+	return stats, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type FocusServiceTestSuite struct {
+	suite.Suite
+	TestUser        *models.User
+	TestStartTime   time.Time
+	DurationService *mocks.DurationServiceMock
+}
+
+func (suite *FocusServiceTestSuite) SetupSuite() {
+	config.Set(config.Empty())
+	suite.TestUser = &models.User{ID: TestUserId, Location: "UTC", HeartbeatsTimeoutSec: 300, FocusSessionLengthSec: 1800}
+	suite.TestStartTime = time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+}
+
+func (suite *FocusServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.DurationService = new(mocks.DurationServiceMock)
+}
+
+func TestFocusServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(FocusServiceTestSuite))
+}
+
+func (suite *FocusServiceTestSuite) TestFocusService_GetStats() {
+	sut := NewFocusService(suite.DurationService)
+
+	from, to := suite.TestStartTime, suite.TestStartTime.AddDate(0, 0, 3)
+	day1, day3 := from.Add(9*time.Hour), from.AddDate(0, 0, 2).Add(14*time.Hour)
+
+	durations := models.Durations{
+		{Time: models.CustomTime(day1), Duration: 45 * time.Minute, Project: "wakapi"},
+		{Time: models.CustomTime(day1.Add(45 * time.Minute)), Duration: 15 * time.Minute, Project: "anchr"},
+		{Time: models.CustomTime(day3), Duration: 10 * time.Minute, Project: "wakapi"},
+		{Time: models.CustomTime(day3.Add(30 * time.Minute)), Duration: 10 * time.Minute, Project: "wakapi"},
+	}
+	suite.DurationService.On("Get", from, to, suite.TestUser, (*models.Filters)(nil), (*time.Duration)(nil), false).Return(durations, nil)
+
+	stats, err := sut.GetStats(context.Background(), suite.TestUser, &models.FocusParams{From: from, To: to}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 1800.0, stats.MinSessionLength)
+	assert.Len(suite.T(), stats.Days, 3)
+
+	assert.Equal(suite.T(), "2023-01-02", stats.Days[0].Date)
+	assert.Equal(suite.T(), 3600.0, stats.Days[0].FocusTime)
+	assert.Equal(suite.T(), 1, stats.Days[0].NumSessions)
+	assert.Equal(suite.T(), 1, stats.Days[0].ContextSwitches)
+
+	assert.Zero(suite.T(), stats.Days[1].Total)
+
+	assert.Equal(suite.T(), 1200.0, stats.Days[2].Total)
+	assert.Zero(suite.T(), stats.Days[2].FocusTime)
+	assert.Equal(suite.T(), 2, stats.Days[2].NumBlocks)
+	assert.Equal(suite.T(), 0, stats.Days[2].Score)
+
+	assert.Equal(suite.T(), 4800.0, stats.Total.Total)
+	assert.Equal(suite.T(), 3600.0, stats.Total.LongestSession)
+	assert.Equal(suite.T(), 1, stats.Total.NumSessions)
+}
+
+func (suite *FocusServiceTestSuite) TestFocusService_GetStats_RangeTooBroad() {
+	sut := NewFocusService(suite.DurationService)
+
+	_, err := sut.GetStats(context.Background(), suite.TestUser, &models.FocusParams{From: suite.TestStartTime, To: suite.TestStartTime.AddDate(1, 0, 0)}, true)
+
+	assert.Error(suite.T(), err)
+	suite.DurationService.AssertNotCalled(suite.T(), "Get")
+}
@@ -1,35 +1,41 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/duke-git/lancet/v2/slice"
 	"github.com/leandro-lugaresi/hub"
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/repositories"
-	"github.com/patrickmn/go-cache"
 
 	"github.com/muety/wakapi/models"
 )
 
 type HeartbeatService struct {
 	config              *config.Config
-	cache               *cache.Cache
+	cache               cache.Cache
 	eventBus            *hub.Hub
 	repository          repositories.IHeartbeatRepository
+	archiveRepository   repositories.IHeartbeatArchiveRepository
 	languageMappingSrvc ILanguageMappingService
 	entityCacheLock     *sync.RWMutex
 }
 
-func NewHeartbeatService(heartbeatRepo repositories.IHeartbeatRepository, languageMappingService ILanguageMappingService) *HeartbeatService {
+func NewHeartbeatService(heartbeatRepo repositories.IHeartbeatRepository, heartbeatArchiveRepo repositories.IHeartbeatArchiveRepository, languageMappingService ILanguageMappingService) *HeartbeatService {
 	srv := &HeartbeatService{
 		config:              config.Get(),
-		cache:               cache.New(24*time.Hour, 24*time.Hour),
+		cache:               cache.New("heartbeats", 24*time.Hour, 24*time.Hour),
 		eventBus:            config.EventBus(),
 		repository:          heartbeatRepo,
+		archiveRepository:   heartbeatArchiveRepo,
 		languageMappingSrvc: languageMappingService,
 		entityCacheLock:     &sync.RWMutex{},
 	}
@@ -47,6 +53,24 @@ func NewHeartbeatService(heartbeatRepo repositories.IHeartbeatRepository, langua
 		}
 	}(&sub1)
 
+	// heartbeats created on other instances
+	sub2 := srv.eventBus.Subscribe(0, config.EventHeartbeatsChanged)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			// only the latest heartbeat is relayed, so entity sets might miss some values until they expire
+			heartbeat, ok := m.Fields[config.FieldPayload].(*models.Heartbeat)
+			if !ok {
+				continue
+			}
+			n, _ := m.Fields[config.FieldCount].(int)
+			count := int64(n)
+			srv.cache.IncrementInt64(srv.countByUserCacheKey(heartbeat.UserID), count)
+			srv.cache.IncrementInt64(srv.countTotalCacheKey(), count)
+			srv.checkInvalidateRangeCache(heartbeat)
+			srv.updateEntityUserCacheByHeartbeat(heartbeat)
+		}
+	}(&sub2)
+
 	return srv
 }
 
@@ -99,10 +123,16 @@ func (srv *HeartbeatService) CountByUser(user *models.User) (int64, error) {
 		return result.(int64), nil
 	}
 	count, err := srv.repository.CountByUser(user)
-	if err == nil {
-		srv.cache.Set(key, count, srv.countCacheTtl())
+	if err != nil {
+		return 0, err
 	}
-	return count, err
+	archivedCount, err := srv.archiveRepository.CountByUser(user)
+	if err != nil {
+		return 0, err
+	}
+	count += archivedCount
+	srv.cache.Set(key, count, srv.countCacheTtl())
+	return count, nil
 }
 
 func (srv *HeartbeatService) CountByUsers(users []*models.User) ([]*models.CountByUser, error) {
@@ -124,6 +154,18 @@ func (srv *HeartbeatService) CountByUsers(users []*models.User) ([]*models.Count
 		return nil, err
 	}
 
+	archivedCounts, err := srv.archiveRepository.CountByUsers(missingUsers)
+	if err != nil {
+		return nil, err
+	}
+	for _, ac := range archivedCounts {
+		if uc, ok := slice.FindBy(counts, func(_ int, c *models.CountByUser) bool { return c.User == ac.User }); ok {
+			uc.Count += ac.Count
+		} else {
+			counts = append(counts, ac)
+		}
+	}
+
 	for _, uc := range counts {
 		key := srv.countByUserCacheKey(uc.User)
 		srv.cache.Set(key, uc.Count, srv.countCacheTtl())
@@ -134,32 +176,66 @@ func (srv *HeartbeatService) CountByUsers(users []*models.User) ([]*models.Count
 }
 
 func (srv *HeartbeatService) GetAllWithin(from, to time.Time, user *models.User) ([]*models.Heartbeat, error) {
-	heartbeats, err := srv.repository.GetWithin(from, to, user)
+	archivedUntil, err := srv.archiveRepository.GetArchivedUntil(user)
+	if err != nil {
+		return nil, err
+	}
+	if !from.Before(archivedUntil) {
+		heartbeats, err := srv.repository.GetWithin(from, to, user)
+		if err != nil {
+			return nil, err
+		}
+		return srv.augmented(heartbeats, user.ID)
+	}
+
+	c, err := srv.StreamAllWithinRaw(context.Background(), from, to, user)
 	if err != nil {
 		return nil, err
 	}
+	heartbeats := make([]*models.Heartbeat, 0)
+	for h := range c {
+		heartbeats = append(heartbeats, h)
+	}
 	return srv.augmented(heartbeats, user.ID)
 }
 
-func (srv *HeartbeatService) StreamAllWithin(from, to time.Time, user *models.User) (chan *models.Heartbeat, error) {
+func (srv *HeartbeatService) StreamAllWithin(ctx context.Context, from, to time.Time, user *models.User) (chan *models.Heartbeat, error) {
 	languageMapping, err := srv.languageMappingSrvc.ResolveByUser(user.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	c, err := srv.repository.StreamWithin(from, to, user)
+	c, err := srv.StreamAllWithinRaw(ctx, from, to, user)
 	if err != nil {
 		return nil, err
 	}
 	return srv.augmentedAsync(c, languageMapping)
 }
 
-func (srv *HeartbeatService) StreamAllWithinRaw(from, to time.Time, user *models.User) (chan *models.Heartbeat, error) {
-	return srv.repository.StreamWithin(from, to, user) // no augmentation
+// StreamAllWithinRaw streams all of the user's heartbeats within the given interval, including archived ones, ordered by time
+func (srv *HeartbeatService) StreamAllWithinRaw(ctx context.Context, from, to time.Time, user *models.User) (chan *models.Heartbeat, error) {
+	archivedUntil, err := srv.archiveRepository.GetArchivedUntil(user)
+	if err != nil {
+		return nil, err
+	}
+	if !from.Before(archivedUntil) {
+		return srv.repository.StreamWithin(ctx, from, to, user) // no augmentation
+	}
+
+	// fetch archives before starting to stream, see augmentedAsync
+	archives, err := srv.archiveRepository.GetByUserWithin(user, from, to)
+	if err != nil {
+		return nil, err
+	}
+	live, err := srv.repository.StreamWithin(ctx, from, to, user)
+	if err != nil {
+		return nil, err
+	}
+	return srv.mergeArchived(ctx, archives, live, from, to, user), nil
 }
 
 func (srv *HeartbeatService) GetAllWithinByFilters(from, to time.Time, user *models.User, filters *models.Filters) ([]*models.Heartbeat, error) {
-	heartbeats, err := srv.repository.GetAllWithinByFilters(from, to, user, srv.filtersToColumnMap(filters))
+	heartbeats, err := srv.repository.GetAllWithinByFilters(from, to, user, srv.filtersToColumnFilters(filters))
 	if err != nil {
 		return nil, err
 	}
@@ -172,7 +248,7 @@ func (srv *HeartbeatService) StreamAllWithinByFilters(from, to time.Time, user *
 		return nil, err
 	}
 
-	c, err := srv.repository.StreamWithinByFilters(from, to, user, srv.filtersToColumnMap(filters))
+	c, err := srv.repository.StreamWithinByFilters(from, to, user, srv.filtersToColumnFilters(filters))
 	if err != nil {
 		return nil, err
 	}
@@ -188,11 +264,32 @@ func (srv *HeartbeatService) GetLatestByOriginAndUser(origin string, user *model
 }
 
 func (srv *HeartbeatService) GetLatestByFilters(user *models.User, filters *models.Filters) (*models.Heartbeat, error) {
-	return srv.repository.GetLatestByFilters(user, srv.filtersToColumnMap(filters))
+	return srv.repository.GetLatestByFilters(user, srv.filtersToColumnFilters(filters))
 }
 
 func (srv *HeartbeatService) GetFirstAll() ([]*models.TimeByUser, error) {
-	return srv.repository.GetFirstAll()
+	result, err := srv.repository.GetFirstAll()
+	if err != nil {
+		return nil, err
+	}
+	archived, err := srv.archiveRepository.GetFirstAll()
+	if err != nil {
+		return nil, err
+	}
+
+	// archived heartbeats are always older than non-archived ones
+	firstByUser := make(map[string]*models.TimeByUser, len(result))
+	for _, e := range result {
+		firstByUser[e.User] = e
+	}
+	for _, e := range archived {
+		if existing, ok := firstByUser[e.User]; ok {
+			existing.Time = e.Time
+		} else {
+			result = append(result, e)
+		}
+	}
+	return result, nil
 }
 
 func (srv *HeartbeatService) GetLastAll() ([]*models.TimeByUser, error) {
@@ -205,7 +302,7 @@ func (srv *HeartbeatService) GetFirstByUser(user *models.User) (time.Time, error
 		return result.(time.Time), nil
 	}
 
-	result, err := srv.repository.GetRangeByUser(user)
+	result, err := srv.GetRangeByUser(user)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -220,7 +317,7 @@ func (srv *HeartbeatService) GetLastByUser(user *models.User) (time.Time, error)
 		return result.(time.Time), nil
 	}
 
-	result, err := srv.repository.GetRangeByUser(user)
+	result, err := srv.GetRangeByUser(user)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -230,7 +327,20 @@ func (srv *HeartbeatService) GetLastByUser(user *models.User) (time.Time, error)
 }
 
 func (srv *HeartbeatService) GetRangeByUser(user *models.User) (*models.RangeByUser, error) {
-	return srv.repository.GetRangeByUser(user)
+	result, err := srv.repository.GetRangeByUser(user)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := srv.archiveRepository.GetRangeByUser(user)
+	if err != nil || archived == nil {
+		return result, err
+	}
+
+	if result == nil || !result.First.Valid() {
+		return archived, nil
+	}
+	result.First = archived.First
+	return result, nil
 }
 
 func (srv *HeartbeatService) GetEntitySetByUser(entityType uint8, userId string) ([]string, error) {
@@ -246,6 +356,12 @@ func (srv *HeartbeatService) GetEntitySetByUser(entityType uint8, userId string)
 		return nil, err
 	}
 
+	archived, err := srv.archiveRepository.GetEntitySetByUser(entityType, userId)
+	if err != nil {
+		return nil, err
+	}
+	results = slice.Union(results, archived)
+
 	filtered := make([]string, 0, len(results))
 	for _, r := range results {
 		if strings.TrimSpace(r) != "" {
@@ -259,29 +375,110 @@ func (srv *HeartbeatService) GetEntitySetByUser(entityType uint8, userId string)
 
 func (srv *HeartbeatService) DeleteBefore(t time.Time) error {
 	go srv.cache.Flush()
+	if err := srv.archiveRepository.DeleteBefore(t); err != nil {
+		return err
+	}
 	return srv.repository.DeleteBefore(t)
 }
 
 func (srv *HeartbeatService) DeleteByUser(user *models.User) error {
 	go srv.cache.Flush()
+	if err := srv.archiveRepository.DeleteByUser(user); err != nil {
+		return err
+	}
 	return srv.repository.DeleteByUser(user)
 }
 
 func (srv *HeartbeatService) DeleteByUserBefore(user *models.User, t time.Time) error {
 	go srv.cache.Flush()
+	if err := srv.archiveRepository.DeleteByUserBefore(user, t); err != nil {
+		return err
+	}
 	return srv.repository.DeleteByUserBefore(user, t)
 }
 
+// ArchiveByUserBefore moves all of the user's heartbeats before the given time from the heartbeats table into compressed archives, one per month.
+// The caller is responsible for making sure that summaries and durations were generated for the archived period before.
+func (srv *HeartbeatService) ArchiveByUserBefore(user *models.User, before time.Time) (int, error) {
+	result, err := srv.repository.GetRangeByUser(user)
+	if err != nil || result == nil || !result.First.Valid() || !result.First.T().Before(before) {
+		return 0, err
+	}
+
+	var count int
+	for from := datetime.BeginOfMonth(result.First.T().In(user.TZ())); from.Before(before); from = from.AddDate(0, 1, 0) {
+		to := from.AddDate(0, 1, 0)
+		if to.After(before) {
+			to = before
+		}
+
+		archive, err := srv.archiveRepository.Archive(user, from, to)
+		if err != nil {
+			return count, err
+		}
+		if archive != nil {
+			count += archive.NumHeartbeats
+		}
+	}
+
+	if count > 0 {
+		go srv.cache.Flush()
+	}
+	return count, nil
+}
+
+// GetArchivedUntil returns the end of the latest period for which the user's heartbeats were archived or zero time, if none were archived
+func (srv *HeartbeatService) GetArchivedUntil(user *models.User) (time.Time, error) {
+	return srv.archiveRepository.GetArchivedUntil(user)
+}
+
 // GetUserAgentsByUser returns a list of all user agents that have been recorded for the given user.
 func (srv *HeartbeatService) GetUserAgentsByUser(user *models.User) ([]*models.UserAgent, error) {
 	userAgents, err := srv.repository.GetUserAgentsByUser(user)
 	if err != nil {
 		return nil, err
 	}
-	for _, ua := range userAgents {
-		ua.WithId()
+
+	archived, err := srv.getArchivedUserAgentsByUser(user)
+	if err != nil {
+		return nil, err
 	}
-	return userAgents, nil
+
+	merged := make(map[models.UserAgent]*models.UserAgent, len(userAgents)+len(archived))
+	for _, ua := range append(archived, userAgents...) {
+		key := models.UserAgent{Value: ua.Value, Os: ua.Os, Editor: ua.Editor, AIModel: ua.AIModel}
+		if existing, ok := merged[key]; ok {
+			if ua.FirstSeen.Before(existing.FirstSeen) {
+				existing.FirstSeen = ua.FirstSeen
+			}
+			if ua.LastSeen.After(existing.LastSeen) {
+				existing.LastSeen = ua.LastSeen
+			}
+			continue
+		}
+		merged[key] = &models.UserAgent{Value: ua.Value, Os: ua.Os, Editor: ua.Editor, AIModel: ua.AIModel, FirstSeen: ua.FirstSeen, LastSeen: ua.LastSeen}
+	}
+
+	results := make([]*models.UserAgent, 0, len(merged))
+	for _, ua := range merged {
+		results = append(results, ua.WithId())
+	}
+	return results, nil
+}
+
+// archives only change when heartbeats get archived, which flushes the cache anyway
+func (srv *HeartbeatService) getArchivedUserAgentsByUser(user *models.User) ([]*models.UserAgent, error) {
+	cacheKey := fmt.Sprintf("archived_user_agents_%s", user.ID)
+	if results, found := srv.cache.Get(cacheKey); found {
+		return results.([]*models.UserAgent), nil
+	}
+
+	results, err := srv.archiveRepository.GetUserAgentsByUser(user)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.SetDefault(cacheKey, results)
+	return results, nil
 }
 
 func (srv *HeartbeatService) augmented(heartbeats []*models.Heartbeat, userId string) ([]*models.Heartbeat, error) {
@@ -308,6 +505,83 @@ func (srv *HeartbeatService) augmentedAsync(in chan *models.Heartbeat, languageM
 	return out, nil
 }
 
+// mergeArchived emits heartbeats from the given archives and the given stream of non-archived heartbeats ordered by time.
+// Usually, archived heartbeats all precede non-archived ones, however, old heartbeats might still have been inserted after archiving (e.g. by a data import).
+func (srv *HeartbeatService) mergeArchived(ctx context.Context, archives []*models.HeartbeatArchive, live chan *models.Heartbeat, from, to time.Time, user *models.User) chan *models.Heartbeat {
+	out := make(chan *models.Heartbeat)
+
+	go func() {
+		defer close(out)
+		// keep consuming live heartbeats after cancellation, so that the underlying row stream can finish
+		defer func() {
+			for range live {
+			}
+		}()
+
+		send := func(h *models.Heartbeat) bool {
+			select {
+			case out <- h:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		next, ok := <-live
+		emit := func(h *models.Heartbeat) bool {
+			for ok && next.Time.T().Before(h.Time.T()) {
+				if !send(next) {
+					return false
+				}
+				next, ok = <-live
+			}
+			return send(h)
+		}
+
+		// multiple archives may exist for the same period, so heartbeats are decompressed and sorted period by period
+		for len(archives) > 0 {
+			n := 1
+			for n < len(archives) && archives[n].FromTime.T().Equal(archives[0].FromTime.T()) {
+				n++
+			}
+
+			var heartbeats []*models.Heartbeat
+			for _, a := range archives[:n] {
+				decoded, err := a.Heartbeats()
+				if err != nil {
+					config.Log().Error("failed to decompress heartbeat archive", "user", user.ID, "archive", a.ID, "error", err)
+					continue
+				}
+				heartbeats = append(heartbeats, decoded...)
+			}
+			archives = archives[n:]
+
+			sort.SliceStable(heartbeats, func(i, j int) bool {
+				return heartbeats[i].Time.T().Before(heartbeats[j].Time.T())
+			})
+
+			for _, h := range heartbeats {
+				if t := h.Time.T(); t.Before(from) || !t.Before(to) {
+					continue
+				}
+				h.UserID = user.ID
+				if !emit(h) {
+					return
+				}
+			}
+		}
+
+		for ok {
+			if !send(next) {
+				return
+			}
+			next, ok = <-live
+		}
+	}()
+
+	return out
+}
+
 func (srv *HeartbeatService) getEntityUserCacheKey(entityType uint8, userId string) string {
 	return fmt.Sprintf("entity_set_%d_%s", entityType, userId)
 }
@@ -370,15 +644,23 @@ func (srv *HeartbeatService) countCacheTtl() time.Duration {
 	return time.Duration(srv.config.App.CountCacheTTLMin) * time.Minute
 }
 
-func (srv *HeartbeatService) filtersToColumnMap(filters *models.Filters) map[string][]string {
-	columnMap := map[string][]string{}
+func (srv *HeartbeatService) filtersToColumnFilters(filters *models.Filters) models.ColumnFilters {
+	columnFilters := models.NewColumnFilters()
+
+	if filters == nil {
+		return columnFilters
+	}
+
 	for _, t := range models.NativeSummaryTypes() {
-		f := filters.ResolveType(t)
-		if len(*f) > 0 {
-			columnMap[models.GetEntityColumn(t)] = *f
+		if f := *filters.ResolveType(t); len(f) > 0 {
+			columnFilters.Included[models.GetEntityColumn(t)] = f
+		}
+		if f := filters.ResolveExcludedType(t); len(f) > 0 {
+			columnFilters.Excluded[models.GetEntityColumn(t)] = f
 		}
 	}
-	return columnMap
+
+	return columnFilters
 }
 
 func (srv *HeartbeatService) checkInvalidateRangeCache(newHeartbeat *models.Heartbeat) {
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatService_MergeArchived(t *testing.T) {
+	user := &models.User{ID: TestUserId}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	at := func(hours int) models.CustomTime {
+		return models.CustomTime(from.Add(time.Duration(hours) * time.Hour))
+	}
+
+	archive, err := models.NewHeartbeatArchive(user.ID, from, from.AddDate(0, 1, 0), []*models.Heartbeat{{Project: "archived", Time: at(1)}, {Project: "archived", Time: at(3)}})
+	require.Nil(t, err)
+
+	sut := &HeartbeatService{}
+	out := sut.mergeArchived(context.Background(), []*models.HeartbeatArchive{archive}, streamSlice([]*models.Heartbeat{{UserID: user.ID, Project: "live", Time: at(2)}, {UserID: user.ID, Project: "live", Time: at(4)}}), from, from.AddDate(0, 2, 0), user)
+
+	var results []*models.Heartbeat
+	for h := range out {
+		results = append(results, h)
+	}
+	require.Len(t, results, 4)
+	for i, h := range results {
+		assert.Equal(t, at(i+1).T(), h.Time.T())
+		assert.Equal(t, user.ID, h.UserID)
+	}
+}
+
+func TestHeartbeatService_MergeArchived_Cancelled(t *testing.T) {
+	user := &models.User{ID: TestUserId}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	at := func(hours int) models.CustomTime {
+		return models.CustomTime(from.Add(time.Duration(hours) * time.Hour))
+	}
+
+	archive, err := models.NewHeartbeatArchive(user.ID, from, from.AddDate(0, 1, 0), []*models.Heartbeat{{Time: at(1)}, {Time: at(3)}})
+	require.Nil(t, err)
+
+	live := make(chan *models.Heartbeat)
+	liveDone := make(chan bool)
+	go func() {
+		defer close(live)
+		defer close(liveDone)
+		for _, h := range []*models.Heartbeat{{Time: at(2)}, {Time: at(4)}} {
+			live <- h
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sut := &HeartbeatService{}
+	out := sut.mergeArchived(ctx, []*models.HeartbeatArchive{archive}, live, from, from.AddDate(0, 2, 0), user)
+
+	<-out // consumer stops reading after the first heartbeat
+	cancel()
+
+	select {
+	case <-liveDone:
+	case <-time.After(time.Second):
+		t.Fatal("live heartbeats producer still blocked")
+	}
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-out:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("output not closed")
+		}
+	}
+}
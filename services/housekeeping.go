@@ -1,6 +1,7 @@
 package services
 
 import (
+	"github.com/duke-git/lancet/v2/datetime"
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/muety/artifex/v2"
 	"github.com/muety/wakapi/config"
@@ -11,33 +12,48 @@ import (
 	"time"
 )
 
+const securityEventsRetentionMonths = 12
+
 type HousekeepingService struct {
-	config        *config.Config
-	userSrvc      IUserService
-	heartbeatSrvc IHeartbeatService
-	projectSrvc   IProjectService
-	summarySrvc   ISummaryService
-	baseRepo      repositories.IBaseRepository
-	queueDefault  *artifex.Dispatcher
-	queueWorkers  *artifex.Dispatcher
+	config          *config.Config
+	userSrvc        IUserService
+	heartbeatSrvc   IHeartbeatService
+	projectSrvc     IProjectService
+	summarySrvc     ISummaryService
+	sessionSrvc     ISessionService
+	securitySrvc    ISecurityEventService
+	diagnosticsSrvc IDiagnosticsService
+	leaseSrvc       ILeaseService
+	baseRepo        repositories.IBaseRepository
+	queueDefault    *artifex.Dispatcher
+	queueWorkers    *artifex.Dispatcher
 }
 
-func NewHousekeepingService(userService IUserService, heartbeatService IHeartbeatService, projectService IProjectService, summaryService ISummaryService, baseRepository repositories.IBaseRepository) *HousekeepingService {
+func NewHousekeepingService(userService IUserService, heartbeatService IHeartbeatService, projectService IProjectService, summaryService ISummaryService, sessionService ISessionService, securityEventService ISecurityEventService, diagnosticsService IDiagnosticsService, leaseService ILeaseService, baseRepository repositories.IBaseRepository) *HousekeepingService {
 	return &HousekeepingService{
-		config:        config.Get(),
-		userSrvc:      userService,
-		heartbeatSrvc: heartbeatService,
-		projectSrvc:   projectService,
-		summarySrvc:   summaryService,
-		baseRepo:      baseRepository,
-		queueDefault:  config.GetDefaultQueue(),
-		queueWorkers:  config.GetQueue(config.QueueHousekeeping),
+		config:          config.Get(),
+		userSrvc:        userService,
+		heartbeatSrvc:   heartbeatService,
+		projectSrvc:     projectService,
+		summarySrvc:     summaryService,
+		sessionSrvc:     sessionService,
+		securitySrvc:    securityEventService,
+		diagnosticsSrvc: diagnosticsService,
+		leaseSrvc:       leaseService,
+		baseRepo:        baseRepository,
+		queueDefault:    config.GetDefaultQueue(),
+		queueWorkers:    config.GetQueue(config.QueueHousekeeping),
 	}
 }
 
 func (s *HousekeepingService) Schedule() {
 	s.scheduleDataCleanups()
+	s.scheduleHeartbeatArchival()
 	s.scheduleInactiveUsersCleanup()
+	s.scheduleExpiredSessionsCleanup()
+	s.scheduleSecurityEventsCleanup()
+	s.scheduleDiagnosticsCleanup()
+	s.scheduleExpiredLeasesCleanup()
 	if s.config.App.WarmCaches {
 		s.scheduleProjectStatsCacheWarming()
 	}
@@ -67,6 +83,26 @@ func (s *HousekeepingService) CleanUserDataBefore(user *models.User, before time
 	return nil
 }
 
+// ArchiveUserHeartbeats moves the user's raw heartbeats before the given time into archives, but only for months that were already aggregated into summaries (and durations, which are always generated right before, see AggregationService)
+func (s *HousekeepingService) ArchiveUserHeartbeats(user *models.User, before time.Time) error {
+	latestSummary, err := s.summarySrvc.GetLatestBySingleUser(user.ID)
+	if err != nil {
+		return err
+	}
+	if latestSummary.Before(before) {
+		before = datetime.BeginOfMonth(latestSummary.In(user.TZ()))
+	}
+
+	n, err := s.heartbeatSrvc.ArchiveByUserBefore(user, before)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		slog.Info("archived heartbeats for user", "userID", user.ID, "before", before, "count", n)
+	}
+	return nil
+}
+
 func (s *HousekeepingService) CleanInactiveUsers(before time.Time) error {
 	slog.Info("cleaning up users inactive since", "date", before)
 	users, err := s.userSrvc.GetAll()
@@ -157,6 +193,24 @@ func (s *HousekeepingService) runCleanData() {
 	}
 }
 
+func (s *HousekeepingService) runArchiveHeartbeats() {
+	users, err := s.userSrvc.GetAll()
+	if err != nil {
+		config.Log().Error("failed to get users for heartbeat archival", "error", err)
+		return
+	}
+
+	for _, u := range users {
+		user := *u
+		before := datetime.BeginOfMonth(time.Now().In(user.TZ())).AddDate(0, -s.config.App.HeartbeatArchiveMonths, 0)
+		s.queueWorkers.Dispatch(func() {
+			if err := s.ArchiveUserHeartbeats(&user, before); err != nil {
+				config.Log().Error("failed to archive heartbeats", "userID", user.ID, "error", err)
+			}
+		})
+	}
+}
+
 func (s *HousekeepingService) runCleanInactiveUsers() {
 	s.queueWorkers.Dispatch(func() {
 		if s.config.App.MaxInactiveMonths <= 0 {
@@ -168,6 +222,42 @@ func (s *HousekeepingService) runCleanInactiveUsers() {
 	})
 }
 
+func (s *HousekeepingService) runCleanExpiredSessions() {
+	n, err := s.sessionSrvc.DeleteExpired()
+	if err != nil {
+		config.Log().Error("failed to clean up expired sessions", "error", err)
+		return
+	}
+	slog.Info("deleted expired sessions", "deletedCount", n)
+}
+
+func (s *HousekeepingService) runCleanSecurityEvents() {
+	n, err := s.securitySrvc.DeleteBefore(time.Now().AddDate(0, -securityEventsRetentionMonths, 0))
+	if err != nil {
+		config.Log().Error("failed to clean up old security events", "error", err)
+		return
+	}
+	slog.Info("deleted old security events", "deletedCount", n)
+}
+
+func (s *HousekeepingService) runCleanDiagnostics() {
+	n, err := s.diagnosticsSrvc.DeleteBefore(time.Now().AddDate(0, 0, -s.config.App.DiagnosticsRetentionDays))
+	if err != nil {
+		config.Log().Error("failed to clean up old diagnostics", "error", err)
+		return
+	}
+	slog.Info("deleted old diagnostics", "deletedCount", n)
+}
+
+func (s *HousekeepingService) runCleanExpiredLeases() {
+	n, err := s.leaseSrvc.DeleteExpired()
+	if err != nil {
+		config.Log().Error("failed to clean up expired leases", "error", err)
+		return
+	}
+	slog.Info("deleted expired leases", "deletedCount", n)
+}
+
 func (s *HousekeepingService) runVacuumOrOptimizeDatabase() {
 	s.baseRepo.VacuumOrOptimize()
 }
@@ -181,12 +271,25 @@ func (s *HousekeepingService) scheduleDataCleanups() {
 
 	slog.Info("scheduling data cleanup")
 
-	_, err := s.queueDefault.DispatchCron(s.runCleanData, s.config.App.DataCleanupTime)
+	_, err := s.queueDefault.DispatchCron(s.leaseSrvc.Exclusive("data_cleanup", s.runCleanData), s.config.App.DataCleanupTime)
 	if err != nil {
 		config.Log().Error("failed to dispatch data cleanup jobs", "error", err)
 	}
 }
 
+func (s *HousekeepingService) scheduleHeartbeatArchival() {
+	if s.config.App.HeartbeatArchiveMonths <= 0 {
+		return
+	}
+
+	slog.Info("scheduling heartbeat archival")
+
+	_, err := s.queueDefault.DispatchCron(s.leaseSrvc.Exclusive("heartbeat_archival", s.runArchiveHeartbeats), s.config.App.DataCleanupTime)
+	if err != nil {
+		config.Log().Error("failed to dispatch heartbeat archival jobs", "error", err)
+	}
+}
+
 func (s *HousekeepingService) scheduleInactiveUsersCleanup() {
 	if s.config.App.MaxInactiveMonths <= 0 {
 		return
@@ -194,12 +297,53 @@ func (s *HousekeepingService) scheduleInactiveUsersCleanup() {
 
 	slog.Info("scheduling inactive users cleanup")
 
-	_, err := s.queueDefault.DispatchCron(s.runCleanInactiveUsers, s.config.App.DataCleanupTime)
+	_, err := s.queueDefault.DispatchCron(s.leaseSrvc.Exclusive("inactive_users_cleanup", s.runCleanInactiveUsers), s.config.App.DataCleanupTime)
 	if err != nil {
 		config.Log().Error("failed to dispatch inactive users cleanup job", "error", err)
 	}
 }
 
+func (s *HousekeepingService) scheduleExpiredSessionsCleanup() {
+	slog.Info("scheduling expired sessions cleanup")
+
+	_, err := s.queueDefault.DispatchCron(s.leaseSrvc.Exclusive("sessions_cleanup", s.runCleanExpiredSessions), s.config.App.DataCleanupTime)
+	if err != nil {
+		config.Log().Error("failed to dispatch expired sessions cleanup job", "error", err)
+	}
+}
+
+func (s *HousekeepingService) scheduleSecurityEventsCleanup() {
+	slog.Info("scheduling security events cleanup")
+
+	_, err := s.queueDefault.DispatchCron(s.leaseSrvc.Exclusive("security_events_cleanup", s.runCleanSecurityEvents), s.config.App.DataCleanupTime)
+	if err != nil {
+		config.Log().Error("failed to dispatch security events cleanup job", "error", err)
+	}
+}
+
+func (s *HousekeepingService) scheduleDiagnosticsCleanup() {
+	if s.config.App.DiagnosticsRetentionDays <= 0 {
+		return
+	}
+
+	slog.Info("scheduling diagnostics cleanup")
+
+	_, err := s.queueDefault.DispatchCron(s.leaseSrvc.Exclusive("diagnostics_cleanup", s.runCleanDiagnostics), s.config.App.DataCleanupTime)
+	if err != nil {
+		config.Log().Error("failed to dispatch diagnostics cleanup job", "error", err)
+	}
+}
+
+func (s *HousekeepingService) scheduleExpiredLeasesCleanup() {
+	slog.Info("scheduling expired leases cleanup")
+
+	_, err := s.queueDefault.DispatchCron(s.leaseSrvc.Exclusive("leases_cleanup", s.runCleanExpiredLeases), s.config.App.DataCleanupTime)
+	if err != nil {
+		config.Log().Error("failed to dispatch expired leases cleanup job", "error", err)
+	}
+}
+
+// cache warming is not exclusive, because every instance has its own local cache
 func (s *HousekeepingService) scheduleProjectStatsCacheWarming() {
 	slog.Info("scheduling project stats cache pre-warming")
 
@@ -218,7 +362,7 @@ func (s *HousekeepingService) scheduleProjectStatsCacheWarming() {
 
 func (s *HousekeepingService) scheduleVacuumOrOptimizeDatabase() {
 	slog.Info("scheduling database vacuuming or optimization")
-	_, err := s.queueDefault.DispatchCron(s.runVacuumOrOptimizeDatabase, s.config.App.OptimizeDatabaseTime)
+	_, err := s.queueDefault.DispatchCron(s.leaseSrvc.Exclusive("database_optimization", s.runVacuumOrOptimizeDatabase), s.config.App.OptimizeDatabaseTime)
 	if err != nil {
 		config.Log().Error("failed to dispatch database vacuuming / optimization", "error", err)
 	}
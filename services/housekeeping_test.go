@@ -11,12 +11,16 @@ import (
 
 type HousekeepingServiceTestSuite struct {
 	suite.Suite
-	TestUsers        []*models.User
-	UserService      *mocks.UserServiceMock
-	HeartbeatService *mocks.HeartbeatServiceMock
-	ProjectService   *mocks.ProjectServiceMock
-	SummaryService   *mocks.SummaryServiceMock
-	BaseRepository   *mocks.BaseRepositoryMock
+	TestUsers          []*models.User
+	UserService        *mocks.UserServiceMock
+	HeartbeatService   *mocks.HeartbeatServiceMock
+	ProjectService     *mocks.ProjectServiceMock
+	SummaryService     *mocks.SummaryServiceMock
+	SessionService     *mocks.SessionServiceMock
+	SecurityService    *mocks.SecurityEventServiceMock
+	DiagnosticsService *mocks.DiagnosticsServiceMock
+	LeaseService       *mocks.LeaseServiceMock
+	BaseRepository     *mocks.BaseRepositoryMock
 }
 
 func (suite *HousekeepingServiceTestSuite) SetupSuite() {
@@ -32,6 +36,10 @@ func (suite *HousekeepingServiceTestSuite) BeforeTest(suiteName, testName string
 	suite.HeartbeatService = new(mocks.HeartbeatServiceMock)
 	suite.ProjectService = new(mocks.ProjectServiceMock)
 	suite.SummaryService = new(mocks.SummaryServiceMock)
+	suite.SessionService = new(mocks.SessionServiceMock)
+	suite.SecurityService = new(mocks.SecurityEventServiceMock)
+	suite.DiagnosticsService = new(mocks.DiagnosticsServiceMock)
+	suite.LeaseService = new(mocks.LeaseServiceMock)
 	suite.BaseRepository = new(mocks.BaseRepositoryMock)
 }
 
@@ -40,7 +48,7 @@ func TestHouseKeepingServiceTestSuite(t *testing.T) {
 }
 
 func (suite *HousekeepingServiceTestSuite) TestHousekeepingService_CleanInactiveUsers() {
-	sut := NewHousekeepingService(suite.UserService, suite.HeartbeatService, suite.ProjectService, suite.SummaryService, suite.BaseRepository)
+	sut := NewHousekeepingService(suite.UserService, suite.HeartbeatService, suite.ProjectService, suite.SummaryService, suite.SessionService, suite.SecurityService, suite.DiagnosticsService, suite.LeaseService, suite.BaseRepository)
 
 	suite.UserService.On("GetAll").Return(suite.TestUsers, nil)
 	suite.UserService.On("Delete", suite.TestUsers[0]).Return(nil)
@@ -52,3 +60,35 @@ func (suite *HousekeepingServiceTestSuite) TestHousekeepingService_CleanInactive
 	suite.UserService.AssertNumberOfCalls(suite.T(), "Delete", 1)
 	suite.UserService.AssertCalled(suite.T(), "Delete", suite.TestUsers[0])
 }
+
+func (suite *HousekeepingServiceTestSuite) TestHousekeepingService_ArchiveUserHeartbeats() {
+	sut := NewHousekeepingService(suite.UserService, suite.HeartbeatService, suite.ProjectService, suite.SummaryService, suite.SessionService, suite.SecurityService, suite.DiagnosticsService, suite.LeaseService, suite.BaseRepository)
+
+	user := &models.User{ID: "testuser01"}
+	before := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	latestSummary := time.Date(2024, 4, 17, 0, 0, 0, 0, time.Local)
+
+	// only archive months that were already aggregated completely
+	suite.SummaryService.On("GetLatestBySingleUser", user.ID).Return(latestSummary, nil)
+	suite.HeartbeatService.On("ArchiveByUserBefore", user, time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)).Return(42, nil)
+
+	err := sut.ArchiveUserHeartbeats(user, before)
+
+	assert.Nil(suite.T(), err)
+	suite.HeartbeatService.AssertNumberOfCalls(suite.T(), "ArchiveByUserBefore", 1)
+}
+
+func (suite *HousekeepingServiceTestSuite) TestHousekeepingService_ArchiveUserHeartbeats_UpToDate() {
+	sut := NewHousekeepingService(suite.UserService, suite.HeartbeatService, suite.ProjectService, suite.SummaryService, suite.SessionService, suite.SecurityService, suite.DiagnosticsService, suite.LeaseService, suite.BaseRepository)
+
+	user := &models.User{ID: "testuser01"}
+	before := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+
+	suite.SummaryService.On("GetLatestBySingleUser", user.ID).Return(time.Date(2024, 8, 3, 0, 0, 0, 0, time.Local), nil)
+	suite.HeartbeatService.On("ArchiveByUserBefore", user, before).Return(0, nil)
+
+	err := sut.ArchiveUserHeartbeats(user, before)
+
+	assert.Nil(suite.T(), err)
+	suite.HeartbeatService.AssertCalled(suite.T(), "ArchiveByUserBefore", user, before)
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/duke-git/lancet/v2/mathutil"
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/leandro-lugaresi/hub"
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/utils"
+)
+
+const maxInsightsDays = 5 * 366
+
+type InsightsService struct {
+	config           *config.Config
+	cache            cache.Cache
+	summaryService   ISummaryService
+	durationService  IDurationService
+	heartbeatService IHeartbeatService
+	dayOffService    IDayOffService
+}
+
+func NewInsightsService(summaryService ISummaryService, durationService IDurationService, heartbeatService IHeartbeatService, dayOffService IDayOffService) *InsightsService {
+	srv := &InsightsService{
+		config:           config.Get(),
+		cache:            cache.New("insights", 1*time.Hour, 1*time.Hour),
+		summaryService:   summaryService,
+		durationService:  durationService,
+		heartbeatService: heartbeatService,
+		dayOffService:    dayOffService,
+	}
+
+	// daily averages depend on the user's working days
+	sub1 := config.EventBus().Subscribe(0, config.EventDaysOffChanged, config.EventUserUpdate)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			userId, _ := m.Fields[config.FieldUserId].(string)
+			if user, ok := m.Fields[config.FieldPayload].(*models.User); ok {
+				userId = user.ID
+			}
+			if userId != "" {
+				srv.cache.DeleteMatching(fmt.Sprintf("%s_%s_", userId, models.InsightDailyAverage))
+			}
+		}
+	}(&sub1)
+
+	return srv
+}
+
+func (s *InsightsService) Get(ctx context.Context, user *models.User, params *models.InsightsParams, skipCache bool) (*models.Insights, error) {
+	if !slice.Contain(models.InsightTypes(), params.Type) {
+		return nil, errors.New("unsupported insight type")
+	}
+	if !params.From.Before(params.To) {
+		return nil, errors.New("invalid time range")
+	}
+
+	from, to := s.clampRange(user, params.From.In(user.TZ()), params.To.In(user.TZ()))
+	params = &models.InsightsParams{Type: params.Type, From: from, To: to}
+
+	cacheKey := fmt.Sprintf("%s_%s", user.ID, params.Hash())
+	if result, found := s.cache.Get(cacheKey); found && !skipCache {
+		return result.(*models.Insights), nil
+	}
+
+	insights := &models.Insights{Type: params.Type, From: from, To: to}
+
+	var err error
+	switch {
+	case params.Type == models.InsightHours:
+		err = s.fillHours(ctx, user, insights)
+	case params.IsEntityType():
+		err = s.fillEntities(ctx, user, insights, models.InsightEntityTypes[params.Type])
+	default:
+		err = s.fillDaily(ctx, user, insights)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.SetDefault(cacheKey, insights)
+	return insights, nil
+}
+
+func (s *InsightsService) fillDaily(ctx context.Context, user *models.User, insights *models.Insights) error {
+	intervals, summaries, err := s.getDailySummaries(ctx, user, insights.From, insights.To)
+	if err != nil {
+		return err
+	}
+	insights.Days = s.getDays(intervals, summaries)
+
+	switch insights.Type {
+	case models.InsightDays:
+	case models.InsightBestDay:
+		insights.BestDay = s.getBestDay(insights.Days)
+		insights.Days = nil
+	case models.InsightWeekdays:
+		insights.Weekdays = s.getWeekdays(insights.Days, user.StartOfWeekDay())
+		insights.Days = nil
+	case models.InsightDailyAverage:
+		dailyAverage, err := s.getDailyAverage(ctx, user, insights.From, insights.To, insights.Days)
+		if err != nil {
+			return err
+		}
+		insights.DailyAverage = dailyAverage
+		insights.Days = nil
+	}
+	return nil
+}
+
+func (s *InsightsService) fillEntities(ctx context.Context, user *models.User, insights *models.Insights, entityType uint8) error {
+	intervals, summaries, err := s.getDailySummaries(ctx, user, insights.From, insights.To)
+	if err != nil {
+		return err
+	}
+	insights.Days = s.getDays(intervals, summaries)
+
+	mappedEntities := make(map[string]*models.InsightsEntity)
+	for i, summary := range summaries {
+		if summary == nil {
+			continue
+		}
+		for _, item := range *summary.GetByType(entityType) {
+			if _, ok := mappedEntities[item.Key]; !ok {
+				mappedEntities[item.Key] = &models.InsightsEntity{Key: item.Key, Days: make([]float64, len(summaries))}
+			}
+			mappedEntities[item.Key].Days[i] += item.TotalFixed().Seconds()
+			mappedEntities[item.Key].Total += item.TotalFixed().Seconds()
+		}
+	}
+
+	insights.Entities = make([]*models.InsightsEntity, 0, len(mappedEntities))
+	for _, entity := range mappedEntities {
+		insights.Entities = append(insights.Entities, entity)
+	}
+	sort.Slice(insights.Entities, func(i, j int) bool {
+		return insights.Entities[i].Total > insights.Entities[j].Total
+	})
+	return nil
+}
+
+func (s *InsightsService) fillHours(ctx context.Context, user *models.User, insights *models.Insights) error {
+	durations, err := s.durationService.Get(ctx, insights.From, insights.To, user, nil, nil, false)
+	if err != nil {
+		return err
+	}
+
+	var totals [24]time.Duration
+	for _, d := range durations {
+		// split durations at full hours
+		for t1, end := d.Time.T().In(user.TZ()), d.TimeEnd().In(user.TZ()); t1.Before(end); {
+			t2 := t1.Truncate(time.Hour).Add(time.Hour)
+			if t2.After(end) {
+				t2 = end
+			}
+			totals[t1.Hour()] += t2.Sub(t1)
+			t1 = t2
+		}
+	}
+
+	numDays := len(utils.SplitRangeByDays(insights.From, insights.To))
+	insights.Hours = make([]*models.InsightsHour, 24)
+	for h := 0; h < 24; h++ {
+		insights.Hours[h] = &models.InsightsHour{
+			Hour:    h,
+			Total:   totals[h].Seconds(),
+			Average: mathutil.RoundToFloat(totals[h].Seconds()/float64(max(numDays, 1)), 0),
+		}
+	}
+	return nil
+}
+
+// getDailySummaries retrieves one summary per day, with aliases and project labels resolved, all at once
+func (s *InsightsService) getDailySummaries(ctx context.Context, user *models.User, from, to time.Time) ([][]time.Time, []*models.Summary, error) {
+	intervals := utils.SplitRangeByDays(from, to)
+	summaries, err := s.summaryService.Daily(ctx, from, to, user, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(summaries) != len(intervals) {
+		return nil, nil, errors.New("number of daily summaries does not match number of days")
+	}
+	return intervals, summaries, nil
+}
+
+func (s *InsightsService) getDays(intervals [][]time.Time, summaries []*models.Summary) []*models.InsightsDay {
+	days := make([]*models.InsightsDay, len(intervals))
+	for i, interval := range intervals {
+		days[i] = &models.InsightsDay{Date: interval[0].Format(time.DateOnly)}
+		if summaries[i] != nil {
+			days[i].Total = summaries[i].TotalTime().Seconds()
+		}
+	}
+	return days
+}
+
+func (s *InsightsService) getBestDay(days []*models.InsightsDay) *models.InsightsDay {
+	var best *models.InsightsDay
+	for _, day := range days {
+		if day.Total > 0 && (best == nil || day.Total > best.Total) {
+			best = day
+		}
+	}
+	return best
+}
+
+func (s *InsightsService) getWeekdays(days []*models.InsightsDay, startOfWeek time.Weekday) []*models.InsightsWeekday {
+	weekdays := make([]*models.InsightsWeekday, 7)
+	for i := range weekdays {
+		weekdays[i] = &models.InsightsWeekday{Weekday: (startOfWeek + time.Weekday(i)) % 7}
+	}
+
+	for _, day := range days {
+		date, _ := time.Parse(time.DateOnly, day.Date)
+		weekday := weekdays[(int(date.Weekday())-int(startOfWeek)+7)%7]
+		weekday.Total += day.Total
+		weekday.NumDays++
+	}
+
+	for _, weekday := range weekdays {
+		if weekday.NumDays > 0 {
+			weekday.Average = mathutil.RoundToFloat(weekday.Total/float64(weekday.NumDays), 0)
+		}
+	}
+	return weekdays
+}
+
+func (s *InsightsService) getDailyAverage(ctx context.Context, user *models.User, from, to time.Time, days []*models.InsightsDay) (*models.InsightsDailyAverage, error) {
+	calendar, err := s.dayOffService.GetCalendar(user)
+	if err != nil {
+		config.Log().Warn("failed to get work calendar for insights, considering all days working days", "userID", user.ID, "error", err)
+	}
+
+	result := &models.InsightsDailyAverage{NumDays: len(days), NumHolidays: calendar.CountHolidays(from, len(days))}
+
+	var total float64
+	for _, day := range days {
+		total += day.Total
+		if day.Total > 0 {
+			result.NumActiveDays++
+		}
+	}
+	if workingDays := result.NumDays - result.NumHolidays; workingDays > 0 {
+		result.Average = mathutil.RoundToFloat(total/float64(workingDays), 0)
+	}
+	if result.NumActiveDays > 0 {
+		result.AverageActiveDays = mathutil.RoundToFloat(total/float64(result.NumActiveDays), 0)
+	}
+
+	// compare against the period of same length right before
+	previousFrom := from.Add(-to.Sub(from))
+	previous, err := s.summaryService.Aliased(ctx, previousFrom, from, user, s.summaryService.Retrieve, nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if previousWorkingDays := calendar.CountWorkingDays(previousFrom, result.NumDays); previousWorkingDays > 0 {
+		result.PreviousAverage = mathutil.RoundToFloat(previous.TotalTime().Seconds()/float64(previousWorkingDays), 0)
+	}
+	if result.PreviousAverage > 0 {
+		result.ChangePercent = mathutil.RoundToFloat((result.Average-result.PreviousAverage)/result.PreviousAverage*100, 1)
+	}
+	if math.IsNaN(result.ChangePercent) || math.IsInf(result.ChangePercent, 0) {
+		result.ChangePercent = 0
+	}
+
+	return result, nil
+}
+
+// clampRange limits the range to begin no earlier than the user's first heartbeat and to span at most maxInsightsDays, e.g. for "all time" ranges
+func (s *InsightsService) clampRange(user *models.User, from, to time.Time) (time.Time, time.Time) {
+	if first, err := s.heartbeatService.GetFirstByUser(user); err == nil && !first.IsZero() {
+		if firstDay := datetime.BeginOfDay(first.In(user.TZ())); from.Before(firstDay) && firstDay.Before(to) {
+			from = firstDay
+		}
+	}
+	if minFrom := to.AddDate(0, 0, -maxInsightsDays); from.Before(minFrom) {
+		from = minFrom
+	}
+	return from, to
+}
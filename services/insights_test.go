@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type InsightsServiceTestSuite struct {
+	suite.Suite
+	TestUser         *models.User
+	TestStartTime    time.Time
+	SummaryService   *mocks.SummaryServiceMock
+	DurationService  *mocks.DurationServiceMock
+	HeartbeatService *mocks.HeartbeatServiceMock
+	DayOffService    *mocks.DayOffServiceMock
+}
+
+func (suite *InsightsServiceTestSuite) SetupSuite() {
+	config.Set(config.Empty())
+	suite.TestUser = &models.User{ID: TestUserId, Location: "UTC", StartOfWeek: int(time.Monday)}
+	suite.TestStartTime = time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC) // a monday
+}
+
+func (suite *InsightsServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.SummaryService = new(mocks.SummaryServiceMock)
+	suite.DurationService = new(mocks.DurationServiceMock)
+	suite.HeartbeatService = new(mocks.HeartbeatServiceMock)
+	suite.HeartbeatService.On("GetFirstByUser", suite.TestUser).Return(suite.TestStartTime.AddDate(-1, 0, 0), nil)
+	suite.DayOffService = new(mocks.DayOffServiceMock)
+	suite.DayOffService.On("GetCalendar", suite.TestUser).Return((*models.WorkCalendar)(nil), nil)
+}
+
+func TestInsightsServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(InsightsServiceTestSuite))
+}
+
+func (suite *InsightsServiceTestSuite) TestInsightsService_Get_Days() {
+	sut := NewInsightsService(suite.SummaryService, suite.DurationService, suite.HeartbeatService, suite.DayOffService)
+	suite.mockDailySummaries()
+
+	insights, err := sut.Get(context.Background(), suite.TestUser, &models.InsightsParams{Type: models.InsightDays, From: suite.TestStartTime, To: suite.TestStartTime.AddDate(0, 0, 14)}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), insights.Days, 14)
+	assert.Equal(suite.T(), "2023-01-02", insights.Days[0].Date)
+	assert.Equal(suite.T(), 3600.0, insights.Days[0].Total)
+	assert.Equal(suite.T(), 7200.0, insights.Days[9].Total)
+	assert.Equal(suite.T(), 0.0, insights.Days[5].Total)
+}
+
+func (suite *InsightsServiceTestSuite) TestInsightsService_Get_BestDay() {
+	sut := NewInsightsService(suite.SummaryService, suite.DurationService, suite.HeartbeatService, suite.DayOffService)
+	suite.mockDailySummaries()
+
+	insights, err := sut.Get(context.Background(), suite.TestUser, &models.InsightsParams{Type: models.InsightBestDay, From: suite.TestStartTime, To: suite.TestStartTime.AddDate(0, 0, 14)}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), insights.Days)
+	assert.Equal(suite.T(), "2023-01-11", insights.BestDay.Date)
+	assert.Equal(suite.T(), 7200.0, insights.BestDay.Total)
+}
+
+func (suite *InsightsServiceTestSuite) TestInsightsService_Get_Weekdays() {
+	sut := NewInsightsService(suite.SummaryService, suite.DurationService, suite.HeartbeatService, suite.DayOffService)
+	suite.mockDailySummaries()
+
+	insights, err := sut.Get(context.Background(), suite.TestUser, &models.InsightsParams{Type: models.InsightWeekdays, From: suite.TestStartTime, To: suite.TestStartTime.AddDate(0, 0, 14)}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), insights.Weekdays, 7)
+	assert.Equal(suite.T(), time.Monday, insights.Weekdays[0].Weekday) // user's week starts on monday
+	assert.Equal(suite.T(), 2, insights.Weekdays[0].NumDays)
+	assert.Equal(suite.T(), 7200.0, insights.Weekdays[0].Total)
+	assert.Equal(suite.T(), 3600.0, insights.Weekdays[0].Average)
+	assert.Equal(suite.T(), time.Wednesday, insights.Weekdays[2].Weekday)
+	assert.Equal(suite.T(), 7200.0, insights.Weekdays[2].Total)
+	assert.Equal(suite.T(), 3600.0, insights.Weekdays[2].Average)
+	assert.Equal(suite.T(), time.Sunday, insights.Weekdays[6].Weekday)
+	assert.Zero(suite.T(), insights.Weekdays[6].Total)
+}
+
+func (suite *InsightsServiceTestSuite) TestInsightsService_Get_DailyAverage() {
+	sut := NewInsightsService(suite.SummaryService, suite.DurationService, suite.HeartbeatService, suite.DayOffService)
+
+	from, to := suite.TestStartTime, suite.TestStartTime.AddDate(0, 0, 14)
+	previousSummary := &models.Summary{Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: "wakapi", Total: 14 * 1800}}}
+	suite.SummaryService.On("Aliased", from.AddDate(0, 0, -14), from, suite.TestUser, mock.Anything, mock.Anything, mock.Anything, false).Return(previousSummary, nil)
+	suite.mockDailySummaries()
+
+	insights, err := sut.Get(context.Background(), suite.TestUser, &models.InsightsParams{Type: models.InsightDailyAverage, From: from, To: to}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 14, insights.DailyAverage.NumDays)
+	assert.Equal(suite.T(), 3, insights.DailyAverage.NumActiveDays)
+	assert.Equal(suite.T(), 1029.0, insights.DailyAverage.Average) // 14400 s / 14 days
+	assert.Equal(suite.T(), 4800.0, insights.DailyAverage.AverageActiveDays)
+	assert.Equal(suite.T(), 1800.0, insights.DailyAverage.PreviousAverage)
+	assert.Equal(suite.T(), -42.8, insights.DailyAverage.ChangePercent)
+}
+
+func (suite *InsightsServiceTestSuite) TestInsightsService_Get_DailyAverage_WorkingDays() {
+	dayOffService := new(mocks.DayOffServiceMock)
+	calendar := models.NewWorkCalendar(&models.User{WorkingWeekdays: 0b0111110}, []*models.DayOff{{Date: "2023-01-04"}}) // monday to friday, one vacation day
+	dayOffService.On("GetCalendar", suite.TestUser).Return(calendar, nil)
+	sut := NewInsightsService(suite.SummaryService, suite.DurationService, suite.HeartbeatService, dayOffService)
+
+	from, to := suite.TestStartTime, suite.TestStartTime.AddDate(0, 0, 14)
+	previousSummary := &models.Summary{Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: "wakapi", Total: 14 * 1800}}}
+	suite.SummaryService.On("Aliased", from.AddDate(0, 0, -14), from, suite.TestUser, mock.Anything, mock.Anything, mock.Anything, false).Return(previousSummary, nil)
+	suite.mockDailySummaries()
+
+	insights, err := sut.Get(context.Background(), suite.TestUser, &models.InsightsParams{Type: models.InsightDailyAverage, From: from, To: to}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 14, insights.DailyAverage.NumDays)
+	assert.Equal(suite.T(), 5, insights.DailyAverage.NumHolidays)
+	assert.Equal(suite.T(), 1600.0, insights.DailyAverage.Average)         // 14400 s / 9 working days
+	assert.Equal(suite.T(), 2520.0, insights.DailyAverage.PreviousAverage) // 25200 s / 10 working days
+	assert.Equal(suite.T(), -36.5, insights.DailyAverage.ChangePercent)
+}
+
+func (suite *InsightsServiceTestSuite) TestInsightsService_Get_Languages() {
+	sut := NewInsightsService(suite.SummaryService, suite.DurationService, suite.HeartbeatService, suite.DayOffService)
+	suite.mockDailySummaries()
+
+	insights, err := sut.Get(context.Background(), suite.TestUser, &models.InsightsParams{Type: models.InsightLanguages, From: suite.TestStartTime, To: suite.TestStartTime.AddDate(0, 0, 14)}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), insights.Days, 14)
+	assert.Len(suite.T(), insights.Entities, 2)
+	assert.Equal(suite.T(), TestLanguageGo, insights.Entities[0].Key)
+	assert.Equal(suite.T(), 10800.0, insights.Entities[0].Total)
+	assert.Len(suite.T(), insights.Entities[0].Days, 14)
+	assert.Equal(suite.T(), 3600.0, insights.Entities[0].Days[9])
+	assert.Equal(suite.T(), TestLanguageJava, insights.Entities[1].Key)
+	assert.Equal(suite.T(), 3600.0, insights.Entities[1].Days[9])
+	assert.Zero(suite.T(), insights.Entities[1].Days[0])
+}
+
+func (suite *InsightsServiceTestSuite) TestInsightsService_Get_Hours() {
+	sut := NewInsightsService(suite.SummaryService, suite.DurationService, suite.HeartbeatService, suite.DayOffService)
+
+	from, to := suite.TestStartTime, suite.TestStartTime.AddDate(0, 0, 2)
+	durations := models.Durations{
+		{Time: models.CustomTime(from.Add(10*time.Hour + 30*time.Minute)), Duration: 1 * time.Hour},  // 10:30 - 11:30
+		{Time: models.CustomTime(from.Add(24*time.Hour + 10*time.Hour)), Duration: 30 * time.Minute}, // 10:00 - 10:30
+	}
+	suite.DurationService.On("Get", from, to, suite.TestUser, (*models.Filters)(nil), (*time.Duration)(nil), false).Return(durations, nil)
+
+	insights, err := sut.Get(context.Background(), suite.TestUser, &models.InsightsParams{Type: models.InsightHours, From: from, To: to}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), insights.Hours, 24)
+	assert.Equal(suite.T(), 3600.0, insights.Hours[10].Total)
+	assert.Equal(suite.T(), 1800.0, insights.Hours[10].Average)
+	assert.Equal(suite.T(), 1800.0, insights.Hours[11].Total)
+	assert.Zero(suite.T(), insights.Hours[12].Total)
+}
+
+func (suite *InsightsServiceTestSuite) TestInsightsService_Get_ClampsToFirstHeartbeat() {
+	heartbeatService := new(mocks.HeartbeatServiceMock)
+	heartbeatService.On("GetFirstByUser", suite.TestUser).Return(suite.TestStartTime.Add(5*time.Hour), nil)
+	sut := NewInsightsService(suite.SummaryService, suite.DurationService, heartbeatService, suite.DayOffService)
+	suite.mockDailySummaries()
+
+	insights, err := sut.Get(context.Background(), suite.TestUser, &models.InsightsParams{Type: models.InsightDays, From: time.Unix(0, 0), To: suite.TestStartTime.AddDate(0, 0, 3)}, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), suite.TestStartTime, insights.From)
+	assert.Len(suite.T(), insights.Days, 3)
+}
+
+func (suite *InsightsServiceTestSuite) TestInsightsService_Get_InvalidType() {
+	sut := NewInsightsService(suite.SummaryService, suite.DurationService, suite.HeartbeatService, suite.DayOffService)
+
+	_, err := sut.Get(context.Background(), suite.TestUser, &models.InsightsParams{Type: "foo", From: suite.TestStartTime, To: suite.TestStartTime.AddDate(0, 0, 1)}, true)
+	assert.Error(suite.T(), err)
+}
+
+// mockDailySummaries mocks one hour of go on both mondays, two hours of go and java on the second wednesday and nothing on all other days
+func (suite *InsightsServiceTestSuite) mockDailySummaries() {
+	isDay := func(dates ...string) func(time.Time) bool {
+		return func(t time.Time) bool {
+			for _, d := range dates {
+				if t.Format(time.DateOnly) == d && t.Sub(t.Truncate(24*time.Hour)) == 0 {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	mondaySummary := &models.Summary{
+		Projects:  []*models.SummaryItem{{Type: models.SummaryProject, Key: "wakapi", Total: 3600}},
+		Languages: []*models.SummaryItem{{Type: models.SummaryLanguage, Key: TestLanguageGo, Total: 3600}},
+	}
+	wednesdaySummary := &models.Summary{
+		Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: "wakapi", Total: 7200}},
+		Languages: []*models.SummaryItem{
+			{Type: models.SummaryLanguage, Key: TestLanguageGo, Total: 3600},
+			{Type: models.SummaryLanguage, Key: TestLanguageJava, Total: 3600},
+		},
+	}
+
+	call := suite.SummaryService.On("Daily", mock.Anything, mock.Anything, suite.TestUser, mock.Anything, mock.Anything)
+	call.Run(func(args mock.Arguments) {
+		intervals := utils.SplitRangeByDays(args.Get(0).(time.Time), args.Get(1).(time.Time))
+		summaries := make([]*models.Summary, len(intervals))
+		for i, interval := range intervals {
+			switch {
+			case isDay("2023-01-02", "2023-01-09")(interval[0]):
+				summaries[i] = mondaySummary
+			case isDay("2023-01-11")(interval[0]):
+				summaries[i] = wednesdaySummary
+			default:
+				summaries[i] = models.NewEmptySummary()
+			}
+		}
+		call.ReturnArguments = mock.Arguments{summaries, nil}
+	})
+}
@@ -6,9 +6,9 @@ import (
 
 	"github.com/leandro-lugaresi/hub"
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/repositories"
-	"github.com/patrickmn/go-cache"
 )
 
 /*
@@ -20,18 +20,30 @@ import (
 
 type LanguageMappingService struct {
 	config     *config.Config
-	cache      *cache.Cache
+	cache      cache.Cache
 	eventBus   *hub.Hub
 	repository repositories.ILanguageMappingRepository
 }
 
 func NewLanguageMappingService(languageMappingsRepo repositories.ILanguageMappingRepository) *LanguageMappingService {
-	return &LanguageMappingService{
+	srv := &LanguageMappingService{
 		config:     config.Get(),
 		eventBus:   config.EventBus(),
 		repository: languageMappingsRepo,
-		cache:      cache.New(24*time.Hour, 24*time.Hour),
+		cache:      cache.New("language_mappings", 24*time.Hour, 24*time.Hour),
 	}
+
+	sub1 := srv.eventBus.Subscribe(0, config.EventLanguageMappingsChanged)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			// mappings were modified on another instance
+			if config.IsRemoteEvent(m) {
+				srv.cache.Delete(m.Fields[config.FieldUserId].(string))
+			}
+		}
+	}(&sub1)
+
+	return srv
 }
 
 func (srv *LanguageMappingService) GetById(id uint) (*models.LanguageMapping, error) {
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"reflect"
@@ -12,32 +13,35 @@ import (
 	"github.com/muety/artifex/v2"
 	"github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/lib/tracing"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/repositories"
 	"github.com/muety/wakapi/utils"
-	"github.com/patrickmn/go-cache"
 )
 
 type LeaderboardService struct {
 	config         *config.Config
-	cache          *cache.Cache
+	cache          cache.Cache
 	eventBus       *hub.Hub
 	repository     repositories.ILeaderboardRepository
 	summaryService ISummaryService
 	userService    IUserService
+	leaseService   ILeaseService
 	queueDefault   *artifex.Dispatcher
 	queueWorkers   *artifex.Dispatcher
 	defaultScope   *models.IntervalKey
 }
 
-func NewLeaderboardService(leaderboardRepo repositories.ILeaderboardRepository, summaryService ISummaryService, userService IUserService) *LeaderboardService {
+func NewLeaderboardService(leaderboardRepo repositories.ILeaderboardRepository, summaryService ISummaryService, userService IUserService, leaseService ILeaseService) *LeaderboardService {
 	srv := &LeaderboardService{
 		config:         config.Get(),
-		cache:          cache.New(6*time.Hour, 6*time.Hour),
+		cache:          cache.New("leaderboard", 6*time.Hour, 6*time.Hour),
 		eventBus:       config.EventBus(),
 		repository:     leaderboardRepo,
 		summaryService: summaryService,
 		userService:    userService,
+		leaseService:   leaseService,
 		queueDefault:   config.GetDefaultQueue(),
 		queueWorkers:   config.GetQueue(config.QueueProcessing),
 	}
@@ -51,6 +55,9 @@ func NewLeaderboardService(leaderboardRepo repositories.ILeaderboardRepository,
 	onUserUpdate := srv.eventBus.Subscribe(0, config.EventUserUpdate)
 	go func(sub *hub.Subscription) {
 		for m := range sub.Receiver {
+			if config.IsRemoteEvent(m) {
+				continue
+			}
 
 			// regenerate leaderboard for updated user, if leaderboard enabled and none present, yet
 			user := m.Fields[config.FieldPayload].(*models.User)
@@ -93,13 +100,16 @@ func (srv *LeaderboardService) Schedule() {
 	}
 
 	for _, cronExp := range srv.config.App.GetLeaderboardGenerationTimeCron() {
-		if _, err := srv.queueDefault.DispatchCron(generate, cronExp); err != nil {
+		if _, err := srv.queueDefault.DispatchCron(srv.leaseService.Exclusive("leaderboard", generate), cronExp); err != nil {
 			config.Log().Error("failed to schedule leaderboard generation", "cronExpression", cronExp, "error", err)
 		}
 	}
 }
 
 func (srv *LeaderboardService) ComputeLeaderboard(users []*models.User, interval *models.IntervalKey, by []uint8) error {
+	ctx, span := tracing.StartJob("compute_leaderboard", tracing.AttrCount.Int(len(users)))
+	defer span.End()
+
 	slog.Info("generating leaderboard", "interval", (*interval)[0], "userCount", len(users), "aggregationCount", len(by))
 
 	for _, user := range users {
@@ -108,7 +118,7 @@ func (srv *LeaderboardService) ComputeLeaderboard(users []*models.User, interval
 			continue
 		}
 
-		item, err := srv.GenerateByUser(user, interval)
+		item, err := srv.GenerateByUser(ctx, user, interval)
 		if err != nil {
 			config.Log().Error("failed to regenerate general leaderboard for user", "userID", user.ID, "error", err)
 			continue
@@ -120,7 +130,7 @@ func (srv *LeaderboardService) ComputeLeaderboard(users []*models.User, interval
 		}
 
 		for _, by := range by {
-			items, err := srv.GenerateAggregatedByUser(user, interval, by)
+			items, err := srv.GenerateAggregatedByUser(ctx, user, interval, by)
 			if err != nil {
 				config.Log().Error("failed to regenerate aggregated leaderboard for user", "aggregatedBy", models.GetEntityColumn(by), "userID", user.ID, "error", err)
 				continue
@@ -225,14 +235,14 @@ func (srv *LeaderboardService) GetAggregatedByIntervalAndUser(interval *models.I
 	return items, nil
 }
 
-func (srv *LeaderboardService) GenerateByUser(user *models.User, interval *models.IntervalKey) (*models.LeaderboardItem, error) {
+func (srv *LeaderboardService) GenerateByUser(ctx context.Context, user *models.User, interval *models.IntervalKey) (*models.LeaderboardItem, error) {
 	err, from, to := helpers.ResolveIntervalTZ(interval, user.TZ(), user.StartOfWeekDay())
 	if err != nil {
 		return nil, err
 	}
 
 	timeout := models.DefaultHeartbeatsTimeout
-	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, &timeout, false)
+	summary, err := srv.summaryService.Aliased(ctx, from, to, user, srv.summaryService.Retrieve, nil, &timeout, false)
 	if err != nil {
 		return nil, err
 	}
@@ -247,13 +257,13 @@ func (srv *LeaderboardService) GenerateByUser(user *models.User, interval *model
 	}, nil
 }
 
-func (srv *LeaderboardService) GenerateAggregatedByUser(user *models.User, interval *models.IntervalKey, by uint8) ([]*models.LeaderboardItem, error) {
+func (srv *LeaderboardService) GenerateAggregatedByUser(ctx context.Context, user *models.User, interval *models.IntervalKey, by uint8) ([]*models.LeaderboardItem, error) {
 	err, from, to := helpers.ResolveIntervalTZ(interval, user.TZ(), user.StartOfWeekDay())
 	if err != nil {
 		return nil, err
 	}
 
-	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, nil, false)
+	summary, err := srv.summaryService.Aliased(ctx, from, to, user, srv.summaryService.Retrieve, nil, nil, false)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/muety/artifex/v2"
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/repositories"
+)
+
+const (
+	leaderLeaseName  = "scheduler_leader"
+	leaderLeaseTTL   = 1 * time.Minute
+	leaderRenewEvery = 20 * time.Second
+)
+
+// LeaseService coordinates multiple wakapi instances sharing the same database.
+// Exactly one instance at a time is elected leader and runs the scheduled jobs. The leader continuously renews its lease,
+// if it dies, the lease expires and another instance takes over within at most leaderLeaseTTL + leaderRenewEvery.
+type LeaseService struct {
+	config       *config.Config
+	repository   repositories.ILeaseRepository
+	owner        string
+	leaderUntil  time.Time
+	leaderMutex  sync.RWMutex
+	queueDefault *artifex.Dispatcher
+}
+
+func NewLeaseService(leaseRepository repositories.ILeaseRepository) *LeaseService {
+	return &LeaseService{
+		config:       config.Get(),
+		repository:   leaseRepository,
+		owner:        config.Get().InstanceId,
+		queueDefault: config.GetDefaultQueue(),
+	}
+}
+
+// Schedule runs the leader election once synchronously (so that it's settled before any other jobs are scheduled) and then periodically renews the lease
+func (srv *LeaseService) Schedule() {
+	slog.Info("scheduling leader election", "instance", srv.owner)
+
+	srv.elect()
+	if _, err := srv.queueDefault.DispatchEvery(srv.elect, leaderRenewEvery); err != nil {
+		config.Log().Error("failed to schedule leader election", "error", err)
+	}
+}
+
+func (srv *LeaseService) Acquire(name string, ttl time.Duration) (bool, error) {
+	if name == "" {
+		return false, errors.New("invalid lease name")
+	}
+	return srv.repository.Acquire(name, srv.owner, ttl)
+}
+
+func (srv *LeaseService) Release(name string) error {
+	return srv.repository.Release(name, srv.owner)
+}
+
+func (srv *LeaseService) DeleteExpired() (int64, error) {
+	return srv.repository.DeleteExpired()
+}
+
+// IsLeader returns whether this instance currently holds the scheduler leadership.
+// Leadership is considered lost one renewal interval before the lease actually expires, so two instances never consider themselves leader at the same time.
+func (srv *LeaseService) IsLeader() bool {
+	srv.leaderMutex.RLock()
+	defer srv.leaderMutex.RUnlock()
+	return time.Now().Before(srv.leaderUntil)
+}
+
+// Exclusive wraps the given job to only be run on the leader instance
+func (srv *LeaseService) Exclusive(job string, f func()) func() {
+	return func() {
+		if !srv.IsLeader() {
+			slog.Debug("skipping job on non-leader instance", "job", job)
+			return
+		}
+		f()
+	}
+}
+
+func (srv *LeaseService) elect() {
+	t0 := time.Now()
+	ok, err := srv.Acquire(leaderLeaseName, leaderLeaseTTL)
+	if err != nil {
+		config.Log().Error("failed to acquire leader lease", "error", err)
+	}
+
+	srv.leaderMutex.Lock()
+	defer srv.leaderMutex.Unlock()
+
+	wasLeader := time.Now().Before(srv.leaderUntil)
+	if ok {
+		srv.leaderUntil = t0.Add(leaderLeaseTTL - leaderRenewEvery)
+	} else if err == nil {
+		srv.leaderUntil = time.Time{}
+	}
+
+	if ok && !wasLeader {
+		slog.Info("instance elected as leader for scheduled jobs", "instance", srv.owner)
+	} else if !ok && wasLeader && err == nil {
+		slog.Warn("instance lost leadership for scheduled jobs", "instance", srv.owner)
+	}
+}
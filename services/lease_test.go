@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const testInstanceId = "instance1"
+
+type LeaseServiceTestSuite struct {
+	suite.Suite
+	LeaseRepository *mocks.LeaseRepositoryMock
+}
+
+func (suite *LeaseServiceTestSuite) SetupSuite() {
+	cfg := config.Empty()
+	cfg.InstanceId = testInstanceId
+	config.Set(cfg)
+}
+
+func (suite *LeaseServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.LeaseRepository = new(mocks.LeaseRepositoryMock)
+}
+
+func TestLeaseServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(LeaseServiceTestSuite))
+}
+
+func (suite *LeaseServiceTestSuite) TestLeaseService_Elect() {
+	sut := NewLeaseService(suite.LeaseRepository)
+	assert.False(suite.T(), sut.IsLeader())
+
+	suite.LeaseRepository.On("Acquire", leaderLeaseName, testInstanceId, leaderLeaseTTL).Return(true, nil).Once()
+	sut.elect()
+	assert.True(suite.T(), sut.IsLeader())
+
+	// transient database errors don't immediately cost leadership
+	suite.LeaseRepository.On("Acquire", leaderLeaseName, testInstanceId, leaderLeaseTTL).Return(false, errors.New("connection lost")).Once()
+	sut.elect()
+	assert.True(suite.T(), sut.IsLeader())
+
+	// another instance took over
+	suite.LeaseRepository.On("Acquire", leaderLeaseName, testInstanceId, leaderLeaseTTL).Return(false, nil).Once()
+	sut.elect()
+	assert.False(suite.T(), sut.IsLeader())
+}
+
+func (suite *LeaseServiceTestSuite) TestLeaseService_Exclusive() {
+	sut := NewLeaseService(suite.LeaseRepository)
+
+	var runs int
+	job := sut.Exclusive("test", func() { runs++ })
+
+	job()
+	assert.Equal(suite.T(), 0, runs)
+
+	suite.LeaseRepository.On("Acquire", leaderLeaseName, testInstanceId, leaderLeaseTTL).Return(true, nil)
+	sut.elect()
+
+	job()
+	assert.Equal(suite.T(), 1, runs)
+}
@@ -21,11 +21,13 @@ const (
 	tplNameWakatimeFailureNotification = "wakatime_connection_failure"
 	tplNameReport                      = "report"
 	tplNameSubscriptionNotification    = "subscription_expiring"
+	tplNameSecurityNotification        = "security_notification"
 	subjectPasswordReset               = "Wakapi - Password Reset"
 	subjectImportNotification          = "Wakapi - Data Import Finished"
 	subjectWakatimeFailureNotification = "Wakapi - WakaTime Connection Failure"
 	subjectReport                      = "Wakapi - Report from %s"
 	subjectSubscriptionNotification    = "Wakapi - Subscription expiring / expired"
+	subjectSecurityNotification        = "Wakapi - Security Notification"
 )
 
 type SendingService interface {
@@ -142,6 +144,23 @@ func (m *MailService) SendSubscriptionNotification(recipient *models.User, hasEx
 	return m.sendingService.Send(mail)
 }
 
+func (m *MailService) SendSecurityNotification(recipient *models.User, event *models.SecurityEvent) error {
+	tpl, err := m.getSecurityNotificationTemplate(SecurityNotificationTplData{
+		PublicUrl: m.config.Server.PublicUrl,
+		Event:     event,
+	})
+	if err != nil {
+		return err
+	}
+	mail := &models.Mail{
+		From:    models.MailAddress(m.config.Mail.Sender),
+		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
+		Subject: subjectSecurityNotification,
+	}
+	mail.WithHTML(tpl.String())
+	return m.sendingService.Send(mail)
+}
+
 func (m *MailService) getPasswordResetTemplate(data PasswordResetTplData) (*bytes.Buffer, error) {
 	var rendered bytes.Buffer
 	if err := m.templates[m.fmtName(tplNamePasswordReset)].Execute(&rendered, data); err != nil {
@@ -182,6 +201,14 @@ func (m *MailService) getSubscriptionNotificationTemplate(data SubscriptionNotif
 	return &rendered, nil
 }
 
+func (m *MailService) getSecurityNotificationTemplate(data SecurityNotificationTplData) (*bytes.Buffer, error) {
+	var rendered bytes.Buffer
+	if err := m.templates[m.fmtName(tplNameSecurityNotification)].Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
 func (m *MailService) fmtName(name string) string {
 	return fmt.Sprintf("%s.tpl.html", name)
 }
@@ -26,3 +26,8 @@ type SubscriptionNotificationTplData struct {
 	HasExpired          bool
 	DataRetentionMonths int
 }
+
+type SecurityNotificationTplData struct {
+	PublicUrl string
+	Event     *models.SecurityEvent
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/muety/artifex/v2"
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/tracing"
 	"github.com/muety/wakapi/utils"
 	"go.uber.org/atomic"
 
@@ -37,12 +38,13 @@ type MiscService struct {
 	summaryService   ISummaryService
 	keyValueService  IKeyValueService
 	mailService      IMailService
+	leaseService     ILeaseService
 	queueDefault     *artifex.Dispatcher
 	queueWorkers     *artifex.Dispatcher
 	queueMails       *artifex.Dispatcher
 }
 
-func NewMiscService(userService IUserService, heartbeatService IHeartbeatService, summaryService ISummaryService, keyValueService IKeyValueService, mailService IMailService) *MiscService {
+func NewMiscService(userService IUserService, heartbeatService IHeartbeatService, summaryService ISummaryService, keyValueService IKeyValueService, mailService IMailService, leaseService ILeaseService) *MiscService {
 	return &MiscService{
 		config:           config.Get(),
 		userService:      userService,
@@ -50,6 +52,7 @@ func NewMiscService(userService IUserService, heartbeatService IHeartbeatService
 		summaryService:   summaryService,
 		keyValueService:  keyValueService,
 		mailService:      mailService,
+		leaseService:     leaseService,
 		queueDefault:     config.GetDefaultQueue(),
 		queueWorkers:     config.GetQueue(config.QueueProcessing),
 		queueMails:       config.GetQueue(config.QueueMails),
@@ -58,25 +61,25 @@ func NewMiscService(userService IUserService, heartbeatService IHeartbeatService
 
 func (srv *MiscService) Schedule() {
 	slog.Info("scheduling total time counting")
-	if _, err := srv.queueDefault.DispatchEvery(srv.CountTotalTime, countUsersEvery); err != nil {
+	if _, err := srv.queueDefault.DispatchEvery(srv.leaseService.Exclusive("total_time_count", srv.CountTotalTime), countUsersEvery); err != nil {
 		config.Log().Error("failed to schedule user counting jobs", "error", err)
 	}
 
 	if srv.config.Subscriptions.Enabled && srv.config.Subscriptions.ExpiryNotifications && srv.config.App.DataRetentionMonths > 0 {
 		slog.Info("scheduling subscription notifications")
-		if _, err := srv.queueDefault.DispatchEvery(srv.NotifyExpiringSubscription, notifyExpiringSubscriptionsEvery); err != nil {
+		if _, err := srv.queueDefault.DispatchEvery(srv.leaseService.Exclusive("subscription_notifications", srv.NotifyExpiringSubscription), notifyExpiringSubscriptionsEvery); err != nil {
 			config.Log().Error("failed to schedule subscription notification jobs", "error", err)
 		}
 	}
 
 	// run once initially for a fresh instance
 	if !srv.existsUsersTotalTime() {
-		if err := srv.queueDefault.Dispatch(srv.CountTotalTime); err != nil {
+		if err := srv.queueDefault.Dispatch(srv.leaseService.Exclusive("total_time_count", srv.CountTotalTime)); err != nil {
 			config.Log().Error("failed to dispatch user counting jobs", "error", err)
 		}
 	}
 	if !srv.existsSubscriptionNotifications() && srv.config.Subscriptions.Enabled && srv.config.Subscriptions.ExpiryNotifications && srv.config.App.DataRetentionMonths > 0 {
-		if err := srv.queueDefault.Dispatch(srv.NotifyExpiringSubscription); err != nil {
+		if err := srv.queueDefault.Dispatch(srv.leaseService.Exclusive("subscription_notifications", srv.NotifyExpiringSubscription)); err != nil {
 			config.Log().Error("failed to schedule subscription notification jobs", "error", err)
 		}
 	}
@@ -196,7 +199,10 @@ func (srv *MiscService) NotifyExpiringSubscription() {
 }
 
 func (srv *MiscService) countUserTotalTime(userId string) time.Duration {
-	result, err := srv.summaryService.Aliased(time.Time{}, time.Now(), &models.User{ID: userId}, srv.summaryService.Retrieve, nil, nil, false)
+	ctx, span := tracing.StartJob("count_total_time", tracing.AttrUser.String(userId))
+	defer span.End()
+
+	result, err := srv.summaryService.Aliased(ctx, time.Time{}, time.Now(), &models.User{ID: userId}, srv.summaryService.Retrieve, nil, nil, false)
 	if err != nil {
 		config.Log().Error("failed to count total for user", "userID", userId, "error", err)
 		return 0
@@ -8,37 +8,49 @@ import (
 	"time"
 
 	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
-	"github.com/duke-git/lancet/v2/maputil"
 	"github.com/leandro-lugaresi/hub"
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/repositories"
 	"github.com/muety/wakapi/utils"
-	"github.com/patrickmn/go-cache"
 )
 
+const maxProjectContributors = 50
+
 type ProjectService struct {
 	config        *config.Config
-	cache         *cache.Cache
+	cache         cache.Cache
 	eventBus      *hub.Hub
 	repository    repositories.IHeartbeatRepository
+	archiveRepo   repositories.IHeartbeatArchiveRepository
+	summaryRepo   repositories.ISummaryRepository
 	aliasService  IAliasService
 	heartbeatSrvc IHeartbeatService
 }
 
-func NewProjectService(aliasService IAliasService, heartbeatRepo repositories.IHeartbeatRepository, heartbeatSrvc IHeartbeatService) *ProjectService {
+func NewProjectService(aliasService IAliasService, heartbeatRepo repositories.IHeartbeatRepository, heartbeatArchiveRepo repositories.IHeartbeatArchiveRepository, summaryRepo repositories.ISummaryRepository, heartbeatSrvc IHeartbeatService) *ProjectService {
 	srv := &ProjectService{
 		config:        config.Get(),
-		cache:         cache.New(24*time.Hour, 24*time.Hour),
+		cache:         cache.New("projects", 24*time.Hour, 24*time.Hour),
 		eventBus:      config.EventBus(),
 		repository:    heartbeatRepo,
+		archiveRepo:   heartbeatArchiveRepo,
+		summaryRepo:   summaryRepo,
 		aliasService:  aliasService,
 		heartbeatSrvc: heartbeatSrvc,
 	}
 
-	sub1 := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate)
+	sub1 := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate, config.EventHeartbeatsChanged)
 	go func(sub *hub.Subscription) {
 		for m := range sub.Receiver {
+			if m.Name == config.EventHeartbeatsChanged {
+				// only the latest of possibly many heartbeats is known
+				if userId, ok := m.Fields[config.FieldUserId].(string); ok {
+					srv.invalidateProjectStatsCache(userId)
+				}
+				continue
+			}
 			heartbeat := m.Fields[config.FieldPayload].(*models.Heartbeat)
 			srv.checkInvalidateProjectStatsCache(heartbeat)
 		}
@@ -84,6 +96,13 @@ func (srv *ProjectService) GetUserProjectStats(user *models.User, from, to time.
 		return nil, err
 	}
 
+	// stats of archived heartbeats are merged just like those of aliased projects
+	archivedResults, err := srv.archiveRepo.GetUserProjectStats(user, from, to)
+	if err != nil {
+		return nil, err
+	}
+	rawResults = append(rawResults, archivedResults...)
+
 	merged := make(map[string]*models.ProjectStats)
 	maxCounts := make(map[string]int64)
 
@@ -141,6 +160,37 @@ func (srv *ProjectService) GetUserProjectStats(user *models.User, from, to time.
 	return paginatedResults, nil
 }
 
+// GetProjectContributors returns all users who coded on a project of the same name as the given one and share their projects publicly, including the user themselves.
+// As a project's name doesn't tell whether it's actually the same project, contributors are only revealed to users who share their projects as well.
+func (srv *ProjectService) GetProjectContributors(user *models.User, project string) ([]*models.ProjectContributor, error) {
+	if !user.ShareProjects {
+		return []*models.ProjectContributor{}, nil
+	}
+
+	cacheKey := fmt.Sprintf("project_contributors_%s_%s", user.ID, project)
+	if results, found := srv.cache.Get(cacheKey); found {
+		return results.([]*models.ProjectContributor), nil
+	}
+
+	// summaries are persisted with original project names, so the user's own ones need to be resolved from the alias
+	projects := []string{project}
+	aliases, err := srv.aliasService.GetByUserAndKeyAndType(user.ID, project, models.SummaryProject)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range aliases {
+		projects = append(projects, a.Value)
+	}
+
+	results, err := srv.summaryRepo.GetProjectContributors(projects, user.ID, maxProjectContributors)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.cache.Set(cacheKey, results, 12*time.Hour)
+	return results, nil
+}
+
 func (srv *ProjectService) populateUniqueUserProjects(userId string) {
 	userProjectsCacheKey := srv.getUserProjectsCacheKey(userId)
 	if _, found := srv.cache.Get(userProjectsCacheKey); !found {
@@ -150,16 +200,8 @@ func (srv *ProjectService) populateUniqueUserProjects(userId string) {
 }
 
 func (srv *ProjectService) invalidateProjectStatsCache(userId string) {
-	var invalidated bool
-	for _, k := range maputil.Keys[string, cache.Item](srv.cache.Items()) {
-		if strings.HasPrefix(k, fmt.Sprintf("project_stats_%s_", userId)) {
-			srv.cache.Delete(k)
-			invalidated = true
-		}
-	}
-	if invalidated {
-		srv.cache.Delete(srv.getUserProjectsCacheKey(userId))
-	}
+	srv.cache.DeleteMatching(fmt.Sprintf("project_stats_%s_", userId))
+	srv.cache.Delete(srv.getUserProjectsCacheKey(userId))
 }
 
 func (srv *ProjectService) checkInvalidateProjectStatsCache(newHeartbeat *models.Heartbeat) {
@@ -6,27 +6,39 @@ import (
 
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/leandro-lugaresi/hub"
-	"github.com/patrickmn/go-cache"
 
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/repositories"
 )
 
 type ProjectLabelService struct {
 	config     *config.Config
-	cache      *cache.Cache
+	cache      cache.Cache
 	eventBus   *hub.Hub
 	repository repositories.IProjectLabelRepository
 }
 
 func NewProjectLabelService(projectLabelRepository repositories.IProjectLabelRepository) *ProjectLabelService {
-	return &ProjectLabelService{
+	srv := &ProjectLabelService{
 		config:     config.Get(),
 		eventBus:   config.EventBus(),
 		repository: projectLabelRepository,
-		cache:      cache.New(24*time.Hour, 24*time.Hour),
+		cache:      cache.New("project_labels", 24*time.Hour, 24*time.Hour),
 	}
+
+	sub1 := srv.eventBus.Subscribe(0, config.TopicProjectLabel)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			// labels were modified on another instance
+			if config.IsRemoteEvent(m) {
+				srv.cache.Delete(m.Fields[config.FieldUserId].(string))
+			}
+		}
+	}(&sub1)
+
+	return srv
 }
 
 func (srv *ProjectLabelService) GetById(id uint) (*models.ProjectLabel, error) {
@@ -25,6 +25,8 @@ type ProjectServiceTestSuite struct {
 	suite.Suite
 	TestUser            *models.User
 	HeartbeatRepository *mocks.HeartbeatRepositoryMock
+	ArchiveRepository   *mocks.HeartbeatArchiveRepositoryMock
+	SummaryRepository   *mocks.SummaryRepositoryMock
 	AliasService        *mocks.AliasServiceMock
 	HeartbeatService    *mocks.HeartbeatServiceMock
 }
@@ -35,6 +37,8 @@ func (suite *ProjectServiceTestSuite) SetupSuite() {
 
 func (suite *ProjectServiceTestSuite) BeforeTest(suiteName, testName string) {
 	suite.HeartbeatRepository = new(mocks.HeartbeatRepositoryMock)
+	suite.ArchiveRepository = new(mocks.HeartbeatArchiveRepositoryMock)
+	suite.SummaryRepository = new(mocks.SummaryRepositoryMock)
 	suite.AliasService = new(mocks.AliasServiceMock)
 	suite.HeartbeatService = new(mocks.HeartbeatServiceMock)
 }
@@ -44,7 +48,7 @@ func (suite *ProjectServiceTestSuite) createSut() (*ProjectService, *hub.Hub) {
 	eventBus := hub.New()
 	config.SetEventBus(eventBus)
 	// restore event bus
-	sut := NewProjectService(suite.AliasService, suite.HeartbeatRepository, suite.HeartbeatService)
+	sut := NewProjectService(suite.AliasService, suite.HeartbeatRepository, suite.ArchiveRepository, suite.SummaryRepository, suite.HeartbeatService)
 	config.SetEventBus(originalEventBus)
 	return sut, eventBus
 }
@@ -66,6 +70,7 @@ func (suite *ProjectServiceTestSuite) TestProjectService_GetUserProjectStats_Pag
 	}
 
 	suite.HeartbeatRepository.On("GetUserProjectStats", suite.TestUser, mock.Anything, mock.Anything).Return(rawStats, nil).Once()
+	suite.ArchiveRepository.On("GetUserProjectStats", suite.TestUser, mock.Anything, mock.Anything).Return([]*models.ProjectStats{}, nil).Once()
 	suite.AliasService.On("GetAliasOrDefault", suite.TestUser.ID, models.SummaryProject, "project1").Return("project1", nil)
 	suite.AliasService.On("GetAliasOrDefault", suite.TestUser.ID, models.SummaryProject, "project2").Return("project2", nil)
 	suite.AliasService.On("GetAliasOrDefault", suite.TestUser.ID, models.SummaryProject, "project3").Return("project3", nil)
@@ -95,6 +100,7 @@ func (suite *ProjectServiceTestSuite) TestProjectService_GetUserProjectStats_Ali
 	}
 
 	suite.HeartbeatRepository.On("GetUserProjectStats", suite.TestUser, mock.Anything, mock.Anything).Return(rawStats, nil).Once()
+	suite.ArchiveRepository.On("GetUserProjectStats", suite.TestUser, mock.Anything, mock.Anything).Return([]*models.ProjectStats{}, nil).Once()
 	suite.AliasService.On("MayInitializeUser", suite.TestUser.ID).Return()
 	suite.AliasService.On("GetAliasOrDefault", suite.TestUser.ID, models.SummaryProject, "wakapi-web").Return("wakapi", nil)
 	suite.AliasService.On("GetAliasOrDefault", suite.TestUser.ID, models.SummaryProject, "wakapi-mobile").Return("wakapi", nil)
@@ -116,6 +122,36 @@ func (suite *ProjectServiceTestSuite) TestProjectService_GetUserProjectStats_Ali
 	assert.Equal(suite.T(), "other-project", results[1].Project)
 }
 
+func (suite *ProjectServiceTestSuite) TestProjectService_GetUserProjectStats_IncludesArchived() {
+	sut, _ := suite.createSut()
+	now := time.Unix(0, MinUnixTime2)
+
+	rawStats := []*models.ProjectStats{
+		{Project: "wakapi", Count: 5, First: models.CustomTime(now.Add(1 * time.Hour)), Last: models.CustomTime(now.Add(5 * time.Hour)), TopLanguage: "Javascript"},
+	}
+	archivedStats := []*models.ProjectStats{
+		{Project: "wakapi", Count: 20, First: models.CustomTime(now.Add(-48 * time.Hour)), Last: models.CustomTime(now.Add(-24 * time.Hour)), TopLanguage: "Go"},
+		{Project: "anchr", Count: 2, First: models.CustomTime(now.Add(-72 * time.Hour)), Last: models.CustomTime(now.Add(-72 * time.Hour)), TopLanguage: "Go"},
+	}
+
+	suite.HeartbeatRepository.On("GetUserProjectStats", suite.TestUser, mock.Anything, mock.Anything).Return(rawStats, nil).Once()
+	suite.ArchiveRepository.On("GetUserProjectStats", suite.TestUser, mock.Anything, mock.Anything).Return(archivedStats, nil).Once()
+	suite.AliasService.On("GetAliasOrDefault", suite.TestUser.ID, models.SummaryProject, "wakapi").Return("wakapi", nil)
+	suite.AliasService.On("GetAliasOrDefault", suite.TestUser.ID, models.SummaryProject, "anchr").Return("anchr", nil)
+	suite.HeartbeatService.On("GetEntitySetByUser", models.SummaryProject, suite.TestUser.ID).Return([]string{}, nil)
+
+	results, err := sut.GetUserProjectStats(suite.TestUser, time.Time{}, time.Now(), "", nil, false)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), results, 2)
+	assert.Equal(suite.T(), "wakapi", results[0].Project)
+	assert.Equal(suite.T(), int64(25), results[0].Count)
+	assert.Equal(suite.T(), models.CustomTime(now.Add(-48*time.Hour)), results[0].First)
+	assert.Equal(suite.T(), models.CustomTime(now.Add(5*time.Hour)), results[0].Last)
+	assert.Equal(suite.T(), "Go", results[0].TopLanguage)
+	assert.Equal(suite.T(), "anchr", results[1].Project)
+}
+
 func (suite *ProjectServiceTestSuite) TestProjectService_GetUserProjectStats_Search() {
 	sut, _ := suite.createSut()
 	now := time.Unix(0, MinUnixTime2)
@@ -127,6 +163,7 @@ func (suite *ProjectServiceTestSuite) TestProjectService_GetUserProjectStats_Sea
 	}
 
 	suite.HeartbeatRepository.On("GetUserProjectStats", suite.TestUser, mock.Anything, mock.Anything).Return(rawStats, nil).Once()
+	suite.ArchiveRepository.On("GetUserProjectStats", suite.TestUser, mock.Anything, mock.Anything).Return([]*models.ProjectStats{}, nil).Once()
 	suite.AliasService.On("MayInitializeUser", suite.TestUser.ID).Return()
 	suite.AliasService.On("GetAliasOrDefault", suite.TestUser.ID, models.SummaryProject, "wakapi-web").Return("wakapi", nil)
 	suite.AliasService.On("GetAliasOrDefault", suite.TestUser.ID, models.SummaryProject, "wakapi-mobile").Return("wakapi", nil)
@@ -140,6 +177,38 @@ func (suite *ProjectServiceTestSuite) TestProjectService_GetUserProjectStats_Sea
 	assert.Equal(suite.T(), "wakapi", results[0].Project)
 }
 
+func (suite *ProjectServiceTestSuite) TestProjectService_GetProjectContributors() {
+	sut, _ := suite.createSut()
+
+	contributors := []*models.ProjectContributor{
+		{UserId: suite.TestUser.ID, Total: 2 * time.Hour},
+		{UserId: "otheruser", Total: 1 * time.Hour},
+	}
+
+	suite.AliasService.On("GetByUserAndKeyAndType", suite.TestUser.ID, "wakapi", models.SummaryProject).Return([]*models.Alias{
+		{Type: models.SummaryProject, UserID: suite.TestUser.ID, Key: "wakapi", Value: "wakapi-web"},
+	}, nil).Once()
+	suite.SummaryRepository.On("GetProjectContributors", []string{"wakapi", "wakapi-web"}, suite.TestUser.ID, maxProjectContributors).Return(contributors, nil).Once()
+
+	// not sharing projects
+	results, err := sut.GetProjectContributors(suite.TestUser, "wakapi")
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), results)
+
+	// sharing projects
+	user := &models.User{ID: suite.TestUser.ID, ShareProjects: true}
+	results, err = sut.GetProjectContributors(user, "wakapi")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), contributors, results)
+
+	// cached
+	results, err = sut.GetProjectContributors(user, "wakapi")
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), results, 2)
+
+	suite.SummaryRepository.AssertNumberOfCalls(suite.T(), "GetProjectContributors", 1)
+}
+
 func (suite *ProjectServiceTestSuite) TestProjectService_EventHeartbeatCreate_InvalidatesCache() {
 	sut, eventBus := suite.createSut()
 
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	svg "github.com/ajstarks/svgo/float"
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/helpers"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/models"
+)
+
+const (
+	cardWidth        = 300
+	cardWidthDonut   = 350
+	cardPadding      = 25
+	cardTitleHeight  = 45
+	cardRowHeight    = 40
+	cardLegendHeight = 25
+	cardBarHeight    = 8
+	cardDonutRadius  = 60
+	cardDonutWidth   = 20
+)
+
+// fallback colors for entities without a configured color (e.g. projects)
+var cardPalette = []string{"#2F855A", "#3182CE", "#D69E2E", "#E53E3E", "#805AD5", "#DD6B20", "#319795", "#D53F8C", "#718096", "#38A169"}
+
+type readmeCardTheme struct {
+	background string
+	border     string
+	title      string
+	text       string
+	track      string
+}
+
+var readmeCardThemes = map[string]readmeCardTheme{
+	models.ReadmeCardThemeLight: {background: "#FFFFFF", border: "#E4E2E2", title: "#2F855A", text: "#37474F", track: "#DCE3E1"},
+	models.ReadmeCardThemeDark:  {background: "#1A202C", border: "#2D3748", title: "#2F855A", text: "#D1D5DB", track: "#242B3A"},
+}
+
+type readmeCardItem struct {
+	key     string
+	color   string
+	total   time.Duration
+	percent float64
+}
+
+type ReadmeCardService struct {
+	config         *config.Config
+	cache          cache.Cache
+	summaryService ISummaryService
+}
+
+func NewReadmeCardService(summaryService ISummaryService) *ReadmeCardService {
+	return &ReadmeCardService{
+		config:         config.Get(),
+		cache:          cache.New("readme_cards", 6*time.Hour, 6*time.Hour),
+		summaryService: summaryService,
+	}
+}
+
+// GetCard renders an svg card showing a user's top languages, projects or editors within the given interval, as an alternative to github-readme-stats
+func (srv *ReadmeCardService) GetCard(ctx context.Context, user *models.User, card *models.ReadmeCard, skipCache bool) (string, error) {
+	cacheKey := fmt.Sprintf("card_%s_%d_%s_%s_%s_%d_%s", user.ID, card.Entity, (*card.Interval.Key)[0], card.Layout, card.Theme, card.Limit, card.Title)
+	if result, found := srv.cache.Get(cacheKey); found && !skipCache {
+		return result.(string), nil
+	}
+
+	summary, err := srv.summaryService.Aliased(ctx, card.Interval.Start, card.Interval.End, user, srv.summaryService.Retrieve, nil, nil, false)
+	if err != nil {
+		return "", err
+	}
+
+	items := srv.getItems(summary, card.Entity, card.Limit)
+	theme, ok := readmeCardThemes[card.Theme]
+	if !ok {
+		theme = readmeCardThemes[models.ReadmeCardThemeLight]
+	}
+
+	title := card.Title
+	if title == "" {
+		title = fmt.Sprintf("Top %s (%s)", srv.entityTitle(card.Entity), card.Interval.Key.GetHumanReadable())
+	}
+
+	buf := &bytes.Buffer{}
+	canvas := svg.New(buf)
+
+	switch card.Layout {
+	case models.ReadmeCardLayoutCompact:
+		srv.renderCompact(canvas, title, items, theme)
+	case models.ReadmeCardLayoutDonut:
+		srv.renderDonut(canvas, title, items, theme)
+	default:
+		srv.renderDefault(canvas, title, items, theme)
+	}
+
+	result := buf.String()
+	srv.cache.SetDefault(cacheKey, result)
+	return result, nil
+}
+
+func (srv *ReadmeCardService) renderDefault(canvas *svg.SVG, title string, items []*readmeCardItem, theme readmeCardTheme) {
+	h := float64(cardTitleHeight + cardPadding + int(math.Max(1, float64(len(items))))*cardRowHeight)
+	srv.startCard(canvas, cardWidth, h, title, theme)
+
+	barWidth := float64(cardWidth - 2*cardPadding)
+	for i, item := range items {
+		y := float64(cardTitleHeight + cardPadding + i*cardRowHeight)
+		canvas.Group()
+		canvas.Title(fmt.Sprintf("%s: %s", item.key, helpers.FmtWakatimeDuration(item.total)))
+		canvas.Text(cardPadding, y, item.key, "class=\"label\"")
+		canvas.Text(cardWidth-cardPadding, y, fmt.Sprintf("%.1f%%", item.percent), "class=\"label\"", "text-anchor=\"end\"")
+		canvas.Roundrect(cardPadding, y+8, barWidth, cardBarHeight, 4, 4, fmt.Sprintf("fill: %s", theme.track))
+		canvas.Roundrect(cardPadding, y+8, math.Max(barWidth*item.percent/100, cardBarHeight), cardBarHeight, 4, 4, fmt.Sprintf("fill: %s", item.color))
+		canvas.Gend()
+	}
+
+	srv.endCard(canvas, items)
+}
+
+func (srv *ReadmeCardService) renderCompact(canvas *svg.SVG, title string, items []*readmeCardItem, theme readmeCardTheme) {
+	rows := int(math.Ceil(float64(len(items)) / 2))
+	h := float64(cardTitleHeight + cardPadding + cardBarHeight + 10 + rows*cardLegendHeight)
+	srv.startCard(canvas, cardWidth, h, title, theme)
+
+	barWidth := float64(cardWidth - 2*cardPadding)
+	barY := float64(cardTitleHeight)
+
+	canvas.Def()
+	canvas.ClipPath("id=\"bar-mask\"")
+	canvas.Roundrect(cardPadding, barY, barWidth, cardBarHeight, 4, 4)
+	canvas.ClipEnd()
+	canvas.DefEnd()
+
+	canvas.Group("clip-path=\"url(#bar-mask)\"")
+	canvas.Rect(cardPadding, barY, barWidth, cardBarHeight, fmt.Sprintf("fill: %s", theme.track))
+	x := float64(cardPadding)
+	for _, item := range items {
+		w := barWidth * item.percent / 100
+		canvas.Rect(x, barY, w, cardBarHeight, fmt.Sprintf("fill: %s", item.color))
+		x += w
+	}
+	canvas.Gend()
+
+	colWidth := float64(cardWidth-2*cardPadding) / 2
+	for i, item := range items {
+		x := cardPadding + float64(i%2)*colWidth
+		y := barY + cardBarHeight + 25 + float64(i/2*cardLegendHeight)
+		srv.legendEntry(canvas, x, y, item)
+	}
+
+	srv.endCard(canvas, items)
+}
+
+func (srv *ReadmeCardService) renderDonut(canvas *svg.SVG, title string, items []*readmeCardItem, theme readmeCardTheme) {
+	h := math.Max(float64(cardTitleHeight+cardPadding+len(items)*cardLegendHeight), float64(cardTitleHeight+2*cardDonutRadius+cardPadding))
+	srv.startCard(canvas, cardWidthDonut, h, title, theme)
+
+	cx, cy := float64(cardWidthDonut-cardPadding-cardDonutRadius), float64(cardTitleHeight)+(h-cardTitleHeight)/2
+	r := float64(cardDonutRadius - cardDonutWidth/2)
+	circumference := 2 * math.Pi * r
+
+	canvas.Circle(cx, cy, r, fmt.Sprintf("fill: none; stroke: %s; stroke-width: %d", theme.track, cardDonutWidth))
+
+	var offset float64
+	for _, item := range items {
+		length := circumference * item.percent / 100
+		canvas.Group()
+		canvas.Title(fmt.Sprintf("%s: %s", item.key, helpers.FmtWakatimeDuration(item.total)))
+		// dash array trick: draw only the item's segment of the circle, starting at 12 o'clock
+		canvas.Circle(cx, cy, r,
+			fmt.Sprintf("fill: none; stroke: %s; stroke-width: %d", item.color, cardDonutWidth),
+			fmt.Sprintf("stroke-dasharray=\"%.2f %.2f\"", length, circumference-length),
+			fmt.Sprintf("stroke-dashoffset=\"%.2f\"", -offset),
+			fmt.Sprintf("transform=\"rotate(-90 %.2f %.2f)\"", cx, cy),
+		)
+		canvas.Gend()
+		offset += length
+	}
+
+	for i, item := range items {
+		srv.legendEntry(canvas, cardPadding, float64(cardTitleHeight+cardPadding/2+i*cardLegendHeight), item)
+	}
+
+	srv.endCard(canvas, items)
+}
+
+func (srv *ReadmeCardService) startCard(canvas *svg.SVG, w, h float64, title string, theme readmeCardTheme) {
+	canvas.Start(w, h)
+	canvas.Style("text/css",
+		fmt.Sprintf("text { font-family: 'Segoe UI', Ubuntu, 'Helvetica Neue', Sans-Serif; fill: %s; }", theme.text),
+		fmt.Sprintf(".title { font-size: 16px; font-weight: 600; fill: %s; }", theme.title),
+		".label { font-size: 12px; font-weight: 400; }",
+	)
+	canvas.Roundrect(0.5, 0.5, w-1, h-1, 4.5, 4.5, fmt.Sprintf("fill: %s; stroke: %s", theme.background, theme.border))
+	canvas.Text(cardPadding, 30, title, "class=\"title\"")
+}
+
+func (srv *ReadmeCardService) endCard(canvas *svg.SVG, items []*readmeCardItem) {
+	if len(items) == 0 {
+		canvas.Text(cardPadding, cardTitleHeight+cardPadding, "No data", "class=\"label\"")
+	}
+	canvas.End()
+}
+
+func (srv *ReadmeCardService) legendEntry(canvas *svg.SVG, x, y float64, item *readmeCardItem) {
+	canvas.Group()
+	canvas.Title(fmt.Sprintf("%s: %s", item.key, helpers.FmtWakatimeDuration(item.total)))
+	canvas.Circle(x+5, y-4, 5, fmt.Sprintf("fill: %s", item.color))
+	canvas.Text(x+15, y, fmt.Sprintf("%s %.1f%%", item.key, item.percent), "class=\"label\"")
+	canvas.Gend()
+}
+
+func (srv *ReadmeCardService) getItems(summary *models.Summary, entity uint8, limit int) []*readmeCardItem {
+	if limit <= 0 || limit > models.ReadmeCardMaxLimit {
+		limit = models.ReadmeCardDefaultLimit
+	}
+
+	summaryItems := make([]*models.SummaryItem, len(*summary.GetByType(entity)))
+	copy(summaryItems, *summary.GetByType(entity))
+	sort.Slice(summaryItems, func(i, j int) bool {
+		return summaryItems[i].Total > summaryItems[j].Total
+	})
+
+	total := summary.TotalTimeBy(entity)
+	colors := srv.entityColors(entity)
+
+	items := make([]*readmeCardItem, 0, limit)
+	for _, item := range summaryItems {
+		if len(items) >= limit || total == 0 {
+			break
+		}
+		if item.Key == models.UnknownSummaryKey || item.Total == 0 {
+			continue
+		}
+		items = append(items, &readmeCardItem{
+			key:     item.Key,
+			color:   srv.resolveColor(item.Key, colors),
+			total:   item.TotalFixed(),
+			percent: float64(item.TotalFixed()) / float64(total) * 100,
+		})
+	}
+	return items
+}
+
+func (srv *ReadmeCardService) entityColors(entity uint8) map[string]string {
+	switch entity {
+	case models.SummaryLanguage:
+		return srv.config.App.GetLanguageColors()
+	case models.SummaryEditor:
+		return srv.config.App.GetEditorColors()
+	default:
+		return map[string]string{}
+	}
+}
+
+func (srv *ReadmeCardService) entityTitle(entity uint8) string {
+	switch entity {
+	case models.SummaryProject:
+		return "Projects"
+	case models.SummaryEditor:
+		return "Editors"
+	default:
+		return "Languages"
+	}
+}
+
+func (srv *ReadmeCardService) resolveColor(key string, colors map[string]string) string {
+	if c, ok := colors[strings.ToLower(key)]; ok {
+		return c
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return cardPalette[h.Sum32()%uint32(len(cardPalette))]
+}
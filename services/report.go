@@ -10,6 +10,7 @@ import (
 	"github.com/leandro-lugaresi/hub"
 	"github.com/muety/artifex/v2"
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/tracing"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/utils"
 )
@@ -26,18 +27,24 @@ type ReportService struct {
 	summaryService ISummaryService
 	userService    IUserService
 	mailService    IMailService
+	leaseService   ILeaseService
+	dayOffService  IDayOffService
+	presetService  IFilterPresetService
 	rand           *rand.Rand
 	queueDefault   *artifex.Dispatcher
 	queueWorkers   *artifex.Dispatcher
 }
 
-func NewReportService(summaryService ISummaryService, userService IUserService, mailService IMailService) *ReportService {
+func NewReportService(summaryService ISummaryService, userService IUserService, mailService IMailService, leaseService ILeaseService, dayOffService IDayOffService, filterPresetService IFilterPresetService) *ReportService {
 	srv := &ReportService{
 		config:         config.Get(),
 		eventBus:       config.EventBus(),
 		summaryService: summaryService,
 		userService:    userService,
 		mailService:    mailService,
+		leaseService:   leaseService,
+		dayOffService:  dayOffService,
+		presetService:  filterPresetService,
 		rand:           rand.New(rand.NewSource(time.Now().Unix())),
 		queueDefault:   config.GetDefaultQueue(),
 		queueWorkers:   config.GetQueue(config.QueueReports),
@@ -67,7 +74,7 @@ func (srv *ReportService) Schedule() {
 		}
 	}
 
-	_, err := srv.queueDefault.DispatchCron(func() {
+	_, err := srv.queueDefault.DispatchCron(srv.leaseService.Exclusive("reports", func() {
 		// fetch all users with reports enabled
 		users, err := srv.userService.GetAllByReports(true)
 		if err != nil {
@@ -85,7 +92,7 @@ func (srv *ReportService) Schedule() {
 		for _, u := range users {
 			scheduleUserReport(u)
 		}
-	}, srv.config.App.GetWeeklyReportCron())
+	}), srv.config.App.GetWeeklyReportCron())
 
 	if err != nil {
 		config.Log().Error("failed to dispatch report generation jobs", "error", err)
@@ -100,10 +107,24 @@ func (srv *ReportService) SendReport(user *models.User, duration time.Duration)
 
 	slog.Info("generating report for user", "userID", user.ID)
 
+	ctx, span := tracing.StartJob("send_report", tracing.AttrUser.String(user.ID))
+	defer span.End()
+
 	end := time.Now().In(user.TZ())
 	start := time.Now().Add(-1 * duration)
 
-	fullSummary, err := srv.summaryService.Aliased(start, end, user, srv.summaryService.Retrieve, nil, nil, false)
+	// optionally restrict report to a filter preset, falling back to an unfiltered report if the preset doesn't exist anymore
+	var preset *models.FilterPreset
+	if user.ReportsPreset != "" {
+		p, err := srv.presetService.GetByUserAndSlug(user.ID, user.ReportsPreset)
+		if err != nil {
+			slog.Warn("failed to get filter preset for report, sending unfiltered report", "userID", user.ID, "preset", user.ReportsPreset, "error", err)
+		} else {
+			preset = p
+		}
+	}
+
+	fullSummary, err := srv.summaryService.Aliased(ctx, start, end, user, srv.summaryService.Retrieve, presetFilters(preset), nil, false)
 	if err != nil {
 		config.Log().Error("failed to regenerate report", "userID", user.ID, "error", err)
 		return err
@@ -115,7 +136,7 @@ func (srv *ReportService) SendReport(user *models.User, duration time.Duration)
 
 	for i, interval := range dayIntervals {
 		from, to := datetime.BeginOfDay(interval[0]), interval[1]
-		summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, nil, false)
+		summary, err := srv.summaryService.Aliased(ctx, from, to, user, srv.summaryService.Retrieve, presetFilters(preset), nil, false)
 		if err != nil {
 			config.Log().Error("failed to regenerate day summary for report", "from", from, "to", to, "userID", user.ID, "error", err)
 			break
@@ -134,12 +155,26 @@ func (srv *ReportService) SendReport(user *models.User, duration time.Duration)
 		}
 	}
 
+	calendar, err := srv.dayOffService.GetCalendar(user)
+	if err != nil {
+		config.Log().Error("failed to get work calendar for report, considering all days working days", "userID", user.ID, "error", err)
+	}
+
+	numDays := int(duration.Hours() / 24)
+	numWorkingDays := calendar.CountWorkingDays(start.In(user.TZ()), numDays)
+
 	report := &models.Report{
 		From:           start,
 		To:             end,
 		User:           user,
 		Summary:        fullSummary,
 		DailySummaries: dailySummaries,
+		NumDays:        numDays,
+		NumWorkingDays: numWorkingDays,
+		Preset:         preset,
+	}
+	if numWorkingDays > 0 {
+		report.DailyAverage = fullSummary.TotalTime() / time.Duration(numWorkingDays)
 	}
 
 	if err := srv.mailService.SendReport(user, report); err != nil {
@@ -150,3 +185,11 @@ func (srv *ReportService) SendReport(user *models.User, duration time.Duration)
 	slog.Info("sent report to user", "userID", user.ID)
 	return nil
 }
+
+// presetFilters returns a fresh copy of the preset's filters (as they get altered when resolving aliases) or nil, if no preset is given
+func presetFilters(preset *models.FilterPreset) *models.Filters {
+	if preset == nil {
+		return nil
+	}
+	return preset.ParsedFilters()
+}
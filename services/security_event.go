@@ -0,0 +1,148 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/leandro-lugaresi/hub"
+	"github.com/muety/artifex/v2"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/repositories"
+	"github.com/muety/wakapi/utils"
+)
+
+type SecurityEventService struct {
+	config      *config.Config
+	eventBus    *hub.Hub
+	mailService IMailService
+	repository  repositories.ISecurityEventRepository
+	queueMails  *artifex.Dispatcher
+}
+
+func NewSecurityEventService(mailService IMailService, securityEventRepository repositories.ISecurityEventRepository) *SecurityEventService {
+	srv := &SecurityEventService{
+		config:      config.Get(),
+		eventBus:    config.EventBus(),
+		mailService: mailService,
+		repository:  securityEventRepository,
+		queueMails:  config.GetQueue(config.QueueMails),
+	}
+
+	onApiKeyCreate := srv.eventBus.Subscribe(0, config.EventApiKeyCreate)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			srv.logApiKeyEvent(m, models.SecurityEventApiKeyCreate)
+		}
+	}(&onApiKeyCreate)
+
+	onApiKeyDelete := srv.eventBus.Subscribe(0, config.EventApiKeyDelete)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			srv.logApiKeyEvent(m, models.SecurityEventApiKeyDelete)
+		}
+	}(&onApiKeyDelete)
+
+	return srv
+}
+
+// Log records a security-relevant event for the given user and, if applicable, notifies them by mail
+func (srv *SecurityEventService) Log(user *models.User, eventType models.SecurityEventType, ip, userAgent, details string) (*models.SecurityEvent, error) {
+	event, err := srv.insert(user, eventType, ip, userAgent, details)
+	if err != nil {
+		return nil, err
+	}
+	if event.Notify() {
+		srv.sendNotification(user, event)
+	}
+	return event, nil
+}
+
+// LogLogin records a login, but only if it originates from an ip address the user has never logged in from before
+func (srv *SecurityEventService) LogLogin(user *models.User, method, ip, userAgent string) (*models.SecurityEvent, error) {
+	if ip == "" {
+		return nil, nil
+	}
+
+	known, err := srv.repository.ExistsByUserAndTypeAndIp(user.ID, models.SecurityEventLoginNewIp, ip)
+	if err != nil || known {
+		return nil, err
+	}
+
+	// the very first recorded login of a user is not worth a notification
+	count, err := srv.repository.CountByUserAndType(user.ID, models.SecurityEventLoginNewIp)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := srv.insert(user, models.SecurityEventLoginNewIp, ip, userAgent, fmt.Sprintf("via %s", method))
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		srv.sendNotification(user, event)
+	}
+	return event, nil
+}
+
+func (srv *SecurityEventService) GetByUser(userId string, limit int) ([]*models.SecurityEvent, error) {
+	return srv.repository.GetByUser(userId, limit)
+}
+
+func (srv *SecurityEventService) DeleteBefore(t time.Time) (int64, error) {
+	return srv.repository.DeleteBefore(t)
+}
+
+// logApiKeyEvent records the creation or deletion of an api key, no matter where it originated from (web ui, api, ...)
+func (srv *SecurityEventService) logApiKeyEvent(m hub.Message, eventType models.SecurityEventType) {
+	if config.IsRemoteEvent(m) {
+		return // already logged by the originating instance
+	}
+
+	apiKey, ok := m.Fields[config.FieldPayload].(*models.ApiKey)
+	if !ok {
+		return
+	}
+	user := apiKey.User
+	if user == nil {
+		user = &models.User{ID: apiKey.UserID} // no mail address known, thus won't be notified
+	}
+	if user.ID == "" {
+		return
+	}
+
+	if _, err := srv.Log(user, eventType, "", "", apiKey.Label); err != nil {
+		config.Log().Error("failed to log security event", "user", user.ID, "event", eventType, "error", err)
+	}
+}
+
+func (srv *SecurityEventService) insert(user *models.User, eventType models.SecurityEventType, ip, userAgent, details string) (*models.SecurityEvent, error) {
+	// truncated by characters, because cutting multi-byte characters in half would result in invalid strings
+	userAgent, details = utils.TruncateRunes(userAgent, 255), utils.TruncateRunes(details, 255)
+
+	return srv.repository.Insert(&models.SecurityEvent{
+		UserID:    user.ID,
+		Type:      eventType,
+		CreatedAt: models.CustomTime(time.Now()),
+		IP:        ip,
+		UserAgent: userAgent,
+		Details:   details,
+	})
+}
+
+func (srv *SecurityEventService) sendNotification(user *models.User, event *models.SecurityEvent) {
+	if !srv.config.Mail.Enabled || !srv.config.Mail.SecurityNotifications || user.Email == "" {
+		return
+	}
+
+	u := *user
+	srv.queueMails.Dispatch(func() {
+		if err := srv.mailService.SendSecurityNotification(&u, event); err != nil {
+			config.Log().Error("failed to send security notification mail to user", "userID", u.ID, "event", event.Type, "error", err)
+			return
+		}
+		slog.Info("sent security notification mail", "userID", u.ID, "event", event.Type)
+	})
+}
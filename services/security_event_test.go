@@ -0,0 +1,165 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leandro-lugaresi/hub"
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type SecurityEventServiceTestSuite struct {
+	suite.Suite
+	TestUser                *models.User
+	MailService             *mocks.MailServiceMock
+	SecurityEventRepository *mocks.SecurityEventRepositoryMock
+}
+
+func (suite *SecurityEventServiceTestSuite) SetupSuite() {
+	suite.TestUser = &models.User{ID: "testuser", Email: "testuser@example.org"}
+}
+
+func (suite *SecurityEventServiceTestSuite) BeforeTest(suiteName, testName string) {
+	cfg := config.Empty()
+	cfg.Mail.Enabled = true
+	cfg.Mail.SecurityNotifications = true
+	config.Set(cfg)
+
+	suite.MailService = new(mocks.MailServiceMock)
+	suite.SecurityEventRepository = new(mocks.SecurityEventRepositoryMock)
+}
+
+func (suite *SecurityEventServiceTestSuite) createSut() (*SecurityEventService, *hub.Hub) {
+	originalEventBus := config.EventBus()
+	eventBus := hub.New()
+	config.SetEventBus(eventBus)
+	sut := NewSecurityEventService(suite.MailService, suite.SecurityEventRepository)
+	config.SetEventBus(originalEventBus)
+	return sut, eventBus
+}
+
+// mockInsert makes the repository mock return whatever event is passed to it
+func (suite *SecurityEventServiceTestSuite) mockInsert() chan *models.SecurityEvent {
+	inserted := make(chan *models.SecurityEvent, 8)
+	suite.SecurityEventRepository.On("Insert", mock.Anything).Run(func(args mock.Arguments) {
+		inserted <- args.Get(0).(*models.SecurityEvent)
+	}).Return(&models.SecurityEvent{}, nil)
+	return inserted
+}
+
+func TestSecurityEventServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(SecurityEventServiceTestSuite))
+}
+
+func (suite *SecurityEventServiceTestSuite) TestSecurityEventService_Log() {
+	sut, _ := suite.createSut()
+
+	isType := func(t models.SecurityEventType) interface{} {
+		return mock.MatchedBy(func(e *models.SecurityEvent) bool {
+			return e.Type == t && e.UserID == suite.TestUser.ID && e.IP == "127.0.0.1"
+		})
+	}
+	suite.SecurityEventRepository.On("Insert", isType(models.SecurityEventPasswordChange)).Return(&models.SecurityEvent{Type: models.SecurityEventPasswordChange}, nil)
+	suite.SecurityEventRepository.On("Insert", isType(models.SecurityEventWebAuthnDelete)).Return(&models.SecurityEvent{Type: models.SecurityEventWebAuthnDelete}, nil)
+
+	notified := make(chan struct{}, 2)
+	suite.MailService.On("SendSecurityNotification", suite.TestUser, mock.Anything).Run(func(args mock.Arguments) { notified <- struct{}{} }).Return(nil)
+
+	event, err := sut.Log(suite.TestUser, models.SecurityEventPasswordChange, "127.0.0.1", "Mozilla/5.0", "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.SecurityEventPasswordChange, event.Type)
+
+	select {
+	case <-notified:
+	case <-time.After(time.Second):
+		suite.Fail("expected security notification to be sent")
+	}
+
+	// events not worth a notification
+	_, err = sut.Log(suite.TestUser, models.SecurityEventWebAuthnDelete, "127.0.0.1", "Mozilla/5.0", "")
+	assert.NoError(suite.T(), err)
+
+	select {
+	case <-notified:
+		suite.Fail("unexpected security notification")
+	case <-time.After(100 * time.Millisecond):
+	}
+	suite.SecurityEventRepository.AssertNumberOfCalls(suite.T(), "Insert", 2)
+}
+
+func (suite *SecurityEventServiceTestSuite) TestSecurityEventService_LogLogin() {
+	sut, _ := suite.createSut()
+	inserted := suite.mockInsert()
+
+	suite.SecurityEventRepository.On("ExistsByUserAndTypeAndIp", suite.TestUser.ID, models.SecurityEventLoginNewIp, "127.0.0.1").Return(true, nil)
+	suite.SecurityEventRepository.On("ExistsByUserAndTypeAndIp", suite.TestUser.ID, models.SecurityEventLoginNewIp, "127.0.0.2").Return(false, nil)
+	suite.SecurityEventRepository.On("CountByUserAndType", suite.TestUser.ID, models.SecurityEventLoginNewIp).Return(0, nil)
+
+	// known ip
+	event, err := sut.LogLogin(suite.TestUser, "password", "127.0.0.1", "")
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), event)
+
+	// no ip
+	event, err = sut.LogLogin(suite.TestUser, "password", "", "")
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), event)
+
+	// new ip, but very first login, so no notification
+	_, err = sut.LogLogin(suite.TestUser, "password", "127.0.0.2", "")
+	assert.NoError(suite.T(), err)
+
+	result := <-inserted
+	assert.Equal(suite.T(), "127.0.0.2", result.IP)
+	assert.Equal(suite.T(), "via password", result.Details)
+	suite.MailService.AssertNotCalled(suite.T(), "SendSecurityNotification", mock.Anything, mock.Anything)
+}
+
+func (suite *SecurityEventServiceTestSuite) TestSecurityEventService_ApiKeyEvents() {
+	_, eventBus := suite.createSut()
+	inserted := suite.mockInsert()
+
+	suite.MailService.On("SendSecurityNotification", mock.Anything, mock.Anything).Return(nil)
+
+	eventBus.Publish(hub.Message{
+		Name:   config.EventApiKeyCreate,
+		Fields: map[string]interface{}{config.FieldPayload: &models.ApiKey{User: suite.TestUser, UserID: suite.TestUser.ID, Label: "my key"}, config.FieldUserId: suite.TestUser.ID},
+	})
+	eventBus.Publish(hub.Message{
+		Name:   config.EventApiKeyDelete,
+		Fields: map[string]interface{}{config.FieldPayload: &models.ApiKey{UserID: suite.TestUser.ID, Label: "my key"}, config.FieldUserId: suite.TestUser.ID},
+	})
+	// relayed from another instance, which already logged it
+	eventBus.Publish(hub.Message{
+		Name:   config.EventApiKeyCreate,
+		Fields: map[string]interface{}{config.FieldPayload: &models.ApiKey{UserID: suite.TestUser.ID, Label: "remote key"}, config.FieldUserId: suite.TestUser.ID, config.FieldRemote: true},
+	})
+
+	var events []*models.SecurityEvent
+	for len(events) < 2 {
+		select {
+		case e := <-inserted:
+			events = append(events, e)
+		case <-time.After(time.Second):
+			suite.FailNow("expected api key events to be logged")
+		}
+	}
+
+	select {
+	case e := <-inserted:
+		suite.Failf("unexpected event", "remote event logged: %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	types := []models.SecurityEventType{events[0].Type, events[1].Type}
+	assert.ElementsMatch(suite.T(), []models.SecurityEventType{models.SecurityEventApiKeyCreate, models.SecurityEventApiKeyDelete}, types)
+	for _, e := range events {
+		assert.Equal(suite.T(), suite.TestUser.ID, e.UserID)
+		assert.Equal(suite.T(), "my key", e.Details)
+	}
+}
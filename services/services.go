@@ -1,12 +1,15 @@
 package services
 
 import (
+	"context"
+	"io"
 	"time"
 
 	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"gorm.io/gorm"
 
+	"github.com/muety/wakapi/lib/backup"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/models/types"
 	"github.com/muety/wakapi/utils"
@@ -37,6 +40,7 @@ type IAliasService interface {
 
 type IProjectService interface {
 	GetUserProjectStats(*models.User, time.Time, time.Time, string, *utils.PageParams, bool) ([]*models.ProjectStats, error)
+	GetProjectContributors(*models.User, string) ([]*models.ProjectContributor, error)
 }
 
 type IHeartbeatService interface {
@@ -56,17 +60,30 @@ type IHeartbeatService interface {
 	GetLatestByOriginAndUser(string, *models.User) (*models.Heartbeat, error)
 	GetLatestByFilters(*models.User, *models.Filters) (*models.Heartbeat, error)
 	GetEntitySetByUser(uint8, string) ([]string, error)
-	StreamAllWithin(time.Time, time.Time, *models.User) (chan *models.Heartbeat, error)
-	StreamAllWithinRaw(time.Time, time.Time, *models.User) (chan *models.Heartbeat, error)
+	StreamAllWithin(context.Context, time.Time, time.Time, *models.User) (chan *models.Heartbeat, error)
+	StreamAllWithinRaw(context.Context, time.Time, time.Time, *models.User) (chan *models.Heartbeat, error)
 	StreamAllWithinByFilters(time.Time, time.Time, *models.User, *models.Filters) (chan *models.Heartbeat, error)
 	DeleteBefore(time.Time) error
 	DeleteByUser(*models.User) error
 	DeleteByUserBefore(*models.User, time.Time) error
 	GetUserAgentsByUser(*models.User) ([]*models.UserAgent, error)
+	ArchiveByUserBefore(*models.User, time.Time) (int, error)
+	GetArchivedUntil(*models.User) (time.Time, error)
+}
+
+type IBackupService interface {
+	Schedule()
+	Create(io.Writer) (*backup.Manifest, error)
+	CreateFile() (string, *backup.Manifest, error)
+	Restore(io.Reader, bool) (*backup.Manifest, error)
 }
 
 type IDiagnosticsService interface {
 	Create(*models.Diagnostics) (*models.Diagnostics, error)
+	GetAll(int) ([]*models.Diagnostics, error)
+	GetByUser(string, int) ([]*models.Diagnostics, error)
+	DeleteByUser(string) error
+	DeleteBefore(time.Time) (int64, error)
 }
 
 type IKeyValueService interface {
@@ -98,25 +115,61 @@ type IProjectLabelService interface {
 	Delete(*models.ProjectLabel) error
 }
 
+type IDayOffService interface {
+	GetById(uint) (*models.DayOff, error)
+	GetByUser(string) ([]*models.DayOff, error)
+	GetCalendar(*models.User) (*models.WorkCalendar, error)
+	Create(*models.DayOff) (*models.DayOff, error)
+	CreateRange(*models.User, time.Time, time.Time, string) (int, error)
+	ImportCalendar(*models.User, io.Reader) (int, error)
+	Delete(*models.DayOff) error
+	DeleteImported(*models.User) error
+}
+
+type IDashboardService interface {
+	GetById(uint) (*models.Dashboard, error)
+	GetByUser(string) ([]*models.Dashboard, error)
+	GetShared(string) (*models.Dashboard, error)
+	Create(*models.User, string) (*models.Dashboard, error)
+	Rename(*models.Dashboard, string) (*models.Dashboard, error)
+	SetShared(*models.Dashboard, bool) (*models.Dashboard, error)
+	Delete(*models.Dashboard) error
+	AddWidget(*models.Dashboard, *models.DashboardWidget) (*models.DashboardWidget, error)
+	MoveWidget(*models.Dashboard, uint, int) error
+	DeleteWidget(*models.Dashboard, uint) error
+	GetWidgetData(context.Context, *models.User, *models.DashboardWidget) (*models.DashboardWidgetData, error)
+}
+
+type IFilterPresetService interface {
+	GetById(uint) (*models.FilterPreset, error)
+	GetByUser(string) ([]*models.FilterPreset, error)
+	GetByUserAndSlug(string, string) (*models.FilterPreset, error)
+	Create(*models.User, string, string, *models.Filters) (*models.FilterPreset, error)
+	Delete(*models.FilterPreset) error
+}
+
 type IMailService interface {
 	SendPasswordReset(*models.User, string) error
 	SendWakatimeFailureNotification(*models.User, int) error
 	SendImportNotification(*models.User, time.Duration, int) error
 	SendReport(*models.User, *models.Report) error
 	SendSubscriptionNotification(*models.User, bool) error
+	SendSecurityNotification(*models.User, *models.SecurityEvent) error
 }
 
 type IDurationService interface {
-	Get(time.Time, time.Time, *models.User, *models.Filters, *time.Duration, bool) (models.Durations, error)
+	Get(context.Context, time.Time, time.Time, *models.User, *models.Filters, *time.Duration, bool) (models.Durations, error)
 	Regenerate(*models.User, bool)
 	RegenerateAll()
 	DeleteByUser(*models.User) error
 }
 
 type ISummaryService interface {
-	Aliased(time.Time, time.Time, *models.User, types.SummaryRetriever, *models.Filters, *time.Duration, bool) (*models.Summary, error)
-	Retrieve(time.Time, time.Time, *models.User, *models.Filters, *time.Duration) (*models.Summary, error)
-	Summarize(time.Time, time.Time, *models.User, *models.Filters, *time.Duration) (*models.Summary, error)
+	Aliased(context.Context, time.Time, time.Time, *models.User, types.SummaryRetriever, *models.Filters, *time.Duration, bool) (*models.Summary, error)
+	Retrieve(context.Context, time.Time, time.Time, *models.User, *models.Filters, *time.Duration) (*models.Summary, error)
+	Summarize(context.Context, time.Time, time.Time, *models.User, *models.Filters, *time.Duration) (*models.Summary, error)
+	Daily(context.Context, time.Time, time.Time, *models.User, *models.Filters, *time.Duration) ([]*models.Summary, error)
+	WithAiSessions(context.Context, *models.Summary, *models.User, *models.Filters) (*models.Summary, error)
 	GetLatestByUser() ([]*models.TimeByUser, error)
 	GetLatestBySingleUser(string) (time.Time, error)
 	DeleteByUser(string) error
@@ -126,7 +179,24 @@ type ISummaryService interface {
 }
 
 type IActivityService interface {
-	GetChart(*models.User, *models.IntervalKey, bool, bool, bool) (string, error)
+	GetChart(context.Context, *models.User, *models.ActivityParams, bool) (string, error)
+	GetData(context.Context, *models.User, *models.ActivityParams, bool) (*models.ActivityData, error)
+}
+
+type IInsightsService interface {
+	Get(context.Context, *models.User, *models.InsightsParams, bool) (*models.Insights, error)
+}
+
+type IFocusService interface {
+	GetStats(context.Context, *models.User, *models.FocusParams, bool) (*models.FocusStats, error)
+}
+
+type IReadmeCardService interface {
+	GetCard(context.Context, *models.User, *models.ReadmeCard, bool) (string, error)
+}
+
+type IBadgeService interface {
+	GetMessage(context.Context, *models.User, *models.BadgeDefinition) (string, error)
 }
 
 type IReportService interface {
@@ -137,6 +207,7 @@ type IReportService interface {
 type IHousekeepingService interface {
 	Schedule()
 	CleanUserDataBefore(*models.User, time.Time) error
+	ArchiveUserHeartbeats(*models.User, time.Time) error
 }
 
 type ILeaderboardService interface {
@@ -149,8 +220,8 @@ type ILeaderboardService interface {
 	GetByIntervalAndUser(*models.IntervalKey, string, bool) (models.Leaderboard, error)
 	GetAggregatedByInterval(*models.IntervalKey, *uint8, *utils.PageParams, bool) (models.Leaderboard, error)
 	GetAggregatedByIntervalAndUser(*models.IntervalKey, string, *uint8, bool) (models.Leaderboard, error)
-	GenerateByUser(*models.User, *models.IntervalKey) (*models.LeaderboardItem, error)
-	GenerateAggregatedByUser(*models.User, *models.IntervalKey, uint8) ([]*models.LeaderboardItem, error)
+	GenerateByUser(context.Context, *models.User, *models.IntervalKey) (*models.LeaderboardItem, error)
+	GenerateAggregatedByUser(context.Context, *models.User, *models.IntervalKey, uint8) ([]*models.LeaderboardItem, error)
 }
 
 type IUserService interface {
@@ -179,6 +250,7 @@ type IUserService interface {
 	SetWakatimeApiCredentials(*models.User, string, string) (*models.User, error)
 	GenerateResetToken(*models.User) (*models.User, error)
 	GenerateUnsubscribeToken(*models.User) (*models.User, error)
+	GetUserBySession(string) (*models.User, *models.Session, error)
 	FlushCache()
 	FlushUserCache(string)
 }
@@ -190,6 +262,32 @@ type IApiKeyService interface {
 	Delete(*models.ApiKey) error
 }
 
+type ILeaseService interface {
+	Schedule()
+	Acquire(string, time.Duration) (bool, error)
+	Release(string) error
+	DeleteExpired() (int64, error)
+	IsLeader() bool
+	Exclusive(string, func()) func()
+}
+
+type ISessionService interface {
+	Create(*models.User, string, string) (*models.Session, error)
+	GetById(string) (*models.Session, error)
+	GetByUser(string) ([]*models.Session, error)
+	Touch(*models.Session) error
+	Delete(*models.Session) error
+	DeleteByUser(string, string) error
+	DeleteExpired() (int64, error)
+}
+
+type ISecurityEventService interface {
+	Log(*models.User, models.SecurityEventType, string, string, string) (*models.SecurityEvent, error)
+	LogLogin(*models.User, string, string, string) (*models.SecurityEvent, error)
+	GetByUser(string, int) ([]*models.SecurityEvent, error)
+	DeleteBefore(time.Time) (int64, error)
+}
+
 type IWebAuthnService interface {
 	CreateCredential(*webauthn.Credential, *models.User, string) (*models.WebAuthnCredential, error)
 	GetCredentialsByUser(*models.User) ([]*models.WebAuthnCredential, error)
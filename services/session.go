@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"uuid"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/models"
+	"github.com/muety/wakapi/repositories"
+)
+
+// sessionTouchInterval is the minimum interval between two updates of a session's last seen timestamp
+const sessionTouchInterval = 1 * time.Minute
+
+type SessionService struct {
+	config     *config.Config
+	cache      cache.Cache
+	repository repositories.ISessionRepository
+}
+
+func NewSessionService(sessionRepository repositories.ISessionRepository) *SessionService {
+	return &SessionService{
+		config:     config.Get(),
+		cache:      cache.New("sessions", sessionTouchInterval, 2*sessionTouchInterval),
+		repository: sessionRepository,
+	}
+}
+
+func (srv *SessionService) Create(user *models.User, ip, userAgent string) (*models.Session, error) {
+	var expiry time.Time
+	if srv.config.Security.CookieMaxAgeSec > 0 {
+		expiry = time.Now().Add(time.Duration(srv.config.Security.CookieMaxAgeSec) * time.Second)
+	} else {
+		expiry = time.Now().Add(2 * time.Hour) // cookies only last for the browser session, so we set the expiry to 2h, which should last long enough for most sessions
+	}
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	now := models.CustomTime(time.Now())
+	return srv.repository.Insert(&models.Session{
+		ID:         uuid.NewV4().String(),
+		UserID:     user.ID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  models.CustomTime(expiry),
+		IP:         ip,
+		UserAgent:  userAgent,
+	})
+}
+
+// GetById returns the session with the given id, but only if it is still valid (i.e. neither revoked nor expired)
+func (srv *SessionService) GetById(id string) (*models.Session, error) {
+	if id == "" {
+		return nil, errors.New("session id must not be empty")
+	}
+
+	if s, ok := srv.cache.Get(id); ok {
+		return s.(*models.Session), nil
+	}
+
+	session, err := srv.repository.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.IsExpired() {
+		return nil, errors.New("session is expired")
+	}
+
+	srv.cache.SetDefault(id, session)
+	return session, nil
+}
+
+func (srv *SessionService) GetByUser(userId string) ([]*models.Session, error) {
+	return srv.repository.GetByUser(userId)
+}
+
+// Touch updates the session's last seen timestamp, at most once every sessionTouchInterval.
+// Sessions are shared among concurrent requests through the cache, so the given instance is never modified, but replaced by an updated copy.
+func (srv *SessionService) Touch(session *models.Session) error {
+	if time.Since(session.LastSeenAt.T()) < sessionTouchInterval {
+		return nil
+	}
+
+	touched := *session
+	touched.LastSeenAt = models.CustomTime(time.Now())
+	if err := srv.repository.Touch(&touched); err != nil {
+		return err
+	}
+
+	if _, ok := srv.cache.Get(session.ID); ok {
+		srv.cache.SetDefault(session.ID, &touched)
+	}
+	return nil
+}
+
+func (srv *SessionService) Delete(session *models.Session) error {
+	srv.cache.Delete(session.ID)
+	return srv.repository.Delete(session.ID)
+}
+
+// DeleteByUser revokes all sessions of the given user, optionally except for the one with the given id
+func (srv *SessionService) DeleteByUser(userId string, exceptId string) error {
+	if userId == "" {
+		return errors.New("no user id specified")
+	}
+
+	var err error
+	if exceptId == "" {
+		err = srv.repository.DeleteByUser(userId)
+	} else {
+		err = srv.repository.DeleteByUserExcept(userId, exceptId)
+	}
+
+	srv.cache.DeleteFunc(func(key string, v interface{}) bool {
+		s, ok := v.(*models.Session)
+		return ok && s.UserID == userId && s.ID != exceptId
+	})
+	return err
+}
+
+func (srv *SessionService) DeleteExpired() (int64, error) {
+	n, err := srv.repository.DeleteExpired()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %v", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/mocks"
+	"github.com/muety/wakapi/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type SessionServiceTestSuite struct {
+	suite.Suite
+	TestUser          *models.User
+	SessionRepository *mocks.SessionRepositoryMock
+}
+
+func (suite *SessionServiceTestSuite) SetupSuite() {
+	cfg := config.Empty()
+	cfg.Security.CookieMaxAgeSec = 3600
+	config.Set(cfg)
+
+	suite.TestUser = &models.User{ID: "testuser"}
+}
+
+func (suite *SessionServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.SessionRepository = new(mocks.SessionRepositoryMock)
+}
+
+func TestSessionServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionServiceTestSuite))
+}
+
+func (suite *SessionServiceTestSuite) TestSessionService_Create() {
+	sut := NewSessionService(suite.SessionRepository)
+
+	var session *models.Session
+	suite.SessionRepository.On("Insert", mock.Anything).Run(func(args mock.Arguments) {
+		session = args.Get(0).(*models.Session)
+	}).Return(&models.Session{}, nil)
+
+	_, err := sut.Create(suite.TestUser, "127.0.0.1", "Mozilla/5.0")
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), session.ID)
+	assert.Equal(suite.T(), suite.TestUser.ID, session.UserID)
+	assert.Equal(suite.T(), "127.0.0.1", session.IP)
+	assert.False(suite.T(), session.IsExpired())
+	assert.WithinDuration(suite.T(), time.Now().Add(time.Hour), session.ExpiresAt.T(), time.Minute)
+}
+
+func (suite *SessionServiceTestSuite) TestSessionService_GetById() {
+	sut := NewSessionService(suite.SessionRepository)
+
+	valid := &models.Session{ID: "valid", UserID: suite.TestUser.ID, ExpiresAt: models.CustomTime(time.Now().Add(time.Hour))}
+	expired := &models.Session{ID: "expired", UserID: suite.TestUser.ID, ExpiresAt: models.CustomTime(time.Now().Add(-time.Hour))}
+
+	suite.SessionRepository.On("GetById", "valid").Return(valid, nil).Once()
+	suite.SessionRepository.On("GetById", "expired").Return(expired, nil)
+	suite.SessionRepository.On("GetById", "revoked").Return(nil, errors.New("record not found"))
+
+	result, err := sut.GetById("valid")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), valid, result)
+
+	// served from cache
+	result, err = sut.GetById("valid")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), valid, result)
+	suite.SessionRepository.AssertNumberOfCalls(suite.T(), "GetById", 1)
+
+	_, err = sut.GetById("expired")
+	assert.Error(suite.T(), err)
+
+	_, err = sut.GetById("revoked")
+	assert.Error(suite.T(), err)
+
+	_, err = sut.GetById("")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SessionServiceTestSuite) TestSessionService_GetByUser() {
+	sut := NewSessionService(suite.SessionRepository)
+
+	sessions := []*models.Session{{ID: "s1", UserID: suite.TestUser.ID}, {ID: "s2", UserID: suite.TestUser.ID}}
+	suite.SessionRepository.On("GetByUser", suite.TestUser.ID).Return(sessions, nil)
+
+	result, err := sut.GetByUser(suite.TestUser.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), result, 2)
+}
+
+func (suite *SessionServiceTestSuite) TestSessionService_DeleteByUser_ExceptCurrent() {
+	sut := NewSessionService(suite.SessionRepository)
+
+	current := &models.Session{ID: "current", UserID: suite.TestUser.ID, ExpiresAt: models.CustomTime(time.Now().Add(time.Hour))}
+	other := &models.Session{ID: "other", UserID: suite.TestUser.ID, ExpiresAt: models.CustomTime(time.Now().Add(time.Hour))}
+
+	suite.SessionRepository.On("GetById", "current").Return(current, nil)
+	suite.SessionRepository.On("GetById", "other").Return(other, nil).Once()
+	suite.SessionRepository.On("GetById", "other").Return(nil, errors.New("record not found"))
+	suite.SessionRepository.On("DeleteByUserExcept", suite.TestUser.ID, "current").Return(nil)
+
+	// populate cache
+	sut.GetById("current")
+	sut.GetById("other")
+
+	assert.NoError(suite.T(), sut.DeleteByUser(suite.TestUser.ID, "current"))
+
+	_, err := sut.GetById("current")
+	assert.NoError(suite.T(), err)
+	_, err = sut.GetById("other")
+	assert.Error(suite.T(), err) // evicted from cache, thus looked up again
+	suite.SessionRepository.AssertCalled(suite.T(), "DeleteByUserExcept", suite.TestUser.ID, "current")
+	suite.SessionRepository.AssertNotCalled(suite.T(), "DeleteByUser", mock.Anything)
+
+	assert.Error(suite.T(), sut.DeleteByUser("", ""))
+}
+
+func (suite *SessionServiceTestSuite) TestSessionService_DeleteExpired() {
+	sut := NewSessionService(suite.SessionRepository)
+
+	suite.SessionRepository.On("DeleteExpired").Return(3, nil)
+
+	n, err := sut.DeleteExpired()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(3), n)
+}
+
+func (suite *SessionServiceTestSuite) TestSessionService_Touch() {
+	sut := NewSessionService(suite.SessionRepository)
+
+	stale := models.CustomTime(time.Now().Add(-2 * sessionTouchInterval))
+	session := &models.Session{ID: "s1", UserID: suite.TestUser.ID, LastSeenAt: stale, ExpiresAt: models.CustomTime(time.Now().Add(time.Hour))}
+
+	suite.SessionRepository.On("GetById", "s1").Return(session, nil).Once()
+	suite.SessionRepository.On("Touch", mock.Anything).Return(nil)
+
+	cached, _ := sut.GetById("s1")
+
+	// concurrent requests share the cached instance, which must not be modified (run with -race)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(suite.T(), sut.Touch(cached))
+			_ = cached.LastSeenAt.T()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(suite.T(), stale, session.LastSeenAt)
+	suite.SessionRepository.AssertCalled(suite.T(), "Touch", mock.MatchedBy(func(s *models.Session) bool {
+		return s.ID == "s1" && time.Since(s.LastSeenAt.T()) < sessionTouchInterval
+	}))
+
+	updated, err := sut.GetById("s1")
+	assert.NoError(suite.T(), err)
+	assert.WithinDuration(suite.T(), time.Now(), updated.LastSeenAt.T(), sessionTouchInterval)
+
+	// recently touched sessions are not written again
+	suite.SessionRepository.Calls = nil
+	assert.NoError(suite.T(), sut.Touch(updated))
+	suite.SessionRepository.AssertNotCalled(suite.T(), "Touch", mock.Anything)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -15,16 +16,19 @@ import (
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/leandro-lugaresi/hub"
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
+	"github.com/muety/wakapi/lib/tracing"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/models/types"
 	"github.com/muety/wakapi/repositories"
-	"github.com/patrickmn/go-cache"
+	"github.com/muety/wakapi/utils"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type SummaryService struct {
 	config              *config.Config
-	cache               *cache.Cache
-	heartbeatCache      *cache.Cache
+	cache               cache.Cache
+	heartbeatCache      cache.Cache
 	eventBus            *hub.Hub
 	repository          repositories.ISummaryRepository
 	heartbeatService    IHeartbeatService
@@ -36,8 +40,8 @@ type SummaryService struct {
 func NewSummaryService(summaryRepo repositories.ISummaryRepository, heartbeatService IHeartbeatService, durationService IDurationService, aliasService IAliasService, projectLabelService IProjectLabelService) *SummaryService {
 	srv := &SummaryService{
 		config:              config.Get(),
-		cache:               cache.New(24*time.Hour, 24*time.Hour),
-		heartbeatCache:      cache.New(24*time.Hour, 24*time.Hour),
+		cache:               cache.New("summaries", 24*time.Hour, 24*time.Hour),
+		heartbeatCache:      cache.New("summary_heartbeats", 24*time.Hour, 24*time.Hour),
 		eventBus:            config.EventBus(),
 		repository:          summaryRepo,
 		heartbeatService:    heartbeatService,
@@ -56,6 +60,10 @@ func NewSummaryService(summaryRepo repositories.ISummaryRepository, heartbeatSer
 	sub2 := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate)
 	go func(sub *hub.Subscription) {
 		for m := range sub.Receiver {
+			if config.IsRemoteEvent(m) {
+				continue // summaries are cleaned up by the originating instance
+			}
+
 			heartbeat := m.Fields[config.FieldPayload].(*models.Heartbeat)
 			cacheKey := fmt.Sprintf("latest_heartbeat_%s", heartbeat.UserID)
 
@@ -87,29 +95,23 @@ func NewSummaryService(summaryRepo repositories.ISummaryRepository, heartbeatSer
 // Public summary generation methods
 
 // Aliased retrieves or computes a new summary based on the given SummaryRetriever and augments it with entity aliases and project labels
-func (srv *SummaryService) Aliased(from, to time.Time, user *models.User, f types.SummaryRetriever, filters *models.Filters, customTimeout *time.Duration, skipCache bool) (*models.Summary, error) {
+func (srv *SummaryService) Aliased(ctx context.Context, from, to time.Time, user *models.User, f types.SummaryRetriever, filters *models.Filters, customTimeout *time.Duration, skipCache bool) (summary *models.Summary, err error) {
+	ctx, span := tracing.Start(ctx, "SummaryService.Aliased", summarySpanAttrs(from, to, user, filters)...)
+	defer func() { tracing.End(span, err) }()
+
 	requestedTimeout := getEffectiveTimeout(user, customTimeout)
 
 	// Check cache (or skip for sub second-level date precision)
 	cacheKey := srv.getHash(from.String(), to.String(), user.ID, filters.Hash(), strconv.Itoa(int(requestedTimeout)), "--aliased")
 	if to.Truncate(time.Second).Equal(to) && from.Truncate(time.Second).Equal(from) {
 		if cacheResult, ok := srv.cache.Get(cacheKey); ok && !skipCache {
+			span.SetAttributes(attribute.Bool("wakapi.cache_hit", true))
 			return cacheResult.(*models.Summary).Sorted().InTZ(user.TZ()), nil
 		}
 	}
 
-	// Resolver functions
-	resolveAliases := srv.getAliasResolver(user)
-	resolveAliasesReverse := srv.getAliasReverseResolver(user)
-	resolveProjectLabelsReverse := srv.getProjectLabelsReverseResolver(user)
-
 	// Post-process filters
-	if filters != nil {
-		if !filters.Project.Exists() {
-			filters = filters.WithProjectLabels(resolveProjectLabelsReverse)
-		}
-		filters = filters.WithAliases(resolveAliasesReverse)
-	}
+	filters = srv.resolveFilters(user, filters)
 
 	// Initialize alias resolver service
 	if err := srv.aliasService.InitializeUser(user.ID); err != nil {
@@ -117,39 +119,107 @@ func (srv *SummaryService) Aliased(from, to time.Time, user *models.User, f type
 	}
 
 	// Get actual summary
-	s, err := f(from, to, user, filters, customTimeout)
+	s, err := f(ctx, from, to, user, filters, customTimeout)
 	if err != nil {
 		return nil, err
 	}
 
 	// Post-process summary and cache it
-	summary := s.WithResolvedAliases(resolveAliases)
-	summary = srv.withProjectLabels(summary)
-	summary.FillBy(models.SummaryProject, models.SummaryLabel) // first fill up labels from projects
-	summary.FillMissing()                                      // then, full up types which are entirely missing
-
-	if withDetails := filters != nil && filters.IsProjectDetails(); !withDetails {
-		summary.Branches = nil
-		summary.Entities = nil
-	}
-
+	summary = srv.withAliasesAndLabels(s, user, filters)
 	srv.cache.SetDefault(cacheKey, summary)
 	return summary.Sorted().InTZ(user.TZ()), nil
 }
 
-func (srv *SummaryService) Retrieve(from, to time.Time, user *models.User, filters *models.Filters, customTimeout *time.Duration) (*models.Summary, error) {
-	summaries := make([]*models.Summary, 0)
+// Daily retrieves one aliased summary for every day within the given interval, equivalent to calling Aliased with Retrieve for each of them.
+// However, persisted summaries are fetched only once for the entire interval and remaining durations only once per missing interval, and are then bucketed per day.
+func (srv *SummaryService) Daily(ctx context.Context, from, to time.Time, user *models.User, filters *models.Filters, customTimeout *time.Duration) (result []*models.Summary, err error) {
+	ctx, span := tracing.Start(ctx, "SummaryService.Daily", summarySpanAttrs(from, to, user, filters)...)
+	defer func() { tracing.End(span, err) }()
+
 	requestedTimeout := getEffectiveTimeout(user, customTimeout)
 
-	// Filtered summaries or summaries at alternative timeouts are not persisted currently
-	// Special case: if (a) filters apply to only one entity type and (b) we're only interested in the summary items of that particular entity type,
-	// we can still fetch the persisted summary and drop all irrelevant parts from it
-	requiresFiltering := filters != nil && !filters.IsEmpty() && (filters.CountDistinctTypes() > 1 || !filters.SelectFilteredOnly)
-	mustRecompute := requiresFiltering || requestedTimeout != user.HeartbeatsTimeout()
+	// Check cache (or skip for sub second-level date precision)
+	cacheKey := srv.getHash(from.String(), to.String(), user.ID, filters.Hash(), strconv.Itoa(int(requestedTimeout)), "--daily")
+	if to.Truncate(time.Second).Equal(to) && from.Truncate(time.Second).Equal(from) {
+		if cacheResult, ok := srv.cache.Get(cacheKey); ok {
+			span.SetAttributes(attribute.Bool("wakapi.cache_hit", true))
+			return slice.Map(cacheResult.([]*models.Summary), func(i int, s *models.Summary) *models.Summary { return s.Sorted().InTZ(user.TZ()) }), nil
+		}
+	}
+
+	filters = srv.resolveFilters(user, filters)
+	if err := srv.aliasService.InitializeUser(user.ID); err != nil {
+		return nil, err
+	}
+
+	days := utils.SplitRangeByDays(from, to)
+	buckets := make([][]*models.Summary, len(days))
 
-	if !mustRecompute {
+	// Get all already existing, pre-generated summaries that fall into the requested interval
+	var persisted []*models.Summary
+	if !srv.mustRecompute(user, filters, customTimeout) {
+		result, err := srv.repository.GetByUserWithin(ctx, user, from, to)
+		if err != nil {
+			return nil, err
+		}
+		persisted = srv.fixZeroDuration(result)
+		for _, s := range persisted {
+			if i := dayIndex(days, s.FromTime.T()); i >= 0 {
+				buckets[i] = append(buckets[i], s)
+			}
+		}
+	}
+
+	// Generate missing slots from durations, fetched once per missing interval and then split up by day
+	missingIntervals := srv.getMissingIntervals(from, to, persisted, false)
+	span.SetAttributes(attribute.Int("wakapi.summaries_persisted", len(persisted)), attribute.Int("wakapi.summaries_missing", len(missingIntervals)))
+	for _, interval := range missingIntervals {
+		durations, err := srv.durationService.Get(ctx, interval.Start, interval.End, user, filters, customTimeout, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(missingIntervals) > 2 && durations.Len() > 0 && durations.First().Time.T().Equal(durations.Last().Time.T()) {
+			continue // intra-day missing interval, whose only activity was already counted by the preceding summary (see Retrieve)
+		}
+
+		durationsPerDay := make(map[int]models.Durations)
+		for _, d := range durations {
+			if i := dayIndex(days, d.Time.T()); i >= 0 {
+				durationsPerDay[i] = append(durationsPerDay[i], d)
+			}
+		}
+		for i, dayDurations := range durationsPerDay {
+			buckets[i] = append(buckets[i], srv.summarizeDurations(dayDurations, days[i][0], days[i][1], user, filters))
+		}
+	}
+
+	result = make([]*models.Summary, len(days))
+	for i, day := range days {
+		if len(buckets[i]) == 0 {
+			buckets[i] = append(buckets[i], &models.Summary{UserID: user.ID, FromTime: models.CustomTime(day[0]), ToTime: models.CustomTime(day[1])})
+		}
+
+		summary, err := srv.mergeRetrieved(buckets[i], day[0], day[1], filters)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = srv.withAliasesAndLabels(summary, user, filters)
+	}
+
+	srv.cache.SetDefault(cacheKey, result)
+	return slice.Map(result, func(i int, s *models.Summary) *models.Summary { return s.Sorted().InTZ(user.TZ()) }), nil
+}
+
+func (srv *SummaryService) Retrieve(ctx context.Context, from, to time.Time, user *models.User, filters *models.Filters, customTimeout *time.Duration) (summary *models.Summary, err error) {
+	ctx, span := tracing.Start(ctx, "SummaryService.Retrieve", summarySpanAttrs(from, to, user, filters)...)
+	defer func() { tracing.End(span, err) }()
+
+	summaries := make([]*models.Summary, 0)
+
+	if !srv.mustRecompute(user, filters, customTimeout) {
 		// Get all already existing, pre-generated summaries that fall into the requested interval
-		result, err := srv.repository.GetByUserWithin(user, from, to)
+		result, err := srv.repository.GetByUserWithin(ctx, user, from, to)
 		if err == nil {
 			summaries = srv.fixZeroDuration(result)
 		} else {
@@ -159,8 +229,9 @@ func (srv *SummaryService) Retrieve(from, to time.Time, user *models.User, filte
 
 	// Generate missing slots (especially before and after existing summaries) from durations (formerly raw heartbeats)
 	missingIntervals := srv.getMissingIntervals(from, to, summaries, false)
+	span.SetAttributes(attribute.Int("wakapi.summaries_persisted", len(summaries)), attribute.Int("wakapi.summaries_missing", len(missingIntervals)))
 	for _, interval := range missingIntervals {
-		if s, err := srv.Summarize(interval.Start, interval.End, user, filters, customTimeout); err == nil {
+		if s, err := srv.Summarize(ctx, interval.Start, interval.End, user, filters, customTimeout); err == nil {
 			if len(missingIntervals) > 2 && s.FromTime.T().Equal(s.ToTime.T()) {
 				// little hack here: GetWithin will query for >= from_date
 				// however, for "in-between" / intra-day missing intervals, we want strictly > from_date to prevent double-counting
@@ -174,6 +245,108 @@ func (srv *SummaryService) Retrieve(from, to time.Time, user *models.User, filte
 	}
 
 	// Merge existing and newly generated summary snippets
+	summary, err = srv.mergeRetrieved(summaries, from, to, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary.Sorted().InTZ(user.TZ()), nil
+}
+
+func (srv *SummaryService) Summarize(ctx context.Context, from, to time.Time, user *models.User, filters *models.Filters, customTimeout *time.Duration) (summary *models.Summary, err error) {
+	ctx, span := tracing.Start(ctx, "SummaryService.Summarize", summarySpanAttrs(from, to, user, filters)...)
+	defer func() { tracing.End(span, err) }()
+
+	// Initialize and fetch data
+	durations, err := srv.durationService.Get(ctx, from, to, user, filters, customTimeout, false)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrCount.Int(durations.Len()))
+
+	return srv.summarizeDurations(durations, from, to, user, filters), nil
+}
+
+// CRUD methods
+
+// WithAiSessions returns a copy of the summary, including the time spent per ai session, as needed for ai usage analytics.
+// Sessions are neither persisted, nor part of durations, but derived from raw heartbeats, thus only for summaries with ai activity that span at most models.MaxAiSessionDays.
+func (srv *SummaryService) WithAiSessions(ctx context.Context, summary *models.Summary, user *models.User, filters *models.Filters) (result *models.Summary, err error) {
+	from, to := summary.FromTime.T(), summary.ToTime.T()
+	if !summary.HasAIActivity() || to.Sub(from) > models.MaxAiSessionDays*24*time.Hour {
+		return summary, nil
+	}
+
+	_, span := tracing.Start(ctx, "SummaryService.WithAiSessions", summarySpanAttrs(from, to, user, filters)...)
+	defer func() { tracing.End(span, err) }()
+
+	heartbeats, err := srv.heartbeatService.GetAllWithinByFilters(from, to, user, srv.resolveFilters(user, filters))
+	if err != nil {
+		return nil, err
+	}
+
+	withSessions := *summary
+	withSessions.AiSessions = models.NewAiSessionItems(heartbeats, user.HeartbeatsTimeout())
+	return &withSessions, nil
+}
+
+func (srv *SummaryService) GetLatestByUser() ([]*models.TimeByUser, error) {
+	return srv.repository.GetLastByUser()
+}
+
+func (srv *SummaryService) GetLatestBySingleUser(userId string) (time.Time, error) {
+	return srv.repository.GetLastBySingleUser(userId)
+}
+
+func (srv *SummaryService) DeleteByUser(userId string) error {
+	srv.invalidateUserCache(userId)
+	return srv.repository.DeleteByUser(userId)
+}
+
+func (srv *SummaryService) DeleteByUserBefore(userId string, t time.Time) error {
+	srv.invalidateUserCache(userId)
+	return srv.repository.DeleteByUserBefore(userId, t)
+}
+
+func (srv *SummaryService) DeleteByUserAfter(userId string, t time.Time) error {
+	srv.invalidateUserCache(userId)
+	return srv.repository.DeleteByUserAfter(userId, t)
+}
+
+func (srv *SummaryService) Insert(summary *models.Summary) error {
+	srv.invalidateUserCache(summary.UserID)
+	return srv.repository.InsertWithRetry(summary)
+}
+
+// Private summary generation and utility methods
+
+// resolveFilters extends the given filters by the project labels and aliases resolving to any of the filtered values
+func (srv *SummaryService) resolveFilters(user *models.User, filters *models.Filters) *models.Filters {
+	if filters == nil {
+		return nil
+	}
+
+	resolveAliasesReverse := srv.getAliasReverseResolver(user)
+	resolveProjectLabelsReverse := srv.getProjectLabelsReverseResolver(user)
+
+	if !filters.Project.Exists() {
+		filters = filters.WithProjectLabels(resolveProjectLabelsReverse)
+	}
+	filters = filters.WithExcludedProjectLabels(resolveProjectLabelsReverse)
+	return filters.WithAliases(resolveAliasesReverse)
+}
+
+// mustRecompute determines whether a summary has to be computed from durations entirely instead of using persisted summaries.
+// Filtered summaries or summaries at alternative timeouts are not persisted currently.
+// Special case: if (a) filters apply to only one entity type and (b) we're only interested in the summary items of that particular entity type,
+// we can still fetch the persisted summary and drop all irrelevant parts from it.
+func (srv *SummaryService) mustRecompute(user *models.User, filters *models.Filters, customTimeout *time.Duration) bool {
+	requiresFiltering := filters != nil && !filters.IsEmpty() && (filters.CountDistinctTypes() > 1 || !filters.SelectFilteredOnly)
+	return requiresFiltering || getEffectiveTimeout(user, customTimeout) != user.HeartbeatsTimeout()
+}
+
+// mergeRetrieved merges persisted and newly generated summary snippets into one summary for the given interval
+func (srv *SummaryService) mergeRetrieved(summaries []*models.Summary, from, to time.Time, filters *models.Filters) (*models.Summary, error) {
 	sort.Sort(models.Summaries(summaries))
 	summary, err := srv.mergeSummaries(summaries)
 	if err != nil {
@@ -193,16 +366,25 @@ func (srv *SummaryService) Retrieve(from, to time.Time, user *models.User, filte
 		summary.KeepOnly(map[uint8]bool{filter.Entity: true}).ApplyFilter(filter)
 	}
 
-	return summary.Sorted().InTZ(user.TZ()), nil
+	return summary, nil
 }
 
-func (srv *SummaryService) Summarize(from, to time.Time, user *models.User, filters *models.Filters, customTimeout *time.Duration) (*models.Summary, error) {
-	// Initialize and fetch data
-	durations, err := srv.durationService.Get(from, to, user, filters, customTimeout, false)
-	if err != nil {
-		return nil, err
+// withAliasesAndLabels augments the given summary with entity aliases and project labels
+func (srv *SummaryService) withAliasesAndLabels(s *models.Summary, user *models.User, filters *models.Filters) *models.Summary {
+	summary := s.WithResolvedAliases(srv.getAliasResolver(user))
+	summary = srv.withProjectLabels(summary)
+	summary.FillBy(models.SummaryProject, models.SummaryLabel) // first fill up labels from projects
+	summary.FillMissing()                                      // then, full up types which are entirely missing
+
+	if withDetails := filters != nil && filters.IsProjectDetails(); !withDetails {
+		summary.Branches = nil
+		summary.Entities = nil
 	}
+	return summary
+}
 
+// summarizeDurations aggregates the given durations into a summary
+func (srv *SummaryService) summarizeDurations(durations models.Durations, from, to time.Time, user *models.User, filters *models.Filters) *models.Summary {
 	types := models.PersistedSummaryTypes()
 	if filters != nil && filters.IsProjectDetails() {
 		types = append(types, models.SummaryBranch)
@@ -224,6 +406,7 @@ func (srv *SummaryService) Summarize(from, to time.Time, user *models.User, filt
 	var branchItems []*models.SummaryItem
 	var entityItems []*models.SummaryItem
 	var categoryItems []*models.SummaryItem
+	var aiModelItems []*models.SummaryItem
 
 	for i := 0; i < len(types); i++ {
 		item := <-typedAggregations
@@ -244,6 +427,8 @@ func (srv *SummaryService) Summarize(from, to time.Time, user *models.User, filt
 			entityItems = item.Items
 		case models.SummaryCategory:
 			categoryItems = item.Items
+		case models.SummaryAiModel:
+			aiModelItems = item.Items
 		}
 	}
 
@@ -264,57 +449,37 @@ func (srv *SummaryService) Summarize(from, to time.Time, user *models.User, filt
 		Branches:         branchItems,
 		Entities:         entityItems,
 		Categories:       categoryItems,
+		AiModels:         aiModelItems,
 		NumHeartbeats:    durations.TotalNumHeartbeats(),
 	}
 
-	return summary.Sorted().InTZ(user.TZ()), nil
-}
-
-// CRUD methods
-
-func (srv *SummaryService) GetLatestByUser() ([]*models.TimeByUser, error) {
-	return srv.repository.GetLastByUser()
+	return summary.Sorted().InTZ(user.TZ())
 }
 
-func (srv *SummaryService) GetLatestBySingleUser(userId string) (time.Time, error) {
-	return srv.repository.GetLastBySingleUser(userId)
-}
-
-func (srv *SummaryService) DeleteByUser(userId string) error {
-	srv.invalidateUserCache(userId)
-	return srv.repository.DeleteByUser(userId)
-}
-
-func (srv *SummaryService) DeleteByUserBefore(userId string, t time.Time) error {
-	srv.invalidateUserCache(userId)
-	return srv.repository.DeleteByUserBefore(userId, t)
-}
-
-func (srv *SummaryService) DeleteByUserAfter(userId string, t time.Time) error {
-	srv.invalidateUserCache(userId)
-	return srv.repository.DeleteByUserAfter(userId, t)
-}
-
-func (srv *SummaryService) Insert(summary *models.Summary) error {
-	srv.invalidateUserCache(summary.UserID)
-	return srv.repository.InsertWithRetry(summary)
-}
-
-// Private summary generation and utility methods
-
 func (srv *SummaryService) aggregateBy(durations []*models.Duration, summaryType uint8, c chan models.SummaryItemContainer) {
 	mapping := make(map[string]time.Duration)
+	aiCounters := make(map[string]models.AICounters)
+	lineCounters := make(map[string]models.LineCounters)
 
 	for _, d := range durations {
-		mapping[d.GetKey(summaryType)] += d.Duration
+		key := d.GetKey(summaryType)
+		mapping[key] += d.Duration
+		ac := aiCounters[key]
+		ac.Add(d.AICounters)
+		aiCounters[key] = ac
+		lc := lineCounters[key]
+		lc.Add(d.LineCounters)
+		lineCounters[key] = lc
 	}
 
 	items := make([]*models.SummaryItem, 0)
 	for k, v := range mapping {
 		items = append(items, &models.SummaryItem{
-			Key:   k,
-			Total: v / time.Second,
-			Type:  summaryType,
+			Key:          k,
+			Total:        v / time.Second,
+			Type:         summaryType,
+			AICounters:   aiCounters[k],
+			LineCounters: lineCounters[k],
 		})
 	}
 
@@ -353,6 +518,8 @@ func (srv *SummaryService) withProjectLabels(summary *models.Summary) *models.Su
 				labelMap[l.Label] = newEntry(l.Label, 0)
 			}
 			labelMap[l.Label].Total += p.Total
+			labelMap[l.Label].AICounters.Add(p.AICounters)
+			labelMap[l.Label].LineCounters.Add(p.LineCounters)
 			totalLabelTime += p.Total
 		}
 	}
@@ -391,6 +558,8 @@ func (srv *SummaryService) mergeSummaries(summaries []*models.Summary) (*models.
 		Branches:         make([]*models.SummaryItem, 0),
 		Entities:         make([]*models.SummaryItem, 0),
 		Categories:       make([]*models.SummaryItem, 0),
+		AiModels:         make([]*models.SummaryItem, 0),
+		AiSessions:       make([]*models.SummaryItem, 0),
 	}
 
 	var processed = map[time.Time]*models.Summary{}
@@ -433,6 +602,7 @@ func (srv *SummaryService) mergeSummaries(summaries []*models.Summary) (*models.
 		finalSummary.Branches = srv.mergeSummaryItems(finalSummary.Branches, s.Branches)
 		finalSummary.Entities = srv.mergeSummaryItems(finalSummary.Entities, s.Entities)
 		finalSummary.Categories = srv.mergeSummaryItems(finalSummary.Categories, s.Categories)
+		finalSummary.AiModels = srv.mergeSummaryItems(finalSummary.AiModels, s.AiModels)
 		finalSummary.NumHeartbeats += s.NumHeartbeats
 
 		processed[hash] = s
@@ -457,13 +627,15 @@ func (srv *SummaryService) mergeSummaryItems(existing []*models.SummaryItem, new
 			items[item.Key] = item
 		} else {
 			(*it).Total += item.Total
+			(*it).AICounters.Add(item.AICounters)
+			(*it).LineCounters.Add(item.LineCounters)
 		}
 	}
 
 	var i int
 	itemList := make([]*models.SummaryItem, len(items))
 	for k, v := range items {
-		itemList[i] = &models.SummaryItem{Key: k, Total: v.Total, Type: v.Type}
+		itemList[i] = &models.SummaryItem{Key: k, Total: v.Total, Type: v.Type, AICounters: v.AICounters, LineCounters: v.LineCounters}
 		i++
 	}
 
@@ -551,11 +723,7 @@ func (srv *SummaryService) getHash(args ...string) string {
 }
 
 func (srv *SummaryService) invalidateUserCache(userId string) {
-	for key := range srv.cache.Items() {
-		if strings.Contains(key, userId) {
-			srv.cache.Delete(key)
-		}
-	}
+	srv.cache.DeleteMatching(userId)
 }
 
 func (srv *SummaryService) getAliasResolver(user *models.User) models.AliasResolver {
@@ -620,3 +788,24 @@ func (srv *SummaryService) getProjectLabelsReverseResolver(user *models.User) mo
 		return projectStrings
 	}
 }
+
+// dayIndex returns the index of the day (as returned by utils.SplitRangeByDays) the given time falls into, or -1 if none
+func dayIndex(days [][]time.Time, t time.Time) int {
+	i := sort.Search(len(days), func(i int) bool { return days[i][1].After(t) })
+	if i < len(days) && !t.Before(days[i][0]) {
+		return i
+	}
+	return -1
+}
+
+func summarySpanAttrs(from, to time.Time, user *models.User, filters *models.Filters) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		tracing.AttrUser.String(user.ID),
+		tracing.AttrFrom.String(from.Format(time.RFC3339)),
+		tracing.AttrTo.String(to.Format(time.RFC3339)),
+	}
+	if filters != nil && !filters.IsEmpty() {
+		attrs = append(attrs, tracing.AttrFilters.String(filters.Hash()))
+	}
+	return attrs
+}
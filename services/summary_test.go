@@ -1,11 +1,14 @@
 package services
 
 import (
+	"context"
 	"math/rand"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/duke-git/lancet/v2/datetime"
 	"github.com/leandro-lugaresi/hub"
 	"github.com/muety/wakapi/config"
 	"github.com/muety/wakapi/mocks"
@@ -130,7 +133,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Summarize() {
 	from, to = suite.TestStartTime.Add(-1*time.Hour), suite.TestStartTime.Add(-1*time.Minute)
 	suite.DurationService.On("Get", from, to, suite.TestUser, mock.Anything, mock.Anything, false).Return(filterDurations(from, to, suite.TestDurations), nil)
 
-	result, err = sut.Summarize(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Summarize(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -144,7 +147,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Summarize() {
 	from, to = suite.TestStartTime.Add(-1*time.Hour), suite.TestStartTime.Add(1*time.Second)
 	suite.DurationService.On("Get", from, to, suite.TestUser, mock.Anything, mock.Anything, false).Return(filterDurations(from, to, suite.TestDurations), nil)
 
-	result, err = sut.Summarize(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Summarize(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -158,7 +161,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Summarize() {
 	from, to = suite.TestStartTime, suite.TestStartTime.Add(1*time.Hour)
 	suite.DurationService.On("Get", from, to, suite.TestUser, mock.Anything, mock.Anything, false).Return(filterDurations(from, to, suite.TestDurations), nil)
 
-	result, err = sut.Summarize(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Summarize(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -182,6 +185,110 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Summarize() {
 	assertNumAllItems(suite.T(), 1, result, "e")
 }
 
+func (suite *SummaryServiceTestSuite) TestSummaryService_Summarize_AICounters() {
+	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
+
+	from, to := suite.TestStartTime, suite.TestStartTime.Add(1*time.Hour)
+	durations := models.Durations{
+		{
+			UserID:     TestUserId,
+			Project:    TestProject1,
+			AIModel:    TestAiModelClaude,
+			Time:       models.CustomTime(suite.TestStartTime),
+			Duration:   60 * time.Second,
+			AICounters: models.AICounters{AIInputTokens: 100, AIOutputTokens: 20, AILineChanges: 6},
+		},
+		{
+			UserID:     TestUserId,
+			Project:    TestProject2,
+			AIModel:    TestAiModelGpt,
+			Time:       models.CustomTime(suite.TestStartTime.Add(2 * time.Minute)),
+			Duration:   30 * time.Second,
+			AICounters: models.AICounters{AIInputTokens: 50, AIOutputTokens: 10, AILineChanges: 2},
+		},
+		{
+			UserID:     TestUserId,
+			Project:    TestProject1,
+			Time:       models.CustomTime(suite.TestStartTime.Add(4 * time.Minute)),
+			Duration:   30 * time.Second,
+			AICounters: models.AICounters{HumanLineChanges: 8},
+		},
+	}
+	suite.DurationService.On("Get", from, to, suite.TestUser, mock.Anything, mock.Anything, false).Return(durations, nil)
+
+	result, err := sut.Summarize(context.Background(), from, to, suite.TestUser, nil, nil)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), result.AiModels, 3)
+	assert.Empty(suite.T(), result.AiSessions) // only derived from heartbeats on demand, see WithAiSessions
+
+	project1 := result.Projects[slices.IndexFunc(result.Projects, func(item *models.SummaryItem) bool { return item.Key == TestProject1 })]
+	assert.Equal(suite.T(), 100, project1.AIInputTokens)
+	assert.Equal(suite.T(), 6, project1.AILineChanges)
+	assert.Equal(suite.T(), 8, project1.HumanLineChanges)
+
+	stats := result.AIStats()
+	assert.Equal(suite.T(), 150, stats.AIInputTokens)
+	assert.Equal(suite.T(), 30, stats.AIOutputTokens)
+	assert.Equal(suite.T(), 0.5, stats.LineChangesRatio)
+	assert.Zero(suite.T(), stats.NumSessions)
+}
+
+func (suite *SummaryServiceTestSuite) TestSummaryService_Summarize_LineCounters() {
+	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
+
+	from, to := suite.TestStartTime, suite.TestStartTime.Add(1*time.Hour)
+	durations := models.Durations{
+		{
+			UserID:       TestUserId,
+			Project:      TestProject1,
+			Language:     TestLanguageGo,
+			Branch:       TestBranchMaster,
+			Time:         models.CustomTime(suite.TestStartTime),
+			Duration:     60 * time.Second,
+			LineCounters: models.LineCounters{LineAdditions: 40, LineDeletions: 10},
+		},
+		{
+			UserID:       TestUserId,
+			Project:      TestProject1,
+			Language:     TestLanguageJava,
+			Branch:       TestBranchDev,
+			Time:         models.CustomTime(suite.TestStartTime.Add(2 * time.Minute)),
+			Duration:     30 * time.Second,
+			LineCounters: models.LineCounters{LineAdditions: 5, LineDeletions: 20},
+		},
+		{
+			UserID:   TestUserId,
+			Project:  TestProject2,
+			Language: TestLanguageGo,
+			Time:     models.CustomTime(suite.TestStartTime.Add(4 * time.Minute)),
+			Duration: 30 * time.Second,
+		},
+	}
+	suite.DurationService.On("Get", from, to, suite.TestUser, mock.Anything, mock.Anything, false).Return(durations, nil)
+
+	result, err := sut.Summarize(context.Background(), from, to, suite.TestUser, models.NewFiltersWith(models.SummaryProject, TestProject1), nil)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), models.LineCounters{LineAdditions: 45, LineDeletions: 30}, result.TotalLines())
+	assert.Equal(suite.T(), 15, result.TotalLines().NetLines())
+	assert.Equal(suite.T(), result.TotalLines(), result.TotalLinesBy(models.SummaryLanguage))
+
+	languageGo := result.Languages[slices.IndexFunc(result.Languages, func(item *models.SummaryItem) bool { return item.Key == TestLanguageGo })]
+	assert.Equal(suite.T(), 40, languageGo.LineAdditions)
+	assert.Equal(suite.T(), 10, languageGo.LineDeletions)
+
+	branchDev := result.Branches[slices.IndexFunc(result.Branches, func(item *models.SummaryItem) bool { return item.Key == TestBranchDev })]
+	assert.Equal(suite.T(), -15, branchDev.NetLines())
+
+	// branches, including their line counters, are neither persisted nor computed without a project filter
+	assert.NotContains(suite.T(), models.PersistedSummaryTypes(), models.SummaryBranch)
+	result, err = sut.Summarize(context.Background(), from, to, suite.TestUser, nil, nil)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), result.Branches)
+	assert.Equal(suite.T(), models.LineCounters{LineAdditions: 45, LineDeletions: 30}, result.TotalLinesBy(models.SummaryProject))
+}
+
 func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve() {
 	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
 
@@ -220,7 +327,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve() {
 	suite.DurationService.On("Get", from, summaries[0].FromTime.T(), suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
 	suite.DurationService.On("Get", summaries[0].ToTime.T(), to, suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
 
-	result, err = sut.Retrieve(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Retrieve(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -273,7 +380,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve() {
 	suite.SummaryRepository.On("GetByUserWithin", suite.TestUser, from, to).Return(summaries, nil)
 	suite.DurationService.On("Get", from, summaries[0].FromTime.T(), suite.TestUser, mock.Anything, mock.Anything, false).Return(filterDurations(from, summaries[0].FromTime.T(), suite.TestDurations), nil)
 
-	result, err = sut.Retrieve(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Retrieve(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -329,7 +436,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve() {
 	suite.SummaryRepository.On("GetByUserWithin", suite.TestUser, from, to).Return(summaries, nil)
 	suite.DurationService.On("Get", summaries[0].ToTime.T(), summaries[1].FromTime.T(), suite.TestUser, mock.Anything, mock.Anything, false).Return(filterDurations(summaries[0].ToTime.T(), summaries[1].FromTime.T(), suite.TestDurations), nil)
 
-	result, err = sut.Retrieve(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Retrieve(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -341,6 +448,45 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve() {
 	suite.DurationService.AssertNumberOfCalls(suite.T(), "Get", 2+1)
 }
 
+func (suite *SummaryServiceTestSuite) TestSummaryService_WithAiSessions() {
+	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
+
+	from, to := suite.TestStartTime, suite.TestStartTime.Add(24*time.Hour)
+	summary := &models.Summary{
+		UserID:   TestUserId,
+		FromTime: models.CustomTime(from),
+		ToTime:   models.CustomTime(to),
+		Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: TestProject1, Total: 90}},
+		AiModels: []*models.SummaryItem{{Type: models.SummaryAiModel, Key: TestAiModelClaude, Total: 90, AICounters: models.AICounters{AIInputTokens: 100}}},
+	}
+	heartbeats := []*models.Heartbeat{
+		{UserID: TestUserId, Project: TestProject1, AISession: "session1", Time: models.CustomTime(from.Add(time.Hour))},
+		{UserID: TestUserId, Project: TestProject1, AISession: "session1", Time: models.CustomTime(from.Add(time.Hour + time.Minute))},
+		{UserID: TestUserId, Project: TestProject1, AISession: "session2", Time: models.CustomTime(from.Add(2 * time.Hour))},
+	}
+	suite.HeartbeatService.On("GetAllWithinByFilters", from, to, suite.TestUser, mock.Anything).Return(heartbeats, nil)
+
+	result, err := sut.WithAiSessions(context.Background(), summary, suite.TestUser, nil)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), result.AiSessions, 2)
+	assert.Equal(suite.T(), "session1", result.AiSessions[0].Key)
+	assert.Equal(suite.T(), 2, result.AIStats().NumSessions)
+	assert.Empty(suite.T(), summary.AiSessions) // original summary, e.g. from cache, is left untouched
+
+	// no ai activity at all
+	result, err = sut.WithAiSessions(context.Background(), &models.Summary{FromTime: models.CustomTime(from), ToTime: models.CustomTime(to)}, suite.TestUser, nil)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), result.AiSessions)
+
+	// too long range
+	summary.FromTime = models.CustomTime(to.AddDate(0, 0, -models.MaxAiSessionDays-1))
+	result, err = sut.WithAiSessions(context.Background(), summary, suite.TestUser, nil)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), result.AiSessions)
+
+	suite.HeartbeatService.AssertNumberOfCalls(suite.T(), "GetAllWithinByFilters", 1)
+}
+
 func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve_DuplicateSummaries() {
 	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
 
@@ -380,7 +526,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve_DuplicateSumma
 	suite.DurationService.On("Get", from, summaries[0].FromTime.T(), suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
 	suite.DurationService.On("Get", summaries[0].ToTime.T(), to, suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
 
-	result, err = sut.Retrieve(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Retrieve(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -421,7 +567,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve_ZeroLengthSumm
 	suite.DurationService.On("Get", from, summaries[0].FromTime.T(), suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
 	suite.DurationService.On("Get", summaries[0].ToTime.T().Add(1*time.Second), to, suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
 
-	result, err = sut.Retrieve(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Retrieve(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -466,7 +612,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve_DateRange() {
 	suite.DurationService.On("Get", from, summaries[0].FromTime.T(), suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
 	suite.DurationService.On("Get", summaries[0].ToTime.T(), to, suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
 
-	result, err = sut.Retrieve(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Retrieve(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -492,7 +638,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Retrieve_DateRange_NoDa
 	suite.SummaryRepository.On("GetByUserWithin", suite.TestUser, from, to).Return([]*models.Summary{}, nil)
 	suite.DurationService.On("Get", from, to, suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
 
-	result, err = sut.Retrieve(from, to, suite.TestUser, nil, nil)
+	result, err = sut.Retrieve(context.Background(), from, to, suite.TestUser, nil, nil)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -535,7 +681,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Aliased() {
 	suite.AliasService.On("GetAliasOrDefault", TestUserId, mock.Anything, mock.Anything).Return("", nil)
 	suite.ProjectLabelService.On("GetByUser", suite.TestUser.ID).Return(suite.TestLabels, nil).Once()
 
-	result, err = sut.Aliased(from, to, suite.TestUser, sut.Summarize, nil, nil, false)
+	result, err = sut.Aliased(context.Background(), from, to, suite.TestUser, sut.Summarize, nil, nil, false)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -576,7 +722,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Aliased_ProjectLabels()
 	suite.AliasService.On("GetAliasOrDefault", TestUserId, mock.Anything, TestProject2).Return(TestProject1, nil)
 	suite.AliasService.On("GetAliasOrDefault", TestUserId, mock.Anything, mock.Anything).Return("", nil)
 
-	result, err = sut.Aliased(from, to, suite.TestUser, sut.Summarize, nil, nil, false)
+	result, err = sut.Aliased(context.Background(), from, to, suite.TestUser, sut.Summarize, nil, nil, false)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), result)
@@ -601,17 +747,69 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Aliased_CustomTimeout()
 
 	suite.SummaryRepository.On("GetByUserWithin", suite.TestUser, from, to).Return([]*models.Summary{testSummary}, nil)
 
-	_, err = sut.Retrieve(from, to, suite.TestUser, nil, nil)
+	_, err = sut.Retrieve(context.Background(), from, to, suite.TestUser, nil, nil)
 	assert.Nil(suite.T(), err)
 
 	suite.DurationService.On("Get", from, to, suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations([]*models.Duration{}), nil)
 
 	customTimeout := 1337 * time.Minute
-	_, err = sut.Retrieve(from, to, suite.TestUser, nil, &customTimeout)
+	_, err = sut.Retrieve(context.Background(), from, to, suite.TestUser, nil, &customTimeout)
 	assert.Nil(suite.T(), err)
 	suite.DurationService.AssertExpectations(suite.T())
 }
 
+func (suite *SummaryServiceTestSuite) TestSummaryService_Daily() {
+	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
+
+	day := datetime.BeginOfDay(suite.TestStartTime)
+	from, to := day.AddDate(0, 0, -3), day.Add(12*time.Hour)
+
+	// persisted summaries for the first and the third day, second day is missing, fourth day is not persisted, yet
+	summaries := []*models.Summary{
+		{
+			UserID:   TestUserId,
+			FromTime: models.CustomTime(from.Add(1 * time.Hour)),
+			ToTime:   models.CustomTime(from.Add(2 * time.Hour)),
+			Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: TestProject1, Total: 60 * time.Minute / time.Second}},
+		},
+		{
+			UserID:   TestUserId,
+			FromTime: models.CustomTime(from.AddDate(0, 0, 2).Add(1 * time.Hour)),
+			ToTime:   models.CustomTime(from.AddDate(0, 0, 2).Add(2 * time.Hour)),
+			Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: TestProject2, Total: 30 * time.Minute / time.Second}},
+		},
+	}
+
+	suite.SummaryRepository.On("GetByUserWithin", suite.TestUser, from, to).Return(summaries, nil)
+	suite.DurationService.On("Get", from, summaries[0].FromTime.T(), suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
+	suite.DurationService.On("Get", summaries[0].ToTime.T(), summaries[1].FromTime.T(), suite.TestUser, mock.Anything, mock.Anything, false).Return(models.Durations{}, nil)
+	suite.DurationService.On("Get", summaries[1].ToTime.T(), to, suite.TestUser, mock.Anything, mock.Anything, false).Return(filterDurations(day, to, suite.TestDurations), nil)
+	suite.AliasService.On("InitializeUser", TestUserId).Return(nil)
+	suite.AliasService.On("GetAliasOrDefault", TestUserId, mock.Anything, mock.Anything).Return("", nil)
+	suite.ProjectLabelService.On("GetByUser", suite.TestUser.ID).Return([]*models.ProjectLabel{}, nil)
+
+	result, err := sut.Daily(context.Background(), from, to, suite.TestUser, nil, nil)
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), result, 4)
+	assert.Equal(suite.T(), 60*time.Minute, result[0].TotalTime())
+	assert.Equal(suite.T(), time.Duration(0), result[1].TotalTime())
+	assert.Equal(suite.T(), from.AddDate(0, 0, 1), result[1].FromTime.T())
+	assert.Equal(suite.T(), 30*time.Minute, result[2].TotalTime())
+	assert.Equal(suite.T(), 185*time.Second, result[3].TotalTime())
+	assert.Equal(suite.T(), 6, result[3].NumHeartbeats)
+	assert.NotNil(suite.T(), result[3].Labels) // post-processed like aliased summaries
+	assert.Nil(suite.T(), result[3].Branches)
+	suite.SummaryRepository.AssertNumberOfCalls(suite.T(), "GetByUserWithin", 1)
+	suite.DurationService.AssertNumberOfCalls(suite.T(), "Get", 3)
+
+	// served from cache
+	result, err = sut.Daily(context.Background(), from, to, suite.TestUser, nil, nil)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), result, 4)
+	suite.SummaryRepository.AssertNumberOfCalls(suite.T(), "GetByUserWithin", 1)
+}
+
 func (suite *SummaryServiceTestSuite) TestSummaryService_Filters() {
 	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
 
@@ -648,7 +846,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Filters() {
 
 	// first request: project details with filtering by project and label
 	// when requesting project details, don't ignore data from other projects even though they'd match the filter's label (see https://github.com/muety/wakapi/issues/883)
-	result1, _ := sut.Aliased(from, to, suite.TestUser, sut.Summarize, filtersWithProject, nil, false)
+	result1, _ := sut.Aliased(context.Background(), from, to, suite.TestUser, sut.Summarize, filtersWithProject, nil, false)
 	effectiveFilters1 := suite.DurationService.Calls[0].Arguments[3].(*models.Filters)
 	assert.NotNil(suite.T(), result1.Branches) // project filters were applied -> include branches
 	assert.NotNil(suite.T(), result1.Entities) // project filters were applied -> include entities
@@ -658,7 +856,7 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Filters() {
 	assert.Contains(suite.T(), effectiveFilters1.Label, TestProjectLabel1)
 
 	// second request: summary with filtering by label
-	result2, _ := sut.Aliased(from, to, suite.TestUser, sut.Summarize, filtersWithoutProject, nil, false)
+	result2, _ := sut.Aliased(context.Background(), from, to, suite.TestUser, sut.Summarize, filtersWithoutProject, nil, false)
 	effectiveFilters2 := suite.DurationService.Calls[1].Arguments[3].(*models.Filters)
 	assert.NotNil(suite.T(), result2.Branches) // project filters were applied -> include branches
 	assert.NotNil(suite.T(), result2.Entities) // project filters were applied -> include entities
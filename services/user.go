@@ -13,10 +13,10 @@ import (
 	"github.com/duke-git/lancet/v2/datetime"
 	"github.com/duke-git/lancet/v2/validator"
 	"github.com/leandro-lugaresi/hub"
-	"github.com/patrickmn/go-cache"
 	"gorm.io/gorm"
 
 	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/models"
 	"github.com/muety/wakapi/repositories"
 	"github.com/muety/wakapi/utils"
@@ -24,28 +24,30 @@ import (
 
 type UserService struct {
 	config              *config.Config
-	cache               *cache.Cache
-	subjectCache        *cache.Cache
+	cache               cache.Cache
+	subjectCache        cache.Cache
 	eventBus            *hub.Hub
 	keyValueService     IKeyValueService
 	mailService         IMailService
 	apiKeyService       IApiKeyService
+	sessionService      ISessionService
 	repository          repositories.IUserRepository
-	currentOnlineUsers  *cache.Cache
+	currentOnlineUsers  cache.Cache
 	countersInitialized atomic.Bool
 }
 
-func NewUserService(keyValueService IKeyValueService, mailService IMailService, apiKeyService IApiKeyService, userRepo repositories.IUserRepository) *UserService {
+func NewUserService(keyValueService IKeyValueService, mailService IMailService, apiKeyService IApiKeyService, sessionService ISessionService, userRepo repositories.IUserRepository) *UserService {
 	srv := &UserService{
 		config:             config.Get(),
 		eventBus:           config.EventBus(),
-		cache:              cache.New(1*time.Hour, 2*time.Hour),
-		subjectCache:       cache.New(1*time.Hour, 2*time.Hour),
+		cache:              cache.New("users", 1*time.Hour, 2*time.Hour),
+		subjectCache:       cache.New("user_subjects", 1*time.Hour, 2*time.Hour),
 		keyValueService:    keyValueService,
 		apiKeyService:      apiKeyService,
+		sessionService:     sessionService,
 		mailService:        mailService,
 		repository:         userRepo,
-		currentOnlineUsers: cache.New(models.DefaultHeartbeatsTimeout, 1*time.Minute),
+		currentOnlineUsers: cache.NewMemoryCache(models.DefaultHeartbeatsTimeout, 1*time.Minute),
 	}
 
 	sub1 := srv.eventBus.Subscribe(0, config.EventWakatimeFailure)
@@ -70,17 +72,42 @@ func NewUserService(keyValueService IKeyValueService, mailService IMailService,
 		}
 	}(&sub1)
 
-	sub2 := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate)
+	sub2 := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate, config.EventHeartbeatsChanged)
 	go func(sub *hub.Subscription) {
 		for m := range sub.Receiver {
-			heartbeat := m.Fields[config.FieldPayload].(*models.Heartbeat)
-			if time.Now().Sub(heartbeat.Time.T()) > models.DefaultHeartbeatsTimeout {
+			heartbeat, ok := m.Fields[config.FieldPayload].(*models.Heartbeat)
+			if !ok || time.Now().Sub(heartbeat.Time.T()) > models.DefaultHeartbeatsTimeout {
 				continue
 			}
 			srv.currentOnlineUsers.SetDefault(heartbeat.UserID, true)
 		}
 	}(&sub2)
 
+	sub3 := srv.eventBus.Subscribe(0, config.EventUserUpdate, config.EventUserDelete)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			// user was modified on another instance
+			if !config.IsRemoteEvent(m) {
+				continue
+			}
+			if user, ok := m.Fields[config.FieldPayload].(*models.User); ok {
+				srv.FlushUserCache(user.ID)
+			}
+		}
+	}(&sub3)
+
+	sub4 := srv.eventBus.Subscribe(0, config.EventApiKeyDelete)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			if config.IsRemoteEvent(m) {
+				continue // shared cache entries were flushed by the originating instance already
+			}
+			if apiKey, ok := m.Fields[config.FieldPayload].(*models.ApiKey); ok {
+				srv.flushApiKeyCache(apiKey.ApiKey)
+			}
+		}
+	}(&sub4)
+
 	return srv
 }
 
@@ -108,20 +135,25 @@ func (srv *UserService) GetUserByKey(key string, requireFullAccessKey bool) (*mo
 		return nil, errors.New("key must not be empty")
 	}
 
-	cacheKey := fmt.Sprintf("key_%s_%t", key, requireFullAccessKey)
-	if u, ok := srv.cache.Get(cacheKey); ok {
-		return u.(*models.User), nil
+	// keys only map to user ids, so that flushing a user's cache entries only requires to drop id_<id>
+	cacheKey := apiKeyCacheKey(key, requireFullAccessKey)
+	if userId, ok := srv.cache.Get(cacheKey); ok {
+		if u, err := srv.GetUserById(userId.(string)); err == nil {
+			return u, nil
+		}
+		srv.cache.Delete(cacheKey)
 	}
 
 	u, err := srv.repository.FindOne(models.User{ApiKey: key})
 	if err == nil {
-		srv.cache.SetDefault(cacheKey, u)
+		srv.cache.SetDefault(fmt.Sprintf("id_%s", u.ID), u)
+		srv.cache.SetDefault(cacheKey, u.ID)
 		return u, nil
 	}
 
 	apiKey, err := srv.apiKeyService.GetByApiKey(key, requireFullAccessKey)
 	if err == nil {
-		srv.cache.SetDefault(cacheKey, apiKey.User)
+		srv.cache.SetDefault(cacheKey, apiKey.User.ID)
 		return apiKey.User, nil
 	}
 
@@ -333,6 +365,7 @@ func (srv *UserService) ChangeUserId(user *models.User, newUserId string) (*mode
 
 func (srv *UserService) ResetApiKey(user *models.User) (*models.User, error) {
 	srv.FlushUserCache(user.ID)
+	srv.flushApiKeyCache(user.ApiKey)
 	user.ApiKey = uuid.NewV4().String()
 	return srv.Update(user)
 }
@@ -361,6 +394,24 @@ func (srv *UserService) GenerateUnsubscribeToken(user *models.User) (*models.Use
 	return srv.repository.UpdateField(user, "unsubscribe_token", uuid.NewV4().String())
 }
 
+// GetUserBySession resolves the user behind a still valid (not expired, not revoked) login session
+func (srv *UserService) GetUserBySession(sessionId string) (*models.User, *models.Session, error) {
+	session, err := srv.sessionService.GetById(sessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := srv.GetUserById(session.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := srv.sessionService.Touch(session); err != nil {
+		config.Log().Error("failed to update session", "userID", user.ID, "error", err)
+	}
+	return user, session, nil
+}
+
 func (srv *UserService) Delete(user *models.User) error {
 	srv.FlushUserCache(user.ID)
 
@@ -393,11 +444,11 @@ func (srv *UserService) FlushCache() {
 func (srv *UserService) FlushUserCache(userId string) {
 	srv.cache.Delete(fmt.Sprintf("id_%s", userId))
 	srv.cache.Delete(userId)
-	for k, item := range srv.cache.Items() {
-		if u, ok := item.Object.(*models.User); ok && u.ID == userId {
-			srv.cache.Delete(k)
-		}
-	}
+}
+
+func (srv *UserService) flushApiKeyCache(key string) {
+	srv.cache.Delete(apiKeyCacheKey(key, true))
+	srv.cache.Delete(apiKeyCacheKey(key, false))
 }
 
 func (srv *UserService) notifyUpdate(user *models.User) {
@@ -421,3 +472,7 @@ func (srv *UserService) checkUpdateCascade() bool {
 	}
 	return true
 }
+
+func apiKeyCacheKey(key string, requireFullAccessKey bool) string {
+	return fmt.Sprintf("key_%s_%t", key, requireFullAccessKey)
+}
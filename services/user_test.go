@@ -3,10 +3,13 @@ package services
 import (
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/leandro-lugaresi/hub"
+	"github.com/muety/wakapi/config"
+	"github.com/muety/wakapi/lib/cache"
 	"github.com/muety/wakapi/mocks"
 	"github.com/muety/wakapi/models"
-	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -21,6 +24,7 @@ type UserServiceTestSuite struct {
 	KeyValueService *mocks.KeyValueServiceMock
 	MailService     *mocks.MailServiceMock
 	ApiKeyService   *mocks.MockApiKeyService
+	SessionService  *mocks.SessionServiceMock
 	UserRepo        *mocks.UserRepositoryMock
 }
 
@@ -32,6 +36,7 @@ func (suite *UserServiceTestSuite) BeforeTest(suiteName, testName string) {
 	suite.KeyValueService = new(mocks.KeyValueServiceMock)
 	suite.MailService = new(mocks.MailServiceMock)
 	suite.ApiKeyService = new(mocks.MockApiKeyService)
+	suite.SessionService = new(mocks.SessionServiceMock)
 	suite.UserRepo = new(mocks.UserRepositoryMock)
 }
 
@@ -40,7 +45,7 @@ func TestUserServiceTestSuite(t *testing.T) {
 }
 
 func (suite *UserServiceTestSuite) TestUserService_GetByEmail_Empty() {
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 
 	result, err := sut.GetUserByEmail("")
 
@@ -50,7 +55,7 @@ func (suite *UserServiceTestSuite) TestUserService_GetByEmail_Empty() {
 }
 
 func (suite *UserServiceTestSuite) TestUserService_GetByEmail_Invalid() {
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 
 	result, err := sut.GetUserByEmail("notanemailaddress")
 
@@ -64,7 +69,7 @@ func (suite *UserServiceTestSuite) TestUserService_GetByEmail_Valid() {
 
 	suite.UserRepo.On("FindOne", models.User{Email: testEmail}).Return(suite.TestUser, nil)
 
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 	result, err := sut.GetUserByEmail(testEmail)
 
 	suite.Equal(suite.TestUser, result)
@@ -72,7 +77,7 @@ func (suite *UserServiceTestSuite) TestUserService_GetByEmail_Valid() {
 }
 
 func (suite *UserServiceTestSuite) TestUserService_GetByEmptyKey_Failed() {
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 
 	result, err := sut.GetUserByKey("", false)
 
@@ -84,21 +89,22 @@ func (suite *UserServiceTestSuite) TestUserService_GetByEmptyKey_Failed() {
 func (suite *UserServiceTestSuite) TestUserService_GetByKeyFromCache_Success() {
 	userCached := &models.User{ID: TestUserID, ApiKey: "cached-key"}
 
-	userCache := cache.New(cache.NoExpiration, cache.NoExpiration)
-	userCache.SetDefault("key_"+TestAPIKey+"_false", userCached)
+	userCache := cache.NewMemoryCache(cache.NoExpiration, cache.NoExpiration)
+	userCache.SetDefault("key_"+TestAPIKey+"_false", TestUserID)
+	userCache.SetDefault("id_"+TestUserID, userCached)
 
 	sut := &UserService{cache: userCache}
 
 	result, err := sut.GetUserByKey(TestAPIKey, false)
 	suite.Nil(err)
 	suite.NotNil(result)
-	suite.Equal(1, userCache.ItemCount())
+	suite.Equal(2, userCache.ItemCount())
 	suite.Equal(userCached, result)
 	suite.Equal(userCached.ApiKey, result.ApiKey)
 }
 
 func (suite *UserServiceTestSuite) TestUserService_GetByKeyFromUserModel_Success() {
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 
 	suite.UserRepo.On("FindOne", models.User{ApiKey: TestAPIKey}).Return(suite.TestUser, nil)
 
@@ -110,7 +116,7 @@ func (suite *UserServiceTestSuite) TestUserService_GetByKeyFromUserModel_Success
 }
 
 func (suite *UserServiceTestSuite) TestUserService_GetByKeyFromAdditionalApiKeys_Success() {
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 
 	suite.UserRepo.On("FindOne", models.User{ApiKey: TestAPIKey}).Return(nil, errors.New("not found"))
 	suite.ApiKeyService.On("GetByApiKey", TestAPIKey, true).Return(&models.ApiKey{User: suite.TestUser}, nil)
@@ -123,7 +129,7 @@ func (suite *UserServiceTestSuite) TestUserService_GetByKeyFromAdditionalApiKeys
 }
 
 func (suite *UserServiceTestSuite) TestUserService_GetByKeyFromAdditionalApiKeys_Failed() {
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 
 	suite.UserRepo.On("FindOne", models.User{ApiKey: TestAPIKey}).Return(nil, errors.New("not found"))
 	suite.ApiKeyService.On("GetByApiKey", TestAPIKey, true).Return(nil, errors.New("not found"))
@@ -135,7 +141,7 @@ func (suite *UserServiceTestSuite) TestUserService_GetByKeyFromAdditionalApiKeys
 }
 
 func (suite *UserServiceTestSuite) TestUserService_GetUserByKey_DoesNotHitUserByIdCache() {
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 
 	suite.UserRepo.On("FindOne", models.User{ID: TestUserID}).Return(suite.TestUser, nil)
 	suite.UserRepo.On("FindOne", models.User{ApiKey: TestUserID}).Return(nil, errors.New("user not found"))
@@ -153,7 +159,7 @@ func (suite *UserServiceTestSuite) TestUserService_GetUserByKey_DoesNotHitUserBy
 }
 
 func (suite *UserServiceTestSuite) TestUserService_GetUserByKey_DoesNotCacheUnderUsername() {
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 
 	suite.UserRepo.On("FindOne", models.User{ApiKey: TestAPIKey}).Return(suite.TestUser, nil)
 	suite.UserRepo.On("FindOne", models.User{ApiKey: TestUserID}).Return(nil, errors.New("user not found"))
@@ -171,7 +177,7 @@ func (suite *UserServiceTestSuite) TestUserService_GetUserByKey_DoesNotCacheUnde
 }
 
 func (suite *UserServiceTestSuite) TestUserService_GetUserByKey_ReadOnlyKeyCacheDoesNotGrantFullAccess() {
-	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.UserRepo)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
 
 	suite.UserRepo.On("FindOne", models.User{ApiKey: TestAPIKey}).Return(nil, errors.New("not primary key"))
 	suite.ApiKeyService.On("GetByApiKey", TestAPIKey, false).Return(&models.ApiKey{User: suite.TestUser, ReadOnly: true}, nil)
@@ -187,3 +193,69 @@ func (suite *UserServiceTestSuite) TestUserService_GetUserByKey_ReadOnlyKeyCache
 	suite.Nil(userFullAccess)
 	suite.NotNil(err)
 }
+
+func (suite *UserServiceTestSuite) TestUserService_FlushUserCache_EvictsKeyLookups() {
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
+
+	userUpdated := &models.User{ID: TestUserID, ApiKey: TestAPIKey, Email: "foo@bar.com"}
+	suite.UserRepo.On("FindOne", models.User{ApiKey: TestAPIKey}).Return(suite.TestUser, nil).Once()
+	suite.UserRepo.On("FindOne", models.User{ID: TestUserID}).Return(userUpdated, nil).Once()
+
+	result, err := sut.GetUserByKey(TestAPIKey, false)
+	suite.Nil(err)
+	suite.Equal(suite.TestUser, result)
+
+	sut.FlushUserCache(TestUserID)
+
+	result, err = sut.GetUserByKey(TestAPIKey, false)
+	suite.Nil(err)
+	suite.Equal(userUpdated, result)
+	suite.UserRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserServiceTestSuite) TestUserService_ResetApiKey_EvictsOldKey() {
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
+
+	user := &models.User{ID: TestUserID, ApiKey: TestAPIKey}
+	suite.UserRepo.On("FindOne", models.User{ApiKey: TestAPIKey}).Return(user, nil).Once()
+	suite.UserRepo.On("Update", user).Return(user, nil)
+
+	_, err := sut.GetUserByKey(TestAPIKey, false)
+	suite.Nil(err)
+
+	_, err = sut.ResetApiKey(user)
+	suite.Nil(err)
+	suite.NotEqual(TestAPIKey, user.ApiKey)
+
+	suite.UserRepo.On("FindOne", models.User{ApiKey: TestAPIKey}).Return(nil, errors.New("not found")).Once()
+	suite.ApiKeyService.On("GetByApiKey", TestAPIKey, false).Return(nil, errors.New("not found"))
+
+	result, err := sut.GetUserByKey(TestAPIKey, false)
+	suite.Nil(result)
+	suite.NotNil(err)
+}
+
+func (suite *UserServiceTestSuite) TestUserService_ApiKeyDelete_IgnoresRemoteEvents() {
+	originalEventBus := config.EventBus()
+	eventBus := hub.New()
+	config.SetEventBus(eventBus)
+	sut := NewUserService(suite.KeyValueService, suite.MailService, suite.ApiKeyService, suite.SessionService, suite.UserRepo)
+	config.SetEventBus(originalEventBus)
+
+	suite.UserRepo.On("FindOne", models.User{ApiKey: TestAPIKey}).Return(suite.TestUser, nil).Once()
+	_, err := sut.GetUserByKey(TestAPIKey, false)
+	suite.Nil(err)
+
+	// relayed events come without the api key itself
+	eventBus.Publish(hub.Message{Name: config.EventApiKeyDelete, Fields: map[string]interface{}{config.FieldUserId: TestUserID, config.FieldRemote: true}})
+	eventBus.Publish(hub.Message{Name: config.EventApiKeyDelete, Fields: map[string]interface{}{config.FieldPayload: &models.ApiKey{ApiKey: TestAPIKey}, config.FieldUserId: TestUserID, config.FieldRemote: true}})
+	time.Sleep(50 * time.Millisecond)
+	_, found := sut.cache.Get(apiKeyCacheKey(TestAPIKey, false))
+	suite.True(found)
+
+	eventBus.Publish(hub.Message{Name: config.EventApiKeyDelete, Fields: map[string]interface{}{config.FieldPayload: &models.ApiKey{ApiKey: TestAPIKey}, config.FieldUserId: TestUserID}})
+	suite.Eventually(func() bool {
+		_, found := sut.cache.Get(apiKeyCacheKey(TestAPIKey, false))
+		return !found
+	}, time.Second, 10*time.Millisecond)
+}
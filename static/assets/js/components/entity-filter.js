@@ -5,23 +5,34 @@ function EntityFilter({type, options, selection}) {
         type: type,
         options: options,
         selection: selection,
+        negated: false,  // whether to show everything except the selection
         display() {
             return this.type.capitalize()
         },
         onSelectionUpdated(e) {
             this.selection = e.target.value == 'null' ? null : e.target.value
-            this.$nextTick(() => {
-                const query = new URLSearchParams(window.location.search)
-                const val = this.selection === 'unknown' ? '-' : this.selection  // will break if the project is actually named "unknown"
-                if (this.selection) query.set(type, val)
-                else query.delete(type)
-                window.location.search = query.toString()
-            })
+            this.$nextTick(() => this.applySelection())
+        },
+        onNegationUpdated(e) {
+            this.negated = e.target.value === 'true'
+            if (this.selection) this.$nextTick(() => this.applySelection())
+        },
+        applySelection() {
+            const query = new URLSearchParams(window.location.search)
+            const val = this.selection === 'unknown' ? '-' : this.selection  // will break if the project is actually named "unknown"
+            if (this.selection) query.set(type, (this.negated ? '!' : '') + val)
+            else query.delete(type)
+            window.location.search = query.toString()
         },
         mounted() {
             const query = new URLSearchParams(window.location.search)
             if (query.has(type)) {
-                const val = query.get(type) === '-' ? 'unknown' : query.get(type)
+                let raw = query.get(type)
+                if (raw.startsWith('!')) {
+                    this.negated = true
+                    raw = raw.substring(1)
+                }
+                const val = raw === '-' ? 'unknown' : raw
                 if (!this.options.includes(val)) {
                     this.options = [val, ...this.options]
                     this.$nextTick(() => { this.selection = val })
@@ -6,6 +6,28 @@ PetiteVue.createApp({
         localStorage.getItem("wakapi_vibrant_colors"),
     ) || false,
     labels: {},
+    badge: {
+        metric: "total",
+        interval: "30_days",
+        filter: "",
+        language: "",
+        label: "",
+        color: "",
+        labelColor: "",
+        style: "flat",
+    },
+    get badgeUrl() {
+        const baseUrl = location.href.substring(0, location.href.lastIndexOf("/"));
+        const params = new URLSearchParams();
+        if (!["streak", "last_active"].includes(this.badge.metric)) params.set("interval", this.badge.interval);
+        if (this.badge.filter) params.set("filter", this.badge.filter);
+        if (this.badge.metric === "language_share" && this.badge.language) params.set("language", this.badge.language);
+        if (this.badge.label) params.set("label", this.badge.label);
+        if (this.badge.color) params.set("color", this.badge.color.replace("#", ""));
+        if (this.badge.labelColor) params.set("label_color", this.badge.labelColor.replace("#", ""));
+        if (this.badge.style !== "flat") params.set("style", this.badge.style);
+        return `${baseUrl}/api/badge/${userId}/metric/${this.badge.metric}?${params.toString()}`;
+    },
     get tzOptions() {
         return [
             defaultTzOption,
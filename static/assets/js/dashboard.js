@@ -0,0 +1,114 @@
+const OTHER_KEY = 'other'
+const OTHER_COLOR = '#6b7280'
+
+Chart.defaults.font.family = 'Source Sans 3, Roboto, Helvetica Neue, Arial, sens-serif'
+
+function formatDuration(seconds) {
+    const hours = Math.floor(seconds / 3600)
+    const minutes = Math.floor((seconds - (hours * 3600)) / 60)
+    return `${hours}h ${minutes.toString().padStart(2, '0')}m`
+}
+
+function getColor(seed, index) {
+    if (seed === OTHER_KEY) return OTHER_COLOR
+    if (index < baseColors.length) return baseColors[(index + 5) % baseColors.length]
+    Math.seedrandom(seed || '1234567')
+    const letters = '0123456789ABCDEF'.split('')
+    let color = '#'
+    for (let i = 0; i < 6; i++) {
+        color += letters[Math.floor(Math.random() * 16)]
+    }
+    return color
+}
+
+function drawBreakdown(canvas, widget) {
+    const isPie = widget.chart_type === 'pie'
+    const values = widget.series[0].values
+
+    new Chart(canvas.getContext('2d'), {
+        type: isPie ? 'pie' : 'bar',
+        data: {
+            labels: widget.labels,
+            datasets: [{
+                data: values,
+                backgroundColor: widget.labels.map((label, i) => getColor(label, i)),
+                barPercentage: 0.9
+            }]
+        },
+        options: {
+            indexAxis: 'y',
+            responsive: true,
+            maintainAspectRatio: false,
+            scales: isPie ? {} : {
+                x: {
+                    ticks: {
+                        callback: value => formatDuration(value)
+                    }
+                }
+            },
+            plugins: {
+                tooltip: {
+                    callbacks: {
+                        label: (context) => formatDuration(context.raw)
+                    }
+                },
+                legend: {
+                    display: isPie,
+                    position: 'right'
+                }
+            }
+        }
+    })
+}
+
+function drawTimeline(canvas, widget) {
+    new Chart(canvas.getContext('2d'), {
+        type: 'bar',
+        data: {
+            labels: widget.labels,
+            datasets: widget.series.map((series, i) => ({
+                label: series.key,
+                data: series.values,
+                backgroundColor: getColor(series.key, i)
+            }))
+        },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            scales: {
+                x: {
+                    stacked: true
+                },
+                y: {
+                    stacked: true,
+                    ticks: {
+                        callback: value => formatDuration(value)
+                    }
+                }
+            },
+            plugins: {
+                tooltip: {
+                    callbacks: {
+                        label: (context) => `${context.dataset.label}: ${formatDuration(context.raw)}`
+                    }
+                },
+                legend: {
+                    position: 'bottom'
+                }
+            }
+        }
+    })
+}
+
+window.addEventListener('load', function () {
+    wakapiData.widgets.forEach(widget => {
+        const canvas = document.getElementById(`chart-widget-${widget.widget_id}`)
+        if (!canvas || !widget.series) return
+
+        if (widget.chart_type === 'timeline') {
+            drawTimeline(canvas, widget)
+        } else {
+            drawBreakdown(canvas, widget)
+        }
+    })
+})
@@ -0,0 +1,52 @@
+const weeklyCanvas = document.getElementById('chart-project-weekly')
+const dailyCanvas = document.getElementById('chart-project-daily')
+
+const ACCENT_COLOR = '#047857'
+
+function formatDuration(seconds) {
+    const hours = Math.floor(seconds / 3600)
+    const minutes = Math.floor((seconds - (hours * 3600)) / 60)
+    return `${hours}h ${minutes.toString().padStart(2, '0')}m`
+}
+
+function drawTotals(canvas, items) {
+    if (!canvas || !items) return
+
+    new Chart(canvas.getContext('2d'), {
+        type: 'bar',
+        data: {
+            labels: items.map(item => item.date),
+            datasets: [{
+                data: items.map(item => item.total),
+                backgroundColor: ACCENT_COLOR,
+                barPercentage: 0.9
+            }]
+        },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            scales: {
+                y: {
+                    ticks: {
+                        callback: value => formatDuration(value)
+                    }
+                }
+            },
+            plugins: {
+                tooltip: {
+                    callbacks: {
+                        label: (context) => formatDuration(context.raw)
+                    }
+                },
+                legend: {
+                    display: false
+                }
+            }
+        }
+    })
+}
+
+window.addEventListener('load', function () {
+    drawTotals(weeklyCanvas, wakapiData.weeks)
+    drawTotals(dailyCanvas, wakapiData.days)
+})
@@ -653,6 +653,112 @@ function draw(subselection) {
     charts[10] = hourlyBreakdownChart ? hourlyBreakdownChart : charts[10]
 }
 
+function drawAiTokens() {
+    const aiTokensCanvas = document.getElementById('chart-ai-tokens')
+    if (!aiTokensCanvas || !wakapiData.timelineStats) return
+
+    new Chart(aiTokensCanvas.getContext('2d'), {
+        type: 'bar',
+        data: {
+            labels: wakapiData.timelineStats.map(day => new Date(day.date).toLocaleDateString()),
+            datasets: [
+                {
+                    label: 'Input',
+                    data: wakapiData.timelineStats.map(day => day.ai_input_tokens),
+                    backgroundColor: getColor('input', 0),
+                    barPercentage: 1.0
+                },
+                {
+                    label: 'Output',
+                    data: wakapiData.timelineStats.map(day => day.ai_output_tokens),
+                    backgroundColor: getColor('output', 1),
+                    barPercentage: 1.0
+                }
+            ]
+        },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            scales: {
+                x: {
+                    stacked: true,
+                    title: {
+                        display: true,
+                        text: 'Date'
+                    }
+                },
+                y: {
+                    stacked: true,
+                    title: {
+                        display: true,
+                        text: 'Tokens'
+                    }
+                }
+            },
+            plugins: {
+                legend: {
+                    position: 'right'
+                }
+            }
+        }
+    })
+}
+
+function drawComparison() {
+    const comparisonCanvas = document.getElementById('chart-comparison')
+    if (!comparisonCanvas || !wakapiData.comparison) return
+
+    const items = (wakapiData.branches.length ? wakapiData.comparison.branches : wakapiData.comparison.projects).slice(0, 10)
+
+    new Chart(comparisonCanvas.getContext('2d'), {
+        type: 'bar',
+        data: {
+            labels: items.map(item => item.key),
+            datasets: [
+                {
+                    label: 'Previous',
+                    data: items.map(item => item.previous_total),
+                    backgroundColor: getColor('previous', 1),
+                    barPercentage: 0.9
+                },
+                {
+                    label: 'Current',
+                    data: items.map(item => item.total),
+                    backgroundColor: getColor('current', 0),
+                    barPercentage: 0.9
+                }
+            ]
+        },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            scales: {
+                y: {
+                    title: {
+                        display: true,
+                        text: 'Duration (hh:mm:ss)'
+                    },
+                    ticks: {
+                        callback: value => value.toString().toHHMMSS()
+                    }
+                }
+            },
+            plugins: {
+                tooltip: {
+                    callbacks: {
+                        label: (context) => {
+                            return `${context.dataset.label}: ${context.raw.toString().toHHMMSS()}`
+                        }
+                    }
+                },
+                legend: {
+                    position: 'right'
+                }
+            }
+        }
+    })
+}
+
 function parseTopN() {
     showTopN = topNPickers.map(e => parseInt(e.value))
 }
@@ -713,6 +819,19 @@ function swapCharts(showEntity, hideEntity) {
     document.getElementById(`${hideEntity}-container`).parentElement.classList.add('hidden')
 }
 
+function selectFilterPreset(slug) {
+    // replace all current filters by the preset, keeping the selected time range, unless the preset comes with its own interval
+    const query = new URLSearchParams(window.location.search)
+    const preset = wakapiData.filterPresets.find(p => p.slug === slug)
+    const filterParams = ['project', 'language', 'editor', 'operating_system', 'machine', 'label', 'branch', 'entity', 'category', 'preset']
+    filterParams.forEach(p => query.delete(p))
+    if (preset) {
+        query.set('preset', preset.slug)
+        if (preset.interval) ['interval', 'from', 'to'].forEach(p => query.delete(p))
+    }
+    window.location.search = query.toString()
+}
+
 function extractFile(filePath) {
     const delimiter = filePath.includes('\\') ? '\\' : '/'  // windows style path?
     return filePath.split(delimiter).at(-1)
@@ -736,5 +855,7 @@ window.addEventListener('load', function () {
     parseTopN()
     togglePlaceholders(getPresentDataMask())
     draw()
+    drawAiTokens()
+    drawComparison()
     updateNumTotal()
 })
\ No newline at end of file
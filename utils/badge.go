@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"sync"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/narqo/go-badge"
+	"github.com/narqo/go-badge/fonts"
+	"golang.org/x/image/font"
+)
+
+// Native badge renderer, loosely based on github.com/narqo/go-badge, which only supports shields.io's "flat" style.
+// Text widths are measured with Vera Sans, which is metrically close to the Verdana font used by shields.io.
+
+const (
+	BadgeStyleFlat        = "flat"
+	BadgeStylePlastic     = "plastic"
+	BadgeStyleForTheBadge = "for-the-badge"
+
+	badgeFontSize      = 11
+	badgeFontSizeLarge = 10
+	badgeDpi           = 72
+	badgeExtraDx       = 13
+	badgeExtraDxLarge  = 24
+	badgeDefaultLabel  = "#555"
+	badgeDefaultColor  = "#4c1"
+)
+
+type badgeBounds struct {
+	LabelDx   float64
+	LabelX    float64
+	MessageDx float64
+	MessageX  float64
+}
+
+func (b badgeBounds) Dx() float64 {
+	return b.LabelDx + b.MessageDx
+}
+
+type badgeTplData struct {
+	Label      string
+	Message    string
+	Color      string
+	LabelColor string
+	Bounds     badgeBounds
+}
+
+var (
+	badgeFontDrawer *font.Drawer
+	badgeFontMutex  = &sync.Mutex{}
+	badgeTemplates  map[string]*template.Template
+)
+
+func init() {
+	ttf, err := truetype.Parse(fonts.VeraSans)
+	if err != nil {
+		panic(err)
+	}
+	badgeFontDrawer = &font.Drawer{
+		Face: truetype.NewFace(ttf, &truetype.Options{
+			Size:    badgeFontSize,
+			DPI:     badgeDpi,
+			Hinting: font.HintingFull,
+		}),
+	}
+	badgeTemplates = map[string]*template.Template{
+		BadgeStyleFlat:        template.Must(template.New(BadgeStyleFlat).Parse(stripBadgeWhitespace(badgeFlatTemplate))),
+		BadgeStylePlastic:     template.Must(template.New(BadgeStylePlastic).Parse(stripBadgeWhitespace(badgePlasticTemplate))),
+		BadgeStyleForTheBadge: template.Must(template.New(BadgeStyleForTheBadge).Parse(stripBadgeWhitespace(badgeForTheBadgeTemplate))),
+	}
+}
+
+// IsValidBadgeStyle returns whether the given style is supported by RenderBadge
+func IsValidBadgeStyle(style string) bool {
+	_, ok := badgeTemplates[style]
+	return ok
+}
+
+// ResolveBadgeColor translates a shields.io-style color (either a named color like "brightgreen" or a hex code with or without leading hash) into a css color
+func ResolveBadgeColor(color, fallback string) string {
+	if color == "" {
+		return fallback
+	}
+	if c, ok := badge.ColorScheme[color]; ok {
+		return c
+	}
+	if !strings.HasPrefix(color, "#") {
+		return "#" + color
+	}
+	return color
+}
+
+// RenderBadge renders a shields.io-like svg badge in the given style (one of flat, plastic or for-the-badge, defaults to flat)
+func RenderBadge(label, message, color, labelColor, style string) ([]byte, error) {
+	tpl, ok := badgeTemplates[style]
+	if !ok {
+		style = BadgeStyleFlat
+		tpl = badgeTemplates[style]
+	}
+
+	if style == BadgeStyleForTheBadge {
+		label, message = strings.ToUpper(label), strings.ToUpper(message)
+	}
+
+	labelDx, messageDx := measureBadgeString(label, style), measureBadgeString(message, style)
+	if label == "" {
+		labelDx = 0
+	}
+
+	data := &badgeTplData{
+		Label:      label,
+		Message:    message,
+		Color:      ResolveBadgeColor(color, badgeDefaultColor),
+		LabelColor: ResolveBadgeColor(labelColor, badgeDefaultLabel),
+		Bounds: badgeBounds{
+			LabelDx:   labelDx,
+			LabelX:    labelDx/2.0 + 1,
+			MessageDx: messageDx,
+			MessageX:  labelDx + messageDx/2.0 - 1,
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func measureBadgeString(s, style string) float64 {
+	badgeFontMutex.Lock()
+	defer badgeFontMutex.Unlock()
+
+	width := float64(badgeFontDrawer.MeasureString(s) >> 6)
+	if style == BadgeStyleForTheBadge {
+		// for-the-badge uses a smaller, but letter-spaced font and more padding
+		return width*badgeFontSizeLarge/badgeFontSize + float64(len([]rune(s))) + badgeExtraDxLarge
+	}
+	return width + badgeExtraDx
+}
+
+func stripBadgeWhitespace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.Join(lines, "")
+}
+
+var badgeFlatTemplate = `
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Bounds.Dx}}" height="20">
+  <linearGradient id="smooth" x2="0" y2="100%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <mask id="round">
+    <rect width="{{.Bounds.Dx}}" height="20" rx="3" fill="#fff"/>
+  </mask>
+  <g mask="url(#round)">
+    <rect width="{{.Bounds.LabelDx}}" height="20" fill="{{.LabelColor}}"/>
+    <rect x="{{.Bounds.LabelDx}}" width="{{.Bounds.MessageDx}}" height="20" fill="{{.Color}}"/>
+    <rect width="{{.Bounds.Dx}}" height="20" fill="url(#smooth)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="DejaVu Sans,Verdana,Geneva,sans-serif" font-size="11">
+    {{ if .Label }}
+    <text x="{{.Bounds.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{.Label}}</text>
+    <text x="{{.Bounds.LabelX}}" y="14">{{.Label}}</text>
+    {{ end }}
+    <text x="{{.Bounds.MessageX}}" y="15" fill="#010101" fill-opacity=".3">{{.Message}}</text>
+    <text x="{{.Bounds.MessageX}}" y="14">{{.Message}}</text>
+  </g>
+</svg>
+`
+
+var badgePlasticTemplate = `
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Bounds.Dx}}" height="18">
+  <linearGradient id="smooth" x2="0" y2="100%">
+    <stop offset="0" stop-color="#fff" stop-opacity=".7"/>
+    <stop offset=".1" stop-color="#aaa" stop-opacity=".1"/>
+    <stop offset=".9" stop-color="#000" stop-opacity=".3"/>
+    <stop offset="1" stop-color="#000" stop-opacity=".5"/>
+  </linearGradient>
+  <mask id="round">
+    <rect width="{{.Bounds.Dx}}" height="18" rx="4" fill="#fff"/>
+  </mask>
+  <g mask="url(#round)">
+    <rect width="{{.Bounds.LabelDx}}" height="18" fill="{{.LabelColor}}"/>
+    <rect x="{{.Bounds.LabelDx}}" width="{{.Bounds.MessageDx}}" height="18" fill="{{.Color}}"/>
+    <rect width="{{.Bounds.Dx}}" height="18" fill="url(#smooth)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="DejaVu Sans,Verdana,Geneva,sans-serif" font-size="11">
+    {{ if .Label }}
+    <text x="{{.Bounds.LabelX}}" y="14" fill="#010101" fill-opacity=".3">{{.Label}}</text>
+    <text x="{{.Bounds.LabelX}}" y="13">{{.Label}}</text>
+    {{ end }}
+    <text x="{{.Bounds.MessageX}}" y="14" fill="#010101" fill-opacity=".3">{{.Message}}</text>
+    <text x="{{.Bounds.MessageX}}" y="13">{{.Message}}</text>
+  </g>
+</svg>
+`
+
+var badgeForTheBadgeTemplate = `
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Bounds.Dx}}" height="28">
+  <g shape-rendering="crispEdges">
+    <rect width="{{.Bounds.LabelDx}}" height="28" fill="{{.LabelColor}}"/>
+    <rect x="{{.Bounds.LabelDx}}" width="{{.Bounds.MessageDx}}" height="28" fill="{{.Color}}"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="10" letter-spacing="1">
+    {{ if .Label }}
+    <text x="{{.Bounds.LabelX}}" y="18">{{.Label}}</text>
+    {{ end }}
+    <text x="{{.Bounds.MessageX}}" y="18" font-weight="bold">{{.Message}}</text>
+  </g>
+</svg>
+`
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minimal icalendar (rfc 5545) parsing, just enough to read holiday and vacation calendars
+
+const (
+	icalDateLayout        = "20060102"
+	icalDateTimeLayout    = "20060102T150405"
+	icalDateTimeUTCLayout = "20060102T150405Z"
+)
+
+var icalDurationRegex = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+type ICalEvent struct {
+	Summary string
+	Start   time.Time
+	End     time.Time // exclusive
+	AllDay  bool
+}
+
+// ParseICalEvents reads all events from an icalendar file, where times without explicit time zone are interpreted in tz.
+// Recurrence rules are not supported, i.e. only an event's first occurrence is considered.
+func ParseICalEvents(r io.Reader, tz *time.Location) ([]*ICalEvent, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, errors.New("not an icalendar file")
+	}
+
+	events := make([]*ICalEvent, 0)
+	var current *ICalEvent
+	var duration *time.Duration
+
+	for _, line := range lines {
+		name, params, value := parseICalProperty(line)
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current, duration = &ICalEvent{}, nil
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current == nil || current.Start.IsZero() {
+				current = nil
+				continue
+			}
+			if current.End.IsZero() && duration != nil {
+				current.End = current.Start.Add(*duration)
+			}
+			if current.End.IsZero() && current.AllDay {
+				current.End = current.Start.AddDate(0, 0, 1)
+			}
+			if current.End.Before(current.Start) {
+				current.End = current.Start
+			}
+			events = append(events, current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "SUMMARY":
+			current.Summary = unescapeICalText(value)
+		case name == "DTSTART":
+			if current.Start, current.AllDay, err = parseICalTime(value, params, tz); err != nil {
+				return nil, err
+			}
+		case name == "DTEND":
+			if current.End, _, err = parseICalTime(value, params, tz); err != nil {
+				return nil, err
+			}
+		case name == "DURATION":
+			d, err := parseICalDuration(value)
+			if err != nil {
+				return nil, err
+			}
+			duration = &d
+		}
+	}
+
+	return events, nil
+}
+
+// unfoldICalLines joins lines continued by a leading whitespace, see https://datatracker.ietf.org/doc/html/rfc5545#section-3.1
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseICalProperty splits a content line like "DTSTART;TZID=Europe/Berlin:20240101T100000" into its name, parameters and value
+func parseICalProperty(line string) (string, map[string]string, string) {
+	var inQuotes bool
+	sep := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == ':' && !inQuotes {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+
+	parts := strings.Split(line[:sep], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, "\"")
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[sep+1:]
+}
+
+func parseICalTime(value string, params map[string]string, tz *time.Location) (time.Time, bool, error) {
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len(icalDateLayout) {
+		t, err := time.ParseInLocation(icalDateLayout, value, tz)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalDateTimeUTCLayout, value)
+		return t, false, err
+	}
+	if tzid, ok := params["TZID"]; ok {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			tz = loc
+		}
+	}
+	t, err := time.ParseInLocation(icalDateTimeLayout, value, tz)
+	return t, false, err
+}
+
+func parseICalDuration(value string) (time.Duration, error) {
+	match := icalDurationRegex.FindStringSubmatch(value)
+	if match == nil {
+		return 0, errors.New("invalid duration")
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if match[i+2] != "" {
+			n, _ := strconv.Atoi(match[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if match[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+func unescapeICalText(value string) string {
+	return strings.NewReplacer(`\\`, `\`, `\,`, `,`, `\;`, `;`, `\n`, " ", `\N`, " ").Replace(value)
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testICal = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//Holidays//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:1\r\n" +
+	"DTSTART;VALUE=DATE:20241225\r\n" +
+	"DTEND;VALUE=DATE:20241227\r\n" +
+	"SUMMARY:Christmas\\, Boxing Day\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:2\r\n" +
+	"DTSTART;VALUE=DATE:20250101\r\n" +
+	"SUMMARY:New Year\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:3\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20250303T090000\r\n" +
+	"DURATION:P1DT2H\r\n" +
+	"SUMMARY:Very long\r\n" +
+	" meeting\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:4\r\n" +
+	"SUMMARY:No start\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICalEvents(t *testing.T) {
+	events, err := ParseICalEvents(strings.NewReader(testICal), tzUtc)
+
+	assert.Nil(t, err)
+	assert.Len(t, events, 3)
+
+	assert.Equal(t, "Christmas, Boxing Day", events[0].Summary)
+	assert.True(t, events[0].AllDay)
+	assert.Equal(t, time.Date(2024, 12, 25, 0, 0, 0, 0, tzUtc), events[0].Start)
+	assert.Equal(t, time.Date(2024, 12, 27, 0, 0, 0, 0, tzUtc), events[0].End)
+
+	assert.Equal(t, "New Year", events[1].Summary)
+	assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, tzUtc), events[1].End)
+
+	assert.Equal(t, "Very longmeeting", events[2].Summary)
+	assert.False(t, events[2].AllDay)
+	assert.Equal(t, time.Date(2025, 3, 3, 9, 0, 0, 0, tzCet), events[2].Start)
+	assert.Equal(t, time.Date(2025, 3, 4, 11, 0, 0, 0, tzCet), events[2].End)
+}
+
+func TestParseICalEvents_Invalid(t *testing.T) {
+	_, err := ParseICalEvents(strings.NewReader("foo,bar\n1,2"), tzUtc)
+	assert.Error(t, err)
+
+	_, err = ParseICalEvents(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:2024-12-25\nEND:VEVENT\nEND:VCALENDAR"), tzUtc)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"strings"
+	"unicode/utf8"
 )
 
 func SplitMulti(s string, delimiters ...string) []string {
@@ -23,3 +24,11 @@ func FindString(needle string, haystack []string, defaultVal string) string {
 	}
 	return defaultVal
 }
+
+// TruncateRunes cuts the string to at most n characters (not bytes), such that no multi-byte character is split
+func TruncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
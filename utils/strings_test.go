@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateRunes(t *testing.T) {
+	assert.Equal(t, "wakapi", TruncateRunes("wakapi", 6))
+	assert.Equal(t, "waka", TruncateRunes("wakapi", 4))
+	assert.Equal(t, "", TruncateRunes("", 4))
+
+	truncated := TruncateRunes("äöü", 2)
+	assert.Equal(t, "äö", truncated)
+	assert.True(t, utf8.ValidString(truncated))
+}